@@ -5,20 +5,24 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
+	skillcompiler "github.com/roberthamel/skill-compiler"
 	"github.com/roberthamel/skill-compiler/internal/cache"
 	"github.com/roberthamel/skill-compiler/internal/config"
+	"github.com/roberthamel/skill-compiler/internal/diag"
 	"github.com/roberthamel/skill-compiler/internal/generate"
 	"github.com/roberthamel/skill-compiler/internal/instructions"
 	"github.com/roberthamel/skill-compiler/internal/ir"
-	cliplugin "github.com/roberthamel/skill-compiler/internal/plugins/cli"
-	"github.com/roberthamel/skill-compiler/internal/plugins/codebase"
-	"github.com/roberthamel/skill-compiler/internal/plugins/openapi"
+	"github.com/roberthamel/skill-compiler/internal/plugins"
 	"github.com/roberthamel/skill-compiler/internal/provider"
 	"github.com/spf13/cobra"
 )
@@ -33,7 +37,7 @@ func main() {
 into Agent Skills spec-compliant skill directories and llms.txt documentation.
 
 It reads a COMPILER_INSTRUCTIONS.md file (YAML frontmatter + markdown body)
-and one or more spec sources (OpenAPI, CLI binary, codebase) to produce:
+and one or more spec sources (OpenAPI, AsyncAPI, CLI binary, codebase) to produce:
   - A skill directory (SKILL.md, references/, scripts/)
   - llms.txt, llms-api.txt, llms-full.txt
   - CHANGELOG.md`,
@@ -44,9 +48,15 @@ and one or more spec sources (OpenAPI, CLI binary, codebase) to produce:
 		newGenerateCmd(),
 		newInitCmd(),
 		newValidateCmd(),
+		newCheckCmd(),
+		newExplainCmd(),
 		newDiffCmd(),
 		newServeCmd(),
+		newModelsCmd(),
 		newConfigCmd(),
+		newUpgradeInstructionsCmd(),
+		newDoctorCmd(),
+		newTUICmd(),
 	)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -62,11 +72,35 @@ func newGenerateCmd() *cobra.Command {
 		RunE:  runGenerate,
 	}
 	cmd.Flags().String("spec", "", "Path to spec file (overrides frontmatter)")
-	cmd.Flags().String("instructions", "COMPILER_INSTRUCTIONS.md", "Path to instructions file")
+	cmd.Flags().StringP("instructions", "f", "COMPILER_INSTRUCTIONS.md", "Path to instructions file (- reads stdin)")
 	cmd.Flags().String("out", "", "Output directory (overrides frontmatter)")
+	cmd.Flags().StringSlice("variant", nil, "Active variant tags for <!-- if:tag --> blocks (overrides frontmatter)")
+	cmd.Flags().String("stdout", "", "Write this artifact to stdout instead of the output directory")
+	cmd.Flags().String("artifact", "", "Build only this artifact and write it to --output; no other files, lockfile, or cache are written")
+	cmd.Flags().StringP("output", "o", "-", "With --artifact, the file (or directory for scripts) to write; - is stdout")
 	cmd.Flags().StringSlice("only", nil, "Generate only these artifacts (comma-separated)")
+	cmd.Flags().String("artifacts-from", "", "Generate only a changelog entry comparing the artifacts in this directory with the previous ones")
+	cmd.Flags().String("git-branch", "", "After building, commit the output directory to this git branch (created if missing)")
+	cmd.Flags().String("git-remote", "", "With --git-branch, push the branch to this remote after committing")
+	cmd.Flags().String("previous-from", "", "With --artifacts-from, the directory of previous artifacts (default: the output directory)")
 	cmd.Flags().Bool("force", false, "Bypass cache and regenerate all artifacts")
+	cmd.Flags().String("since-commit", "", "Skip the whole build when the instructions file and local spec paths are unchanged in git since this commit")
+	cmd.Flags().Bool("offline", false, "Forbid network access; use only cached LLM outputs")
+	cmd.Flags().Bool("enrich", false, "Draft missing operation/parameter descriptions with the LLM before generating")
+	cmd.Flags().Bool("enrich-write-back", false, "With --enrich, also write drafted descriptions into YAML OpenAPI spec files")
+	cmd.Flags().Bool("continue-on-error", false, "Keep generating and writing other artifacts when one fails (exit non-zero)")
+	cmd.Flags().Int("max-tokens", 0, "Output token limit per LLM request (default: per artifact; frontmatter max-tokens wins; capped by the model)")
+	cmd.Flags().String("seed-examples", "", "JSON or JSONL file of recorded requests/responses to ground examples.md in")
+	cmd.Flags().String("output-format", "", "Format of the reference and examples: markdown, mdx, asciidoc (frontmatter format wins)")
+	cmd.Flags().Bool("include-raw-help", false, "Send the reference each CLI command's verbatim --help output (more input tokens)")
+	cmd.Flags().Bool("explain-cache", false, "Report why each artifact is or is not up to date: which input (spec, sections, prompt) changed")
+	cmd.Flags().Bool("fail-on-warn", false, "Exit non-zero if instructions or spec parsing report warnings the last build's lockfile did not record")
+	cmd.Flags().Bool("progress", false, "Print a line as each artifact is found cached, starts, finishes, or fails")
+	cmd.Flags().Bool("json", false, "Print a JSON summary (per-artifact status, tokens, cost, warnings) to stdout; progress goes to stderr")
+	cmd.Flags().Duration("timeout", 0, "Abort the whole build after this long, e.g. 10m; finished artifacts are kept (0 means no limit)")
 	cmd.Flags().Bool("dry-run", false, "Show what would be generated without making LLM calls")
+	cmd.Flags().Bool("prompt-only", false, "Print each artifact's system prompt and user message instead of calling the LLM")
+	cmd.Flags().String("prompt-dir", "", "With --prompt-only, write each artifact's prompts to <dir>/<artifact>.prompt.txt instead of stdout")
 	cmd.Flags().Bool("diff", false, "Show diff against existing files instead of overwriting")
 	cmd.Flags().Bool("verbose", false, "Show LLM prompts, token usage, and timing")
 	cmd.Flags().String("model", "", "LLM model to use (overrides all other config)")
@@ -80,19 +114,55 @@ func newInitCmd() *cobra.Command {
 		Short: "Scaffold a COMPILER_INSTRUCTIONS.md from a spec",
 		RunE:  runInit,
 	}
-	cmd.Flags().String("spec", "", "Path to spec file or CLI binary name")
-	cmd.Flags().String("type", "", "Spec type: openapi, cli, codebase")
+	cmd.Flags().String("spec", "", "Path to spec file or CLI binary name (auto-detected if omitted)")
+	cmd.Flags().String("type", "", "Spec type: openapi, asyncapi, jsonschema, postman, har, cli, codebase")
 	cmd.Flags().String("name", "", "Project/tool name")
 	cmd.Flags().Bool("force", false, "Overwrite existing instructions file")
+	cmd.Flags().Bool("no-llm", false, "Write a skeleton with review-marked sections instead of calling the LLM")
+	cmd.Flags().String("language", "", "Language for generated prose, e.g. es (default English)")
 	return cmd
 }
 
 func newValidateCmd() *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "validate",
 		Short: "Validate instructions and spec consistency",
-		RunE:  runValidate,
+		Long: `Validate parses the instructions file and every spec source, runs the
+instruction and plugin checks, and exits non-zero on errors. It never calls
+the LLM, so it suits pre-commit hooks and CI gates.`,
+		RunE: runValidate,
+	}
+	cmd.Flags().StringP("instructions", "f", "COMPILER_INSTRUCTIONS.md", "Path to instructions file")
+	cmd.Flags().Bool("strict", false, "Treat warnings as errors")
+	return cmd
+}
+
+func newCheckCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check",
+		Short: "Report instruction and spec diagnostics (text, JSON, or SARIF)",
+		RunE:  runCheck,
+	}
+	cmd.Flags().StringP("instructions", "f", "COMPILER_INSTRUCTIONS.md", "Path to instructions file")
+	cmd.Flags().String("format", diag.FormatText, "Output format: text, json, sarif")
+	return cmd
+}
+
+func newExplainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain <operation>",
+		Short: "Show how an operation was parsed from the spec (no LLM calls)",
+		Long: `Parses the spec sources, finds the operation matching the argument by ID,
+path, or "METHOD /path" (exact or partial, case-insensitive), then by name,
+tag, or description, and prints its parameters, request/response shapes,
+and auth. Queries matching several operations equally well list the
+candidates.`,
+		Args: cobra.ExactArgs(1),
+		RunE: runExplain,
 	}
+	cmd.Flags().StringP("instructions", "f", "COMPILER_INSTRUCTIONS.md", "Path to instructions file")
+	cmd.Flags().String("spec", "", "Path to spec file (overrides frontmatter)")
+	return cmd
 }
 
 func newDiffCmd() *cobra.Command {
@@ -102,6 +172,10 @@ func newDiffCmd() *cobra.Command {
 		RunE:  runDiff,
 	}
 	cmd.Flags().String("against", "", "Directory to compare against")
+	cmd.Flags().String("against-version", "", "Compare the spec's operations against this version of it")
+	cmd.Flags().String("seed-examples", "", "Seed examples file the artifacts were generated with")
+	cmd.Flags().String("output-format", "", "Output format the artifacts were generated with")
+	cmd.Flags().Bool("include-raw-help", false, "Whether the artifacts were generated with --include-raw-help")
 	return cmd
 }
 
@@ -116,6 +190,17 @@ func newServeCmd() *cobra.Command {
 	return cmd
 }
 
+func newModelsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "models",
+		Short: "List the models available from the resolved provider",
+		RunE:  runModels,
+	}
+	cmd.Flags().StringP("instructions", "f", "COMPILER_INSTRUCTIONS.md", "Instructions file whose provider block is used, if present")
+	cmd.Flags().String("provider", "", "LLM provider (overrides frontmatter and config)")
+	return cmd
+}
+
 func newConfigCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "config",
@@ -154,12 +239,34 @@ func newConfigResetCmd() *cobra.Command {
 	}
 }
 
-func newPluginRegistry() *ir.Registry {
-	reg := ir.NewRegistry()
-	reg.Register(openapi.New())
-	reg.Register(cliplugin.New())
-	reg.Register(codebase.New())
-	return reg
+func newUpgradeInstructionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upgrade-instructions",
+		Short: "Migrate an instructions file to the current format",
+		Long: `Upgrade-instructions rewrites the instructions file's frontmatter in the
+current format: it sets the format version, makes defaults explicit,
+normalizes values, and orders keys canonically. Frontmatter comments and
+the markdown body are preserved, and running it again changes nothing.`,
+		RunE: runUpgradeInstructions,
+	}
+	cmd.Flags().StringP("instructions", "f", "COMPILER_INSTRUCTIONS.md", "Path to instructions file")
+	cmd.Flags().Bool("dry-run", false, "Show the diff without writing the file")
+	return cmd
+}
+
+func newDoctorCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the environment sc needs to build skills",
+		Long: `Doctor checks that the config file loads, the instructions parse, the
+provider resolves and accepts the API key, every spec source can be fetched
+and parsed, and each output directory is writable. It prints a checklist
+with a hint for each problem and exits non-zero if any check fails.`,
+		RunE: runDoctor,
+	}
+	cmd.Flags().StringP("instructions", "f", "COMPILER_INSTRUCTIONS.md", "Path to instructions file")
+	cmd.Flags().Bool("offline", false, "Skip checks that call the provider API")
+	return cmd
 }
 
 func runGenerate(cmd *cobra.Command, args []string) error {
@@ -167,205 +274,611 @@ func runGenerate(cmd *cobra.Command, args []string) error {
 	specFlag, _ := cmd.Flags().GetString("spec")
 	outFlag, _ := cmd.Flags().GetString("out")
 	only, _ := cmd.Flags().GetStringSlice("only")
+	artifactsFrom, _ := cmd.Flags().GetString("artifacts-from")
+	previousFrom, _ := cmd.Flags().GetString("previous-from")
+	gitBranch, _ := cmd.Flags().GetString("git-branch")
+	gitRemote, _ := cmd.Flags().GetString("git-remote")
 	force, _ := cmd.Flags().GetBool("force")
+	sinceCommit, _ := cmd.Flags().GetString("since-commit")
+	offline, _ := cmd.Flags().GetBool("offline")
+	enrich, _ := cmd.Flags().GetBool("enrich")
+	enrichWriteBack, _ := cmd.Flags().GetBool("enrich-write-back")
+	continueOnError, _ := cmd.Flags().GetBool("continue-on-error")
+	failOnWarn, _ := cmd.Flags().GetBool("fail-on-warn")
+	jsonOutput, _ := cmd.Flags().GetBool("json")
+	progress, _ := cmd.Flags().GetBool("progress")
+	timeout, _ := cmd.Flags().GetDuration("timeout")
+	maxTokens, _ := cmd.Flags().GetInt("max-tokens")
+	seedExamples, _ := cmd.Flags().GetString("seed-examples")
+	outputFormat, _ := cmd.Flags().GetString("output-format")
+	includeRawHelp, _ := cmd.Flags().GetBool("include-raw-help")
+	explainCache, _ := cmd.Flags().GetBool("explain-cache")
 	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	promptOnly, _ := cmd.Flags().GetBool("prompt-only")
+	promptDir, _ := cmd.Flags().GetString("prompt-dir")
 	diffMode, _ := cmd.Flags().GetBool("diff")
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	modelFlag, _ := cmd.Flags().GetString("model")
 	providerFlag, _ := cmd.Flags().GetString("provider")
+	stdoutArtifact, _ := cmd.Flags().GetString("stdout")
+	artifact, _ := cmd.Flags().GetString("artifact")
+	output, _ := cmd.Flags().GetString("output")
+	var variants []string
+	if cmd.Flags().Changed("variant") {
+		variants, _ = cmd.Flags().GetStringSlice("variant")
+		if variants == nil {
+			variants = []string{}
+		}
+	}
+
+	if offline && force {
+		return fmt.Errorf("--offline and --force cannot be combined: --force regenerates every artifact")
+	}
+	if sinceCommit != "" && (force || stdoutArtifact != "" || artifact != "") {
+		return fmt.Errorf("--since-commit cannot be combined with --force, --stdout, or --artifact")
+	}
+	if explainCache && (force || dryRun) {
+		return fmt.Errorf("--explain-cache cannot be combined with --force or --dry-run, which skip the cache check")
+	}
+	if offline && enrich {
+		return fmt.Errorf("--offline and --enrich cannot be combined: enrichment calls the LLM")
+	}
+	if promptDir != "" && !promptOnly {
+		return fmt.Errorf("--prompt-dir requires --prompt-only")
+	}
+	if promptOnly && (diffMode || explainCache || stdoutArtifact != "" || artifact != "") {
+		return fmt.Errorf("--prompt-only cannot be combined with --diff, --explain-cache, --stdout, or --artifact")
+	}
+	if jsonOutput && (dryRun || diffMode || promptOnly || stdoutArtifact != "" || artifact != "") {
+		return fmt.Errorf("--json cannot be combined with --dry-run, --diff, --prompt-only, --stdout, or --artifact, which print to stdout")
+	}
+	if enrichWriteBack && !enrich {
+		return fmt.Errorf("--enrich-write-back requires --enrich")
+	}
+	if maxTokens < 0 {
+		return fmt.Errorf("--max-tokens must be positive")
+	}
+	if timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+	if err := checkOutputFormat(outputFormat); err != nil {
+		return err
+	}
+	if gitRemote != "" && gitBranch == "" {
+		return fmt.Errorf("--git-remote requires --git-branch")
+	}
+	if gitBranch != "" && (stdoutArtifact != "" || artifact != "") {
+		return fmt.Errorf("--git-branch commits the output directory; it cannot be combined with --stdout or --artifact")
+	}
+	var gitTarget *skillcompiler.GitTarget
+	if gitBranch != "" {
+		gitTarget = &skillcompiler.GitTarget{Branch: gitBranch, Remote: gitRemote}
+	}
+	if previousFrom != "" && artifactsFrom == "" {
+		return fmt.Errorf("--previous-from requires --artifacts-from")
+	}
+	if artifactsFrom != "" && (len(only) > 0 || stdoutArtifact != "" || artifact != "") {
+		return fmt.Errorf("--artifacts-from generates only the changelog; drop --only, --stdout, and --artifact")
+	}
+	if stdoutArtifact != "" {
+		if !isArtifactID(stdoutArtifact) {
+			return fmt.Errorf("--stdout: unknown artifact %q", stdoutArtifact)
+		}
+		if dryRun || diffMode {
+			return fmt.Errorf("--stdout cannot be combined with --dry-run or --diff")
+		}
+		if len(only) > 0 {
+			return fmt.Errorf("--stdout and --only cannot be combined")
+		}
+		only = []string{stdoutArtifact}
+	}
+	if cmd.Flags().Changed("output") && artifact == "" {
+		return fmt.Errorf("--output requires --artifact")
+	}
+	if artifact != "" {
+		if !isArtifactID(artifact) {
+			return fmt.Errorf("--artifact: unknown artifact %q", artifact)
+		}
+		switch {
+		case stdoutArtifact != "":
+			return fmt.Errorf("--artifact and --stdout cannot be combined")
+		case dryRun || diffMode:
+			return fmt.Errorf("--artifact cannot be combined with --dry-run or --diff")
+		case len(only) > 0:
+			return fmt.Errorf("--artifact and --only cannot be combined")
+		case enrichWriteBack:
+			return fmt.Errorf("--artifact writes no spec files; drop --enrich-write-back")
+		}
+		only = []string{artifact}
+	}
 
 	// Parse instructions
-	inst, err := instructions.Parse(instPath)
+	data, err := readInstructions(cmd, instPath)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			return fmt.Errorf("no %s found in current directory — run `sc init` to create one", instPath)
 		}
 		return err
 	}
+	inst, err := instructions.ParseBytesVariants(data, variants)
+	if err != nil {
+		return err
+	}
+	multi := len(inst.Frontmatter.Skills) > 0
+	if multi && specFlag != "" {
+		return fmt.Errorf("--spec cannot be used with a multi-skill instructions file")
+	}
+	if multi && stdoutArtifact != "" {
+		return fmt.Errorf("--stdout cannot be used with a multi-skill instructions file")
+	}
+	if multi && artifact != "" {
+		return fmt.Errorf("--artifact cannot be used with a multi-skill instructions file")
+	}
 
-	// Resolve output directory
-	outputDir := inst.Frontmatter.Out
-	if outFlag != "" {
-		outputDir = outFlag
+	// With --stdout, progress goes to stderr so stdout carries only the artifact
+	log := io.Writer(os.Stdout)
+	if stdoutArtifact != "" || artifact != "" || (promptOnly && promptDir == "") || jsonOutput {
+		log = os.Stderr
 	}
 
-	// Resolve spec sources
-	sources, err := inst.ResolveSpecSources()
-	if err != nil {
-		return fmt.Errorf("resolving spec sources: %w", err)
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
-	if specFlag != "" {
-		sources = []instructions.SpecSource{{Path: specFlag}}
+
+	var onEvent func(skillcompiler.Event)
+	if progress {
+		onEvent = progressPrinter(log, multi)
 	}
 
-	// Resolve provider
-	fmProvider := &config.Config{
-		Provider: inst.Frontmatter.Provider.Provider,
-		Model:    inst.Frontmatter.Provider.Model,
-		APIKey:   inst.Frontmatter.Provider.APIKey,
-		BaseURL:  inst.Frontmatter.Provider.BaseURL,
+	projectDir, _ := os.Getwd()
+	start := time.Now()
+	result, err := skillcompiler.Build(ctx, skillcompiler.BuildOptions{
+		InstructionsPath: instPath,
+		Instructions:     data,
+		Dir:              projectDir,
+		Spec:             specFlag,
+		OutputDir:        outFlag,
+		Variants:         variants,
+		Provider:         skillcompiler.ProviderConfig{Provider: providerFlag, Model: modelFlag},
+		Only:             only,
+		ArtifactsFrom:    artifactsFrom,
+		PreviousFrom:     previousFrom,
+		GitTarget:        gitTarget,
+		SinceCommit:      sinceCommit,
+		Force:            force,
+		Offline:          offline,
+		Enrich:           enrich,
+		EnrichWriteBack:  enrichWriteBack,
+		ContinueOnError:  continueOnError,
+		MaxTokens:        maxTokens,
+		SeedExamples:     seedExamples,
+		OutputFormat:     outputFormat,
+		IncludeRawHelp:   includeRawHelp,
+		ExplainCache:     explainCache,
+		PromptOnly:       promptOnly,
+		DryRun:           dryRun,
+		Diff:             diffMode,
+		NoWrite:          stdoutArtifact != "",
+		ReadOnly:         artifact != "",
+		Verbose:          verbose,
+		OnEvent:          onEvent,
+		Log:              log,
+		ErrLog:           os.Stderr,
+	})
+	if jsonOutput && result != nil {
+		if err := writeBuildReport(os.Stdout, result, time.Since(start), err); err != nil {
+			return err
+		}
 	}
-	resolved, err := config.Resolve(providerFlag, modelFlag, "", "", fmProvider)
-	if err != nil {
-		return fmt.Errorf("resolving provider config: %w", err)
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return timeoutErr(result, timeout, multi)
 	}
-
-	// Process specs through plugin pipeline
-	fmt.Println("Parsing spec sources...")
-	reg := newPluginRegistry()
-	parsedIR, warnings, err := reg.ProcessSources(sources)
 	if err != nil {
-		return fmt.Errorf("processing specs: %w", err)
+		return err
 	}
-	for _, w := range warnings {
-		fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
+	if result.Unchanged {
+		if !jsonOutput {
+			fmt.Println("up to date.")
+		}
+		return nil
+	}
+	elapsed := time.Since(start)
+	skills := result.Skills
+	warnErr := warningsErr(skills, failOnWarn)
+	if jsonOutput {
+		if err := failedArtifactsErr(skills, multi); err != nil {
+			return err
+		}
+		return warnErr
 	}
-	fmt.Printf("Parsed %d operations, %d types, %d auth schemes\n",
-		len(parsedIR.Operations), len(parsedIR.Types), len(parsedIR.Auth))
-
-	// Load previous artifacts for changelog
-	prevArtifacts := generate.LoadPreviousArtifacts(outputDir, inst.Frontmatter.Name)
 
-	// Cache check (unless force)
-	projectDir, _ := os.Getwd()
-	lockFile, _ := cache.LoadLockFile(projectDir)
-	irJSON, _ := json.Marshal(parsedIR)
-	specContent := string(irJSON)
+	if promptOnly {
+		if err := emitPrompts(skills, promptDir, multi); err != nil {
+			return err
+		}
+		return warnErr
+	}
+	if dryRun {
+		fmt.Printf("\nDry run complete (%s)\n", elapsed.Round(time.Millisecond))
+		return warnErr
+	}
+	if diffMode {
+		return warnErr
+	}
 
-	// Create provider (unless dry-run)
-	var prov provider.Provider
-	if !dryRun {
-		prov, err = provider.New(resolved)
+	if stdoutArtifact != "" {
+		if err := emitArtifact(os.Stdout, skills[0].Files, inst, generate.ArtifactID(stdoutArtifact), projectDir); err != nil {
+			return err
+		}
+		return warnErr
+	}
+	if artifact != "" {
+		if err := failedArtifactsErr(skills, false); err != nil {
+			return err
+		}
+		id := generate.ArtifactID(artifact)
+		if output == "-" {
+			err = emitArtifact(os.Stdout, skills[0].Files, inst, id, projectDir)
+		} else {
+			err = saveArtifact(output, skills[0].Files, inst, id, projectDir)
+		}
 		if err != nil {
 			return err
 		}
-		fmt.Printf("Using provider: %s (model: %s)\n", prov.Name(), resolved.Model)
+		return warnErr
 	}
 
-	// Build pipeline
-	pipeline := &generate.Pipeline{
-		Provider: prov,
-		IR:       parsedIR,
-		Inst:     inst,
-		Opts: generate.Options{
-			OutputDir:     outputDir,
-			Only:          only,
-			Force:         force,
-			DryRun:        dryRun,
-			Diff:          diffMode,
-			Verbose:       verbose,
-			PrevArtifacts: prevArtifacts,
-		},
-	}
-
-	// Check cache per artifact — skip unchanged ones unless --force
-	skipArtifact := make(map[generate.ArtifactID]bool)
-	if !force && !dryRun {
-		fmt.Println("Checking cache...")
-		allUpToDate := true
-		for _, id := range generate.AllArtifacts {
-			prompt := pipeline.SystemPromptFor(id)
-			sections := pipeline.RelevantSections(id)
-			inputHash := cache.HashInput(specContent, sections, prompt)
-			if lockFile.IsUpToDate(string(id), inputHash) {
-				skipArtifact[id] = true
-			} else {
-				allUpToDate = false
-			}
+	if !multi {
+		sr := skills[0]
+		if len(sr.Warnings) > 0 {
+			fmt.Printf("Warnings: %s%s\n", diag.FormatCounts(diag.Counts(sr.Warnings)), newWarningsNote(sr.NewWarnings))
 		}
-		if allUpToDate {
+		switch {
+		case sr.UpToDate:
 			fmt.Println("All artifacts up to date — nothing to generate.")
-			return nil
+		case len(sr.Failed()) == 0:
+			fmt.Printf("\nGeneration complete (%s) — output written to %s\n", elapsed.Round(time.Millisecond), sr.OutputDir)
+		default:
+			fmt.Printf("\nGeneration finished with errors (%s) — other artifacts written to %s\n", elapsed.Round(time.Millisecond), sr.OutputDir)
+		}
+	} else {
+		fmt.Printf("\nBuilt %d skills (%s):\n", len(skills), elapsed.Round(time.Millisecond))
+		for _, sr := range skills {
+			fmt.Printf("  %s: %d generated, %d cached, %d failed, warnings: %s%s → %s\n",
+				sr.Name, sr.Generated(), sr.Cached, len(sr.Failed()),
+				diag.FormatCounts(diag.Counts(sr.Warnings)), newWarningsNote(sr.NewWarnings), sr.OutputDir)
 		}
 	}
-	pipeline.Opts.SkipArtifacts = skipArtifact
+	if err := failedArtifactsErr(skills, multi); err != nil {
+		return err
+	}
+	return warnErr
+}
 
-	// Run generation
-	fmt.Println("Generating artifacts...")
-	ctx := context.Background()
-	start := time.Now()
-	results, err := pipeline.Run(ctx)
-	elapsed := time.Since(start)
+// buildReport is the --json summary of a build.
+type buildReport struct {
+	Skills    []skillReport `json:"skills"`
+	TokensIn  int           `json:"tokensIn"`
+	TokensOut int           `json:"tokensOut"`
+	// CostUSD is estimated from list prices; artifacts from models
+	// without a known price add nothing.
+	CostUSD    float64         `json:"costUSD"`
+	Warnings   []warningReport `json:"warnings"`
+	GitCommit  string          `json:"gitCommit,omitempty"`
+	Unchanged  bool            `json:"unchanged,omitempty"` // --since-commit skipped the build
+	DurationMS int64           `json:"durationMs"`
+	Error      string          `json:"error,omitempty"`
+}
 
-	if err != nil {
-		return err
+type skillReport struct {
+	Name      string           `json:"name"`
+	OutputDir string           `json:"outputDir"`
+	Artifacts []artifactReport `json:"artifacts"`
+}
+
+// artifactReport is one artifact of a skillReport. Status is cache-hit,
+// generated, skipped (all its instruction sections are empty, or the spec
+// is), or error.
+type artifactReport struct {
+	ID        string   `json:"id"`
+	Path      string   `json:"path"`
+	Status    string   `json:"status"`
+	Provider  string   `json:"provider,omitempty"`
+	Model     string   `json:"model,omitempty"`
+	TokensIn  int      `json:"tokensIn,omitempty"`
+	TokensOut int      `json:"tokensOut,omitempty"`
+	CostUSD   *float64 `json:"costUSD,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// warningReport is a diagnostic tagged with the skill that reported it.
+type warningReport struct {
+	Skill string `json:"skill"`
+	diag.Diagnostic
+}
+
+// progressPrinter renders build events for --progress as plain text, one
+// line each, such as "[progress] reference: done (references/REFERENCE.md)".
+// Multi-skill builds prefix the artifact with the skill name.
+func progressPrinter(w io.Writer, multi bool) func(skillcompiler.Event) {
+	var mu sync.Mutex
+	return func(e skillcompiler.Event) {
+		name := e.Artifact
+		if multi {
+			name = e.Skill + "/" + name
+		}
+		line := fmt.Sprintf("[progress] %s: %s", name, e.Kind)
+		if e.Err != nil {
+			line += ": " + e.Err.Error()
+		} else if e.Path != "" {
+			line += " (" + e.Path + ")"
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintln(w, line)
 	}
+}
 
-	// Display results
-	for _, r := range results {
-		if r.Err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR generating %s: %s\n", r.ID, r.Err)
-			continue
+// writeBuildReport writes the --json summary of a build to w. buildErr, if
+// set, is the error the build stopped with.
+func writeBuildReport(w io.Writer, result *skillcompiler.BuildResult, elapsed time.Duration, buildErr error) error {
+	report := buildReport{
+		Skills:     []skillReport{},
+		Warnings:   []warningReport{},
+		GitCommit:  result.GitCommit,
+		Unchanged:  result.Unchanged,
+		DurationMS: elapsed.Milliseconds(),
+	}
+	if buildErr != nil {
+		report.Error = buildErr.Error()
+	}
+	for _, sr := range result.Skills {
+		skill := skillReport{Name: sr.Name, OutputDir: sr.OutputDir, Artifacts: []artifactReport{}}
+		cached := make(map[string]bool)
+		for _, a := range sr.CachedArtifacts {
+			cached[a.ID+"\x00"+a.Path] = true
+			skill.Artifacts = append(skill.Artifacts, artifactReport{ID: a.ID, Path: a.Path, Status: "cache-hit"})
 		}
-		status := "generated"
-		if r.Content == "" {
-			status = "skipped"
+		for _, a := range sr.Artifacts {
+			ar := artifactReport{ID: a.ID, Path: a.Path, Provider: a.Provider, Model: a.Model, TokensIn: a.TokensIn, TokensOut: a.TokensOut}
+			switch {
+			case a.Err != nil:
+				ar.Status, ar.Error = "error", a.Err.Error()
+			case cached[a.ID+"\x00"+a.Path]:
+				continue // already listed as a cache hit
+			case a.Content == "":
+				ar.Status = "skipped"
+			default:
+				ar.Status = "generated"
+			}
+			if price, ok := provider.PriceFor(a.Model); ok && (a.TokensIn > 0 || a.TokensOut > 0) {
+				cost := price.Cost(a.TokensIn, a.TokensOut)
+				ar.CostUSD = &cost
+				report.CostUSD += cost
+			}
+			report.TokensIn += a.TokensIn
+			report.TokensOut += a.TokensOut
+			skill.Artifacts = append(skill.Artifacts, ar)
 		}
-		tokenInfo := ""
-		if verbose && r.Response != nil {
-			tokenInfo = fmt.Sprintf(" (in: %d, out: %d tokens)", r.Response.TokensIn, r.Response.TokensOut)
+		report.Skills = append(report.Skills, skill)
+		for _, d := range sr.Warnings {
+			report.Warnings = append(report.Warnings, warningReport{Skill: sr.Name, Diagnostic: d})
 		}
-		fmt.Printf("  %s: %s%s\n", r.ID, status, tokenInfo)
 	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
 
-	if dryRun {
-		fmt.Printf("\nDry run complete (%s)\n", elapsed.Round(time.Millisecond))
+func newWarningsNote(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d new since last build)", n)
+}
+
+// warningsErr fails the build under --fail-on-warn when any skill reported
+// warnings or errors the lockfile did not record from the previous build,
+// so warnings already accepted don't fail CI; info diagnostics don't count.
+func warningsErr(skills []skillcompiler.SkillResult, failOnWarn bool) error {
+	if !failOnWarn {
+		return nil
+	}
+	n := 0
+	for _, sr := range skills {
+		n += sr.NewWarnings
+	}
+	if n == 0 {
 		return nil
 	}
+	return fmt.Errorf("%d new warning(s) reported (--fail-on-warn)", n)
+}
 
-	// Handle diff mode
-	if diffMode {
-		fmt.Println("\nDiff mode — showing changes without writing:")
-		for _, r := range results {
-			if r.Content == "" || r.Err != nil {
-				continue
-			}
-			existing, err := os.ReadFile(filepath.Join(outputDir, r.FilePath))
-			if err != nil {
-				fmt.Printf("\n--- %s (new file) ---\n", r.FilePath)
-			} else if string(existing) != r.Content {
-				fmt.Printf("\n--- %s (changed) ---\n", r.FilePath)
+// failedArtifactsErr lists the artifacts that failed under
+// --continue-on-error, or returns nil if none did.
+func failedArtifactsErr(skills []skillcompiler.SkillResult, multi bool) error {
+	var failures []string
+	for _, sr := range skills {
+		for _, a := range sr.Failed() {
+			f := fmt.Sprintf("%s: %s", a.ID, a.Err)
+			if multi {
+				f = sr.Name + "/" + f
 			}
+			failures = append(failures, f)
 		}
+	}
+	if len(failures) == 0 {
 		return nil
 	}
-
-	// Write artifacts to output directory
-	if err := generate.WriteResults(outputDir, results); err != nil {
-		return fmt.Errorf("writing artifacts: %w", err)
+	fmt.Fprintln(os.Stderr, "\nFailed artifacts:")
+	for _, f := range failures {
+		fmt.Fprintf(os.Stderr, "  %s\n", f)
 	}
+	return fmt.Errorf("%d artifact(s) failed", len(failures))
+}
 
-	// Handle changelog append semantics
-	for i, r := range results {
-		if r.ID == generate.ArtifactChangelog && r.Content != "" {
-			existingChangelog := prevArtifacts[generate.ArtifactChangelog]
-			results[i].Content = generate.PrependChangelogEntry(r.Content, existingChangelog)
-			changelogPath := filepath.Join(outputDir, r.FilePath)
-			if err := os.MkdirAll(filepath.Dir(changelogPath), 0o755); err == nil {
-				_ = os.WriteFile(changelogPath, []byte(results[i].Content), 0o644)
+// timeoutErr reports a build stopped by --timeout, listing the artifacts
+// that finished (and were written) and those still pending.
+func timeoutErr(result *skillcompiler.BuildResult, timeout time.Duration, multi bool) error {
+	var done, pending []string
+	for _, sr := range result.Skills {
+		for _, a := range sr.Artifacts {
+			name := a.ID
+			if multi {
+				name = sr.Name + "/" + name
+			}
+			switch {
+			case a.Err != nil:
+				pending = append(pending, name)
+			case a.Content != "":
+				done = append(done, name)
 			}
 		}
 	}
+	fmt.Fprintf(os.Stderr, "\nBuild timed out after %s.\n", timeout)
+	for _, line := range []struct {
+		label string
+		ids   []string
+	}{{"Completed", done}, {"Pending", pending}} {
+		list := strings.Join(line.ids, ", ")
+		if list == "" {
+			list = "none"
+		}
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", line.label+":", list)
+	}
+	return fmt.Errorf("build timed out after %s (--timeout); rerun to resume from the completed artifacts", timeout)
+}
 
-	// Update cache and lockfile
-	for _, r := range results {
-		if r.Err != nil || r.Content == "" {
-			continue
+// readInstructions reads the instructions file, or stdin when path is "-".
+// Relative spec paths then resolve against the working directory.
+func readInstructions(cmd *cobra.Command, path string) ([]byte, error) {
+	if path != "-" {
+		return os.ReadFile(path)
+	}
+	data, err := io.ReadAll(cmd.InOrStdin())
+	if err != nil {
+		return nil, fmt.Errorf("reading instructions from stdin: %w", err)
+	}
+	return data, nil
+}
+
+func isArtifactID(name string) bool {
+	for _, id := range generate.AllArtifacts {
+		if string(id) == name {
+			return true
 		}
-		prompt := pipeline.SystemPromptFor(r.ID)
-		sections := pipeline.RelevantSections(r.ID)
-		inputHash := cache.HashInput(specContent, sections, prompt)
-		outputHash := cache.HashOutput(r.Content)
-		model := ""
-		if r.Response != nil {
-			model = r.Response.Model
+	}
+	return false
+}
+
+// artifactFiles collects the files generated for an artifact. When the
+// build found the artifact up to date, they come from the cached copy.
+func artifactFiles(files map[string][]byte, inst *instructions.Instructions, id generate.ArtifactID, projectDir string) (*generate.MemorySink, error) {
+	sink := generate.NewMemorySink()
+	for path, data := range files {
+		_ = sink.WriteFile(path, data, 0o644)
+	}
+	if len(files) == 0 {
+		content, err := cache.ReadCached(projectDir, string(id))
+		if err != nil {
+			return nil, fmt.Errorf("%s was not generated and has no cached copy — rerun with --force", id)
+		}
+		header, err := inst.FileHeader()
+		if err != nil {
+			return nil, err
+		}
+		p := &generate.Pipeline{Inst: inst}
+		result := generate.ArtifactResult{ID: id, Content: content, FilePath: p.ArtifactPath(id)}
+		if err := generate.WriteResultsTo(generate.HeaderSink{Sink: sink, Header: header}, []generate.ArtifactResult{result}); err != nil {
+			return nil, err
+		}
+	}
+	return sink, nil
+}
+
+// saveArtifact writes the files generated for an artifact to dest: the
+// file itself, or for artifacts with several files (scripts, a split
+// reference) a directory holding them by base name.
+func saveArtifact(dest string, files map[string][]byte, inst *instructions.Instructions, id generate.ArtifactID, projectDir string) error {
+	sink, err := artifactFiles(files, inst, id, projectDir)
+	if err != nil {
+		return err
+	}
+	paths := sink.Paths()
+	if len(paths) == 1 {
+		data, _ := sink.File(paths[0])
+		return os.WriteFile(dest, data, 0o644)
+	}
+	if err := os.MkdirAll(dest, 0o755); err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, _ := sink.File(path)
+		mode := os.FileMode(0o644)
+		if id == generate.ArtifactScripts {
+			mode = 0o755
+		}
+		if err := os.WriteFile(filepath.Join(dest, filepath.Base(path)), data, mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// emitArtifact writes the files generated for an artifact to w. When the
+// build found the artifact up to date, the cached copy is emitted instead.
+func emitArtifact(w io.Writer, files map[string][]byte, inst *instructions.Instructions, id generate.ArtifactID, projectDir string) error {
+	sink, err := artifactFiles(files, inst, id, projectDir)
+	if err != nil {
+		return err
+	}
+
+	// Scripts expand to several files; label each so the stream stays readable
+	paths := sink.Paths()
+	for _, path := range paths {
+		data, _ := sink.File(path)
+		if len(paths) > 1 {
+			if _, err := fmt.Fprintf(w, "==> %s <==\n", path); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
 		}
-		lockFile.UpdateEntry(string(r.ID), inputHash, outputHash, model)
-		_ = cache.WriteCached(projectDir, string(r.ID), r.Content)
 	}
-	_ = cache.SaveLockFile(projectDir, lockFile)
+	return nil
+}
 
-	fmt.Printf("\nGeneration complete (%s) — output written to %s\n", elapsed.Round(time.Millisecond), outputDir)
+// emitPrompts writes the prompts of a --prompt-only build to stdout, or to
+// <dir>/<artifact>.prompt.txt (under a directory per skill for multi-skill
+// files) when dir is set.
+func emitPrompts(skills []skillcompiler.SkillResult, dir string, multi bool) error {
+	for _, sr := range skills {
+		skillDir := dir
+		if multi {
+			skillDir = filepath.Join(dir, instructions.Slug(sr.Name))
+		}
+		if dir == "" && multi {
+			fmt.Printf("== %s ==\n\n", sr.Name)
+		}
+		for _, a := range sr.Artifacts {
+			if dir == "" {
+				fmt.Print(a.Content)
+				continue
+			}
+			if err := os.MkdirAll(skillDir, 0o755); err != nil {
+				return err
+			}
+			path := filepath.Join(skillDir, a.ID+".prompt.txt")
+			if err := os.WriteFile(path, []byte(a.Content), 0o644); err != nil {
+				return err
+			}
+			fmt.Printf("Wrote %s\n", path)
+		}
+	}
 	return nil
 }
 
@@ -374,6 +887,8 @@ func runInit(cmd *cobra.Command, args []string) error {
 	typeFlag, _ := cmd.Flags().GetString("type")
 	nameFlag, _ := cmd.Flags().GetString("name")
 	force, _ := cmd.Flags().GetBool("force")
+	noLLM, _ := cmd.Flags().GetBool("no-llm")
+	language, _ := cmd.Flags().GetString("language")
 
 	outputFile := "COMPILER_INSTRUCTIONS.md"
 	if _, err := os.Stat(outputFile); err == nil && !force {
@@ -384,6 +899,20 @@ func runInit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("--name is required")
 	}
 
+	// Auto-detect a spec when neither --spec nor --type is given
+	if specFlag == "" && typeFlag == "" {
+		detectedType, detectedSpec, ok := detectInitSpec(".", nameFlag)
+		if !ok {
+			return fmt.Errorf("no spec found in current directory — pass --spec and --type")
+		}
+		typeFlag, specFlag = detectedType, detectedSpec
+		if typeFlag == "" {
+			fmt.Printf("Detected spec: %s\n", specFlag)
+		} else {
+			fmt.Printf("Detected %s spec: %s\n", typeFlag, specFlag)
+		}
+	}
+
 	// Build spec source for processing
 	var sources []instructions.SpecSource
 	switch typeFlag {
@@ -393,22 +922,30 @@ func runInit(cmd *cobra.Command, args []string) error {
 		}
 		sources = []instructions.SpecSource{{Type: "cli", Binary: specFlag}}
 	case "codebase":
-		path := "."
-		if specFlag != "" {
-			path = specFlag
+		if specFlag == "" {
+			specFlag = "."
 		}
-		sources = []instructions.SpecSource{{Type: "codebase", Path: path}}
+		sources = []instructions.SpecSource{{Type: "codebase", Path: specFlag}}
 	default:
 		if specFlag == "" {
 			specFlag = "./openapi.yaml"
 		}
-		sources = []instructions.SpecSource{{Path: specFlag}}
+		sources = []instructions.SpecSource{{Path: specFlag, Type: typeFlag}}
+	}
+	specConfig := initSpecConfig(typeFlag, specFlag)
+
+	if noLLM {
+		if err := os.WriteFile(outputFile, []byte(initSkeleton(nameFlag, specConfig, language)), 0o644); err != nil {
+			return fmt.Errorf("writing %s: %w", outputFile, err)
+		}
+		fmt.Printf("Created %s — fill in the REVIEW-marked sections before running `sc generate`\n", outputFile)
+		return nil
 	}
 
 	// Process specs
 	fmt.Println("Parsing spec sources...")
-	reg := newPluginRegistry()
-	parsedIR, _, err := reg.ProcessSources(sources)
+	reg := plugins.NewRegistry()
+	parsedIR, _, err := reg.ProcessSources(cmd.Context(), sources)
 	if err != nil {
 		return fmt.Errorf("processing specs: %w", err)
 	}
@@ -426,24 +963,19 @@ func runInit(cmd *cobra.Command, args []string) error {
 	// Generate instructions file using LLM
 	irJSON, _ := json.MarshalIndent(parsedIR, "", "  ")
 
-	specConfig := specFlag
-	switch typeFlag {
-	case "cli":
-		specConfig = fmt.Sprintf("\n  type: cli\n  binary: %s", specFlag)
-	case "codebase":
-		specConfig = "\n  type: codebase\n  path: ."
-	}
-
 	userMsg := fmt.Sprintf("Project name: %s\nSpec type: %s\nSpec config: %s\n\nSpec (IR):\n```json\n%s\n```",
 		nameFlag, typeFlag, specConfig, string(irJSON))
+	if !generate.IsEnglish(language) {
+		userMsg = fmt.Sprintf("Language: %s\n", language) + userMsg
+	}
 
 	fmt.Println("Generating instructions file...")
-	ctx := context.Background()
-	resp, err := prov.Generate(ctx, provider.GenerateRequest{
-		SystemPrompt: generate.InitPrompt,
+	ctx := cmd.Context()
+	resp, err := prov.Generate(ctx, provider.CapabilitiesOf(prov).Adapt(provider.GenerateRequest{
+		SystemPrompt: generate.InitPrompt + generate.LanguagePrompt(language),
 		UserMessage:  userMsg,
 		MaxTokens:    8192,
-	})
+	}))
 	if err != nil {
 		return fmt.Errorf("generating instructions: %w", err)
 	}
@@ -456,68 +988,491 @@ func runInit(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
-func runValidate(cmd *cobra.Command, args []string) error {
-	inst, err := instructions.Parse("COMPILER_INSTRUCTIONS.md")
-	if err != nil {
-		return err
-	}
-
-	hasErrors := false
-
-	// Validate instructions
-	warnings := inst.Validate()
-	for _, w := range warnings {
-		fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
-	}
+// initSpecFiles are well-known spec filenames checked by `sc init`, in order.
+var initSpecFiles = []string{
+	"openapi.yaml", "openapi.yml", "openapi.json",
+	"swagger.yaml", "swagger.yml", "swagger.json",
+	"asyncapi.yaml", "asyncapi.yml", "asyncapi.json",
+}
 
-	// Resolve and validate spec sources
-	sources, err := inst.ResolveSpecSources()
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
-		hasErrors = true
-	} else {
-		reg := newPluginRegistry()
-		parsedIR, parseWarnings, err := reg.ProcessSources(sources)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "ERROR parsing specs: %s\n", err)
-			hasErrors = true
-		} else {
-			for _, w := range parseWarnings {
-				fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
-			}
-			fmt.Printf("Spec valid: %d operations, %d types\n", len(parsedIR.Operations), len(parsedIR.Types))
+// initManifests mark a directory as a codebase spec source.
+var initManifests = []string{"go.mod", "Cargo.toml", "package.json", "pyproject.toml"}
+
+// detectInitSpec looks for a likely spec in dir: a well-known spec file or
+// Postman collection first, then a package manifest (codebase), then a HAR
+// capture, then a binary on PATH named after the project. The returned type is empty when the
+// registry can detect it from the path.
+func detectInitSpec(dir, name string) (specType, spec string, ok bool) {
+	for _, f := range initSpecFiles {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			return "", "./" + f, true
 		}
 	}
-
-	// Check for skills-ref validate
-	if skillsRef, err := exec.LookPath("skills-ref"); err == nil {
-		outputDir := inst.Frontmatter.Out
-		skillDir := filepath.Join(outputDir, inst.Frontmatter.Name)
-		if _, err := os.Stat(skillDir); err == nil {
-			fmt.Printf("Running skills-ref validate on %s...\n", skillDir)
-			validateCmd := exec.Command(skillsRef, "validate", skillDir)
-			validateCmd.Stdout = os.Stdout
-			validateCmd.Stderr = os.Stderr
-			if err := validateCmd.Run(); err != nil {
-				hasErrors = true
-			}
-		} else {
-			fmt.Println("Skill directory not found — run `sc generate` first to validate against Agent Skills spec")
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.postman_collection.json")); len(matches) > 0 {
+		return "", "./" + filepath.Base(matches[0]), true
+	}
+	for _, f := range initManifests {
+		if _, err := os.Stat(filepath.Join(dir, f)); err == nil {
+			return "codebase", ".", true
 		}
-	} else {
-		fmt.Println("Note: Install skills-ref for Agent Skills spec validation:")
-		fmt.Println("  go install github.com/agentskills/agentskills/skills-ref@latest")
 	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.har")); len(matches) > 0 {
+		return "", "./" + filepath.Base(matches[0]), true
+	}
+	if _, err := exec.LookPath(name); err == nil {
+		return "cli", name, true
+	}
+	return "", "", false
+}
 
-	if hasErrors {
-		os.Exit(1)
+// initSpecConfig renders the value of the frontmatter spec: key.
+func initSpecConfig(specType, spec string) string {
+	switch specType {
+	case "cli":
+		return fmt.Sprintf("\n  type: cli\n  binary: %s", spec)
+	case "codebase":
+		return fmt.Sprintf("\n  type: codebase\n  path: %s", spec)
+	case "":
+		return spec
+	default:
+		return fmt.Sprintf("\n  type: %s\n  path: %s", specType, spec)
 	}
-	fmt.Println("Validation passed")
-	return nil
 }
 
-func runDiff(cmd *cobra.Command, args []string) error {
-	againstDir, _ := cmd.Flags().GetString("against")
+// initSkeleton is the instructions file `sc init --no-llm` writes: the
+// frontmatter plus empty sections marked for review.
+func initSkeleton(name, specConfig, language string) string {
+	languageLine := ""
+	if !generate.IsEnglish(language) {
+		languageLine = "language: " + language + "\n"
+	}
+	return fmt.Sprintf(`---
+version: %d
+name: %s
+spec: %s
+out: ./sc-out/
+%s---
+
+# Product
+
+<!-- REVIEW: What the tool does, who uses it, and its key value props. -->
+
+# Workflows
+
+<!-- REVIEW: Common multi-step workflows agents will perform. -->
+
+# Guardrails
+
+<!-- REVIEW: Safety rules, rate limits, and things to avoid. -->
+
+# Conventions
+
+<!-- REVIEW: Naming patterns, value formats, and common patterns. -->
+`, instructions.CurrentVersion, name, specConfig, languageLine)
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	instPath, _ := cmd.Flags().GetString("instructions")
+	strict, _ := cmd.Flags().GetBool("strict")
+
+	diags := collectDiagnostics(cmd.Context(), instPath, func(skill string, parsed *ir.IntermediateRepr) {
+		if skill != "" {
+			fmt.Printf("%s: ", skill)
+		}
+		fmt.Printf("Spec valid: %d operations, %d types\n", len(parsed.Operations), len(parsed.Types))
+	})
+	if err := diag.Write(os.Stderr, diag.FormatText, diags); err != nil {
+		return err
+	}
+
+	skillsRefFailed := false
+	if inst, err := instructions.Parse(instPath); err == nil {
+		skillsRefFailed = runSkillsRef(inst)
+	}
+
+	counts := diag.Counts(diags)
+	switch {
+	case counts[diag.SeverityError] > 0 || skillsRefFailed:
+		return fmt.Errorf("validation failed: %s", diag.FormatCounts(counts))
+	case strict && counts[diag.SeverityWarning] > 0:
+		return fmt.Errorf("validation failed (--strict): %s", diag.FormatCounts(counts))
+	}
+	if len(diags) > 0 {
+		fmt.Printf("Validation passed (%s)\n", diag.FormatCounts(counts))
+		return nil
+	}
+	fmt.Println("Validation passed")
+	return nil
+}
+
+// runSkillsRef checks each skill's latest output directory against the
+// Agent Skills spec when skills-ref is installed, reporting whether any
+// check failed.
+func runSkillsRef(inst *instructions.Instructions) bool {
+	skillsRef, err := exec.LookPath("skills-ref")
+	if err != nil {
+		fmt.Println("Note: Install skills-ref for Agent Skills spec validation:")
+		fmt.Println("  go install github.com/agentskills/agentskills/skills-ref@latest")
+		return false
+	}
+	failed := false
+	for _, sk := range inst.Skills() {
+		skillDir := filepath.Join(generate.LatestOutputDir(sk.Frontmatter.Out, sk.Frontmatter.Name), sk.Slug())
+		if _, err := os.Stat(skillDir); err != nil {
+			fmt.Println("Skill directory not found — run `sc generate` first to validate against Agent Skills spec")
+			continue
+		}
+		fmt.Printf("Running skills-ref validate on %s...\n", skillDir)
+		validateCmd := exec.Command(skillsRef, "validate", skillDir)
+		validateCmd.Stdout = os.Stdout
+		validateCmd.Stderr = os.Stderr
+		if err := validateCmd.Run(); err != nil {
+			failed = true
+		}
+	}
+	return failed
+}
+
+func runCheck(cmd *cobra.Command, args []string) error {
+	instPath, _ := cmd.Flags().GetString("instructions")
+	format, _ := cmd.Flags().GetString("format")
+
+	switch format {
+	case diag.FormatText, diag.FormatJSON, diag.FormatSARIF:
+	default:
+		return fmt.Errorf("unknown --format %q (expected text, json, or sarif)", format)
+	}
+
+	diags := collectDiagnostics(cmd.Context(), instPath, nil)
+	if format == diag.FormatText && len(diags) == 0 {
+		fmt.Println("No issues found")
+		return nil
+	}
+	if err := diag.Write(os.Stdout, format, diags); err != nil {
+		return err
+	}
+	if diag.HasErrors(diags) {
+		os.Exit(1)
+	}
+	return nil
+}
+
+// collectDiagnostics gathers instruction and spec diagnostics, reporting
+// fatal problems as error-severity diagnostics rather than returning early
+// with an error, so machine-readable output always covers every finding.
+// onSpec, if set, is called with each skill's parsed IR; skill is empty for
+// single-skill files.
+func collectDiagnostics(ctx context.Context, instPath string, onSpec func(skill string, parsed *ir.IntermediateRepr)) []diag.Diagnostic {
+	inst, err := instructions.Parse(instPath)
+	if err != nil {
+		return []diag.Diagnostic{{
+			Severity: diag.SeverityError,
+			Code:     "instructions",
+			Message:  err.Error(),
+			Source:   instPath,
+		}}
+	}
+
+	var diags []diag.Diagnostic
+	for _, d := range inst.Validate() {
+		if d.Source == "" {
+			d.Source = instPath
+		}
+		diags = append(diags, d)
+	}
+
+	multi := len(inst.Frontmatter.Skills) > 0
+	for _, sk := range inst.Skills() {
+		sources, err := sk.ResolveSpecSources()
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.SeverityError,
+				Code:     "spec-sources",
+				Message:  instructions.SkillError(multi, sk, err).Error(),
+				Source:   instPath,
+			})
+			continue
+		}
+		parsedIR, warnings, err := plugins.NewRegistry().ProcessSources(ctx, sources)
+		if err != nil {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.SeverityError,
+				Code:     "spec-parse",
+				Message:  instructions.SkillError(multi, sk, err).Error(),
+			})
+			continue
+		}
+		diags = append(diags, warnings...)
+		skillFile := (&generate.Pipeline{Inst: sk}).ArtifactPath(generate.ArtifactSkill)
+		skillPath := filepath.Join(generate.LatestOutputDir(sk.Frontmatter.Out, sk.Frontmatter.Name), skillFile)
+		if content, err := os.ReadFile(skillPath); err == nil {
+			diags = append(diags, generate.LintSkill(string(content), skillPath, generate.LintConfig(sk.Frontmatter))...)
+		}
+		if onSpec != nil {
+			skill := ""
+			if multi {
+				skill = sk.Frontmatter.Name
+			}
+			onSpec(skill, parsedIR)
+		}
+	}
+	return diags
+}
+
+// explainMatch is an operation found by `sc explain`, with the IR it came
+// from so its type references can be resolved.
+type explainMatch struct {
+	skill string // set in multi-skill builds
+	op    ir.Operation
+	ir    *ir.IntermediateRepr
+	score int // see ir.Operation.MatchScore
+}
+
+func runExplain(cmd *cobra.Command, args []string) error {
+	instPath, _ := cmd.Flags().GetString("instructions")
+	specFlag, _ := cmd.Flags().GetString("spec")
+	query := args[0]
+
+	inst, err := instructions.Parse(instPath)
+	if err != nil {
+		return err
+	}
+	multi := len(inst.Frontmatter.Skills) > 0
+
+	var matches []explainMatch
+	for _, sk := range inst.Skills() {
+		var sources []instructions.SpecSource
+		if specFlag != "" {
+			sources = []instructions.SpecSource{{Path: specFlag}}
+		} else {
+			sources, err = sk.ResolveSpecSources()
+			if err != nil {
+				return instructions.SkillError(multi, sk, fmt.Errorf("resolving spec sources: %w", err))
+			}
+		}
+		parsedIR, _, err := plugins.NewRegistry().ProcessSources(cmd.Context(), sources)
+		if err != nil {
+			return instructions.SkillError(multi, sk, fmt.Errorf("processing specs: %w", err))
+		}
+		skill := ""
+		if multi {
+			skill = sk.Frontmatter.Name
+		}
+		for _, op := range parsedIR.Search(query) {
+			matches = append(matches, explainMatch{skill: skill, op: op, ir: parsedIR, score: op.MatchScore(query)})
+		}
+		if specFlag != "" {
+			break // every skill would parse the same spec
+		}
+	}
+
+	// Only the best matches are candidates
+	best := 0
+	for _, m := range matches {
+		best = max(best, m.score)
+	}
+	matches = slices.DeleteFunc(matches, func(m explainMatch) bool { return m.score < best })
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("no operation matches %q", query)
+	case 1:
+		fmt.Print(explainOperation(matches[0].op, matches[0].ir))
+		return nil
+	}
+
+	fmt.Printf("%q matches %d operations:\n", query, len(matches))
+	labels := make([]string, len(matches))
+	width := 0
+	for i, m := range matches {
+		labels[i] = m.op.ID
+		if m.skill != "" {
+			labels[i] = m.skill + ": " + m.op.ID
+		}
+		width = max(width, len(labels[i]))
+	}
+	for i, m := range matches {
+		fmt.Printf("  %-*s  %s %s\n", width, labels[i], m.op.Method, m.op.Path)
+	}
+	return fmt.Errorf("ambiguous operation %q — use a full ID or path", query)
+}
+
+// explainOperation renders an operation's normalized details, expanding
+// request and response body types from the IR.
+func explainOperation(op ir.Operation, parsed *ir.IntermediateRepr) string {
+	types := make(map[string]ir.TypeDef, len(parsed.Types))
+	for _, td := range parsed.Types {
+		types[td.Name] = td
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", op.ID)
+	if route := strings.TrimSpace(op.Method + " " + op.Path); route != "" {
+		fmt.Fprintf(&b, "  %s\n", route)
+	}
+	if op.Name != "" && op.Name != op.ID {
+		fmt.Fprintf(&b, "  Summary:     %s\n", op.Name)
+	}
+	if op.Description != "" && op.Description != op.Name {
+		fmt.Fprintf(&b, "  Description: %s\n", op.Description)
+	}
+	if len(op.Tags) > 0 {
+		fmt.Fprintf(&b, "  Tags:        %s\n", strings.Join(op.Tags, ", "))
+	}
+	if op.Deprecated {
+		fmt.Fprintf(&b, "  Deprecated:  %s\n", deprecationText(op.DeprecationNote, op.Sunset))
+	}
+	if op.Pagination != nil {
+		fmt.Fprintf(&b, "  Pagination:  %s (%s)\n", op.Pagination.Style, strings.Join(op.Pagination.Params, ", "))
+	}
+
+	if len(op.Parameters) > 0 {
+		b.WriteString("\nParameters:\n")
+		for _, param := range op.Parameters {
+			attrs := []string{}
+			for _, a := range []string{param.In, param.Type} {
+				if a != "" {
+					attrs = append(attrs, a)
+				}
+			}
+			if param.Required {
+				attrs = append(attrs, "required")
+			}
+			if param.Default != "" {
+				attrs = append(attrs, "default "+param.Default)
+			}
+			if param.Constraints != nil {
+				if c := param.Constraints.String(); c != "" {
+					attrs = append(attrs, c)
+				}
+			}
+			if param.Deprecated || param.DeprecationNote != "" || param.Sunset != "" {
+				attrs = append(attrs, "deprecated: "+deprecationText(param.DeprecationNote, param.Sunset))
+			}
+			line := "  " + param.Name
+			if len(attrs) > 0 {
+				line += " (" + strings.Join(attrs, ", ") + ")"
+			}
+			if param.Description != "" {
+				line += " — " + param.Description
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	if op.RequestBody != nil {
+		b.WriteString("\nRequest body:\n")
+		writeTypeRef(&b, op.RequestBody, types)
+	}
+
+	if len(op.Responses) > 0 {
+		b.WriteString("\nResponses:\n")
+		for _, resp := range op.Responses {
+			line := "  " + resp.StatusCode
+			if resp.Description != "" {
+				line += " — " + resp.Description
+			}
+			b.WriteString(line + "\n")
+			if resp.Body != nil {
+				writeTypeRef(&b, resp.Body, types)
+			}
+		}
+	}
+
+	if len(op.Auth) > 0 {
+		b.WriteString("\nAuth: " + op.AuthText() + "\n")
+		seen := make(map[string]bool)
+		for _, ids := range op.Auth {
+			for _, id := range ids {
+				if seen[id] {
+					continue
+				}
+				seen[id] = true
+				line := "  " + id
+				for _, scheme := range parsed.Auth {
+					if scheme.ID == id {
+						line += " (" + strings.Join(nonEmpty(scheme.Type, scheme.Scheme, scheme.In, scheme.Name), ", ") + ")"
+						break
+					}
+				}
+				b.WriteString(line + "\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// deprecationText renders a deprecation note and sunset date for explain.
+func deprecationText(note, sunset string) string {
+	text := "yes"
+	if note != "" {
+		text = note
+	}
+	if sunset != "" {
+		text += " (sunset " + sunset + ")"
+	}
+	return text
+}
+
+// writeTypeRef prints a body type and, when the IR defines it, its fields.
+func writeTypeRef(b *strings.Builder, ref *ir.TypeRef, types map[string]ir.TypeDef) {
+	name := ref.TypeName
+	if name == "" {
+		name = "(inline)"
+	}
+	if name == "(inline)" && ref.ContentType == "" && ref.Description != "" {
+		b.WriteString("    " + ref.Description + "\n") // e.g. an empty body
+		return
+	}
+	line := "    " + name
+	if ref.ContentType != "" {
+		line += " [" + strings.Join(append([]string{ref.ContentType}, ref.AltContentTypes...), ", ") + "]"
+	}
+	if ref.Description != "" {
+		line += " — " + ref.Description
+	}
+	b.WriteString(line + "\n")
+
+	td, ok := types[ref.TypeName]
+	if !ok {
+		return
+	}
+	for _, f := range td.Fields {
+		field := fmt.Sprintf("      %s: %s", f.Name, f.Type)
+		if f.Required {
+			field += " (required)"
+		}
+		if f.Description != "" {
+			field += " — " + f.Description
+		}
+		b.WriteString(field + "\n")
+	}
+	if len(td.Enum) > 0 {
+		fmt.Fprintf(b, "      enum: %s\n", strings.Join(td.Enum, ", "))
+	}
+}
+
+func nonEmpty(values ...string) []string {
+	var out []string
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	againstDir, _ := cmd.Flags().GetString("against")
+	seedPath, _ := cmd.Flags().GetString("seed-examples")
+	outputFormat, _ := cmd.Flags().GetString("output-format")
+	if err := checkOutputFormat(outputFormat); err != nil {
+		return err
+	}
+	includeRawHelp, _ := cmd.Flags().GetBool("include-raw-help")
+
+	if againstVersion, _ := cmd.Flags().GetString("against-version"); againstVersion != "" {
+		return runDiffVersions(cmd.Context(), againstVersion)
+	}
 
 	projectDir, _ := os.Getwd()
 	lockFile, err := cache.LoadLockFile(projectDir)
@@ -529,25 +1484,138 @@ func runDiff(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	var seeds []generate.SeedExample
+	if seedPath != "" {
+		if seeds, err = generate.LoadSeedExamples(seedPath); err != nil {
+			return fmt.Errorf("loading seed examples: %w", err)
+		}
+	}
 
-	sources, err := inst.ResolveSpecSources()
+	multi := len(inst.Frontmatter.Skills) > 0
+	drifted := false
+	for _, sk := range inst.Skills() {
+		cachePrefix := ""
+		if multi {
+			cachePrefix = sk.Frontmatter.Name + "/"
+		}
+		skillDrifted, err := diffSkill(cmd.Context(), sk, lockFile, cachePrefix, againstDir, generate.Options{SeedExamples: seeds, OutputFormat: outputFormat, IncludeRawHelp: includeRawHelp})
+		if err != nil {
+			return instructions.SkillError(multi, sk, err)
+		}
+		drifted = drifted || skillDrifted
+	}
+
+	if drifted {
+		fmt.Println("\nSpec or instructions have changed since last generation.")
+		fmt.Println("Run `sc generate` to update artifacts.")
+		os.Exit(1)
+	}
+
+	fmt.Println("All artifacts up to date.")
+	return nil
+}
+
+// runDiffVersions prints how each skill's spec changed between
+// againstVersion and the version the frontmatter selects.
+func runDiffVersions(ctx context.Context, againstVersion string) error {
+	inst, err := instructions.Parse("COMPILER_INSTRUCTIONS.md")
 	if err != nil {
 		return err
 	}
+	multi := len(inst.Frontmatter.Skills) > 0
+	for _, sk := range inst.Skills() {
+		if err := diffSkillVersions(ctx, sk, againstVersion); err != nil {
+			return instructions.SkillError(multi, sk, err)
+		}
+	}
+	return nil
+}
 
-	reg := newPluginRegistry()
-	parsedIR, _, err := reg.ProcessSources(sources)
+func diffSkillVersions(ctx context.Context, inst *instructions.Instructions, againstVersion string) error {
+	current, err := inst.ResolveSpecSources()
+	if err != nil {
+		return err
+	}
+	currentVersion := ""
+	for _, src := range current {
+		if src.Versioned() {
+			currentVersion = src.Version
+			break
+		}
+	}
+	if currentVersion == "" {
+		return errors.New("--against-version: no spec source selects a version; set spec.version or use a {version} placeholder")
+	}
+	against, err := inst.ResolveSpecSourcesAt(againstVersion)
 	if err != nil {
 		return err
 	}
 
-	irJSON, _ := json.Marshal(parsedIR)
+	currentIR, _, err := plugins.NewRegistry().ProcessSources(ctx, current)
+	if err != nil {
+		return fmt.Errorf("version %s: %w", currentVersion, err)
+	}
+	againstIR, _, err := plugins.NewRegistry().ProcessSources(ctx, against)
+	if err != nil {
+		return fmt.Errorf("version %s: %w", againstVersion, err)
+	}
+
+	changes := ir.Diff(againstIR, currentIR)
+	if changes.Empty() {
+		fmt.Printf("No operation changes from %s to %s.\n", againstVersion, currentVersion)
+		return nil
+	}
+	fmt.Printf("Changes from %s to %s:\n", againstVersion, currentVersion)
+	for _, op := range changes.Added {
+		fmt.Printf("  ADDED:      %s\n", op.Label())
+	}
+	for _, op := range changes.Removed {
+		fmt.Printf("  REMOVED:    %s\n", op.Label())
+	}
+	for _, op := range changes.Deprecated {
+		fmt.Printf("  DEPRECATED: %s\n", op.Label())
+	}
+	for _, d := range changes.DeprecatedParameters {
+		fmt.Printf("  DEPRECATED: parameter %s\n", d.Label())
+	}
+	for _, ch := range changes.Changed {
+		fmt.Printf("  CHANGED:    %s: %s\n", ch.Operation.Label(), strings.Join(ch.Details, "; "))
+	}
+	return nil
+}
+
+// checkOutputFormat validates an --output-format value.
+func checkOutputFormat(format string) error {
+	switch format {
+	case "", instructions.FormatMarkdown, instructions.FormatMDX, instructions.FormatAsciiDoc:
+		return nil
+	}
+	return fmt.Errorf("--output-format: unknown format %q (expected %s, %s, or %s)",
+		format, instructions.FormatMarkdown, instructions.FormatMDX, instructions.FormatAsciiDoc)
+}
+
+// diffSkill reports lockfile drift for one skill and, with againstDir, file
+// differences between its output directory and againstDir.
+func diffSkill(ctx context.Context, inst *instructions.Instructions, lockFile *cache.LockFile, cachePrefix, againstDir string, opts generate.Options) (bool, error) {
+	sources, err := inst.ResolveSpecSources()
+	if err != nil {
+		return false, err
+	}
+
+	reg := plugins.NewRegistry()
+	parsedIR, _, err := reg.ProcessSources(ctx, sources)
+	if err != nil {
+		return false, err
+	}
+
+	irJSON, _ := parsedIR.CanonicalJSON()
 	specContent := string(irJSON)
 
 	// Build a pipeline to get per-artifact system prompts and relevant sections
 	pipeline := &generate.Pipeline{
 		IR:   parsedIR,
 		Inst: inst,
+		Opts: opts,
 	}
 
 	drifted := false
@@ -555,15 +1623,18 @@ func runDiff(cmd *cobra.Command, args []string) error {
 		prompt := pipeline.SystemPromptFor(id)
 		sections := pipeline.RelevantSections(id)
 		inputHash := cache.HashInput(specContent, sections, prompt)
-		if !lockFile.IsUpToDate(string(id), inputHash) {
-			fmt.Printf("  DRIFTED: %s\n", id)
+		if !lockFile.IsUpToDate(cachePrefix+string(id), inputHash) {
+			fmt.Printf("  DRIFTED: %s%s\n", cachePrefix, id)
 			drifted = true
 		}
 	}
 
 	// If --against is provided, compare generated files against that directory
 	if againstDir != "" {
-		outputDir := inst.Frontmatter.Out
+		outputDir := generate.LatestOutputDir(inst.Frontmatter.Out, inst.Frontmatter.Name)
+		if cachePrefix != "" {
+			againstDir = filepath.Join(againstDir, inst.Slug())
+		}
 		fmt.Printf("Comparing %s against %s:\n", outputDir, againstDir)
 		for _, id := range generate.AllArtifacts {
 			filePath := pipeline.ArtifactPath(id)
@@ -587,15 +1658,7 @@ func runDiff(cmd *cobra.Command, args []string) error {
 			}
 		}
 	}
-
-	if drifted {
-		fmt.Println("\nSpec or instructions have changed since last generation.")
-		fmt.Println("Run `sc generate` to update artifacts.")
-		os.Exit(1)
-	}
-
-	fmt.Println("All artifacts up to date.")
-	return nil
+	return drifted, nil
 }
 
 func runServe(cmd *cobra.Command, args []string) error {
@@ -606,7 +1669,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 		// Try to infer from instructions
 		inst, err := instructions.Parse("COMPILER_INSTRUCTIONS.md")
 		if err == nil {
-			dir = inst.Frontmatter.Out
+			dir = generate.LatestOutputDir(inst.Frontmatter.Out, inst.Frontmatter.Name)
 		} else {
 			dir = "./sc-out/"
 		}
@@ -655,12 +1718,26 @@ func runConfigList(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	width := 0
+	for _, key := range config.ValidKeys {
+		width = max(width, len(key))
+	}
 	for _, key := range config.ValidKeys {
 		v := values[key]
 		if v == "" {
 			v = "(not set)"
 		}
-		fmt.Printf("%-10s %s\n", key, v)
+		fmt.Printf("%-*s %s\n", width, key, v)
+	}
+	var defaults []string
+	for key := range values {
+		if !slices.Contains(config.ValidKeys, key) {
+			defaults = append(defaults, key)
+		}
+	}
+	slices.Sort(defaults)
+	for _, key := range defaults {
+		fmt.Printf("%s %s\n", key, values[key])
 	}
 	return nil
 }
@@ -672,3 +1749,369 @@ func runConfigReset(cmd *cobra.Command, args []string) error {
 	fmt.Println("Config reset to defaults")
 	return nil
 }
+
+func runUpgradeInstructions(cmd *cobra.Command, args []string) error {
+	instPath, _ := cmd.Flags().GetString("instructions")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	info, err := os.Stat(instPath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(instPath)
+	if err != nil {
+		return err
+	}
+	upgraded, changes, err := instructions.Upgrade(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", instPath, err)
+	}
+	if len(changes) == 0 {
+		fmt.Printf("%s is up to date (version %d)\n", instPath, instructions.CurrentVersion)
+		return nil
+	}
+	if dryRun {
+		fmt.Print(lineDiff(instPath, string(data), string(upgraded)))
+		fmt.Printf("\nWould upgrade %s:\n", instPath)
+	} else {
+		if err := os.WriteFile(instPath, upgraded, info.Mode().Perm()); err != nil {
+			return err
+		}
+		fmt.Printf("Upgraded %s:\n", instPath)
+	}
+	for _, change := range changes {
+		fmt.Printf("  - %s\n", change)
+	}
+	return nil
+}
+
+// lineDiff renders a unified-style diff of two texts with a few lines of
+// context around each change. It is meant for short files like instructions.
+func lineDiff(name, a, b string) string {
+	const context = 2
+	x, y := strings.SplitAfter(strings.TrimSuffix(a, "\n"), "\n"), strings.SplitAfter(strings.TrimSuffix(b, "\n"), "\n")
+
+	// lcs[i][j] is the longest common subsequence of x[i:] and y[j:]
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	type line struct {
+		op   byte
+		text string
+	}
+	var lines []line
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			lines = append(lines, line{' ', x[i]})
+			i, j = i+1, j+1
+		case j < len(y) && (i == len(x) || lcs[i][j+1] >= lcs[i+1][j]):
+			lines = append(lines, line{'+', y[j]})
+			j++
+		default:
+			lines = append(lines, line{'-', x[i]})
+			i++
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s (upgraded)\n", name, name)
+	skipped := false
+	for k, l := range lines {
+		near := false
+		for d := max(0, k-context); d <= min(len(lines)-1, k+context); d++ {
+			near = near || lines[d].op != ' '
+		}
+		if !near {
+			skipped = true
+			continue
+		}
+		if skipped || k == 0 {
+			out.WriteString("@@\n")
+			skipped = false
+		}
+		out.WriteByte(l.op)
+		out.WriteString(strings.TrimSuffix(l.text, "\n"))
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
+
+func runModels(cmd *cobra.Command, args []string) error {
+	instPath, _ := cmd.Flags().GetString("instructions")
+	providerFlag, _ := cmd.Flags().GetString("provider")
+
+	var fmProvider *config.Config
+	if inst, err := instructions.Parse(instPath); err == nil {
+		fmProvider = &config.Config{
+			Provider: inst.Frontmatter.Provider.Provider,
+			Model:    inst.Frontmatter.Provider.Model,
+			APIKey:   inst.Frontmatter.Provider.APIKey,
+			BaseURL:  inst.Frontmatter.Provider.BaseURL,
+		}
+	}
+	resolved, err := config.Resolve(providerFlag, "", "", "", fmProvider)
+	if err != nil {
+		return fmt.Errorf("resolving provider config: %w", err)
+	}
+
+	name := strings.ToLower(resolved.Provider)
+	if name == "" {
+		name = "anthropic"
+	}
+	var models []string
+	prov, err := provider.NewLister(resolved)
+	if err == nil {
+		name = prov.Name()
+		if lister, ok := prov.(provider.ModelLister); ok {
+			models, err = lister.Models(context.Background())
+		} else {
+			err = fmt.Errorf("%s does not list models", name)
+		}
+	}
+	if err == nil && len(models) == 0 {
+		err = errors.New("no models returned")
+	}
+	if err != nil {
+		models = provider.KnownModels(name)
+		if len(models) == 0 {
+			return fmt.Errorf("listing models for %s: %w", name, err)
+		}
+		fmt.Fprintf(os.Stderr, "Could not list models (%s); showing known %s models\n", err, name)
+	}
+
+	current, _ := provider.ModelFor(resolved)
+	fmt.Printf("Models for %s:\n", name)
+	for _, m := range models {
+		marker := " "
+		if m == current {
+			marker = "*"
+		}
+		fmt.Printf("%s %s\n", marker, m)
+	}
+	return nil
+}
+
+// doctorCheck is one line of the `sc doctor` report.
+type doctorCheck struct {
+	name string
+	err  error  // nil when the check passed
+	warn bool   // err is a warning rather than a failure
+	skip bool   // the check did not run
+	hint string // how to fix it, shown when err is set
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	instPath, _ := cmd.Flags().GetString("instructions")
+	offline, _ := cmd.Flags().GetBool("offline")
+
+	checks := doctorChecks(cmd.Context(), instPath, offline)
+	failed, warned := 0, 0
+	for _, c := range checks {
+		switch {
+		case c.skip:
+			fmt.Printf("[skip] %s\n", c.name)
+		case c.err == nil:
+			fmt.Printf("[ok]   %s\n", c.name)
+		case c.warn:
+			warned++
+			fmt.Printf("[warn] %s: %s\n", c.name, c.err)
+		default:
+			failed++
+			fmt.Printf("[FAIL] %s: %s\n", c.name, c.err)
+		}
+		if c.err != nil && c.hint != "" {
+			fmt.Printf("       hint: %s\n", c.hint)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("doctor: %d of %d checks failed", failed, len(checks))
+	}
+	if warned > 0 {
+		fmt.Printf("\nAll critical checks passed (%d warning(s))\n", warned)
+	} else {
+		fmt.Println("\nAll checks passed")
+	}
+	return nil
+}
+
+// doctorChecks runs the `sc doctor` checks in order. Checks that depend on
+// an earlier one that failed are left out.
+func doctorChecks(ctx context.Context, instPath string, offline bool) []doctorCheck {
+	var checks []doctorCheck
+
+	_, err := config.Load()
+	checks = append(checks, doctorCheck{
+		name: "Config file loads",
+		err:  err,
+		hint: "fix the YAML in ~/.config/sc/config.yaml, or remove it with `sc config reset`",
+	})
+
+	inst, err := instructions.Parse(instPath)
+	checks = append(checks, doctorCheck{
+		name: "Instructions parse (" + instPath + ")",
+		err:  err,
+		hint: "create one with `sc init`, or pass its path with -f",
+	})
+	if inst != nil {
+		var problems []string
+		for _, d := range inst.Validate() {
+			if d.Severity == diag.SeverityError {
+				problems = append(problems, d.Message)
+			}
+		}
+		if len(problems) > 0 {
+			checks = append(checks, doctorCheck{
+				name: "Instructions are valid",
+				err:  errors.New(strings.Join(problems, "; ")),
+				hint: "run `sc validate` for details",
+			})
+		}
+	}
+
+	var fmProvider *config.Config
+	if inst != nil {
+		fmProvider = &config.Config{
+			Provider: inst.Frontmatter.Provider.Provider,
+			Model:    inst.Frontmatter.Provider.Model,
+			APIKey:   inst.Frontmatter.Provider.APIKey,
+			BaseURL:  inst.Frontmatter.Provider.BaseURL,
+		}
+	}
+	var prov provider.Provider
+	resolved, err := config.Resolve("", "", "", "", fmProvider)
+	if err == nil {
+		prov, err = provider.New(resolved)
+	}
+	checks = append(checks, doctorCheck{
+		name: "Provider resolves",
+		err:  err,
+		hint: "set an API key with `sc config set api-key <key>` or SC_API_KEY, and a model with `sc config set model <model>`",
+	})
+	if prov != nil {
+		checks = append(checks, doctorAuthCheck(prov, resolved, offline))
+	}
+
+	if inst == nil {
+		return checks
+	}
+	multi := len(inst.Frontmatter.Skills) > 0
+	for _, sk := range inst.Skills() {
+		label := ""
+		if multi {
+			label = sk.Frontmatter.Name + ": "
+		}
+		sources, err := sk.ResolveSpecSources()
+		if err != nil {
+			checks = append(checks, doctorCheck{
+				name: label + "Spec sources resolve",
+				err:  err,
+				hint: "fix the spec key in the frontmatter",
+			})
+		}
+		for _, src := range sources {
+			parsed, _, err := plugins.NewRegistry().ProcessSources(ctx, []instructions.SpecSource{src})
+			c := doctorCheck{name: fmt.Sprintf("%sSpec %s is readable", label, src), err: err, hint: specHint(src)}
+			if err == nil {
+				c.name += fmt.Sprintf(" (%d operations, %d types)", len(parsed.Operations), len(parsed.Types))
+			}
+			checks = append(checks, c)
+		}
+
+		out := sk.Frontmatter.Out
+		checks = append(checks, doctorCheck{
+			name: fmt.Sprintf("%sOutput directory %s is writable", label, out),
+			err:  checkWritable(out),
+			hint: "choose another out directory in the frontmatter, or fix its permissions",
+		})
+	}
+	return checks
+}
+
+// doctorAuthCheck verifies the API key by listing models, which costs no
+// tokens. Providers that cannot list models are only warned about.
+func doctorAuthCheck(prov provider.Provider, resolved *config.Resolved, offline bool) doctorCheck {
+	c := doctorCheck{
+		name: "Provider " + prov.Name() + " authenticates",
+		hint: "check the API key and base URL with `sc config list`",
+	}
+	if resolved.BaseURL != "" {
+		c.hint = "check the API key and that " + resolved.BaseURL + " is reachable"
+	}
+	lister, ok := prov.(provider.ModelLister)
+	switch {
+	case offline:
+		c.skip = true
+	case !ok:
+		c.err, c.warn = fmt.Errorf("%s cannot be checked without generating", prov.Name()), true
+		c.hint = ""
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_, c.err = lister.Models(ctx)
+	}
+	return c
+}
+
+// specHint suggests how to fix a spec source that cannot be read.
+func specHint(src instructions.SpecSource) string {
+	switch {
+	case src.Binary != "":
+		return "install " + src.Binary + " or add it to PATH"
+	case src.Command != "":
+		return "check that the command runs in a shell: " + src.Command
+	case src.URL != "":
+		return "check the URL, the network, and any auth the source needs"
+	case src.Path != "":
+		return "check the path; it is relative to the instructions file"
+	default:
+		return ""
+	}
+}
+
+// checkWritable reports whether files can be created under dir. A
+// directory that does not exist yet is checked at its nearest existing
+// parent, and nothing is left behind.
+func checkWritable(dir string) error {
+	if i := strings.Index(dir, "{"); i >= 0 {
+		dir = filepath.Dir(dir[:i] + "x") // the static part of an out template
+	}
+	dir = filepath.Clean(dir)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("%s is not a directory", dir)
+			}
+			break
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return err
+		}
+		dir = parent
+	}
+	f, err := os.CreateTemp(dir, ".sc-doctor-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
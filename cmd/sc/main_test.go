@@ -2,16 +2,27 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
+	skillcompiler "github.com/roberthamel/skill-compiler"
+	"github.com/roberthamel/skill-compiler/internal/cache"
+	"github.com/roberthamel/skill-compiler/internal/generate"
+	"github.com/roberthamel/skill-compiler/internal/instructions"
 	"github.com/spf13/cobra"
 )
 
@@ -26,9 +37,15 @@ func newRootCmd() *cobra.Command {
 		newGenerateCmd(),
 		newInitCmd(),
 		newValidateCmd(),
+		newCheckCmd(),
+		newExplainCmd(),
 		newDiffCmd(),
 		newServeCmd(),
+		newModelsCmd(),
 		newConfigCmd(),
+		newUpgradeInstructionsCmd(),
+		newDoctorCmd(),
+		newTUICmd(),
 	)
 	return rootCmd
 }
@@ -138,6 +155,357 @@ func TestGenerateDryRun(t *testing.T) {
 	}
 }
 
+func TestProgressPrinter(t *testing.T) {
+	var buf bytes.Buffer
+	printEvent := progressPrinter(&buf, true)
+	printEvent(skillcompiler.Event{Kind: skillcompiler.EventDone, Skill: "pets", Artifact: "skill", Path: "pets/SKILL.md"})
+	printEvent(skillcompiler.Event{Kind: skillcompiler.EventFailed, Skill: "pets", Artifact: "llms", Path: "llms.txt", Err: errors.New("boom")})
+	want := "[progress] pets/skill: done (pets/SKILL.md)\n[progress] pets/llms: failed: boom\n"
+	if buf.String() != want {
+		t.Errorf("progress output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestTUI(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+	instPath := validInstructionsFixture(t, dir, filepath.Join(dir, "petstore.yaml"))
+	out := filepath.Join(dir, "out")
+
+	// The initial plan lists every artifact as pending
+	m := newTUIModel(context.Background(), skillcompiler.BuildOptions{InstructionsPath: instPath, Dir: dir, OutputDir: out})
+	msg := m.Init()()
+	for {
+		_, cmd := m.Update(msg)
+		if _, done := msg.(tuiDoneMsg); done {
+			break
+		}
+		msg = cmd()
+	}
+	if len(m.rows) == 0 || m.rows[0].artifact != "skill" {
+		t.Fatalf("rows = %+v, want the skill first", m.rows)
+	}
+	for _, r := range m.rows {
+		if r.status != skillcompiler.EventPending {
+			t.Errorf("%s status = %s, want pending", r.artifact, r.status)
+		}
+	}
+
+	key := func(k string) {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		if k == "enter" {
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		}
+		m.Update(msg)
+	}
+	key("g")
+	if !strings.Contains(m.status, "Select artifacts") || m.running {
+		t.Errorf("generate with nothing selected: status %q, running %v", m.status, m.running)
+	}
+	key(" ")
+	if got := m.selected(); !slices.Equal(got, []string{"skill"}) {
+		t.Errorf("selected = %v, want [skill]", got)
+	}
+
+	// A generated artifact opens in the pager until q closes it
+	key("enter")
+	if m.pager != nil {
+		t.Fatal("pager opened for an artifact not generated yet")
+	}
+	path := filepath.Join(out, m.rows[0].path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("# Generated skill\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	key("enter")
+	if m.pager == nil || !strings.Contains(m.View(), "# Generated skill") {
+		t.Fatalf("view after enter = %q, want the skill", m.View())
+	}
+	key("q")
+	if m.pager != nil || !strings.Contains(m.View(), "[x] skill") {
+		t.Errorf("view after q = %q, want the list", m.View())
+	}
+}
+
+func TestBuildPromptOnly(t *testing.T) {
+	dir := t.TempDir()
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+	validInstructionsFixture(t, dir, "./petstore.yaml")
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	// No API key is configured: the provider is never called
+	stdout, stderr, err := execCmd(t, "generate", "--prompt-only", "--only", "skill")
+	if err != nil {
+		t.Fatalf("build --prompt-only failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{"===== skill: system prompt =====", "===== skill: user message =====", "## Spec (Intermediate Representation)", "listPets"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout should contain %q, got:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "Parsing spec sources") {
+		t.Errorf("progress should go to stderr, got stdout:\n%s", stdout)
+	}
+
+	promptDir := filepath.Join(dir, "prompts")
+	if _, stderr, err := execCmd(t, "generate", "--prompt-only", "--prompt-dir", promptDir); err != nil {
+		t.Fatalf("build --prompt-dir failed: %v\nstderr: %s", err, stderr)
+	}
+	data, err := os.ReadFile(filepath.Join(promptDir, "reference.prompt.txt"))
+	if err != nil || !strings.Contains(string(data), "===== reference: user message =====") {
+		t.Errorf("reference.prompt.txt = %q, %v, want the reference prompts", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".sc-lock.json")); !os.IsNotExist(err) {
+		t.Errorf("--prompt-only should not write a lockfile, stat err = %v", err)
+	}
+
+	if _, _, err := execCmd(t, "generate", "--prompt-dir", promptDir); err == nil {
+		t.Error("--prompt-dir without --prompt-only should fail")
+	}
+}
+
+func TestGenerateFailOnWarn(t *testing.T) {
+	dir := t.TempDir()
+	spec := `openapi: "3.0.0"
+info: {title: t, version: "1"}
+paths:
+  /things:
+    get:
+      operationId: listThings
+      responses:
+        "200": {description: ok}
+`
+	if err := os.WriteFile(filepath.Join(dir, "api.yaml"), []byte(spec), 0o644); err != nil {
+		t.Fatalf("writing api.yaml: %v", err)
+	}
+	validInstructionsFixture(t, dir, "./api.yaml")
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	stdout, stderr, err := execCmd(t, "generate", "--dry-run")
+	if err != nil {
+		t.Fatalf("generate without --fail-on-warn failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "listThings") {
+		t.Errorf("stderr should report the undocumented operation, got:\n%s", stderr)
+	}
+	if !strings.Contains(stdout, "Dry run complete") {
+		t.Errorf("stdout should contain 'Dry run complete', got:\n%s", stdout)
+	}
+
+	_, _, err = execCmd(t, "generate", "--dry-run", "--fail-on-warn")
+	if err == nil || !strings.Contains(err.Error(), "--fail-on-warn") {
+		t.Errorf("error = %v, want --fail-on-warn failure", err)
+	}
+
+	// A build records its warnings in the lockfile, so a second build with
+	// the same warnings passes
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m1","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+	t.Setenv("SC_PROVIDER", "openai")
+	t.Setenv("SC_API_KEY", "test")
+	t.Setenv("SC_BASE_URL", srv.URL)
+	t.Setenv("SC_MODEL", "m1")
+	_, _, err = execCmd(t, "generate", "--only", "skill", "--fail-on-warn")
+	if err == nil || !strings.Contains(err.Error(), "new warning") {
+		t.Errorf("first build error = %v, want --fail-on-warn failure", err)
+	}
+	if _, stderr, err := execCmd(t, "generate", "--only", "skill", "--fail-on-warn", "--force"); err != nil {
+		t.Errorf("second build with the same warnings failed: %v\nstderr: %s", err, stderr)
+	}
+}
+
+func TestGenerateDryRunMultiSkill(t *testing.T) {
+	dir := t.TempDir()
+
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+	content := `---
+name: suite
+skills:
+  - name: pets
+    spec: ./petstore.yaml
+  - name: pets-admin
+    spec: ./petstore.yaml
+    sections:
+      Product: Admin view of the pet store.
+---
+
+# Product
+
+Shared product description.
+`
+	if err := os.WriteFile(filepath.Join(dir, "COMPILER_INSTRUCTIONS.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("writing instructions: %v", err)
+	}
+	t.Setenv("HOME", dir)
+
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	stdout, stderr, err := execCmd(t, "generate", "--dry-run")
+	if err != nil {
+		t.Fatalf("generate --dry-run failed: %v\nstderr: %s", err, stderr)
+	}
+	for _, want := range []string{"== pets ==", "== pets-admin ==", "Dry run complete"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout should contain %q, got:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestGenerateInstructionsFromStdin(t *testing.T) {
+	dir := t.TempDir()
+
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+
+	// Move the fixture aside so only stdin can supply the instructions
+	path := validInstructionsFixture(t, dir, "./petstore.yaml")
+	piped := filepath.Join(dir, "piped.md")
+	if err := os.Rename(path, piped); err != nil {
+		t.Fatalf("renaming fixture: %v", err)
+	}
+	stdin, err := os.Open(piped)
+	if err != nil {
+		t.Fatalf("opening fixture: %v", err)
+	}
+	defer func() { _ = stdin.Close() }()
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	t.Cleanup(func() { os.Stdin = oldStdin })
+
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	stdout, stderr, err := execCmd(t, "generate", "-f", "-", "--dry-run")
+	if err != nil {
+		t.Fatalf("build -f - failed: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stdout, "Dry run complete") {
+		t.Errorf("stdout should contain 'Dry run complete', got:\n%s", stdout)
+	}
+}
+
+func TestGenerateStdoutFlagConflicts(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--stdout", "nope"}, "unknown artifact"},
+		{[]string{"--stdout", "skill", "--dry-run"}, "cannot be combined"},
+		{[]string{"--stdout", "skill", "--only", "llms"}, "cannot be combined"},
+		{[]string{"--artifact", "nope"}, "unknown artifact"},
+		{[]string{"--artifact", "skill", "--stdout", "skill"}, "cannot be combined"},
+		{[]string{"--artifact", "skill", "--diff"}, "cannot be combined"},
+		{[]string{"-o", "SKILL.md"}, "--output requires --artifact"},
+		{[]string{"--since-commit", "HEAD", "--force"}, "cannot be combined"},
+		{[]string{"--since-commit", "HEAD", "--stdout", "skill"}, "cannot be combined"},
+	}
+	for _, tt := range tests {
+		args := append([]string{"generate"}, tt.args...)
+		_, _, err := execCmd(t, args...)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%v: error = %v, want %q", tt.args, err, tt.want)
+		}
+	}
+}
+
+func TestEmitArtifactFromCache(t *testing.T) {
+	dir := t.TempDir()
+	if err := cache.WriteCached(dir, "llms", "# llms.txt\n"); err != nil {
+		t.Fatalf("writing cache: %v", err)
+	}
+	inst := &instructions.Instructions{Frontmatter: instructions.Frontmatter{Name: "test-tool"}}
+
+	var buf bytes.Buffer
+	if err := emitArtifact(&buf, nil, inst, generate.ArtifactLlms, dir); err != nil {
+		t.Fatalf("emitArtifact: %v", err)
+	}
+	if buf.String() != "# llms.txt\n" {
+		t.Errorf("emitted %q, want cached content", buf.String())
+	}
+
+	err := emitArtifact(&buf, nil, inst, generate.ArtifactSkill, dir)
+	if err == nil || !strings.Contains(err.Error(), "no cached copy") {
+		t.Errorf("error = %v, want missing cache error", err)
+	}
+}
+
+func TestSaveArtifact(t *testing.T) {
+	dir := t.TempDir()
+	inst := &instructions.Instructions{Frontmatter: instructions.Frontmatter{Name: "test-tool"}}
+
+	dest := filepath.Join(dir, "SKILL.md")
+	files := map[string][]byte{"test-tool/SKILL.md": []byte("# skill\n")}
+	if err := saveArtifact(dest, files, inst, generate.ArtifactSkill, dir); err != nil {
+		t.Fatalf("saveArtifact: %v", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "# skill\n" {
+		t.Errorf("%s = %q", dest, data)
+	}
+
+	// Several files go into a directory by base name
+	dest = filepath.Join(dir, "scripts")
+	files = map[string][]byte{
+		"test-tool/scripts/a.sh": []byte("#!/bin/sh\n"),
+		"test-tool/scripts/b.sh": []byte("#!/bin/sh\n"),
+	}
+	if err := saveArtifact(dest, files, inst, generate.ArtifactScripts, dir); err != nil {
+		t.Fatalf("saveArtifact: %v", err)
+	}
+	for _, name := range []string{"a.sh", "b.sh"} {
+		info, err := os.Stat(filepath.Join(dest, name))
+		if err != nil || info.Mode().Perm()&0o100 == 0 {
+			t.Errorf("%s: %v, mode %v, want an executable script", name, err, info)
+		}
+	}
+}
+
 func TestGenerateErrorNoInstructions(t *testing.T) {
 	dir := t.TempDir()
 	t.Setenv("HOME", dir)
@@ -157,6 +525,20 @@ func TestGenerateErrorNoInstructions(t *testing.T) {
 	}
 }
 
+// TestInstructionsShorthand keeps -f available wherever --instructions is.
+func TestInstructionsShorthand(t *testing.T) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		if f := cmd.Flags().Lookup("instructions"); f != nil && f.Shorthand != "f" {
+			t.Errorf("%s --instructions shorthand = %q, want f", cmd.CommandPath(), f.Shorthand)
+		}
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+		}
+	}
+	walk(newRootCmd())
+}
+
 func TestInitRequiresName(t *testing.T) {
 	dir := t.TempDir()
 	t.Setenv("HOME", dir)
@@ -203,6 +585,73 @@ func TestInitRefusesOverwrite(t *testing.T) {
 	}
 }
 
+func TestInitNoLLMDetectsSpec(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "openapi.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing openapi.yaml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/x\n"), 0o644); err != nil {
+		t.Fatalf("writing go.mod: %v", err)
+	}
+
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	if _, _, err := execCmd(t, "init", "--name", "test-tool", "--no-llm"); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	inst, err := instructions.Parse("COMPILER_INSTRUCTIONS.md")
+	if err != nil {
+		t.Fatalf("scaffolded file does not parse: %v", err)
+	}
+	sources, _ := inst.ResolveSpecSources()
+	if len(sources) != 1 || sources[0].Path != "./openapi.yaml" {
+		t.Errorf("spec sources = %+v, want ./openapi.yaml", sources)
+	}
+	if len(inst.Reviews) == 0 || inst.Reviews[0].Section != "Product" {
+		t.Errorf("Product section should carry a REVIEW marker, got %+v", inst.Reviews)
+	}
+
+	// Without a spec file the manifest selects the codebase plugin
+	if err := os.Remove("openapi.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := execCmd(t, "init", "--name", "test-tool", "--no-llm", "--force"); err != nil {
+		t.Fatalf("init --force failed: %v", err)
+	}
+	inst, err = instructions.Parse("COMPILER_INSTRUCTIONS.md")
+	if err != nil {
+		t.Fatalf("scaffolded file does not parse: %v", err)
+	}
+	sources, _ = inst.ResolveSpecSources()
+	if len(sources) != 1 || sources[0].Type != "codebase" || sources[0].Path != "." {
+		t.Errorf("spec sources = %+v, want codebase at .", sources)
+	}
+	if inst.Frontmatter.Language != "" {
+		t.Errorf("Language = %q, want unset by default", inst.Frontmatter.Language)
+	}
+
+	if _, _, err := execCmd(t, "init", "--name", "test-tool", "--no-llm", "--force", "--language", "es"); err != nil {
+		t.Fatalf("init --language failed: %v", err)
+	}
+	inst, err = instructions.Parse("COMPILER_INSTRUCTIONS.md")
+	if err != nil {
+		t.Fatalf("scaffolded file does not parse: %v", err)
+	}
+	if inst.Frontmatter.Language != "es" {
+		t.Errorf("Language = %q, want es", inst.Frontmatter.Language)
+	}
+}
+
 func TestValidateWarningsMissingProduct(t *testing.T) {
 	dir := t.TempDir()
 	t.Setenv("HOME", dir)
@@ -244,6 +693,165 @@ Some workflow.
 	}
 }
 
+func TestValidateStrict(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	content := `---
+name: test-tool
+spec: ./petstore.yaml
+---
+
+# Workflows
+
+Some workflow.
+`
+	instPath := filepath.Join(dir, "instructions.md")
+	if err := os.WriteFile(instPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing instructions: %v", err)
+	}
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	stdout, _, err := execCmd(t, "validate", "-f", instPath)
+	if err != nil {
+		t.Fatalf("validate with warnings only: %v", err)
+	}
+	if !strings.Contains(stdout, "Validation passed (") {
+		t.Errorf("stdout should report passing with warnings, got:\n%s", stdout)
+	}
+
+	_, _, err = execCmd(t, "validate", "-f", instPath, "--strict")
+	if err == nil || !strings.Contains(err.Error(), "--strict") {
+		t.Errorf("validate --strict error = %v, want failure on warnings", err)
+	}
+
+	// A generated SKILL.md with an over-long description fails validation
+	skillDir := filepath.Join(dir, "sc-out", "test-tool")
+	_ = os.MkdirAll(skillDir, 0o755)
+	skillMD := "---\nname: test-tool\ndescription: " + strings.Repeat("Manage pets. ", 100) + "\n---\n# Test Tool\n"
+	_ = os.WriteFile(filepath.Join(skillDir, "SKILL.md"), []byte(skillMD), 0o644)
+	_, stderr, err := execCmd(t, "validate", "-f", instPath)
+	if err == nil || !strings.Contains(stderr, "over the 1024 limit") {
+		t.Errorf("validate with a long description: err = %v, stderr:\n%s", err, stderr)
+	}
+	_ = os.RemoveAll(filepath.Join(dir, "sc-out"))
+
+	if err := os.Remove(filepath.Join(dir, "petstore.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr, err = execCmd(t, "validate", "-f", instPath)
+	if err == nil || !strings.Contains(stderr, "ERROR:") {
+		t.Errorf("validate with a missing spec: err = %v, stderr:\n%s", err, stderr)
+	}
+}
+
+func TestUpgradeInstructions(t *testing.T) {
+	dir := t.TempDir()
+	content := "---\nspec: ./petstore.yaml\nname: test-tool\n---\n\n# Product\n\nA tool.\n"
+	instPath := filepath.Join(dir, "instructions.md")
+	if err := os.WriteFile(instPath, []byte(content), 0o644); err != nil {
+		t.Fatalf("writing instructions: %v", err)
+	}
+
+	stdout, _, err := execCmd(t, "upgrade-instructions", "-f", instPath, "--dry-run")
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	for _, want := range []string{"+version: 1", "-name: test-tool", "Would upgrade"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("dry-run output missing %q:\n%s", want, stdout)
+		}
+	}
+	if data, _ := os.ReadFile(instPath); string(data) != content {
+		t.Error("--dry-run modified the file")
+	}
+
+	if _, _, err := execCmd(t, "upgrade-instructions", "-f", instPath); err != nil {
+		t.Fatalf("upgrade: %v", err)
+	}
+	data, _ := os.ReadFile(instPath)
+	if !strings.HasPrefix(string(data), "---\nversion: 1\nname: test-tool\n") || !strings.HasSuffix(string(data), "\n# Product\n\nA tool.\n") {
+		t.Errorf("upgraded file:\n%s", data)
+	}
+
+	stdout, _, err = execCmd(t, "upgrade-instructions", "-f", instPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "up to date") {
+		t.Errorf("second run output = %q, want up to date", stdout)
+	}
+}
+
+func TestCheckSARIF(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	content := `---
+name: test-tool
+spec: ./petstore.yaml
+---
+
+# Workflows
+
+Some workflow.
+`
+	if err := os.WriteFile(filepath.Join(dir, "COMPILER_INSTRUCTIONS.md"), []byte(content), 0o644); err != nil {
+		t.Fatalf("writing instructions: %v", err)
+	}
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	stdout, _, err := execCmd(t, "check", "--format", "sarif")
+	if err != nil {
+		t.Fatalf("check failed: %v", err)
+	}
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+				Level  string `json:"level"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &log); err != nil {
+		t.Fatalf("check output is not valid SARIF JSON: %v\n%s", err, stdout)
+	}
+	found := false
+	for _, r := range log.Runs[0].Results {
+		if r.RuleID == "missing-section" && r.Level == "warning" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("SARIF results missing the missing-section warning:\n%s", stdout)
+	}
+}
+
 func TestDiffErrorNoInstructions(t *testing.T) {
 	dir := t.TempDir()
 	t.Setenv("HOME", dir)
@@ -360,6 +968,47 @@ func TestDiffUpToDate(t *testing.T) {
 	}
 }
 
+func TestDiffAgainstVersion(t *testing.T) {
+	dir := t.TempDir()
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "specs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// The newer version deprecates getPet
+	newer := strings.Replace(string(petstore), "operationId: getPet", "operationId: getPet\n      deprecated: true", 1)
+	for name, content := range map[string]string{"2024-01-01.yaml": string(petstore), "2024-06-01.yaml": newer} {
+		if err := os.WriteFile(filepath.Join(dir, "specs", name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	validInstructionsFixture(t, dir, "{path: ./specs, version: latest}")
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	stdout, _, err := execCmd(t, "diff", "--against-version", "2024-01-01")
+	if err != nil {
+		t.Fatalf("diff --against-version: %v", err)
+	}
+	if !strings.Contains(stdout, "Changes from 2024-01-01 to 2024-06-01") || !strings.Contains(stdout, "DEPRECATED: GET /pets/{petId} (getPet)") {
+		t.Errorf("want getPet deprecated between the versions, got:\n%s", stdout)
+	}
+
+	stdout, _, err = execCmd(t, "diff", "--against-version", "2024-06-01")
+	if err != nil {
+		t.Fatalf("diff --against-version: %v", err)
+	}
+	if !strings.Contains(stdout, "No operation changes") {
+		t.Errorf("want no changes against the same version, got:\n%s", stdout)
+	}
+}
+
 func mustGetwd(t *testing.T) string {
 	t.Helper()
 	wd, err := os.Getwd()
@@ -404,6 +1053,156 @@ func TestConfigSetListReset(t *testing.T) {
 	}
 }
 
+func TestModels(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"id":"m1"},{"id":"m2"}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("SC_PROVIDER", "openai")
+	t.Setenv("SC_API_KEY", "test")
+	t.Setenv("SC_BASE_URL", srv.URL)
+	t.Setenv("SC_MODEL", "m2")
+
+	stdout, _, err := execCmd(t, "models")
+	if err != nil {
+		t.Fatalf("models: %v", err)
+	}
+	if !strings.Contains(stdout, "  m1\n* m2\n") {
+		t.Errorf("stdout should list models and mark the configured one, got:\n%s", stdout)
+	}
+
+	// Without an API key the static list is shown instead
+	t.Setenv("SC_API_KEY", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	stdout, stderr, err := execCmd(t, "models")
+	if err != nil {
+		t.Fatalf("models without a key: %v", err)
+	}
+	if !strings.Contains(stdout, "gpt-4o") || !strings.Contains(stderr, "showing known openai models") {
+		t.Errorf("want the known openai models, got stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestDoctor(t *testing.T) {
+	dir := t.TempDir()
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+	validInstructionsFixture(t, dir, "./petstore.yaml")
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	authorized := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized {
+			http.Error(w, `{"error":{"message":"invalid api key"}}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"m1"}]}`))
+	}))
+	defer srv.Close()
+	t.Setenv("SC_PROVIDER", "openai")
+	t.Setenv("SC_API_KEY", "test")
+	t.Setenv("SC_BASE_URL", srv.URL)
+	t.Setenv("SC_MODEL", "m1")
+
+	stdout, _, err := execCmd(t, "doctor")
+	if err != nil {
+		t.Fatalf("doctor: %v\n%s", err, stdout)
+	}
+	for _, want := range []string{"[ok]   Config file loads", "[ok]   Provider openai authenticates", "[ok]   Spec ./petstore.yaml is readable", "[ok]   Output directory ./output/ is writable", "All checks passed"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout should contain %q, got:\n%s", want, stdout)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "output")); !os.IsNotExist(err) {
+		t.Errorf("doctor should not create the output directory")
+	}
+
+	// A rejected key and a missing spec both fail, with hints
+	authorized = false
+	if err := os.Remove(filepath.Join(dir, "petstore.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	stdout, _, err = execCmd(t, "doctor")
+	if err == nil || !strings.Contains(err.Error(), "2 of") {
+		t.Fatalf("want two failed checks, got %v\n%s", err, stdout)
+	}
+	for _, want := range []string{"[FAIL] Provider openai authenticates", "[FAIL] Spec ./petstore.yaml is readable", "hint: check the path"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("stdout should contain %q, got:\n%s", want, stdout)
+		}
+	}
+
+	// --offline skips the provider call
+	stdout, _, _ = execCmd(t, "doctor", "--offline")
+	if !strings.Contains(stdout, "[skip] Provider openai authenticates") {
+		t.Errorf("--offline should skip the auth check, got:\n%s", stdout)
+	}
+}
+
+func TestGenerate_Timeout(t *testing.T) {
+	dir := t.TempDir()
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+	validInstructionsFixture(t, dir, "./petstore.yaml")
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	// The provider does not answer before the test ends, so only the
+	// deadline ends the build
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+	t.Setenv("SC_PROVIDER", "openai")
+	t.Setenv("SC_API_KEY", "test")
+	t.Setenv("SC_BASE_URL", srv.URL)
+	t.Setenv("SC_MODEL", "m1")
+
+	start := time.Now()
+	_, stderr, err := execCmd(t, "generate", "--only", "skill,llms", "--timeout", "200ms")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Fatalf("want a timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("build took %s, want it stopped at the deadline", elapsed)
+	}
+	if !strings.Contains(stderr, "Completed: none") || !strings.Contains(stderr, "Pending:   skill, llms") {
+		t.Errorf("stderr should summarize the artifacts, got:\n%s", stderr)
+	}
+}
+
 func TestServeRespondsToHTTP(t *testing.T) {
 	dir := t.TempDir()
 
@@ -454,3 +1253,202 @@ func TestServeRespondsToHTTP(t *testing.T) {
 		t.Errorf("GET /llms.txt body = %q, want to contain 'test-tool'", body.String())
 	}
 }
+
+func TestExplain(t *testing.T) {
+	dir := t.TempDir()
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+	validInstructionsFixture(t, dir, "./petstore.yaml")
+	t.Setenv("HOME", dir)
+
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	tests := []struct {
+		query   string
+		want    []string
+		wantErr string
+	}{
+		{"getpet", []string{"GET /pets/{petId}", "petId (path, string, required)", "404 — Pet not found"}, ""},
+		{"POST /pets", []string{"createPet", "Request body:"}, ""},
+		{"pets", []string{"matches 3 operations", "listPets", "getPet"}, "ambiguous"},
+		{"create pet", []string{"createPet", "Request body:"}, ""},
+		{"orders", nil, "no operation matches"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			stdout, _, err := execCmd(t, "explain", tt.query)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatalf("explain failed: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("stdout missing %q, got:\n%s", want, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestBuildJSON(t *testing.T) {
+	dir := t.TempDir()
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+	validInstructionsFixture(t, dir, "./petstore.yaml")
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"generated"},"finish_reason":"stop"}],` +
+			`"model":"gpt-4o","usage":{"prompt_tokens":1000,"completion_tokens":100}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("SC_PROVIDER", "openai")
+	t.Setenv("SC_API_KEY", "test")
+	t.Setenv("SC_BASE_URL", srv.URL)
+	t.Setenv("SC_MODEL", "gpt-4o")
+
+	var report buildReport
+	build := func() {
+		t.Helper()
+		stdout, stderr, err := execCmd(t, "generate", "--json", "--only", "skill,reference")
+		if err != nil {
+			t.Fatalf("build --json: %v\nstderr: %s", err, stderr)
+		}
+		report = buildReport{}
+		if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+			t.Fatalf("stdout is not a JSON report: %v\n%s", err, stdout)
+		}
+	}
+
+	build()
+	if len(report.Skills) != 1 || len(report.Skills[0].Artifacts) != 2 {
+		t.Fatalf("report = %+v, want one skill with two artifacts", report)
+	}
+	a := report.Skills[0].Artifacts[0]
+	if a.ID != "skill" || a.Status != "generated" || a.Model != "gpt-4o" || a.TokensIn != 1000 || a.CostUSD == nil {
+		t.Errorf("first artifact = %+v, want a generated skill with tokens and a cost", a)
+	}
+	if report.TokensIn != 2000 || report.TokensOut != 200 || report.CostUSD != 0.007 {
+		t.Errorf("totals = %d/%d tokens, $%v, want 2000/200 and $0.007", report.TokensIn, report.TokensOut, report.CostUSD)
+	}
+
+	build()
+	for _, a := range report.Skills[0].Artifacts {
+		if a.Status != "cache-hit" || a.Path == "" {
+			t.Errorf("artifact on rebuild = %+v, want a cache hit with its path", a)
+		}
+	}
+
+	if _, _, err := execCmd(t, "generate", "--json", "--dry-run"); err == nil {
+		t.Error("--json with --dry-run should fail")
+	}
+}
+
+func TestBuildFallbackProvider(t *testing.T) {
+	dir := t.TempDir()
+	petstore, err := os.ReadFile("../../internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatalf("reading petstore fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "petstore.yaml"), petstore, 0o644); err != nil {
+		t.Fatalf("writing petstore.yaml: %v", err)
+	}
+
+	// The primary answers once and then goes down
+	var primaryCalls atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if primaryCalls.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"generated"},"finish_reason":"stop"}],` +
+			`"model":"gpt-4o","usage":{"prompt_tokens":10,"completion_tokens":1}}`))
+	}))
+	defer primary.Close()
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"generated"}],"model":"claude-sonnet-4-6",` +
+			`"stop_reason":"end_turn","usage":{"input_tokens":10,"output_tokens":1}}`))
+	}))
+	defer fallback.Close()
+
+	path := validInstructionsFixture(t, dir, "./petstore.yaml")
+	content, _ := os.ReadFile(path)
+	content = []byte(strings.Replace(string(content), "out: ./output/\n", fmt.Sprintf(`out: ./output/
+provider:
+  fallback-providers:
+    - provider: anthropic
+      base-url: %s
+      api-key: fallback-key
+`, fallback.URL), 1))
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", dir)
+	orig, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("chdir: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(orig) })
+	t.Setenv("SC_PROVIDER", "openai")
+	t.Setenv("SC_API_KEY", "test")
+	t.Setenv("SC_BASE_URL", primary.URL)
+	t.Setenv("SC_MODEL", "gpt-4o")
+
+	stdout, stderr, err := execCmd(t, "generate", "--json", "--only", "skill,reference,examples")
+	if err != nil {
+		t.Fatalf("build: %v\nstderr: %s", err, stderr)
+	}
+	if !strings.Contains(stderr, "WARNING: openai (model: gpt-4o) is unavailable") || !strings.Contains(stderr, "using anthropic (model: claude-sonnet-4-6) for the remaining artifacts") {
+		t.Errorf("stderr should report the switch:\n%s", stderr)
+	}
+	var report buildReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("stdout is not a JSON report: %v\n%s", err, stdout)
+	}
+	providers := map[string]int{}
+	for _, a := range report.Skills[0].Artifacts {
+		providers[a.Provider]++
+	}
+	if providers["openai"] != 1 || providers["anthropic"] != 2 {
+		t.Errorf("artifact providers = %v, want one from openai and two from anthropic", providers)
+	}
+	mixed := false
+	for _, w := range report.Warnings {
+		mixed = mixed || w.Code == "mixed-providers"
+	}
+	if !mixed {
+		t.Errorf("warnings = %+v, want a mixed-providers warning", report.Warnings)
+	}
+
+	lock, err := cache.LoadLockFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, entry := range lock.Artifacts {
+		if entry.Provider == "" || (entry.Provider == "anthropic") != (entry.Model == "claude-sonnet-4-6") {
+			t.Errorf("lockfile %s = %s/%s, want the provider and model that generated it", id, entry.Provider, entry.Model)
+		}
+	}
+}
@@ -8,17 +8,14 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 
 	"github.com/roberthamel/skill-compiler/internal/cache"
 	"github.com/roberthamel/skill-compiler/internal/generate"
 	"github.com/roberthamel/skill-compiler/internal/instructions"
-	"github.com/roberthamel/skill-compiler/internal/ir"
-	cliplugin "github.com/roberthamel/skill-compiler/internal/plugins/cli"
-	"github.com/roberthamel/skill-compiler/internal/plugins/codebase"
-	"github.com/roberthamel/skill-compiler/internal/plugins/openapi"
+	"github.com/roberthamel/skill-compiler/internal/plugins"
 )
 
 func main() {
@@ -46,18 +43,15 @@ func main() {
 		os.Exit(1)
 	}
 
-	reg := ir.NewRegistry()
-	reg.Register(openapi.New())
-	reg.Register(cliplugin.New())
-	reg.Register(codebase.New())
+	reg := plugins.NewRegistry()
 
-	parsedIR, _, err := reg.ProcessSources(sources)
+	parsedIR, _, err := reg.ProcessSources(context.Background(), sources)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "process: %v\n", err)
 		os.Exit(1)
 	}
 
-	irJSON, _ := json.Marshal(parsedIR)
+	irJSON, _ := parsedIR.CanonicalJSON()
 	specContent := string(irJSON)
 
 	pipeline := &generate.Pipeline{IR: parsedIR, Inst: inst}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	skillcompiler "github.com/roberthamel/skill-compiler"
+	"github.com/spf13/cobra"
+)
+
+func newTUICmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Select artifacts to (re)generate and preview the output interactively",
+		Long: `Tui lists the enabled artifacts with their cache status. Select artifacts
+with space (or every pending one with a), press g to regenerate them while
+progress updates in place, and press enter to page through an artifact's
+output. In a multi-skill file, selecting an artifact selects it for every
+skill, as --only does.`,
+		RunE: runTUI,
+	}
+	cmd.Flags().StringP("instructions", "f", "COMPILER_INSTRUCTIONS.md", "Path to instructions file")
+	cmd.Flags().String("spec", "", "Path to spec file (overrides frontmatter)")
+	cmd.Flags().String("out", "", "Output directory (overrides frontmatter)")
+	cmd.Flags().String("model", "", "LLM model to use (overrides all other config)")
+	cmd.Flags().String("provider", "", "LLM provider to use (overrides all other config)")
+	return cmd
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	instPath, _ := cmd.Flags().GetString("instructions")
+	specFlag, _ := cmd.Flags().GetString("spec")
+	outFlag, _ := cmd.Flags().GetString("out")
+	modelFlag, _ := cmd.Flags().GetString("model")
+	providerFlag, _ := cmd.Flags().GetString("provider")
+	if _, err := os.Stat(instPath); err != nil {
+		return fmt.Errorf("instructions file %s not found — run `sc init` first", instPath)
+	}
+
+	projectDir, _ := os.Getwd()
+	m := newTUIModel(cmd.Context(), skillcompiler.BuildOptions{
+		InstructionsPath: instPath,
+		Dir:              projectDir,
+		Spec:             specFlag,
+		OutputDir:        outFlag,
+		Provider:         skillcompiler.ProviderConfig{Provider: providerFlag, Model: modelFlag},
+	})
+	_, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(cmd.Context())).Run()
+	return err
+}
+
+// tuiRow is one artifact of one skill in the sc tui list.
+type tuiRow struct {
+	skill, artifact, path string
+	status                skillcompiler.EventKind
+	err                   error
+	selected              bool
+}
+
+// tuiModel is the sc tui state. Builds run in the background and report
+// their events and result as messages through events.
+type tuiModel struct {
+	ctx     context.Context
+	opts    skillcompiler.BuildOptions // Plan, Only, Force, and OnEvent are set per build
+	rows    []tuiRow
+	outDirs map[string]string // skill name to output directory
+	cursor  int
+	events  chan tea.Msg
+	running bool
+	status  string
+	pager   *viewport.Model // set while an artifact is shown
+	width   int
+	height  int
+}
+
+// tuiEventMsg carries a build event to the model.
+type tuiEventMsg skillcompiler.Event
+
+// tuiDoneMsg ends a build.
+type tuiDoneMsg struct {
+	plan   bool
+	result *skillcompiler.BuildResult
+	err    error
+}
+
+func newTUIModel(ctx context.Context, opts skillcompiler.BuildOptions) *tuiModel {
+	return &tuiModel{ctx: ctx, opts: opts, outDirs: make(map[string]string), width: 80, height: 24}
+}
+
+// Init checks the cache to list the artifacts.
+func (m *tuiModel) Init() tea.Cmd {
+	opts := m.opts
+	opts.Plan = true
+	m.status = "Checking cache..."
+	return m.build(opts)
+}
+
+// build starts a Build with opts and waits for its first message.
+func (m *tuiModel) build(opts skillcompiler.BuildOptions) tea.Cmd {
+	events := make(chan tea.Msg, 64)
+	m.events = events
+	m.running = true
+	opts.OnEvent = func(e skillcompiler.Event) { events <- tuiEventMsg(e) }
+	go func() {
+		result, err := skillcompiler.Build(m.ctx, opts)
+		events <- tuiDoneMsg{plan: opts.Plan, result: result, err: err}
+	}()
+	return waitForMsg(events)
+}
+
+// waitForMsg delivers the next message of a running build.
+func waitForMsg(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg { return <-events }
+}
+
+// Update implements tea.Model.
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		if m.pager != nil {
+			m.pager.Width, m.pager.Height = msg.Width, msg.Height-1
+		}
+	case tuiEventMsg:
+		m.apply(skillcompiler.Event(msg))
+		return m, waitForMsg(m.events)
+	case tuiDoneMsg:
+		m.finish(msg)
+	case tea.KeyMsg:
+		if m.pager != nil {
+			return m.updatePager(msg)
+		}
+		return m.updateList(msg)
+	}
+	return m, nil
+}
+
+// apply records an event on its artifact's row, adding the row if new.
+func (m *tuiModel) apply(e skillcompiler.Event) {
+	for i := range m.rows {
+		if m.rows[i].skill == e.Skill && m.rows[i].artifact == e.Artifact {
+			m.rows[i].status, m.rows[i].err, m.rows[i].path = e.Kind, e.Err, e.Path
+			return
+		}
+	}
+	m.rows = append(m.rows, tuiRow{skill: e.Skill, artifact: e.Artifact, path: e.Path, status: e.Kind, err: e.Err})
+}
+
+// finish reports a finished build and clears the selection it used.
+func (m *tuiModel) finish(msg tuiDoneMsg) {
+	m.running = false
+	if msg.result != nil {
+		for _, sr := range msg.result.Skills {
+			m.outDirs[sr.Name] = sr.OutputDir
+		}
+	}
+	counts := make(map[skillcompiler.EventKind]int)
+	for i := range m.rows {
+		counts[m.rows[i].status]++
+		if !msg.plan {
+			m.rows[i].selected = false
+		}
+	}
+	switch {
+	case msg.err != nil:
+		m.status = "Build failed: " + msg.err.Error()
+	case msg.plan:
+		m.status = fmt.Sprintf("%d pending, %d cached", counts[skillcompiler.EventPending], counts[skillcompiler.EventCached])
+	default:
+		m.status = fmt.Sprintf("%d generated, %d failed", counts[skillcompiler.EventDone], counts[skillcompiler.EventFailed])
+	}
+}
+
+func (m *tuiModel) updateList(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "up", "k":
+		m.cursor = max(m.cursor-1, 0)
+	case "down", "j":
+		m.cursor = max(min(m.cursor+1, len(m.rows)-1), 0)
+	case " ":
+		if m.cursor < len(m.rows) {
+			m.selectArtifact(m.rows[m.cursor].artifact, !m.rows[m.cursor].selected)
+		}
+	case "a":
+		for _, r := range m.rows {
+			if r.status == skillcompiler.EventPending {
+				m.selectArtifact(r.artifact, true)
+			}
+		}
+	case "g":
+		if m.running {
+			return m, nil
+		}
+		only := m.selected()
+		if len(only) == 0 {
+			m.status = "Select artifacts with space (or a for every pending one) first"
+			return m, nil
+		}
+		opts := m.opts
+		opts.Only, opts.Force = only, true
+		m.status = fmt.Sprintf("Generating %s...", strings.Join(only, ", "))
+		return m, m.build(opts)
+	case "enter", "v":
+		if m.cursor < len(m.rows) {
+			m.show(m.rows[m.cursor])
+		}
+	}
+	return m, nil
+}
+
+// selectArtifact sets the selection of an artifact in every skill, since
+// a build's Only applies to them all.
+func (m *tuiModel) selectArtifact(artifact string, selected bool) {
+	for i := range m.rows {
+		if m.rows[i].artifact == artifact {
+			m.rows[i].selected = selected
+		}
+	}
+}
+
+// selected lists the selected artifacts, once each, in list order.
+func (m *tuiModel) selected() []string {
+	var only []string
+	for _, r := range m.rows {
+		if r.selected && !slices.Contains(only, r.artifact) {
+			only = append(only, r.artifact)
+		}
+	}
+	return only
+}
+
+// show opens the row's output in the pager.
+func (m *tuiModel) show(r tuiRow) {
+	data, err := os.ReadFile(filepath.Join(m.outDirs[r.skill], r.path))
+	if err != nil {
+		m.status = fmt.Sprintf("%s has not been generated yet", r.path)
+		return
+	}
+	pager := viewport.New(m.width, m.height-1)
+	pager.SetContent(string(data))
+	m.pager = &pager
+	m.status = r.path
+}
+
+func (m *tuiModel) updatePager(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "esc":
+		m.pager = nil
+		m.status = ""
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	}
+	pager, cmd := m.pager.Update(msg)
+	m.pager = &pager
+	return m, cmd
+}
+
+// View implements tea.Model.
+func (m *tuiModel) View() string {
+	if m.pager != nil {
+		return fmt.Sprintf("%s\n%s  %3.f%% · ↑/↓ scroll · q back", m.pager.View(), m.status, m.pager.ScrollPercent()*100)
+	}
+
+	multi := false
+	nameWidth, pathWidth := 0, 0
+	for _, r := range m.rows {
+		multi = multi || r.skill != m.rows[0].skill
+	}
+	names := make([]string, len(m.rows))
+	for i, r := range m.rows {
+		names[i] = r.artifact
+		if multi {
+			names[i] = r.skill + "/" + r.artifact
+		}
+		nameWidth = max(nameWidth, len(names[i]))
+		pathWidth = max(pathWidth, len(r.path))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "sc tui: %s\n\n", m.opts.InstructionsPath)
+	for i, r := range m.rows {
+		cursor, check := " ", " "
+		if i == m.cursor {
+			cursor = ">"
+		}
+		if r.selected {
+			check = "x"
+		}
+		status := string(r.status)
+		if r.status == skillcompiler.EventStarted {
+			status = "generating..."
+		} else if r.err != nil {
+			status += ": " + r.err.Error()
+		}
+		fmt.Fprintf(&b, "%s [%s] %-*s  %-*s  %s\n", cursor, check, nameWidth, names[i], pathWidth, r.path, status)
+	}
+	b.WriteString("\nspace select · a select pending · g generate · enter view · q quit\n")
+	b.WriteString(m.status + "\n")
+	return b.String()
+}
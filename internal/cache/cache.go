@@ -7,12 +7,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 // LockFile represents the .sc-lock.json structure.
 type LockFile struct {
 	Artifacts map[string]LockEntry `json:"artifacts"`
+	// Warnings records each skill's spec and instructions diagnostics so CI
+	// can diff them across runs.
+	Warnings map[string]WarningEntry `json:"warnings,omitempty"`
 }
 
 // LockEntry records hashes and metadata for a single artifact.
@@ -21,6 +25,28 @@ type LockEntry struct {
 	OutputHash string `json:"outputHash"`
 	Timestamp  string `json:"timestamp"`
 	Model      string `json:"model"`
+	// Provider names the provider that generated the output, which with
+	// fallback providers may differ between artifacts.
+	Provider string `json:"provider,omitempty"`
+	// Inputs breaks InputHash down by input, so a cache miss can be
+	// attributed; entries written before it was recorded lack it.
+	Inputs *InputHashes `json:"inputs,omitempty"`
+}
+
+// InputHashes are the hashes of an artifact's inputs taken separately.
+type InputHashes struct {
+	Spec     string `json:"spec"`
+	Sections string `json:"sections"`
+	Prompt   string `json:"prompt"`
+	// Model is the configured model. Unlike the others it is not part of
+	// InputHash: changing models keeps cached outputs.
+	Model string `json:"model,omitempty"`
+}
+
+// WarningEntry records the diagnostics reported while building one skill.
+type WarningEntry struct {
+	Counts   map[string]int `json:"counts"`             // by severity
+	Messages []string       `json:"messages,omitempty"` // "severity: message", sorted
 }
 
 // HashInput computes a SHA-256 hash of the given inputs for an artifact.
@@ -32,6 +58,16 @@ func HashInput(specContent, instructionsSections, systemPrompt string) string {
 	return hex.EncodeToString(h.Sum(nil))
 }
 
+// HashInputs hashes each of an artifact's inputs on its own; see HashInput.
+func HashInputs(specContent, instructionsSections, systemPrompt, model string) InputHashes {
+	return InputHashes{
+		Spec:     HashOutput(specContent),
+		Sections: HashOutput(instructionsSections),
+		Prompt:   HashOutput(systemPrompt),
+		Model:    model,
+	}
+}
+
 // HashOutput computes a SHA-256 hash of the artifact output.
 func HashOutput(content string) string {
 	h := sha256.New()
@@ -70,13 +106,35 @@ func SaveLockFile(dir string, lf *LockFile) error {
 }
 
 // UpdateEntry updates a single artifact entry in the lockfile.
-func (lf *LockFile) UpdateEntry(artifactID, inputHash, outputHash, model string) {
+func (lf *LockFile) UpdateEntry(artifactID, inputHash, outputHash, provider, model string, inputs InputHashes) {
 	lf.Artifacts[artifactID] = LockEntry{
 		InputHash:  inputHash,
 		OutputHash: outputHash,
 		Timestamp:  time.Now().UTC().Format(time.RFC3339),
 		Model:      model,
+		Provider:   provider,
+		Inputs:     &inputs,
+	}
+}
+
+// SetWarnings replaces the warnings recorded for a skill and returns the
+// messages that were not recorded by the previous build.
+func (lf *LockFile) SetWarnings(skill string, entry WarningEntry) []string {
+	prev := make(map[string]bool)
+	for _, m := range lf.Warnings[skill].Messages {
+		prev[m] = true
+	}
+	var added []string
+	for _, m := range entry.Messages {
+		if !prev[m] {
+			added = append(added, m)
+		}
 	}
+	if lf.Warnings == nil {
+		lf.Warnings = make(map[string]WarningEntry)
+	}
+	lf.Warnings[skill] = entry
+	return added
 }
 
 // IsUpToDate checks if an artifact's input hash matches the lockfile.
@@ -88,6 +146,43 @@ func (lf *LockFile) IsUpToDate(artifactID, inputHash string) bool {
 	return entry.InputHash == inputHash
 }
 
+// ExplainCache says why an artifact is or is not up to date: which of its
+// recorded inputs differ from inputs, or why none can be compared.
+func (lf *LockFile) ExplainCache(artifactID, inputHash string, inputs InputHashes) string {
+	entry, ok := lf.Artifacts[artifactID]
+	if !ok {
+		return "not cached: no lockfile entry"
+	}
+	var changed []string
+	if prev := entry.Inputs; prev != nil {
+		for _, c := range []struct{ name, prev, cur string }{
+			{"spec", prev.Spec, inputs.Spec},
+			{"sections", prev.Sections, inputs.Sections},
+			{"prompt", prev.Prompt, inputs.Prompt},
+		} {
+			if c.prev != c.cur {
+				changed = append(changed, c.name)
+			}
+		}
+	}
+
+	var explanation string
+	switch {
+	case entry.InputHash == inputHash:
+		explanation = "up to date"
+	case entry.Inputs == nil:
+		explanation = "changed: the lockfile entry predates per-input hashes, so the changed input is unknown"
+	case len(changed) == 0:
+		explanation = "changed: the inputs match but the combined hash differs"
+	default:
+		explanation = "changed: " + strings.Join(changed, ", ")
+	}
+	if entry.Inputs != nil && entry.Inputs.Model != "" && inputs.Model != "" && entry.Inputs.Model != inputs.Model {
+		explanation += fmt.Sprintf(" (model changed from %s to %s; the model does not invalidate the cache)", entry.Inputs.Model, inputs.Model)
+	}
+	return explanation
+}
+
 // CacheDir returns the .sc-cache directory path.
 func CacheDir(projectDir string) string {
 	return filepath.Join(projectDir, ".sc-cache")
@@ -103,11 +198,12 @@ func ReadCached(projectDir, artifactID string) (string, error) {
 	return string(data), nil
 }
 
-// WriteCached writes an artifact output to the cache.
+// WriteCached writes an artifact output to the cache. Artifact IDs may be
+// namespaced ("skill/artifact"), which maps to a subdirectory.
 func WriteCached(projectDir, artifactID, content string) error {
-	dir := CacheDir(projectDir)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
+	path := filepath.Join(CacheDir(projectDir), artifactID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return err
 	}
-	return os.WriteFile(filepath.Join(dir, artifactID), []byte(content), 0o644)
+	return os.WriteFile(path, []byte(content), 0o644)
 }
@@ -70,6 +70,50 @@ func TestIsUpToDate(t *testing.T) {
 	}
 }
 
+func TestExplainCache(t *testing.T) {
+	lf := &LockFile{Artifacts: map[string]LockEntry{"old": {InputHash: "x"}}}
+	inputs := HashInputs("spec", "sections", "prompt", "model-a")
+	lf.UpdateEntry("skill", HashInput("spec", "sections", "prompt"), "out", "anthropic", "model-a", inputs)
+
+	tests := []struct {
+		name, id                      string
+		spec, sections, prompt, model string
+		want                          string
+	}{
+		{"unchanged", "skill", "spec", "sections", "prompt", "model-a", "up to date"},
+		{"sections and prompt", "skill", "spec", "sections v2", "prompt v2", "model-a", "changed: sections, prompt"},
+		{"model only", "skill", "spec", "sections", "prompt", "model-b",
+			"up to date (model changed from model-a to model-b; the model does not invalidate the cache)"},
+		{"missing", "other", "spec", "sections", "prompt", "model-a", "not cached: no lockfile entry"},
+		{"no breakdown", "old", "spec", "sections", "prompt", "model-a",
+			"changed: the lockfile entry predates per-input hashes, so the changed input is unknown"},
+	}
+	for _, tt := range tests {
+		got := lf.ExplainCache(tt.id, HashInput(tt.spec, tt.sections, tt.prompt), HashInputs(tt.spec, tt.sections, tt.prompt, tt.model))
+		if got != tt.want {
+			t.Errorf("%s: ExplainCache = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSetWarnings_ReportsNewMessages(t *testing.T) {
+	lf := &LockFile{Artifacts: map[string]LockEntry{}}
+
+	first := WarningEntry{Counts: map[string]int{"warning": 1}, Messages: []string{"warning: a"}}
+	if added := lf.SetWarnings("api", first); len(added) != 1 {
+		t.Errorf("first build added = %v, want every message", added)
+	}
+
+	second := WarningEntry{Counts: map[string]int{"warning": 2}, Messages: []string{"warning: a", "warning: b"}}
+	added := lf.SetWarnings("api", second)
+	if len(added) != 1 || added[0] != "warning: b" {
+		t.Errorf("second build added = %v, want [warning: b]", added)
+	}
+	if lf.Warnings["api"].Counts["warning"] != 2 {
+		t.Errorf("counts = %v, want replaced entry", lf.Warnings["api"].Counts)
+	}
+}
+
 func TestCachedReadWrite(t *testing.T) {
 	dir := t.TempDir()
 	content := "cached artifact content"
@@ -15,10 +15,25 @@ type Config struct {
 	APIKey   string `yaml:"api-key,omitempty" mapstructure:"api-key"`
 	Model    string `yaml:"model,omitempty" mapstructure:"model"`
 	BaseURL  string `yaml:"base-url,omitempty" mapstructure:"base-url"`
+	// DefaultModels overrides the built-in default model per provider name,
+	// used when no model is configured.
+	DefaultModels map[string]string `yaml:"default-models,omitempty" mapstructure:"default-models"`
+	// AnthropicVersion replaces the anthropic-version header sent to
+	// Anthropic; AnthropicBeta is a comma-separated anthropic-beta list.
+	AnthropicVersion string `yaml:"anthropic-version,omitempty" mapstructure:"anthropic-version"`
+	AnthropicBeta    string `yaml:"anthropic-beta,omitempty" mapstructure:"anthropic-beta"`
+	// FallbackProviders are tried in order when the provider is
+	// unavailable. Each sets provider, model, api-key, and base-url; it is
+	// edited in the config file, as sc config set takes only single values.
+	FallbackProviders []Config `yaml:"fallback-providers,omitempty" mapstructure:"fallback-providers"`
 }
 
-// ValidKeys lists the allowed config keys.
-var ValidKeys = []string{"provider", "api-key", "model", "base-url"}
+// ValidKeys lists the allowed config keys. Default models are set per
+// provider as default-models.<provider>.
+var ValidKeys = []string{"provider", "api-key", "model", "base-url", "anthropic-version", "anthropic-beta"}
+
+// defaultModelsKey prefixes the per-provider default model keys.
+const defaultModelsKey = "default-models"
 
 func configDir() (string, error) {
 	home, err := os.UserHomeDir()
@@ -65,18 +80,25 @@ func Load() (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Config{
-		Provider: v.GetString("provider"),
-		APIKey:   v.GetString("api-key"),
-		Model:    v.GetString("model"),
-		BaseURL:  v.GetString("base-url"),
-	}, nil
+	cfg := &Config{
+		Provider:         v.GetString("provider"),
+		APIKey:           v.GetString("api-key"),
+		Model:            v.GetString("model"),
+		BaseURL:          v.GetString("base-url"),
+		DefaultModels:    v.GetStringMapString(defaultModelsKey),
+		AnthropicVersion: v.GetString("anthropic-version"),
+		AnthropicBeta:    v.GetString("anthropic-beta"),
+	}
+	if err := v.UnmarshalKey("fallback-providers", &cfg.FallbackProviders); err != nil {
+		return nil, fmt.Errorf("reading fallback-providers: %w", err)
+	}
+	return cfg, nil
 }
 
 // Set updates a single key in the config file.
 func Set(key, value string) error {
 	if !isValidKey(key) {
-		return fmt.Errorf("unknown config key %q (valid keys: %s)", key, strings.Join(ValidKeys, ", "))
+		return fmt.Errorf("unknown config key %q (valid keys: %s, %s.<provider>)", key, strings.Join(ValidKeys, ", "), defaultModelsKey)
 	}
 
 	v, err := newViper()
@@ -106,10 +128,24 @@ func List() (map[string]string, error) {
 		return nil, err
 	}
 	m := map[string]string{
-		"provider": cfg.Provider,
-		"api-key":  maskKey(cfg.APIKey),
-		"model":    cfg.Model,
-		"base-url": cfg.BaseURL,
+		"provider":          cfg.Provider,
+		"api-key":           maskKey(cfg.APIKey),
+		"model":             cfg.Model,
+		"base-url":          cfg.BaseURL,
+		"anthropic-version": cfg.AnthropicVersion,
+		"anthropic-beta":    cfg.AnthropicBeta,
+	}
+	for name, model := range cfg.DefaultModels {
+		m[defaultModelsKey+"."+name] = model
+	}
+	for i, fb := range cfg.FallbackProviders {
+		var parts []string
+		for _, part := range []string{fb.Provider, fb.Model, fb.BaseURL} {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		m[fmt.Sprintf("fallback-providers.%d", i)] = strings.Join(parts, " ")
 	}
 	return m, nil
 }
@@ -135,6 +171,9 @@ func maskKey(key string) string {
 }
 
 func isValidKey(key string) bool {
+	if name, ok := strings.CutPrefix(key, defaultModelsKey+"."); ok {
+		return name != "" && !strings.Contains(name, ".")
+	}
 	for _, k := range ValidKeys {
 		if k == key {
 			return true
@@ -149,6 +188,16 @@ type Resolved struct {
 	APIKey   string
 	Model    string
 	BaseURL  string
+	// DefaultModels are the configured per-provider default models; see
+	// Config.DefaultModels.
+	DefaultModels map[string]string
+	// AnthropicVersion and AnthropicBeta are the anthropic-version and
+	// anthropic-beta headers; empty means the provider's defaults.
+	AnthropicVersion string
+	AnthropicBeta    []string
+	// Fallbacks are the resolved fallback providers, in order; see
+	// Config.FallbackProviders. They have no fallbacks of their own.
+	Fallbacks []*Resolved
 }
 
 // Resolve merges provider settings in priority order:
@@ -163,10 +212,17 @@ func Resolve(cliProvider, cliModel, cliAPIKey, cliBaseURL string, frontmatter *C
 
 	// Viper already merged: config file < env vars (SC_PROVIDER, SC_API_KEY, etc.)
 	r := &Resolved{
-		Provider: v.GetString("provider"),
-		APIKey:   v.GetString("api-key"),
-		Model:    v.GetString("model"),
-		BaseURL:  v.GetString("base-url"),
+		Provider:         v.GetString("provider"),
+		APIKey:           v.GetString("api-key"),
+		Model:            v.GetString("model"),
+		BaseURL:          v.GetString("base-url"),
+		DefaultModels:    v.GetStringMapString(defaultModelsKey),
+		AnthropicVersion: strings.TrimSpace(v.GetString("anthropic-version")),
+	}
+	for _, beta := range strings.Split(v.GetString("anthropic-beta"), ",") {
+		if beta = strings.TrimSpace(beta); beta != "" {
+			r.AnthropicBeta = append(r.AnthropicBeta, beta)
+		}
 	}
 
 	// Frontmatter overrides env vars
@@ -201,13 +257,49 @@ func Resolve(cliProvider, cliModel, cliAPIKey, cliBaseURL string, frontmatter *C
 
 	// Also check provider-specific env vars as fallback for API key
 	if r.APIKey == "" {
-		switch strings.ToLower(r.Provider) {
-		case "anthropic":
-			r.APIKey = os.Getenv("ANTHROPIC_API_KEY")
-		case "openai":
-			r.APIKey = os.Getenv("OPENAI_API_KEY")
+		r.APIKey = providerAPIKey(r.Provider)
+	}
+
+	// Frontmatter fallbacks replace the config file's. Each keeps only its
+	// own connection settings, so the primary's API key is never sent to
+	// another provider.
+	var fallbacks []Config
+	if err := v.UnmarshalKey("fallback-providers", &fallbacks); err != nil {
+		return nil, fmt.Errorf("reading fallback-providers: %w", err)
+	}
+	if frontmatter != nil && len(frontmatter.FallbackProviders) > 0 {
+		fallbacks = frontmatter.FallbackProviders
+	}
+	for i, fb := range fallbacks {
+		if fb.Provider == "" && fb.BaseURL == "" {
+			return nil, fmt.Errorf("fallback-providers[%d]: set provider or base-url", i)
+		}
+		fr := &Resolved{
+			Provider:         fb.Provider,
+			APIKey:           fb.APIKey,
+			Model:            fb.Model,
+			BaseURL:          fb.BaseURL,
+			DefaultModels:    r.DefaultModels,
+			AnthropicVersion: r.AnthropicVersion,
+			AnthropicBeta:    r.AnthropicBeta,
 		}
+		if fr.APIKey == "" {
+			fr.APIKey = providerAPIKey(fr.Provider)
+		}
+		r.Fallbacks = append(r.Fallbacks, fr)
 	}
 
 	return r, nil
 }
+
+// providerAPIKey returns the API key from the provider's own env var, such
+// as ANTHROPIC_API_KEY.
+func providerAPIKey(provider string) string {
+	switch strings.ToLower(provider) {
+	case "anthropic":
+		return os.Getenv("ANTHROPIC_API_KEY")
+	case "openai":
+		return os.Getenv("OPENAI_API_KEY")
+	}
+	return ""
+}
@@ -22,6 +22,8 @@ func setupTempConfig(t *testing.T) string {
 	t.Setenv("SC_API_KEY", "")
 	t.Setenv("SC_MODEL", "")
 	t.Setenv("SC_BASE_URL", "")
+	t.Setenv("SC_ANTHROPIC_VERSION", "")
+	t.Setenv("SC_ANTHROPIC_BETA", "")
 	return dir
 }
 
@@ -92,6 +94,28 @@ func TestSet_UnknownKey(t *testing.T) {
 	}
 }
 
+func TestSet_DefaultModels(t *testing.T) {
+	setupTempConfig(t)
+
+	if err := Set("default-models.openai", "gpt-4.1"); err != nil {
+		t.Fatalf("set error: %v", err)
+	}
+	if err := Set("default-models.", "x"); err == nil {
+		t.Error("expected error for default-models key without a provider")
+	}
+	r, err := Resolve("openai", "", "", "", nil)
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	if r.DefaultModels["openai"] != "gpt-4.1" {
+		t.Errorf("DefaultModels = %v, want openai: gpt-4.1", r.DefaultModels)
+	}
+	values, _ := List()
+	if values["default-models.openai"] != "gpt-4.1" {
+		t.Errorf("List() = %v, want default-models.openai", values)
+	}
+}
+
 func TestResolve_Priority(t *testing.T) {
 	setupTempConfig(t)
 
@@ -133,3 +157,65 @@ func TestResolve_Priority(t *testing.T) {
 		t.Errorf("Provider = %q, want %q (env should win over config)", resolved.Provider, "from-env")
 	}
 }
+
+func TestResolve_AnthropicHeaders(t *testing.T) {
+	setupTempConfig(t)
+
+	if err := Set("anthropic-version", "2024-01-01"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SC_ANTHROPIC_BETA", "feature-a, ,feature-b ")
+	resolved, err := Resolve("", "", "", "", nil)
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	if resolved.AnthropicVersion != "2024-01-01" {
+		t.Errorf("AnthropicVersion = %q, want 2024-01-01", resolved.AnthropicVersion)
+	}
+	if strings.Join(resolved.AnthropicBeta, "|") != "feature-a|feature-b" {
+		t.Errorf("AnthropicBeta = %q, want [feature-a feature-b]", resolved.AnthropicBeta)
+	}
+}
+
+func TestResolve_FallbackProviders(t *testing.T) {
+	dir := setupTempConfig(t)
+	cfg := "provider: anthropic\nfallback-providers:\n  - provider: openai\n    model: gpt-4o\n"
+	if err := os.WriteFile(filepath.Join(dir, ".config", "sc", "config.yaml"), []byte(cfg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SC_API_KEY", "primary-key")
+	t.Setenv("OPENAI_API_KEY", "openai-key")
+
+	resolved, err := Resolve("", "", "", "", nil)
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	if len(resolved.Fallbacks) != 1 {
+		t.Fatalf("Fallbacks = %+v, want the config file's", resolved.Fallbacks)
+	}
+	fb := resolved.Fallbacks[0]
+	if fb.Provider != "openai" || fb.Model != "gpt-4o" || fb.APIKey != "openai-key" {
+		t.Errorf("fallback = %+v, want openai with its own API key, not the primary's", fb)
+	}
+
+	// Frontmatter fallbacks replace the config file's
+	resolved, err = Resolve("", "", "", "", &Config{FallbackProviders: []Config{{Provider: "anthropic", APIKey: "fm-key"}}})
+	if err != nil {
+		t.Fatalf("resolve error: %v", err)
+	}
+	if len(resolved.Fallbacks) != 1 || resolved.Fallbacks[0].Provider != "anthropic" || resolved.Fallbacks[0].APIKey != "fm-key" {
+		t.Errorf("Fallbacks = %+v, want the frontmatter's", resolved.Fallbacks)
+	}
+
+	if _, err := Resolve("", "", "", "", &Config{FallbackProviders: []Config{{Model: "gpt-4o"}}}); err == nil {
+		t.Error("a fallback without provider or base-url should be rejected")
+	}
+
+	list, err := List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if list["fallback-providers.0"] != "openai gpt-4o" {
+		t.Errorf("List fallback-providers.0 = %q", list["fallback-providers.0"])
+	}
+}
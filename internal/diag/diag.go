@@ -0,0 +1,244 @@
+// Package diag defines the diagnostic type shared by instruction and spec
+// validation, plus text, JSON, and SARIF renderers for it.
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how serious a diagnostic is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Diagnostic is a single validation finding.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code,omitempty"`   // stable rule identifier, e.g. "missing-section"
+	Message  string   `json:"message"`          // human-readable description
+	Source   string   `json:"source,omitempty"` // optional: file/location context
+	Line     int      `json:"line,omitempty"`   // optional: 1-based line in Source
+}
+
+// String formats the diagnostic as "source:line: message", omitting
+// whichever location parts are unknown.
+func (d Diagnostic) String() string {
+	switch {
+	case d.Source != "" && d.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", d.Source, d.Line, d.Message)
+	case d.Source != "":
+		return fmt.Sprintf("%s: %s", d.Source, d.Message)
+	}
+	return d.Message
+}
+
+// HasErrors reports whether any diagnostic has error severity.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Counts tallies diagnostics by severity; an empty severity counts as a warning.
+func Counts(diags []Diagnostic) map[Severity]int {
+	counts := make(map[Severity]int)
+	for _, d := range diags {
+		sev := d.Severity
+		if sev == "" {
+			sev = SeverityWarning
+		}
+		counts[sev]++
+	}
+	return counts
+}
+
+// FormatCounts renders counts as e.g. "2 warnings, 1 info", most severe first.
+func FormatCounts(counts map[Severity]int) string {
+	var parts []string
+	for _, sev := range []Severity{SeverityError, SeverityWarning, SeverityInfo} {
+		n := counts[sev]
+		if n == 0 {
+			continue
+		}
+		label := string(sev)
+		if n != 1 && sev != SeverityInfo {
+			label += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s", n, label))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Formats accepted by Write.
+const (
+	FormatText  = "text"
+	FormatJSON  = "json"
+	FormatSARIF = "sarif"
+)
+
+// Write renders diagnostics in the given format.
+func Write(w io.Writer, format string, diags []Diagnostic) error {
+	switch format {
+	case "", FormatText:
+		return writeText(w, diags)
+	case FormatJSON:
+		return writeJSON(w, diags)
+	case FormatSARIF:
+		return writeSARIF(w, diags)
+	default:
+		return fmt.Errorf("unknown format %q (expected %s, %s, or %s)", format, FormatText, FormatJSON, FormatSARIF)
+	}
+}
+
+func writeText(w io.Writer, diags []Diagnostic) error {
+	for _, d := range diags {
+		sev := d.Severity
+		if sev == "" {
+			sev = SeverityWarning
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", strings.ToUpper(string(sev)), d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, diags []Diagnostic) error {
+	if diags == nil {
+		diags = []Diagnostic{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diags)
+}
+
+// SARIF 2.1.0 subset sufficient for GitHub code scanning.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+func writeSARIF(w io.Writer, diags []Diagnostic) error {
+	ruleSet := make(map[string]bool)
+	results := make([]sarifResult, 0, len(diags))
+	for _, d := range diags {
+		code := d.Code
+		if code == "" {
+			code = "sc"
+		}
+		ruleSet[code] = true
+
+		res := sarifResult{
+			RuleID:  code,
+			Level:   sarifLevel(d.Severity),
+			Message: sarifMessage{Text: d.Message},
+		}
+		if d.Source != "" {
+			loc := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: d.Source}}
+			if d.Line > 0 {
+				loc.Region = &sarifRegion{StartLine: d.Line}
+			}
+			res.Locations = []sarifLocation{{PhysicalLocation: loc}}
+		}
+		results = append(results, res)
+	}
+
+	var ruleIDs []string
+	for id := range ruleSet {
+		ruleIDs = append(ruleIDs, id)
+	}
+	sort.Strings(ruleIDs)
+	rules := make([]sarifRule, len(ruleIDs))
+	for i, id := range ruleIDs {
+		rules[i] = sarifRule{ID: id}
+	}
+
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "sc",
+				InformationURI: "https://github.com/roberthamel/skill-compiler",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityInfo:
+		return "note"
+	default:
+		return "warning"
+	}
+}
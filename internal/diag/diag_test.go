@@ -0,0 +1,92 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var sample = []Diagnostic{
+	{Severity: SeverityError, Code: "parse", Message: "bad document", Source: "api.yaml", Line: 12},
+	{Severity: SeverityWarning, Code: "missing-section", Message: "missing recommended section: # Product"},
+}
+
+func TestString(t *testing.T) {
+	if got := sample[0].String(); got != "api.yaml:12: bad document" {
+		t.Errorf("String() = %q", got)
+	}
+	if got := sample[1].String(); got != "missing recommended section: # Product" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestFormatCounts(t *testing.T) {
+	diags := append(append([]Diagnostic(nil), sample...), Diagnostic{Message: "no severity"}, Diagnostic{Severity: SeverityInfo, Message: "coverage"})
+	if got := FormatCounts(Counts(diags)); got != "1 error, 2 warnings, 1 info" {
+		t.Errorf("FormatCounts = %q", got)
+	}
+	if got := FormatCounts(Counts(nil)); got != "none" {
+		t.Errorf("FormatCounts(nil) = %q, want none", got)
+	}
+}
+
+func TestWrite_Text(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatText, sample); err != nil {
+		t.Fatal(err)
+	}
+	want := "ERROR: api.yaml:12: bad document\nWARNING: missing recommended section: # Product\n"
+	if buf.String() != want {
+		t.Errorf("text output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWrite_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatJSON, sample); err != nil {
+		t.Fatal(err)
+	}
+	var got []Diagnostic
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(got) != 2 || got[0] != sample[0] {
+		t.Errorf("round-trip = %+v, want %+v", got, sample)
+	}
+}
+
+func TestWrite_SARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatSARIF, sample); err != nil {
+		t.Fatal(err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v", err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected SARIF envelope: %+v", log)
+	}
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("rules = %+v, want 2", run.Tool.Driver.Rules)
+	}
+	first := run.Results[0]
+	if first.Level != "error" || first.RuleID != "parse" {
+		t.Errorf("first result = %+v", first)
+	}
+	if loc := first.Locations[0].PhysicalLocation; loc.ArtifactLocation.URI != "api.yaml" || loc.Region.StartLine != 12 {
+		t.Errorf("location = %+v", loc)
+	}
+	if len(run.Results[1].Locations) != 0 {
+		t.Errorf("diagnostic without source should have no locations")
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	err := Write(&bytes.Buffer{}, "xml", sample)
+	if err == nil || !strings.Contains(err.Error(), "unknown format") {
+		t.Errorf("error = %v, want unknown format", err)
+	}
+}
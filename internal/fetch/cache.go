@@ -0,0 +1,74 @@
+package fetch
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+)
+
+// cachedSpec is the validators of a cached URL fetch, stored as
+// <key>.json beside the body in <key>.
+type cachedSpec struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	body         []byte
+}
+
+// cacheKey names a source's cache files. Headers and auth are part of it,
+// since they can select a different document at the same URL.
+func cacheKey(source instructions.SpecSource) string {
+	h := sha256.New()
+	data, _ := json.Marshal(struct {
+		URL     string
+		Headers map[string]string
+		Auth    *instructions.SpecAuth
+	}{source.URL, source.Headers, source.Auth})
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// loadCached returns the cached fetch of source, or nil when caching is off
+// or nothing usable is cached.
+func loadCached(source instructions.SpecSource) *cachedSpec {
+	if source.CacheDir == "" {
+		return nil
+	}
+	path := filepath.Join(source.CacheDir, cacheKey(source))
+	meta, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return nil
+	}
+	var c cachedSpec
+	if err := json.Unmarshal(meta, &c); err != nil || c.URL != source.URL || (c.ETag == "" && c.LastModified == "") {
+		return nil
+	}
+	if c.body, err = os.ReadFile(path); err != nil {
+		return nil
+	}
+	return &c
+}
+
+// storeCached records a fetched spec and its validators. Responses without
+// an ETag or Last-Modified cannot be revalidated and are not cached. Errors
+// are ignored: the cache only saves downloads.
+func storeCached(source instructions.SpecSource, header http.Header, body []byte) {
+	c := cachedSpec{URL: source.URL, ETag: header.Get("ETag"), LastModified: header.Get("Last-Modified")}
+	if source.CacheDir == "" || (c.ETag == "" && c.LastModified == "") {
+		return
+	}
+	meta, err := json.MarshalIndent(c, "", "  ")
+	if err != nil || os.MkdirAll(source.CacheDir, 0o755) != nil {
+		return
+	}
+	path := filepath.Join(source.CacheDir, cacheKey(source))
+	// Body first, so validators never describe a body that was not written
+	if os.WriteFile(path, body, 0o644) == nil {
+		_ = os.WriteFile(path+".json", meta, 0o644)
+	}
+}
@@ -0,0 +1,67 @@
+package fetch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+)
+
+// CommandTimeout bounds a command spec source unless the source sets timeout.
+const CommandTimeout = 2 * time.Minute
+
+// maxStderr caps how much of a failing command's stderr is quoted in errors.
+const maxStderr = 2048
+
+// Command runs source.Command through the shell and returns its stdout. On
+// failure the error quotes the tail of the command's stderr. Cancelling ctx
+// kills the command.
+func Command(parent context.Context, source instructions.SpecSource) ([]byte, error) {
+	timeout := CommandTimeout
+	if source.Timeout != "" {
+		d, err := time.ParseDuration(source.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("command %q: invalid timeout %q", source.Command, source.Timeout)
+		}
+		timeout = d
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "sh", "-c", source.Command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// Children that outlive a killed shell would otherwise hold the pipes open
+	cmd.WaitDelay = time.Second
+	err := cmd.Run()
+	if err := parent.Err(); err != nil {
+		return nil, fmt.Errorf("command %q: %w", source.Command, err)
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("command %q timed out after %s%s", source.Command, timeout, stderrSuffix(stderr.String()))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("command %q: %w%s", source.Command, err, stderrSuffix(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// stderrSuffix formats captured stderr for an error message, keeping the
+// end where the actual failure is usually reported.
+func stderrSuffix(stderr string) string {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return ""
+	}
+	if len(stderr) > maxStderr {
+		stderr = "..." + stderr[len(stderr)-maxStderr:]
+	}
+	return "\nstderr:\n" + stderr
+}
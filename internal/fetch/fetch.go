@@ -0,0 +1,152 @@
+// Package fetch retrieves URL-based spec sources with the headers and
+// credentials configured on the source.
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/redact"
+)
+
+// Timeout bounds a whole fetch, including redirects and reading the body.
+const Timeout = 30 * time.Second
+
+// maxRedirects caps how many redirects a fetch follows.
+const maxRedirects = 10
+
+var (
+	// ErrUnauthorized is returned for HTTP 401 and 403 responses.
+	ErrUnauthorized = errors.New("access denied")
+	// ErrNotFound is returned for HTTP 404 responses.
+	ErrNotFound = errors.New("not found")
+	// ErrNetwork is returned when the server could not be reached.
+	ErrNetwork = errors.New("network error")
+	// ErrOffline is returned when a fetch is attempted in offline mode.
+	ErrOffline = errors.New("network access disabled (--offline)")
+)
+
+var client = &http.Client{
+	Timeout: Timeout,
+	// Go already strips Authorization when a redirect leaves the original host.
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		return nil
+	},
+}
+
+// URL fetches source.URL, applying the source's headers and auth. Tokens in
+// the URL's query string are masked in returned errors. With
+// source.CacheDir set, the spec is revalidated with the ETag and
+// Last-Modified of the previous fetch, and a 304 returns the cached copy.
+// Cancelling ctx aborts the fetch.
+func URL(ctx context.Context, source instructions.SpecSource) (_ []byte, err error) {
+	defer func() { err = redact.Error(err) }()
+	if source.Offline {
+		return nil, fmt.Errorf("fetching URL %s: %w", source.URL, ErrOffline)
+	}
+	req, err := newRequest(ctx, source)
+	if err != nil {
+		return nil, fmt.Errorf("fetching URL %s: %w", source.URL, err)
+	}
+	cached := loadCached(source)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching URL %s: %w: %v", source.URL, ErrNetwork, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached.body, nil
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("fetching URL %s: %w (HTTP %d); check the source's headers and auth",
+			source.URL, ErrUnauthorized, resp.StatusCode)
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("fetching URL %s: %w (HTTP 404)", source.URL, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetching URL %s: HTTP %d", source.URL, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetching URL %s: %w: %v", source.URL, ErrNetwork, err)
+	}
+	storeCached(source, resp.Header, data)
+	return data, nil
+}
+
+// newRequest builds a GET request with env-expanded headers and credentials.
+func newRequest(ctx context.Context, source instructions.SpecSource) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range source.Headers {
+		expanded, err := expandEnv(value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %w", name, err)
+		}
+		req.Header.Set(name, expanded)
+	}
+
+	if auth := source.Auth; auth != nil {
+		switch {
+		case auth.Bearer != "":
+			token, err := expandEnv(auth.Bearer)
+			if err != nil {
+				return nil, fmt.Errorf("auth bearer: %w", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		case auth.Username != "":
+			user, err := expandEnv(auth.Username)
+			if err != nil {
+				return nil, fmt.Errorf("auth username: %w", err)
+			}
+			pass, err := expandEnv(auth.Password)
+			if err != nil {
+				return nil, fmt.Errorf("auth password: %w", err)
+			}
+			req.SetBasicAuth(user, pass)
+		}
+	}
+	return req, nil
+}
+
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${VAR} references, failing on unset variables so a
+// missing token isn't silently sent as an empty credential.
+func expandEnv(s string) (string, error) {
+	var missing string
+	out := envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		name := envRef.FindStringSubmatch(ref)[1]
+		value, ok := os.LookupEnv(name)
+		if !ok && missing == "" {
+			missing = name
+		}
+		return value
+	})
+	if missing != "" {
+		return "", fmt.Errorf("environment variable %s is not set", missing)
+	}
+	return out, nil
+}
@@ -0,0 +1,162 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+)
+
+func TestURL_HeadersAndAuth(t *testing.T) {
+	t.Setenv("SPEC_TOKEN", "s3cret")
+	t.Setenv("SPEC_KEY", "k1")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/spec", http.StatusFound)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer s3cret" || r.Header.Get("X-Api-Key") != "k1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte("openapi: 3.0.0"))
+	}))
+	defer srv.Close()
+
+	data, err := URL(context.Background(), instructions.SpecSource{
+		URL:     srv.URL + "/old",
+		Headers: map[string]string{"X-Api-Key": "${SPEC_KEY}"},
+		Auth:    &instructions.SpecAuth{Bearer: "${SPEC_TOKEN}"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(data) != "openapi: 3.0.0" {
+		t.Errorf("body = %q", data)
+	}
+}
+
+func TestURL_Errors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/private":
+			w.WriteHeader(http.StatusForbidden)
+		case "/login":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want error
+	}{
+		{"forbidden", srv.URL + "/private", ErrUnauthorized},
+		{"unauthorized", srv.URL + "/login", ErrUnauthorized},
+		{"not found", srv.URL + "/missing", ErrNotFound},
+		{"unreachable", closed.URL, ErrNetwork},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := URL(context.Background(), instructions.SpecSource{URL: tt.url})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestURL_MissingEnv(t *testing.T) {
+	_, err := URL(context.Background(), instructions.SpecSource{
+		URL:  "http://127.0.0.1:1/spec",
+		Auth: &instructions.SpecAuth{Bearer: "${SC_TEST_UNSET_TOKEN}"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "SC_TEST_UNSET_TOKEN is not set") {
+		t.Errorf("error = %v, want missing env var error", err)
+	}
+}
+
+func TestURL_Offline(t *testing.T) {
+	_, err := URL(context.Background(), instructions.SpecSource{URL: "http://127.0.0.1:1/spec", Offline: true})
+	if !errors.Is(err, ErrOffline) {
+		t.Errorf("error = %v, want ErrOffline", err)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  instructions.SpecSource
+		want    string
+		wantErr string
+	}{
+		{"stdout", instructions.SpecSource{Command: "printf 'openapi: 3.0.0'"}, "openapi: 3.0.0", ""},
+		{"pipeline", instructions.SpecSource{Command: "echo spec | tr a-z A-Z"}, "SPEC\n", ""},
+		{"failure quotes stderr", instructions.SpecSource{Command: "echo boom >&2; exit 3"}, "", "stderr:\nboom"},
+		{"timeout", instructions.SpecSource{Command: "exec sleep 5", Timeout: "50ms"}, "", "timed out after 50ms"},
+		{"bad timeout", instructions.SpecSource{Command: "true", Timeout: "soon"}, "", `invalid timeout "soon"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := Command(context.Background(), tt.source)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("stdout = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestURL_ConditionalGet(t *testing.T) {
+	spec, downloads := "openapi: 3.0.0", 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf("%q", spec)
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(spec))
+	}))
+	defer srv.Close()
+	source := instructions.SpecSource{URL: srv.URL + "/spec", CacheDir: t.TempDir()}
+
+	for range 2 {
+		if data, err := URL(context.Background(), source); err != nil || string(data) != "openapi: 3.0.0" {
+			t.Fatalf("URL = %q, %v", data, err)
+		}
+	}
+	if downloads != 1 {
+		t.Errorf("downloads = %d, want 1: an unchanged spec should be served from the cache", downloads)
+	}
+
+	spec = "openapi: 3.1.0"
+	if data, err := URL(context.Background(), source); err != nil || string(data) != spec {
+		t.Errorf("URL after an upstream change = %q, %v, want the new spec", data, err)
+	}
+
+	source.CacheDir = ""
+	if _, err := URL(context.Background(), source); err != nil || downloads != 3 {
+		t.Errorf("without a cache dir every fetch should download (downloads = %d, err %v)", downloads, err)
+	}
+}
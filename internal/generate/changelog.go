@@ -2,20 +2,29 @@ package generate
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
 )
 
 // LoadPreviousArtifacts reads existing artifacts from the output directory.
+// The skill directory is the slug of skillName, matching where they were
+// written.
 func LoadPreviousArtifacts(outputDir, skillName string) map[ArtifactID]string {
+	return LoadPreviousArtifactsFS(OSFS{}, outputDir, skillName)
+}
+
+// LoadPreviousArtifactsFS is LoadPreviousArtifacts reading from fsys.
+func LoadPreviousArtifactsFS(fsys FS, outputDir, skillName string) map[ArtifactID]string {
 	prev := make(map[ArtifactID]string)
+	skillDir := instructions.Slug(skillName)
 
 	paths := map[ArtifactID]string{
-		ArtifactSkill:     filepath.Join(outputDir, skillName, "SKILL.md"),
-		ArtifactReference: filepath.Join(outputDir, skillName, "references", "reference.md"),
-		ArtifactExamples:  filepath.Join(outputDir, skillName, "references", "examples.md"),
+		ArtifactSkill:     filepath.Join(outputDir, skillDir, "SKILL.md"),
+		ArtifactReference: filepath.Join(outputDir, skillDir, "references", "reference.md"),
+		ArtifactExamples:  filepath.Join(outputDir, skillDir, "references", "examples.md"),
 		ArtifactLlms:      filepath.Join(outputDir, "llms.txt"),
 		ArtifactLlmsAPI:   filepath.Join(outputDir, "llms-api.txt"),
 		ArtifactLlmsFull:  filepath.Join(outputDir, "llms-full.txt"),
@@ -23,7 +32,16 @@ func LoadPreviousArtifacts(outputDir, skillName string) map[ArtifactID]string {
 	}
 
 	for id, path := range paths {
-		data, err := os.ReadFile(path)
+		data, err := fsys.ReadFile(path)
+		if err != nil && (id == ArtifactReference || id == ArtifactExamples) {
+			// The previous run may have used another output format
+			base := strings.TrimSuffix(path, ".md")
+			for _, ext := range []string{".mdx", ".adoc"} {
+				if data, err = fsys.ReadFile(base + ext); err == nil {
+					break
+				}
+			}
+		}
 		if err == nil {
 			prev[id] = string(data)
 		}
@@ -60,3 +78,20 @@ func PrependChangelogEntry(newEntry, existingChangelog string) string {
 	// No header — just prepend
 	return entry + "\n" + existingChangelog
 }
+
+// LatestChangelogEntry returns the body of the newest entry in a
+// CHANGELOG.md written by PrependChangelogEntry, without its date heading.
+func LatestChangelogEntry(changelog string) string {
+	_, rest, ok := strings.Cut(changelog, "\n## ")
+	if !ok {
+		if !strings.HasPrefix(changelog, "## ") {
+			return ""
+		}
+		rest = strings.TrimPrefix(changelog, "## ")
+	}
+	_, body, _ := strings.Cut(rest, "\n")
+	if next := strings.Index(body, "\n## "); next >= 0 {
+		body = body[:next]
+	}
+	return strings.TrimSpace(body)
+}
@@ -0,0 +1,126 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/provider"
+)
+
+// DefaultTokenBudget is the estimated input-token budget per request used
+// when Options.TokenBudget is unset.
+const DefaultTokenBudget = 100000
+
+// chunked reports whether an artifact is batched when its prompt is too big.
+func chunked(id ArtifactID) bool {
+	return id == ArtifactReference || id == ArtifactLlmsFull
+}
+
+func (p *Pipeline) tokenBudget() int {
+	if p.Opts.TokenBudget > 0 {
+		return p.Opts.TokenBudget
+	}
+	return DefaultTokenBudget
+}
+
+// chunks splits the IR's operations into batches whose prompts fit the token
+// budget, walking groups in order so each group's operations stay together
+// and in sequence. It returns nil when the whole prompt already fits.
+func (p *Pipeline) chunks(id ArtifactID) [][]string {
+	if !chunked(id) || p.IR == nil || len(p.IR.Operations) < 2 {
+		return nil
+	}
+	budget := p.tokenBudget()
+	if p.estimateTokens(p.systemPrompt(id)+p.userMessage(id)) <= budget {
+		return nil
+	}
+
+	// Prompt overhead shared by every chunk: instructions, types, auth
+	overhead := p.estimateTokens(p.systemPrompt(id) + ChunkPrompt + p.userMessageFor(id, p.IR.Subset(nil)))
+
+	opTokens := make(map[string]int, len(p.IR.Operations))
+	for _, op := range p.IR.Operations {
+		data, _ := json.MarshalIndent(op, "", "  ")
+		opTokens[op.ID] = p.estimateTokens(string(data))
+	}
+
+	var ordered []string
+	seen := make(map[string]bool)
+	for _, g := range p.IR.Groups {
+		for _, opID := range g.Operations {
+			if _, ok := opTokens[opID]; ok && !seen[opID] {
+				seen[opID] = true
+				ordered = append(ordered, opID)
+			}
+		}
+	}
+	for _, op := range p.IR.Operations {
+		if !seen[op.ID] {
+			seen[op.ID] = true
+			ordered = append(ordered, op.ID)
+		}
+	}
+
+	var out [][]string
+	var cur []string
+	size := overhead
+	for _, opID := range ordered {
+		// An operation too large for any chunk still gets one of its own
+		if len(cur) > 0 && size+opTokens[opID] > budget {
+			out = append(out, cur)
+			cur, size = nil, overhead
+		}
+		cur = append(cur, opID)
+		size += opTokens[opID]
+	}
+	if len(cur) > 0 {
+		out = append(out, cur)
+	}
+	if len(out) < 2 {
+		return nil
+	}
+	return out
+}
+
+// generateChunked generates an artifact batch by batch and joins the parts
+// into one document.
+func (p *Pipeline) generateChunked(ctx context.Context, id ArtifactID, chunks [][]string) ArtifactResult {
+	p.logf("  Generating %s (%d chunks)...\n", id, len(chunks))
+
+	result := ArtifactResult{ID: id, FilePath: p.artifactPath(id)}
+	total := &provider.GenerateResponse{}
+	contents := make([]string, 0, len(chunks))
+	systemPrompt := p.systemPrompt(id) + ChunkPrompt
+	for i, opIDs := range chunks {
+		userMessage := p.chunkMessage(id, opIDs, i, len(chunks))
+		resp, err := p.call(ctx, id, chunkLabel(id, i, len(chunks)), systemPrompt, userMessage)
+		if err != nil {
+			result.Err = fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+			return result
+		}
+		contents = append(contents, strings.TrimSpace(resp.Content))
+		total.Provider, total.Model = resp.Provider, resp.Model
+		total.TokensIn += resp.TokensIn
+		total.TokensOut += resp.TokensOut
+	}
+	result.Content = strings.Join(contents, "\n\n") + "\n"
+	result.Response = total
+	return result
+}
+
+// chunkMessage is the user message for chunk i of n, holding opIDs.
+func (p *Pipeline) chunkMessage(id ArtifactID, opIDs []string, i, n int) string {
+	userMessage := p.userMessageFor(id, p.IR.Subset(opIDs)) +
+		fmt.Sprintf("\n\n## Chunk\nThis is part %d of %d.", i+1, n)
+	if i > 0 {
+		userMessage += " Continue the document: do not repeat the title or introduction."
+	}
+	return userMessage
+}
+
+// chunkLabel names chunk i of n in progress messages, e.g. "reference [2/3]".
+func chunkLabel(id ArtifactID, i, n int) string {
+	return fmt.Sprintf("%s [%d/%d]", id, i+1, n)
+}
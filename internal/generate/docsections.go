@@ -0,0 +1,104 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// docsHeadingKeywords select the project docs headings each section is
+// derived from under the "docs" empty-sections policy. Product also takes
+// the root README's introduction.
+var docsHeadingKeywords = []struct {
+	section  string
+	keywords []string
+}{
+	{"Product", []string{"overview", "about", "introduction", "features"}},
+	{"Workflows", []string{"usage", "getting started", "quickstart", "quick start", "workflow", "workflows", "tutorial", "how to"}},
+	{"Examples", []string{"example", "examples"}},
+	{"Common patterns", []string{"patterns", "recipes", "tips", "best practices", "faq"}},
+}
+
+// maxDerivedSectionBytes caps the docs content derived for one section.
+const maxDerivedSectionBytes = 8000
+
+// DerivedSections returns the sections the "docs" empty-sections policy
+// derives from the codebase's docs, by section name: those that are empty
+// or absent in inst and for which the docs have matching content. It
+// returns nil under other policies or without docs.
+func DerivedSections(inst *instructions.Instructions, spec *ir.IntermediateRepr) map[string]string {
+	if inst.Frontmatter.EmptySections != instructions.EmptySectionsDocs || spec == nil || spec.Structure == nil {
+		return nil
+	}
+	var derived map[string]string
+	for _, kw := range docsHeadingKeywords {
+		if strings.TrimSpace(inst.Sections[kw.section]) != "" {
+			continue
+		}
+		var parts []string
+		for i, doc := range spec.Structure.Docs {
+			blocks := docBlocks(doc.Content)
+			if i == 0 && kw.section == "Product" && len(blocks) > 0 && blocks[0].level <= 1 {
+				parts = append(parts, fmt.Sprintf("From %s:\n\n%s", doc.Path, blocks[0].content))
+			}
+			for _, b := range blocks {
+				if b.heading != "" && containsAny(lintWords(b.heading), kw.keywords) {
+					parts = append(parts, fmt.Sprintf("From %s, %q:\n\n%s", doc.Path, b.heading, b.content))
+				}
+			}
+		}
+		content := strings.Join(parts, "\n\n")
+		if content == "" {
+			continue
+		}
+		if len(content) > maxDerivedSectionBytes {
+			content = content[:maxDerivedSectionBytes] + "\n\n[truncated]"
+		}
+		if derived == nil {
+			derived = make(map[string]string)
+		}
+		derived[kw.section] = content
+	}
+	return derived
+}
+
+// docBlock is the content under one heading of a markdown doc. Content
+// before the first heading has level 0; a doc's introduction is its first
+// block when that has level 0 or 1.
+type docBlock struct {
+	heading string
+	level   int
+	content string
+}
+
+// docBlocks splits a markdown doc on its headings, ignoring those in code
+// fences.
+func docBlocks(doc string) []docBlock {
+	var blocks []docBlock
+	var cur docBlock
+	var lines []string
+	flush := func() {
+		cur.content = strings.TrimSpace(strings.Join(lines, "\n"))
+		if cur.content != "" {
+			blocks = append(blocks, cur)
+		}
+		lines = nil
+	}
+	inFence := false
+	for _, line := range strings.Split(doc, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if rest := strings.TrimLeft(line, "#"); !inFence && rest != line && strings.HasPrefix(rest, " ") {
+			flush()
+			cur = docBlock{heading: strings.TrimSpace(rest), level: len(line) - len(rest)}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return blocks
+}
@@ -0,0 +1,170 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/provider"
+)
+
+// enrichConcurrency bounds simultaneous enrichment calls.
+const enrichConcurrency = 4
+
+// enrichRequest is the per-operation context sent to the model.
+type enrichRequest struct {
+	ID                string         `json:"id"`
+	Method            string         `json:"method,omitempty"`
+	Path              string         `json:"path,omitempty"`
+	Description       string         `json:"description,omitempty"`
+	Parameters        []ir.Parameter `json:"parameters,omitempty"`
+	MissingParameters []string       `json:"missingParameters,omitempty"`
+	RequestBody       *ir.TypeDef    `json:"requestBody,omitempty"`
+	Responses         []ir.Response  `json:"responses,omitempty"`
+}
+
+// enrichResponse is the JSON the model returns.
+type enrichResponse struct {
+	Description string            `json:"description"`
+	Parameters  map[string]string `json:"parameters"`
+}
+
+// needsEnrichment reports whether an operation or any of its parameters
+// lacks a description.
+func needsEnrichment(op ir.Operation) bool {
+	if op.Description == "" {
+		return true
+	}
+	for _, param := range op.Parameters {
+		if param.Description == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Enrich drafts descriptions for operations and parameters that have none,
+// writing them back into the IR. It makes one focused call per sparse
+// operation and returns how many descriptions were added. Existing
+// descriptions are never replaced.
+func (p *Pipeline) Enrich(ctx context.Context) (int, error) {
+	types := make(map[string]ir.TypeDef, len(p.IR.Types))
+	for _, td := range p.IR.Types {
+		types[td.Name] = td
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		added    int
+		firstErr error
+	)
+	sem := make(chan struct{}, enrichConcurrency)
+	for i := range p.IR.Operations {
+		if !needsEnrichment(p.IR.Operations[i]) {
+			continue
+		}
+		wg.Add(1)
+		go func(op *ir.Operation) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			resp, err := p.enrichOperation(ctx, *op, types)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("enriching %s: %w", op.ID, err)
+				}
+				return
+			}
+			added += applyEnrichment(op, resp)
+		}(&p.IR.Operations[i])
+	}
+	wg.Wait()
+
+	return added, firstErr
+}
+
+func (p *Pipeline) enrichOperation(ctx context.Context, op ir.Operation, types map[string]ir.TypeDef) (*enrichResponse, error) {
+	req := enrichRequest{
+		ID:          op.ID,
+		Method:      op.Method,
+		Path:        op.Path,
+		Description: op.Description,
+		Responses:   op.Responses,
+	}
+	// Vendor extensions may be private; enrichment never needs them
+	for _, param := range op.Parameters {
+		param.Extensions = nil
+		req.Parameters = append(req.Parameters, param)
+	}
+	for _, param := range op.Parameters {
+		if param.Description == "" {
+			req.MissingParameters = append(req.MissingParameters, param.Name)
+		}
+	}
+	if op.RequestBody != nil {
+		if td, ok := types[op.RequestBody.TypeName]; ok {
+			req.RequestBody = &td
+		}
+	}
+	msg, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := p.Provider.Generate(ctx, provider.CapabilitiesOf(p.Provider).Adapt(provider.GenerateRequest{
+		SystemPrompt: EnrichPrompt,
+		UserMessage:  string(msg),
+		MaxTokens:    1024,
+		JSON:         true,
+	}))
+	if err != nil {
+		return nil, err
+	}
+
+	var out enrichResponse
+	if err := json.Unmarshal([]byte(stripCodeFence(resp.Content)), &out); err != nil {
+		return nil, fmt.Errorf("parsing enrichment response: %w", err)
+	}
+	return &out, nil
+}
+
+// applyEnrichment fills empty descriptions from resp and returns how many
+// were added.
+func applyEnrichment(op *ir.Operation, resp *enrichResponse) int {
+	added := 0
+	if op.Description == "" && strings.TrimSpace(resp.Description) != "" {
+		op.Description = strings.TrimSpace(resp.Description)
+		added++
+	}
+	for i := range op.Parameters {
+		param := &op.Parameters[i]
+		if param.Description != "" {
+			continue
+		}
+		if desc := strings.TrimSpace(resp.Parameters[param.Name]); desc != "" {
+			param.Description = desc
+			added++
+		}
+	}
+	return added
+}
+
+// stripCodeFence removes a surrounding ``` fence that models sometimes add
+// despite being asked not to.
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") {
+		return s
+	}
+	if nl := strings.Index(s, "\n"); nl >= 0 {
+		s = s[nl+1:]
+	}
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "```"))
+}
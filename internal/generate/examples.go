@@ -0,0 +1,121 @@
+package generate
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// exampleSlot locates one spec example of an operation: in its request body
+// (response -1) or in one of its response bodies.
+type exampleSlot struct {
+	response int
+	example  ir.Example
+}
+
+// selectExamples caps the spec examples sent with the examples prompt at
+// perOperation per operation and total overall; zero means no limit. The most
+// representative examples are kept: those named "default", then those with a
+// summary, then the shortest. The total is shared out one example per
+// operation at a time so every operation keeps one while the budget lasts.
+// It returns a copy of spec holding the kept examples, and how many were
+// dropped.
+func selectExamples(spec *ir.IntermediateRepr, perOperation, total int) (*ir.IntermediateRepr, int) {
+	if perOperation <= 0 && total <= 0 {
+		return spec, 0
+	}
+
+	ranked := make([][]exampleSlot, len(spec.Operations))
+	for i, op := range spec.Operations {
+		var slots []exampleSlot
+		if op.RequestBody != nil {
+			for _, ex := range op.RequestBody.Examples {
+				slots = append(slots, exampleSlot{response: -1, example: ex})
+			}
+		}
+		for r, resp := range op.Responses {
+			if resp.Body != nil {
+				for _, ex := range resp.Body.Examples {
+					slots = append(slots, exampleSlot{response: r, example: ex})
+				}
+			}
+		}
+		slices.SortStableFunc(slots, func(a, b exampleSlot) int {
+			return compareExamples(a.example, b.example)
+		})
+		if perOperation > 0 && len(slots) > perOperation {
+			slots = slots[:perOperation]
+		}
+		ranked[i] = slots
+	}
+
+	kept := make([][]exampleSlot, len(ranked))
+	if total > 0 {
+		budget := total
+		for round := 0; budget > 0; round++ {
+			progressed := false
+			for i, slots := range ranked {
+				if round < len(slots) && budget > 0 {
+					kept[i] = append(kept[i], slots[round])
+					budget--
+					progressed = true
+				}
+			}
+			if !progressed {
+				break
+			}
+		}
+	} else {
+		kept = ranked
+	}
+
+	out := *spec
+	out.Operations = make([]ir.Operation, len(spec.Operations))
+	omitted := 0
+	for i, op := range spec.Operations {
+		if op.RequestBody != nil {
+			body := *op.RequestBody
+			omitted += len(body.Examples)
+			body.Examples = nil
+			op.RequestBody = &body
+		}
+		op.Responses = slices.Clone(op.Responses)
+		for r := range op.Responses {
+			if op.Responses[r].Body != nil {
+				body := *op.Responses[r].Body
+				omitted += len(body.Examples)
+				body.Examples = nil
+				op.Responses[r].Body = &body
+			}
+		}
+		// Kept examples go back in rank order within each body
+		for _, slot := range kept[i] {
+			omitted--
+			if slot.response < 0 {
+				op.RequestBody.Examples = append(op.RequestBody.Examples, slot.example)
+			} else {
+				body := op.Responses[slot.response].Body
+				body.Examples = append(body.Examples, slot.example)
+			}
+		}
+		out.Operations[i] = op
+	}
+	return &out, omitted
+}
+
+// compareExamples orders examples for selectExamples, most representative
+// first.
+func compareExamples(a, b ir.Example) int {
+	tier := func(ex ir.Example) int {
+		switch {
+		case ex.Name == "default":
+			return 0
+		case ex.Summary != "":
+			return 1
+		default:
+			return 2
+		}
+	}
+	return cmp.Or(cmp.Compare(tier(a), tier(b)), cmp.Compare(len(a.Value), len(b.Value)))
+}
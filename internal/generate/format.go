@@ -0,0 +1,182 @@
+package generate
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+)
+
+// outputFormat returns the output format of an artifact: its frontmatter
+// format, then Options.OutputFormat. Only the reference and examples
+// support one; everything else is markdown.
+func (p *Pipeline) outputFormat(id ArtifactID) string {
+	if id != ArtifactReference && id != ArtifactExamples {
+		return instructions.FormatMarkdown
+	}
+	f := p.Inst.Frontmatter.Artifacts[string(id)].Format
+	if f == "" {
+		f = p.Opts.OutputFormat
+	}
+	switch f {
+	case instructions.FormatMDX, instructions.FormatAsciiDoc:
+		return f
+	default:
+		return instructions.FormatMarkdown
+	}
+}
+
+// formatExt is the file extension for an output format.
+func formatExt(format string) string {
+	switch format {
+	case instructions.FormatMDX:
+		return ".mdx"
+	case instructions.FormatAsciiDoc:
+		return ".adoc"
+	default:
+		return ".md"
+	}
+}
+
+// formatPrompt is appended to an artifact's system prompt for its output
+// format.
+func formatPrompt(format string) string {
+	switch format {
+	case instructions.FormatMDX:
+		return MDXFormatPrompt
+	case instructions.FormatAsciiDoc:
+		return AsciiDocFormatPrompt
+	default:
+		return ""
+	}
+}
+
+// formatted post-processes a generated result for its artifact's output
+// format, part by part for a split artifact.
+func (p *Pipeline) formatted(r ArtifactResult) ArtifactResult {
+	format := p.outputFormat(r.ID)
+	if r.Err != nil || r.Content == "" || format == instructions.FormatMarkdown {
+		return r
+	}
+	convert := func(content string) string {
+		if format == instructions.FormatAsciiDoc {
+			return toAsciiDoc(content)
+		}
+		return toMDX(content, p.Inst.Frontmatter.Name+" "+string(r.ID))
+	}
+	if len(r.Parts) == 0 {
+		r.Content = convert(r.Content)
+		return r
+	}
+	contents := make([]string, len(r.Parts))
+	for i := range r.Parts {
+		r.Parts[i].Content = convert(r.Parts[i].Content)
+		contents[i] = r.Parts[i].Content
+	}
+	r.Content = strings.Join(contents, "\n\n")
+	return r
+}
+
+// toMDX makes markdown safe for an MDX compiler: it adds frontmatter with a
+// title (the first H1, or fallbackTitle) unless the model wrote some, and
+// escapes braces and tag-like "<" outside code, which MDX would otherwise
+// parse as JSX expressions and elements.
+func toMDX(content, fallbackTitle string) string {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "---\n") {
+		if end := strings.Index(content[4:], "\n---\n"); end >= 0 {
+			split := 4 + end + len("\n---\n")
+			return content[:split] + escapeMDX(content[split:]) + "\n"
+		}
+	}
+
+	title := fallbackTitle
+	for _, line := range strings.Split(content, "\n") {
+		if heading, ok := strings.CutPrefix(line, "# "); ok {
+			title = strings.TrimSpace(heading)
+			break
+		}
+	}
+	return "---\ntitle: " + strconv.Quote(title) + "\n---\n\n" + escapeMDX(content) + "\n"
+}
+
+// escapeMDX escapes MDX-significant characters outside fenced code blocks
+// and inline code spans.
+func escapeMDX(content string) string {
+	lines := strings.Split(content, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			continue
+		}
+		spans := strings.Split(line, "`")
+		for j := 0; j < len(spans); j += 2 {
+			spans[j] = escapeMDXText(spans[j])
+		}
+		lines[i] = strings.Join(spans, "`")
+	}
+	return strings.Join(lines, "\n")
+}
+
+func escapeMDXText(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		escaped := i > 0 && s[i-1] == '\\'
+		switch {
+		case (c == '{' || c == '}') && !escaped:
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		case c == '<' && !escaped && i+1 < len(s) && isTagStart(s[i+1]):
+			b.WriteString("&lt;")
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// toAsciiDoc converts markdown code fences the model left in an AsciiDoc
+// document into AsciiDoc source blocks.
+func toAsciiDoc(content string) string {
+	lines := strings.Split(strings.TrimSpace(content), "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "```") {
+			continue
+		}
+		if inFence {
+			lines[i] = "----"
+		} else if lang := strings.TrimSpace(strings.TrimPrefix(trimmed, "```")); lang != "" {
+			lines[i] = "[source," + lang + "]\n----"
+		} else {
+			lines[i] = "----"
+		}
+		inFence = !inFence
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// referencePath is an artifact's path relative to the skill directory, as
+// SKILL.md links it.
+func (p *Pipeline) referencePath(id ArtifactID) string {
+	rel, err := filepath.Rel(p.skillDir(), p.artifactPath(id))
+	if err != nil {
+		return p.artifactPath(id)
+	}
+	return filepath.ToSlash(rel)
+}
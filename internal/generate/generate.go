@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
@@ -40,11 +42,18 @@ var AllArtifacts = []ArtifactID{
 type ArtifactResult struct {
 	ID       ArtifactID
 	Content  string
-	FilePath string // relative to output dir
+	FilePath string         // relative to output dir
+	Parts    []ArtifactPart // set when the artifact is split across files
 	Response *provider.GenerateResponse
 	Err      error
 }
 
+// ArtifactPart is one file of a split artifact.
+type ArtifactPart struct {
+	FilePath string // relative to output dir
+	Content  string
+}
+
 // Options controls artifact generation.
 type Options struct {
 	OutputDir     string
@@ -55,6 +64,51 @@ type Options struct {
 	Verbose       bool
 	PrevArtifacts map[ArtifactID]string // previous artifact contents for changelog
 	SkipArtifacts map[ArtifactID]bool   // per-artifact cache hits to skip
+	Offline       bool                  // fail rather than call the provider
+	Enrich        bool                  // draft missing descriptions before generating
+	TokenBudget   int                   // estimated input tokens per request; 0 uses DefaultTokenBudget
+	MaxTokens     int                   // output token limit per request; 0 uses per-artifact defaults
+	// Tokenizer counts tokens for the token budget and dry-run estimates;
+	// nil uses the heuristic.
+	Tokenizer provider.Tokenizer
+	// SeedExamples are recorded interactions examples.md is grounded in,
+	// matched to operations by method and path.
+	SeedExamples []SeedExample
+	// OutputFormat is the reference and examples format for artifacts whose
+	// frontmatter sets none; empty means markdown.
+	OutputFormat string
+	// IncludeRawHelp sends the reference each CLI command's verbatim --help
+	// output, as if the reference artifact set include-raw-help.
+	IncludeRawHelp bool
+	// PromptOnly, with DryRun, makes each result's Content the prompts the
+	// artifact would send (see FormatPrompts) instead of an estimate.
+	PromptOnly bool
+	// ContinueOnError keeps generating after an artifact fails; Run then
+	// returns no error and failures are reported through ArtifactResult.Err.
+	ContinueOnError bool
+	// OnArtifact, if set, is called with each successfully generated
+	// artifact as soon as it completes, possibly from several goroutines at
+	// once. Callers use it to persist progress so a failed build can resume.
+	OnArtifact func(ArtifactResult)
+	// OnStart, if set, is called as each artifact starts calling the
+	// provider, and OnFinish with every result, after OnArtifact: failed,
+	// skipped, and dry-run ones included. Both may be called from several
+	// goroutines at once.
+	OnStart  func(ArtifactID)
+	OnFinish func(ArtifactResult)
+	// Log receives progress messages; nil writes to os.Stdout.
+	Log io.Writer
+	// SkillDir is where SKILL.md and its references go, relative to
+	// OutputDir; empty uses the skill's slug.
+	SkillDir string
+	// CurrentArtifacts, when set, are the artifacts the changelog compares
+	// PrevArtifacts against, such as hand-edited outputs, instead of the
+	// spec alone.
+	CurrentArtifacts map[ArtifactID]string
+	// EmptySpec is set when the spec sources yielded an empty IR (see
+	// ir.IntermediateRepr.Empty); the artifacts built from operations are
+	// then skipped.
+	EmptySpec bool
 }
 
 // Pipeline generates all artifacts from IR and instructions.
@@ -67,7 +121,22 @@ type Pipeline struct {
 
 // Run executes the generation pipeline.
 func (p *Pipeline) Run(ctx context.Context) ([]ArtifactResult, error) {
-	artifacts := p.enabledArtifacts()
+	if p.Opts.Offline && !p.Opts.DryRun {
+		if pending := p.NeedsGeneration(); len(pending) > 0 {
+			return nil, fmt.Errorf("offline mode: %s not cached — run without --offline to generate", joinIDs(pending))
+		}
+	}
+
+	if p.Opts.Enrich && !p.Opts.DryRun {
+		p.logf("  Enriching sparse operations...\n")
+		added, err := p.Enrich(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.logf("  Enriched %d descriptions\n", added)
+	}
+
+	artifacts := p.EnabledArtifacts()
 
 	// Separate changelog (depends on all others) from parallel artifacts
 	var parallel []ArtifactID
@@ -80,35 +149,36 @@ func (p *Pipeline) Run(ctx context.Context) ([]ArtifactResult, error) {
 		}
 	}
 
-	// Generate parallel artifacts concurrently
-	var mu sync.Mutex
-	var results []ArtifactResult
+	// Generate parallel artifacts concurrently; results keep artifact order
+	results := make([]ArtifactResult, len(parallel))
 	var wg sync.WaitGroup
 
-	for _, id := range parallel {
+	for i, id := range parallel {
 		wg.Add(1)
-		go func(id ArtifactID) {
+		go func(i int, id ArtifactID) {
 			defer wg.Done()
-			result := p.generateArtifact(ctx, id)
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}(id)
+			results[i] = p.generateArtifact(ctx, id)
+			p.completed(results[i])
+		}(i, id)
 	}
 	wg.Wait()
 
-	// Check for errors in parallel generation
-	for _, r := range results {
-		if r.Err != nil {
-			return results, fmt.Errorf("generating %s: %w", r.ID, r.Err)
+	// Check for errors in parallel generation. With ContinueOnError, failed
+	// results are returned alongside the successful ones for the caller to report.
+	if !p.Opts.ContinueOnError {
+		for _, r := range results {
+			if r.Err != nil {
+				return results, fmt.Errorf("generating %s: %w", r.ID, r.Err)
+			}
 		}
 	}
 
 	// Generate changelog after all others
 	if hasChangelog {
 		result := p.generateArtifact(ctx, ArtifactChangelog)
+		p.completed(result)
 		results = append(results, result)
-		if result.Err != nil {
+		if result.Err != nil && !p.Opts.ContinueOnError {
 			return results, fmt.Errorf("generating changelog: %w", result.Err)
 		}
 	}
@@ -116,7 +186,29 @@ func (p *Pipeline) Run(ctx context.Context) ([]ArtifactResult, error) {
 	return results, nil
 }
 
-func (p *Pipeline) enabledArtifacts() []ArtifactID {
+// logf writes a progress message to Opts.Log.
+func (p *Pipeline) logf(format string, args ...any) {
+	w := p.Opts.Log
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+// completed reports a successful, non-empty result to Opts.OnArtifact, then
+// every result to Opts.OnFinish.
+func (p *Pipeline) completed(r ArtifactResult) {
+	if p.Opts.OnArtifact != nil && r.Err == nil && r.Content != "" && !p.Opts.DryRun {
+		p.Opts.OnArtifact(r)
+	}
+	if p.Opts.OnFinish != nil {
+		p.Opts.OnFinish(r)
+	}
+}
+
+// EnabledArtifacts lists the artifacts Run generates, in artifact order:
+// those Opts.Only names, or else those the frontmatter enables.
+func (p *Pipeline) EnabledArtifacts() []ArtifactID {
 	if len(p.Opts.Only) > 0 {
 		onlySet := make(map[string]bool)
 		for _, o := range p.Opts.Only {
@@ -133,13 +225,9 @@ func (p *Pipeline) enabledArtifacts() []ArtifactID {
 
 	var filtered []ArtifactID
 	for _, id := range AllArtifacts {
-		artifactName := string(id)
-		if toggle, ok := p.Inst.Frontmatter.Artifacts[artifactName]; ok {
-			if !toggle.IsEnabled() {
-				continue
-			}
+		if p.Inst.Frontmatter.ArtifactEnabled(string(id)) {
+			filtered = append(filtered, id)
 		}
-		filtered = append(filtered, id)
 	}
 	return filtered
 }
@@ -149,53 +237,157 @@ func (p *Pipeline) generateArtifact(ctx context.Context, id ArtifactID) Artifact
 	userMessage := p.userMessage(id)
 	filePath := p.artifactPath(id)
 
+	// Fail before spending a provider call on output that cannot be written
+	if err := instructions.CheckPath(p.Inst.Frontmatter.Artifacts[string(id)].Filename); err != nil {
+		return ArtifactResult{ID: id, FilePath: filePath, Err: fmt.Errorf("artifacts.%s.filename: %w", id, err)}
+	}
+
+	if p.Opts.DryRun && p.Opts.PromptOnly {
+		return ArtifactResult{ID: id, FilePath: filePath, Content: FormatPrompts(p.Prompts(id))}
+	}
 	if p.Opts.DryRun {
-		tokens := estimateTokens(systemPrompt + userMessage)
+		tokens := p.estimateTokens(systemPrompt + userMessage)
+		content := fmt.Sprintf("[dry-run] Would generate %s (~%d input tokens)", id, tokens)
+		if files := p.referenceFiles(id); len(files) > 0 {
+			content = fmt.Sprintf("[dry-run] Would generate %s as %d files (~%d input tokens)", id, len(files), tokens)
+		} else if chunks := p.chunks(id); len(chunks) > 0 {
+			content = fmt.Sprintf("[dry-run] Would generate %s in %d chunks (~%d input tokens)", id, len(chunks), tokens)
+		}
 		return ArtifactResult{
 			ID:       id,
 			FilePath: filePath,
-			Content:  fmt.Sprintf("[dry-run] Would generate %s (~%d input tokens)", id, tokens),
+			Content:  content,
 		}
 	}
 
+	if p.SkipForEmptySections(id) {
+		p.logf("  WARNING: skipping %s (all relevant instruction sections are empty)\n", id)
+		return ArtifactResult{ID: id, FilePath: filePath}
+	}
+	if p.SkipForEmptySpec(id) {
+		p.logf("  WARNING: skipping %s (the spec sources yielded no operations or types)\n", id)
+		return ArtifactResult{ID: id, FilePath: filePath}
+	}
+
 	// Skip if cache says this artifact is up to date
 	if p.Opts.SkipArtifacts[id] {
-		fmt.Printf("  Skipping %s (cached)\n", id)
+		p.logf("  Skipping %s (cached)\n", id)
 		return ArtifactResult{ID: id, FilePath: filePath}
 	}
 
-	fmt.Printf("  Generating %s...\n", id)
+	if p.Opts.OnStart != nil {
+		p.Opts.OnStart(id)
+	}
+
+	if files := p.referenceFiles(id); len(files) > 0 {
+		return p.formatted(p.generateSplit(ctx, id, files))
+	}
+	if chunks := p.chunks(id); len(chunks) > 0 {
+		return p.formatted(p.generateChunked(ctx, id, chunks))
+	}
+
+	p.logf("  Generating %s...\n", id)
+
+	resp, err := p.call(ctx, id, string(id), systemPrompt, userMessage)
+	if err != nil {
+		return ArtifactResult{ID: id, FilePath: filePath, Err: err}
+	}
+
+	content := resp.Content
+	if id == ArtifactScripts {
+		if templates := p.scriptTemplates(p.IR); len(templates) > 0 {
+			content = withScriptTemplates(content, templates)
+		}
+	}
+	return p.formatted(ArtifactResult{
+		ID:       id,
+		Content:  content,
+		FilePath: filePath,
+		Response: resp,
+	})
+}
 
+// call sends one generation request for an artifact, logging progress under
+// label (the artifact ID, or a file name for split artifacts).
+func (p *Pipeline) call(ctx context.Context, id ArtifactID, label, systemPrompt, userMessage string) (*provider.GenerateResponse, error) {
 	if p.Opts.Verbose {
-		fmt.Printf("  [verbose] %s system prompt: %d chars\n", id, len(systemPrompt))
-		fmt.Printf("  [verbose] %s user message: %d chars\n", id, len(userMessage))
+		p.logf("  [verbose] %s system prompt: %d chars\n", label, len(systemPrompt))
+		p.logf("  [verbose] %s user message: %d chars\n", label, len(userMessage))
 	}
 
 	start := time.Now()
-	resp, err := p.Provider.Generate(ctx, provider.GenerateRequest{
-		SystemPrompt: systemPrompt,
-		UserMessage:  userMessage,
-		MaxTokens:    maxTokensForArtifact(id),
-	})
+	// Fold the system prompt into the message for models without a system role
+	resp, err := p.Provider.Generate(ctx, provider.CapabilitiesOf(p.Provider).Adapt(provider.GenerateRequest{
+		SystemPrompt:  systemPrompt,
+		UserMessage:   userMessage,
+		MaxTokens:     p.maxTokens(id),
+		StopSequences: p.stopSequences(id),
+	}))
 	elapsed := time.Since(start)
 
 	if err != nil {
-		fmt.Printf("  FAILED %s: %s\n", id, err)
-		return ArtifactResult{ID: id, FilePath: filePath, Err: err}
+		p.logf("  FAILED %s: %s\n", label, err)
+		return nil, err
+	}
+	if resp != nil {
+		if resp.Provider == "" {
+			resp.Provider = p.Provider.Name()
+		}
+		if trimmed := trimResponse(id, resp.Content); trimmed != resp.Content {
+			if p.Opts.Verbose {
+				p.logf("  [verbose] %s: trimmed a wrapping fence or closing remarks from the response\n", label)
+			}
+			resp.Content = trimmed
+		}
 	}
 
 	if p.Opts.Verbose && resp != nil {
-		fmt.Printf("  [verbose] %s: %d in / %d out tokens, %s\n", id, resp.TokensIn, resp.TokensOut, elapsed.Round(time.Millisecond))
+		p.logf("  [verbose] %s: %d in / %d out tokens, %s\n", label, resp.TokensIn, resp.TokensOut, elapsed.Round(time.Millisecond))
+		p.logf("  [verbose] %s: model %s, request %s, finish reason %s\n", label,
+			orUnknown(resp.Model), orUnknown(resp.RequestID), orUnknown(resp.FinishReason))
+		if resp.RateLimit != nil {
+			p.logf("  [verbose] %s: rate limit %s\n", label, resp.RateLimit)
+		}
+		for _, warning := range resp.Warnings {
+			p.logf("  [verbose] %s: WARNING: %s\n", label, warning)
+		}
+	}
+	if resp != nil && resp.FinishReason == provider.FinishLength {
+		p.logf("  WARNING: %s was cut off at the output token limit (%d tokens); raise max-tokens for %s\n",
+			label, resp.TokensOut, id)
 	}
 
-	fmt.Printf("  Done %s (%s)\n", id, elapsed.Round(time.Millisecond))
+	p.logf("  Done %s (%s)\n", label, elapsed.Round(time.Millisecond))
+	return resp, nil
+}
 
-	return ArtifactResult{
-		ID:       id,
-		Content:  resp.Content,
-		FilePath: filePath,
-		Response: resp,
+// orUnknown is s, or "unknown" when the provider did not report it.
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
 	}
+	return s
+}
+
+// NeedsGeneration lists enabled artifacts that would require a provider call,
+// i.e. those neither cached nor skipped for empty sections or an empty spec.
+func (p *Pipeline) NeedsGeneration() []ArtifactID {
+	var pending []ArtifactID
+	for _, id := range p.EnabledArtifacts() {
+		if p.Opts.SkipArtifacts[id] || p.SkipForEmptySections(id) || p.SkipForEmptySpec(id) {
+			continue
+		}
+		pending = append(pending, id)
+	}
+	return pending
+}
+
+func joinIDs(ids []ArtifactID) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = string(id)
+	}
+	return strings.Join(parts, ", ")
 }
 
 // SystemPromptFor returns the system prompt for a given artifact ID (exported for cache hashing).
@@ -203,10 +395,81 @@ func (p *Pipeline) SystemPromptFor(id ArtifactID) string {
 	return p.systemPrompt(id)
 }
 
+// Prompt is one request an artifact sends to the provider.
+type Prompt struct {
+	Label        string // the artifact ID, a split file's path, or a chunk such as "reference [2/3]"
+	SystemPrompt string
+	UserMessage  string
+}
+
+// Prompts returns the requests generating an artifact sends, one per split
+// file or chunk, as built before the provider adapts them to the model.
+func (p *Pipeline) Prompts(id ArtifactID) []Prompt {
+	systemPrompt := p.systemPrompt(id)
+	if files := p.referenceFiles(id); len(files) > 0 {
+		prompts := make([]Prompt, len(files))
+		for i, f := range files {
+			prompts[i] = Prompt{Label: f.Path, SystemPrompt: systemPrompt, UserMessage: p.userMessageFor(id, p.IR.Subset(f.Group.Operations))}
+		}
+		return prompts
+	}
+	if chunks := p.chunks(id); len(chunks) > 0 {
+		prompts := make([]Prompt, len(chunks))
+		for i, opIDs := range chunks {
+			prompts[i] = Prompt{Label: chunkLabel(id, i, len(chunks)), SystemPrompt: systemPrompt + ChunkPrompt, UserMessage: p.chunkMessage(id, opIDs, i, len(chunks))}
+		}
+		return prompts
+	}
+	return []Prompt{{Label: string(id), SystemPrompt: systemPrompt, UserMessage: p.userMessage(id)}}
+}
+
+// FormatPrompts renders prompts for reading, each part under a
+// "===== label: system prompt =====" or "user message" banner.
+func FormatPrompts(prompts []Prompt) string {
+	var b strings.Builder
+	for _, pr := range prompts {
+		fmt.Fprintf(&b, "===== %s: system prompt =====\n%s\n\n", pr.Label, pr.SystemPrompt)
+		fmt.Fprintf(&b, "===== %s: user message =====\n%s\n\n", pr.Label, pr.UserMessage)
+	}
+	return b.String()
+}
+
 // RelevantSections returns the instruction sections relevant to a given artifact,
-// concatenated as a single string for cache hashing.
+// concatenated as a single string for cache hashing. Recorded examples the
+// artifact draws on are included, so changing them regenerates it.
 func (p *Pipeline) RelevantSections(id ArtifactID) string {
 	var parts []string
+	for _, sec := range p.sections(id) {
+		parts = append(parts, sec.Name+"\n"+sec.Content)
+	}
+	if p.IR != nil {
+		if seeds := p.seedExamples(id, p.IR); seeds != "" {
+			parts = append(parts, seeds)
+		}
+	}
+	// Example limits and raw help change the prompt, so they invalidate its
+	// cache
+	if limits := p.Inst.Frontmatter.Artifacts[string(id)]; id == ArtifactExamples && (limits.MaxExamplesPerOperation > 0 || limits.MaxExamples > 0) {
+		parts = append(parts, fmt.Sprintf("Example limits: %d per operation, %d total", limits.MaxExamplesPerOperation, limits.MaxExamples))
+	}
+	if id == ArtifactReference && p.includeRawHelp() {
+		parts = append(parts, "Raw help: included")
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// instructionSection is a named block of instructions sent with an artifact.
+type instructionSection struct {
+	Name    string
+	Content string
+}
+
+// fullInstructionsSection names the fallback block holding the whole body.
+const fullInstructionsSection = "Full instructions"
+
+// sectionKeys returns the instruction section names mapped to an artifact and
+// whether the artifact draws on instruction sections at all.
+func (p *Pipeline) sectionKeys(id ArtifactID) ([]string, bool) {
 	switch id {
 	case ArtifactSkill, ArtifactLlmsFull, ArtifactScripts:
 		keys := make([]string, 0, len(p.Inst.Sections))
@@ -214,25 +477,108 @@ func (p *Pipeline) RelevantSections(id ArtifactID) string {
 			keys = append(keys, name)
 		}
 		sort.Strings(keys)
-		for _, name := range keys {
-			parts = append(parts, name+"\n"+p.Inst.Sections[name])
-		}
+		return keys, true
 	case ArtifactExamples:
-		for _, key := range []string{"Workflows", "Examples", "Common patterns"} {
-			if content, ok := p.Inst.Sections[key]; ok {
-				parts = append(parts, key+"\n"+content)
-			}
-		}
+		return []string{"Workflows", "Examples", "Common patterns"}, true
 	case ArtifactLlms:
-		if content, ok := p.Inst.Sections["Product"]; ok {
-			parts = append(parts, "Product\n"+content)
-		}
-	case ArtifactChangelog:
-		// Changelog depends on previous artifacts, not instruction sections
+		return []string{"Product"}, true
 	default:
-		// reference, llms-api: no specific sections
+		// reference, llms-api: spec only; changelog: previous artifacts
+		return nil, false
 	}
-	return strings.Join(parts, "\n\n")
+}
+
+// mappedSections returns the non-missing sections mapped to an artifact, in order.
+func (p *Pipeline) mappedSections(id ArtifactID) []instructionSection {
+	keys, _ := p.sectionKeys(id)
+	var out []instructionSection
+	for _, key := range keys {
+		if content, ok := p.Inst.Sections[key]; ok {
+			out = append(out, instructionSection{Name: key, Content: content})
+		}
+	}
+	return out
+}
+
+// sectionsEmpty reports whether an artifact draws on instruction sections but
+// every mapped section is missing or blank.
+func (p *Pipeline) sectionsEmpty(id ArtifactID) bool {
+	if _, uses := p.sectionKeys(id); !uses {
+		return false
+	}
+	for _, sec := range p.mappedSections(id) {
+		if strings.TrimSpace(sec.Content) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// SkipForEmptySections reports whether an artifact should be skipped because
+// its mapped sections are all empty and the empty-sections policy is "skip".
+func (p *Pipeline) SkipForEmptySections(id ArtifactID) bool {
+	return p.Inst.Frontmatter.EmptySections == instructions.EmptySectionsSkip && p.sectionsEmpty(id)
+}
+
+// operationArtifacts are built from the spec's operations, so an empty
+// spec leaves them nothing to document.
+var operationArtifacts = map[ArtifactID]bool{
+	ArtifactReference: true,
+	ArtifactLlmsAPI:   true,
+	ArtifactExamples:  true,
+}
+
+// SkipForEmptySpec reports whether an artifact should be skipped because
+// it is built from operations and Options.EmptySpec is set. Artifacts that
+// can stand on the instruction sections, such as llms.txt, are still built.
+func (p *Pipeline) SkipForEmptySpec(id ArtifactID) bool {
+	return p.Opts.EmptySpec && operationArtifacts[id]
+}
+
+// sections returns the instruction sections sent with an artifact. Under the
+// "docs" policy, empty sections are replaced by content derived from the
+// project docs. When all mapped sections are still empty, the full markdown
+// body is used instead so the model still has the author's guidance to
+// ground on.
+func (p *Pipeline) sections(id ArtifactID) []instructionSection {
+	if derived := DerivedSections(p.Inst, p.IR); derived != nil {
+		if out := p.withDerivedSections(id, derived); len(out) > 0 {
+			return out
+		}
+	}
+	if p.sectionsEmpty(id) && strings.TrimSpace(p.Inst.RawBody) != "" &&
+		p.Inst.Frontmatter.EmptySections != instructions.EmptySectionsSkip {
+		return []instructionSection{{Name: fullInstructionsSection, Content: p.Inst.RawBody}}
+	}
+	return p.mappedSections(id)
+}
+
+// derivedSectionSuffix marks section names whose content was derived from
+// the project docs rather than written by the author.
+const derivedSectionSuffix = " (derived from the project docs)"
+
+// withDerivedSections returns an artifact's non-empty sections followed by
+// the derived sections it draws on, or nil when it has neither.
+func (p *Pipeline) withDerivedSections(id ArtifactID, derived map[string]string) []instructionSection {
+	keys, uses := p.sectionKeys(id)
+	if !uses {
+		return nil
+	}
+	var out []instructionSection
+	for _, sec := range p.mappedSections(id) {
+		if strings.TrimSpace(sec.Content) != "" {
+			out = append(out, sec)
+		}
+	}
+	// Artifacts given every section get every derived one; others only
+	// those mapped to them
+	all := id == ArtifactSkill || id == ArtifactLlmsFull || id == ArtifactScripts
+	for _, kw := range docsHeadingKeywords {
+		if content, ok := derived[kw.section]; ok && (all || slices.Contains(keys, kw.section)) {
+			out = append(out, instructionSection{Name: kw.section + derivedSectionSuffix, Content: content})
+		}
+	}
+	return out
 }
 
 // ArtifactPath returns the relative file path for a given artifact ID.
@@ -240,14 +586,31 @@ func (p *Pipeline) ArtifactPath(id ArtifactID) string {
 	return p.artifactPath(id)
 }
 
+// systemPrompt is an artifact's system prompt, asking for prose in the
+// frontmatter language.
 func (p *Pipeline) systemPrompt(id ArtifactID) string {
+	return p.artifactPrompt(id) + LanguagePrompt(p.Inst.Frontmatter.Language)
+}
+
+func (p *Pipeline) artifactPrompt(id ArtifactID) string {
 	switch id {
 	case ArtifactSkill:
+		if len(p.referenceFiles(ArtifactReference)) > 0 {
+			return SkillPrompt + SkillSplitReferencePrompt
+		}
+		if p.outputFormat(ArtifactReference) != instructions.FormatMarkdown ||
+			p.outputFormat(ArtifactExamples) != instructions.FormatMarkdown {
+			return SkillPrompt + fmt.Sprintf(SkillReferencePathsPrompt,
+				p.referencePath(ArtifactReference), p.referencePath(ArtifactExamples))
+		}
 		return SkillPrompt
 	case ArtifactReference:
-		return ReferencePrompt
+		if len(p.referenceFiles(id)) > 0 {
+			return ReferencePrompt + ReferenceSplitPrompt + formatPrompt(p.outputFormat(id))
+		}
+		return ReferencePrompt + formatPrompt(p.outputFormat(id))
 	case ArtifactExamples:
-		return ExamplesPrompt
+		return ExamplesPrompt + formatPrompt(p.outputFormat(id))
 	case ArtifactScripts:
 		return ScriptsPrompt
 	case ArtifactLlms:
@@ -263,8 +626,61 @@ func (p *Pipeline) systemPrompt(id ArtifactID) string {
 	}
 }
 
+// promptIR strips vendor extensions from the IR sent with an artifact's
+// prompt. Only the reference and SKILL.md see extensions, and only those the
+// frontmatter lists. Raw CLI help is dropped unless the reference asks for
+// it, and project docs are dropped too; userMessageFor includes them as their
+// own section.
+func (p *Pipeline) promptIR(id ArtifactID, spec *ir.IntermediateRepr) *ir.IntermediateRepr {
+	surfaces := id == ArtifactSkill || id == ArtifactReference
+	out := spec.FilterExtensions(func(name string) bool {
+		return surfaces && p.Inst.Frontmatter.ExtensionSurfaced(name)
+	})
+	if id != ArtifactReference || !p.includeRawHelp() {
+		for i := range out.Operations {
+			out.Operations[i].RawHelpText = ""
+		}
+	}
+	if out.Structure != nil && len(out.Structure.Docs) > 0 {
+		structure := *out.Structure
+		structure.Docs = nil
+		out.Structure = &structure
+	}
+	return out
+}
+
+// includeRawHelp reports whether the reference is sent raw CLI help: the
+// frontmatter's include-raw-help, or Options.IncludeRawHelp.
+func (p *Pipeline) includeRawHelp() bool {
+	return p.Opts.IncludeRawHelp || p.Inst.Frontmatter.Artifacts[string(ArtifactReference)].IncludeRawHelp
+}
+
+// projectDocs renders a codebase's docs (README first) verbatim for every
+// artifact but the changelog, or returns "" when there are none.
+func projectDocs(id ArtifactID, spec *ir.IntermediateRepr) string {
+	if id == ArtifactChangelog || spec.Structure == nil || len(spec.Structure.Docs) == 0 {
+		return ""
+	}
+	parts := []string{"## Project Documentation\nVerbatim from the repository, most important first. Prefer its terminology, commands, and setup steps."}
+	for _, doc := range spec.Structure.Docs {
+		parts = append(parts, fmt.Sprintf("### %s\n%s", doc.Path, doc.Content))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
 func (p *Pipeline) userMessage(id ArtifactID) string {
-	irJSON, _ := json.MarshalIndent(p.IR, "", "  ")
+	return p.userMessageFor(id, p.IR)
+}
+
+// userMessageFor builds an artifact's user message around the given IR,
+// which is a subset of p.IR for split artifacts.
+func (p *Pipeline) userMessageFor(id ArtifactID, spec *ir.IntermediateRepr) string {
+	promptSpec, omittedExamples := p.promptIR(id, spec), 0
+	if id == ArtifactExamples {
+		limits := p.Inst.Frontmatter.Artifacts[string(ArtifactExamples)]
+		promptSpec, omittedExamples = selectExamples(promptSpec, limits.MaxExamplesPerOperation, limits.MaxExamples)
+	}
+	irJSON, _ := json.MarshalIndent(promptSpec, "", "  ")
 	name := p.Inst.Frontmatter.Name
 	envPrefix := p.Inst.EnvPrefix()
 
@@ -286,38 +702,42 @@ func (p *Pipeline) userMessage(id ArtifactID) string {
 			parts = append(parts, fmt.Sprintf("Compatibility: %s", p.Inst.Frontmatter.Skill.Compatibility))
 		}
 		if p.Inst.Frontmatter.Skill.AllowedTools != "" {
-			parts = append(parts, fmt.Sprintf("Allowed Tools: %s", p.Inst.Frontmatter.Skill.AllowedTools))
+			tools, _ := instructions.NormalizeAllowedTools(p.Inst.Frontmatter.Skill.AllowedTools)
+			parts = append(parts, fmt.Sprintf("Allowed Tools: %s", tools))
 		}
 		if len(p.Inst.Frontmatter.Skill.Metadata) > 0 {
 			metaJSON, _ := json.Marshal(p.Inst.Frontmatter.Skill.Metadata)
 			parts = append(parts, fmt.Sprintf("Metadata: %s", string(metaJSON)))
 		}
-	}
-
-	// Add relevant instructions sections based on artifact type
-	switch id {
-	case ArtifactSkill:
-		for name, content := range p.Inst.Sections {
-			parts = append(parts, fmt.Sprintf("## Instructions: %s\n%s", name, content))
-		}
-	case ArtifactExamples:
-		for _, key := range []string{"Workflows", "Examples", "Common patterns"} {
-			if content, ok := p.Inst.Sections[key]; ok {
-				parts = append(parts, fmt.Sprintf("## Instructions: %s\n%s", key, content))
+		if files := p.referenceFiles(ArtifactReference); len(files) > 0 {
+			lines := make([]string, len(files))
+			for i, f := range files {
+				lines[i] = fmt.Sprintf("- references/%s (%s)", f.Name, f.Group.Name)
 			}
+			parts = append(parts, "Reference Files:\n"+strings.Join(lines, "\n"))
 		}
-	case ArtifactLlms:
-		if content, ok := p.Inst.Sections["Product"]; ok {
-			parts = append(parts, fmt.Sprintf("## Instructions: Product\n%s", content))
-		}
-	case ArtifactLlmsFull:
-		for name, content := range p.Inst.Sections {
-			parts = append(parts, fmt.Sprintf("## Instructions: %s\n%s", name, content))
+	}
+
+	if id == ArtifactSkill || id == ArtifactScripts {
+		parts = append(parts, p.derivedContext(spec))
+	}
+	if id == ArtifactSkill || id == ArtifactLlms || id == ArtifactLlmsAPI || id == ArtifactLlmsFull {
+		if info := specInfo(spec); info != "" {
+			parts = append(parts, info)
 		}
-	case ArtifactScripts:
-		for name, content := range p.Inst.Sections {
-			parts = append(parts, fmt.Sprintf("## Instructions: %s\n%s", name, content))
+	}
+	if id == ArtifactReference || id == ArtifactExamples || id == ArtifactScripts {
+		if locations := parameterLocations(spec); locations != "" {
+			parts = append(parts, locations)
 		}
+	}
+
+	// Add relevant instructions sections based on artifact type
+	for _, sec := range p.sections(id) {
+		parts = append(parts, fmt.Sprintf("## Instructions: %s\n%s", sec.Name, sec.Content))
+	}
+
+	switch id {
 	case ArtifactChangelog:
 		hasPrev := false
 		for _, prevID := range []ArtifactID{ArtifactSkill, ArtifactReference, ArtifactExamples} {
@@ -333,15 +753,283 @@ func (p *Pipeline) userMessage(id ArtifactID) string {
 		if !hasPrev {
 			parts = append(parts, "## Note\nThis is the first generation — no previous artifacts exist.")
 		}
+		for _, curID := range []ArtifactID{ArtifactSkill, ArtifactReference, ArtifactExamples} {
+			if cur := p.Opts.CurrentArtifacts[curID]; cur != "" {
+				parts = append(parts, fmt.Sprintf("## Current %s\n%s", curID, cur))
+			}
+		}
+		if deprecated := deprecatedParameterList(spec); deprecated != "" {
+			parts = append(parts, deprecated)
+		}
+	}
+
+	if docs := projectDocs(id, spec); docs != "" {
+		parts = append(parts, docs)
+	}
+	if seeds := p.seedExamples(id, spec); seeds != "" {
+		parts = append(parts, seeds)
+	}
+	if id == ArtifactScripts {
+		if templates := p.scriptTemplates(spec); len(templates) > 0 {
+			parts = append(parts, scriptTemplatesContext(templates))
+		}
 	}
 
+	if omittedExamples > 0 {
+		parts = append(parts, fmt.Sprintf("## Note\n%d more spec examples are not shown below; additional examples exist in the reference. Do not try to cover them all.", omittedExamples))
+	}
 	parts = append(parts, fmt.Sprintf("## Spec (Intermediate Representation)\n```json\n%s\n```", string(irJSON)))
 
 	return strings.Join(parts, "\n\n")
 }
 
+// specInfo lists the spec's external documentation, license, and contact,
+// or returns "" when it declares none.
+func specInfo(spec *ir.IntermediateRepr) string {
+	m := spec.Metadata
+	var lines []string
+	if url := m["externalDocsUrl"]; url != "" {
+		line := "- External documentation: " + url
+		if desc := m["externalDocsDescription"]; desc != "" {
+			line += " — " + desc
+		}
+		lines = append(lines, line)
+	}
+	if license := m["license"]; license != "" {
+		line := "- License: " + license
+		if url := m["licenseUrl"]; url != "" {
+			line += " (" + url + ")"
+		}
+		lines = append(lines, line)
+	}
+	var contact []string
+	for _, key := range []string{"contactName", "contactEmail", "contactUrl"} {
+		if v := m[key]; v != "" {
+			contact = append(contact, v)
+		}
+	}
+	if len(contact) > 0 {
+		lines = append(lines, "- Contact: "+strings.Join(contact, ", "))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	header := []string{
+		"## Spec Info",
+		"Link to the external documentation as the official docs, and note the license and contact where the document has room. Do not invent links.",
+	}
+	return strings.Join(append(header, lines...), "\n")
+}
+
+// derivedContext spells out the values scripts and SKILL.md must agree on:
+// the env var prefix, where the base URL comes from, which env vars hold
+// each auth scheme's credentials, and which operations combine schemes.
+func (p *Pipeline) derivedContext(spec *ir.IntermediateRepr) string {
+	prefix := p.Inst.EnvPrefix()
+	lines := []string{
+		"## Derived Context",
+		"Use exactly these environment variable names; do not invent others.",
+		fmt.Sprintf("- Environment variable prefix: %s", prefix),
+	}
+
+	baseURL := spec.Metadata["baseUrl"]
+	if baseURL == "" {
+		baseURL = "(not declared in the spec)"
+	}
+	lines = append(lines, fmt.Sprintf("- Base URL: %s — read from ${%s_API_URL}", baseURL, prefix))
+
+	env := append(scriptEnv(prefix, spec.Auth), p.Inst.Frontmatter.Skill.Env...)
+	lines = append(lines, fmt.Sprintf("- Environment variables: %s. Name any other variable a script needs %s_<NAME>.", strings.Join(env, ", "), prefix))
+
+	if len(spec.Auth) == 0 {
+		lines = append(lines, "- Auth: none declared")
+	} else {
+		lines = append(lines, "- Auth:")
+		for _, scheme := range spec.Auth {
+			lines = append(lines, fmt.Sprintf("  - %s: %s — %s", scheme.ID, authDetail(scheme), authEnv(prefix, scheme)))
+		}
+	}
+
+	// Operations needing more than one credential, or offering a choice,
+	// so the combination is stated rather than guessed
+	var combined []string
+	for _, op := range spec.Operations {
+		if len(op.Auth) > 1 || (len(op.Auth) == 1 && len(op.Auth[0]) != 1) {
+			combined = append(combined, fmt.Sprintf("  - %s: %s", op.ID, op.AuthText()))
+		}
+	}
+	if len(combined) > 0 {
+		lines = append(lines, "- Auth requirements (AND = send all together; OR = any one alternative; none = anonymous allowed):")
+		lines = append(lines, combined...)
+	}
+
+	// Request encodings, so examples send the right Content-Type and body
+	var bodies []string
+	for _, op := range spec.Operations {
+		if op.RequestBody == nil || op.RequestBody.ContentType == "" {
+			continue
+		}
+		line := fmt.Sprintf("  - %s: Content-Type %s (%s)", op.ID, op.RequestBody.ContentType, bodyEncoding(op.RequestBody.ContentType))
+		if len(op.RequestBody.AltContentTypes) > 0 {
+			line += "; also accepts " + strings.Join(op.RequestBody.AltContentTypes, ", ")
+		}
+		bodies = append(bodies, line)
+	}
+	if len(bodies) > 0 {
+		lines = append(lines, "- Request bodies:")
+		lines = append(lines, bodies...)
+	}
+
+	// Rate limits exactly as documented, so guardrails don't invent numbers
+	var limits []string
+	for _, op := range spec.Operations {
+		if op.RateLimit != nil {
+			limits = append(limits, fmt.Sprintf("  - %s: %s", op.ID, rateLimitText(op.RateLimit)))
+		}
+	}
+	if len(limits) > 0 {
+		lines = append(lines, "- Rate limits (as documented; state no limit for other operations):")
+		lines = append(lines, limits...)
+	} else {
+		lines = append(lines, "- Rate limits: none documented; do not state any")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// rateLimitText describes an operation's documented rate-limit signals.
+func rateLimitText(rl *ir.RateLimit) string {
+	var parts []string
+	if rl.Limit != "" {
+		parts = append(parts, fmt.Sprintf("%s (from %s)", rl.Limit, rl.Source))
+	}
+	if rl.TooManyRequests {
+		part := "may return 429 Too Many Requests"
+		if rl.RetryAfter {
+			part += "; wait for Retry-After before retrying"
+		}
+		parts = append(parts, part)
+	}
+	if len(rl.Headers) > 0 {
+		parts = append(parts, "headers "+strings.Join(rl.Headers, ", "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// deprecatedParameterList lists the spec's deprecated parameters with their
+// operations, so the changelog names them in context rather than only the
+// operations. It is empty when no parameter is deprecated.
+func deprecatedParameterList(spec *ir.IntermediateRepr) string {
+	var lines []string
+	for _, op := range spec.Operations {
+		for _, param := range op.Parameters {
+			if !param.Deprecated {
+				continue
+			}
+			line := fmt.Sprintf("- %s:%s on %s", param.In, param.Name, op.Label())
+			if param.DeprecationNote != "" {
+				line += " — " + param.DeprecationNote
+			}
+			if param.Sunset != "" {
+				line += " (sunset " + param.Sunset + ")"
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	header := []string{
+		"## Deprecated Parameters",
+		"Parameters the current spec deprecates. List those not already deprecated in the previous artifacts under Deprecated, with their operation.",
+	}
+	return strings.Join(append(header, lines...), "\n")
+}
+
+// parameterLocations lists the header and cookie parameters of each
+// operation that has any, so they are not mistaken for query parameters. It
+// is empty when no operation has one.
+func parameterLocations(spec *ir.IntermediateRepr) string {
+	var lines []string
+	for _, op := range spec.Operations {
+		var params []string
+		for _, param := range op.Parameters {
+			var how string
+			switch param.In {
+			case "header":
+				how = fmt.Sprintf("header %s (-H %q)", param.Name, param.Name+": <value>")
+			case "cookie":
+				how = fmt.Sprintf("cookie %s (--cookie %q)", param.Name, param.Name+"=<value>")
+			default:
+				continue
+			}
+			if param.Required {
+				how += ", required"
+			}
+			params = append(params, how)
+		}
+		if len(params) > 0 {
+			lines = append(lines, fmt.Sprintf("- %s: %s", op.ID, strings.Join(params, "; ")))
+		}
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	header := []string{
+		"## Parameter Locations",
+		"Send these as headers or cookies, never in the query string. Every other parameter goes where its \"in\" says.",
+	}
+	return strings.Join(append(header, lines...), "\n")
+}
+
+// bodyEncoding names the curl option that encodes a body of this content type.
+func bodyEncoding(contentType string) string {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "JSON body via -d"
+	case mediaType == "application/x-www-form-urlencoded":
+		return "form fields via --data-urlencode"
+	case mediaType == "multipart/form-data":
+		return "form parts via -F, files as -F field=@path"
+	case strings.HasPrefix(mediaType, "text/"):
+		return "raw text via --data-binary"
+	default:
+		return "raw bytes via --data-binary @file"
+	}
+}
+
+// authDetail describes how a scheme's credential is sent.
+func authDetail(scheme ir.AuthScheme) string {
+	switch {
+	case scheme.Type == "apiKey":
+		return fmt.Sprintf("API key in %s %q", scheme.In, scheme.Name)
+	case scheme.Type == "http" && scheme.Scheme != "":
+		return fmt.Sprintf("HTTP %s authentication", strings.ToLower(scheme.Scheme))
+	default:
+		return scheme.Type
+	}
+}
+
+// authEnv names the env vars holding a scheme's credentials.
+func authEnv(prefix string, scheme ir.AuthScheme) string {
+	names := schemeEnv(prefix, scheme)
+	for i, name := range names {
+		names[i] = "${" + name + "}"
+	}
+	return "read from " + strings.Join(names, " and ")
+}
+
+// skillDir is the directory of the skill's files relative to the output
+// directory: Options.SkillDir, or the skill's slug.
+func (p *Pipeline) skillDir() string {
+	if p.Opts.SkillDir != "" {
+		return p.Opts.SkillDir
+	}
+	return p.Inst.Slug()
+}
+
 func (p *Pipeline) artifactPath(id ArtifactID) string {
-	name := p.Inst.Frontmatter.Name
+	name := p.skillDir()
 	artifactKey := string(id)
 
 	// Check for custom filename
@@ -362,9 +1050,9 @@ func (p *Pipeline) artifactPath(id ArtifactID) string {
 	case ArtifactSkill:
 		return filepath.Join(name, "SKILL.md")
 	case ArtifactReference:
-		return filepath.Join(name, "references", "reference.md")
+		return filepath.Join(name, "references", "reference"+formatExt(p.outputFormat(id)))
 	case ArtifactExamples:
-		return filepath.Join(name, "references", "examples.md")
+		return filepath.Join(name, "references", "examples"+formatExt(p.outputFormat(id)))
 	case ArtifactScripts:
 		return filepath.Join(name, "scripts") // directory; scripts parsed from content
 	case ArtifactLlms:
@@ -382,24 +1070,34 @@ func (p *Pipeline) artifactPath(id ArtifactID) string {
 
 // WriteResults writes all generated artifacts to the output directory.
 func WriteResults(outputDir string, results []ArtifactResult) error {
+	return WriteResultsTo(DirSink(outputDir), results)
+}
+
+// WriteResultsTo writes all generated artifacts to sink.
+func WriteResultsTo(sink Sink, results []ArtifactResult) error {
 	for _, r := range results {
 		if r.Err != nil || r.Content == "" {
 			continue
 		}
 
+		if len(r.Parts) > 0 {
+			for _, part := range r.Parts {
+				if err := sink.WriteFile(part.FilePath, []byte(part.Content), 0o644); err != nil {
+					return fmt.Errorf("writing %s: %w", part.FilePath, err)
+				}
+			}
+			continue
+		}
+
 		if r.ID == ArtifactScripts {
 			// Parse scripts from content and write each one
-			if err := writeScripts(outputDir, r.FilePath, r.Content); err != nil {
+			if err := writeScripts(sink, r.FilePath, r.Content); err != nil {
 				return fmt.Errorf("writing scripts: %w", err)
 			}
 			continue
 		}
 
-		fullPath := filepath.Join(outputDir, r.FilePath)
-		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
-			return fmt.Errorf("creating directory for %s: %w", r.FilePath, err)
-		}
-		if err := os.WriteFile(fullPath, []byte(r.Content), 0o644); err != nil {
+		if err := sink.WriteFile(r.FilePath, []byte(r.Content), 0o644); err != nil {
 			return fmt.Errorf("writing %s: %w", r.FilePath, err)
 		}
 	}
@@ -407,13 +1105,20 @@ func WriteResults(outputDir string, results []ArtifactResult) error {
 }
 
 // writeScripts parses code blocks from LLM output and writes each as a file.
-func writeScripts(outputDir, scriptsDir, content string) error {
-	dir := filepath.Join(outputDir, scriptsDir)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return err
+func writeScripts(sink Sink, scriptsDir, content string) error {
+	for _, script := range parseScripts(content) {
+		path := filepath.Join(scriptsDir, script.Name)
+		if err := sink.WriteFile(path, []byte(script.Content), 0o755); err != nil {
+			return fmt.Errorf("writing script %s: %w", script.Name, err)
+		}
 	}
+	return nil
+}
 
-	// Parse code blocks: ```filename\n...\n```
+// parseScripts splits the scripts artifact into its files: code blocks
+// whose info string is the filename (```name.sh ... ```).
+func parseScripts(content string) []scriptFile {
+	var scripts []scriptFile
 	lines := strings.Split(content, "\n")
 	var currentFile string
 	var currentContent []string
@@ -427,11 +1132,7 @@ func writeScripts(outputDir, scriptsDir, content string) error {
 			inBlock = true
 		} else if line == "```" && inBlock {
 			if currentFile != "" {
-				path := filepath.Join(dir, currentFile)
-				data := strings.Join(currentContent, "\n") + "\n"
-				if err := os.WriteFile(path, []byte(data), 0o755); err != nil {
-					return fmt.Errorf("writing script %s: %w", currentFile, err)
-				}
+				scripts = append(scripts, scriptFile{Name: currentFile, Content: strings.Join(currentContent, "\n") + "\n"})
 			}
 			inBlock = false
 			currentFile = ""
@@ -439,8 +1140,33 @@ func writeScripts(outputDir, scriptsDir, content string) error {
 			currentContent = append(currentContent, line)
 		}
 	}
+	return scripts
+}
 
-	return nil
+// maxTokens returns the output token limit for an artifact's requests: the
+// artifact's max-tokens frontmatter override, then Options.MaxTokens, then the
+// built-in default. Providers reject or clamp limits above the model's own
+// output cap, so raising this only helps up to that cap.
+func (p *Pipeline) maxTokens(id ArtifactID) int {
+	if a, ok := p.Inst.Frontmatter.Artifacts[string(id)]; ok && a.MaxTokens > 0 {
+		return a.MaxTokens
+	}
+	if p.Opts.MaxTokens > 0 {
+		return p.Opts.MaxTokens
+	}
+	return maxTokensForArtifact(id)
+}
+
+// stopSequences returns the artifact's stop-sequences frontmatter, without
+// blank entries.
+func (p *Pipeline) stopSequences(id ArtifactID) []string {
+	var stops []string
+	for _, s := range p.Inst.Frontmatter.Artifacts[string(id)].StopSequences {
+		if strings.TrimSpace(s) != "" {
+			stops = append(stops, s)
+		}
+	}
+	return stops
 }
 
 func maxTokensForArtifact(id ArtifactID) int {
@@ -466,7 +1192,11 @@ func maxTokensForArtifact(id ArtifactID) int {
 	}
 }
 
-func estimateTokens(text string) int {
-	// Rough estimate: ~4 chars per token
-	return len(text) / 4
+// estimateTokens counts text's tokens with the configured tokenizer.
+func (p *Pipeline) estimateTokens(text string) int {
+	if p.Opts.Tokenizer != nil {
+		return p.Opts.Tokenizer.CountTokens(text)
+	}
+	t, _ := provider.TokenizerFor("", "", provider.TokenizerHeuristic)
+	return t.CountTokens(text)
 }
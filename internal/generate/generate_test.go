@@ -1,13 +1,19 @@
 package generate
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/roberthamel/skill-compiler/internal/instructions"
 	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/provider"
 )
 
 func testPipeline(t *testing.T) *Pipeline {
@@ -39,7 +45,7 @@ func TestEnabledArtifacts_OnlyFilter(t *testing.T) {
 	p := testPipeline(t)
 	p.Opts.Only = []string{"skill", "llms"}
 
-	artifacts := p.enabledArtifacts()
+	artifacts := p.EnabledArtifacts()
 	if len(artifacts) != 2 {
 		t.Fatalf("got %d artifacts, want 2", len(artifacts))
 	}
@@ -55,7 +61,7 @@ func TestEnabledArtifacts_OnlyFilter(t *testing.T) {
 func TestEnabledArtifacts_DisabledToggle(t *testing.T) {
 	p := testPipeline(t)
 
-	artifacts := p.enabledArtifacts()
+	artifacts := p.EnabledArtifacts()
 	for _, a := range artifacts {
 		if a == ArtifactScripts {
 			t.Error("scripts should be disabled but was included")
@@ -63,6 +69,16 @@ func TestEnabledArtifacts_DisabledToggle(t *testing.T) {
 	}
 }
 
+func TestEnabledArtifacts_DefaultDisabled(t *testing.T) {
+	p := testPipeline(t)
+	p.Inst.Frontmatter.ArtifactsDefault = instructions.ArtifactsDisabled
+
+	artifacts := p.EnabledArtifacts()
+	if len(artifacts) != 1 || artifacts[0] != ArtifactExamples {
+		t.Errorf("got %v, want only explicitly enabled examples", artifacts)
+	}
+}
+
 func TestArtifactPath_Default(t *testing.T) {
 	p := testPipeline(t)
 
@@ -83,6 +99,41 @@ func TestArtifactPath_Default(t *testing.T) {
 	}
 }
 
+func TestOutputFormat(t *testing.T) {
+	p := testPipeline(t)
+	p.Inst.Frontmatter.Artifacts["reference"] = instructions.Artifact{Format: instructions.FormatMDX}
+	p.Inst.Frontmatter.Artifacts["examples"] = instructions.Artifact{Enabled: p.Inst.Frontmatter.Artifacts["examples"].Enabled, Format: instructions.FormatAsciiDoc}
+
+	if got, want := p.artifactPath(ArtifactReference), filepath.Join("test-tool", "references", "reference.mdx"); got != want {
+		t.Errorf("reference path = %q, want %q", got, want)
+	}
+	if got, want := p.artifactPath(ArtifactExamples), filepath.Join("test-tool", "references", "examples.adoc"); got != want {
+		t.Errorf("examples path = %q, want %q", got, want)
+	}
+	if !strings.HasSuffix(p.systemPrompt(ArtifactReference), MDXFormatPrompt) {
+		t.Error("reference prompt missing the MDX instructions")
+	}
+	if skill := p.systemPrompt(ArtifactSkill); !strings.Contains(skill, "references/reference.mdx") || !strings.Contains(skill, "references/examples.adoc") {
+		t.Errorf("skill prompt does not link the formatted files:\n%s", skill)
+	}
+
+	mdx := p.formatted(ArtifactResult{ID: ArtifactReference, Content: "# Pets API\n\nGET /pets/{petId} returns <Pet>. See `{petId}`.\n\n```json\n{\"id\": 1}\n```\n"})
+	wantMDX := "---\ntitle: \"Pets API\"\n---\n\n# Pets API\n\nGET /pets/\\{petId\\} returns &lt;Pet>. See `{petId}`.\n\n```json\n{\"id\": 1}\n```\n"
+	if mdx.Content != wantMDX {
+		t.Errorf("MDX content:\n%s\nwant:\n%s", mdx.Content, wantMDX)
+	}
+
+	adoc := p.formatted(ArtifactResult{ID: ArtifactExamples, Content: "= Examples\n\n```bash\ncurl /pets\n```\n"})
+	if want := "= Examples\n\n[source,bash]\n----\ncurl /pets\n----\n"; adoc.Content != want {
+		t.Errorf("AsciiDoc content:\n%s\nwant:\n%s", adoc.Content, want)
+	}
+
+	md := p.formatted(ArtifactResult{ID: ArtifactSkill, Content: "GET /pets/{petId}"})
+	if md.Content != "GET /pets/{petId}" {
+		t.Errorf("markdown artifact was post-processed: %q", md.Content)
+	}
+}
+
 func TestArtifactPath_CustomFilename(t *testing.T) {
 	p := testPipeline(t)
 	p.Inst.Frontmatter.Artifacts["skill"] = instructions.Artifact{Filename: "custom.md"}
@@ -94,6 +145,67 @@ func TestArtifactPath_CustomFilename(t *testing.T) {
 	}
 }
 
+func TestGenerateArtifact_RejectsUnsafeFilenames(t *testing.T) {
+	for _, filename := range []string{"../../etc/cron.d/job", "/etc/passwd"} {
+		stub := &stubProvider{content: "ok"}
+		p := testPipeline(t)
+		p.Provider = stub
+		p.Inst.Frontmatter.Artifacts["skill"] = instructions.Artifact{Filename: filename}
+
+		r := p.generateArtifact(context.Background(), ArtifactSkill)
+		if r.Err == nil || !strings.Contains(r.Err.Error(), "artifacts.skill.filename") {
+			t.Errorf("filename %q: err = %v, want an artifacts.skill.filename error", filename, r.Err)
+		}
+		if len(stub.requests) != 0 {
+			t.Errorf("filename %q: the provider should not be called", filename)
+		}
+	}
+}
+
+func TestWriteResults_RejectsPathsOutsideOutputDir(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	for _, path := range []string{"../escaped.md", filepath.Join(dir, "absolute.md")} {
+		err := WriteResults(out, []ArtifactResult{{ID: ArtifactSkill, FilePath: path, Content: "x"}})
+		if !errors.Is(err, ErrUnsafePath) {
+			t.Errorf("WriteResults(%q) = %v, want ErrUnsafePath", path, err)
+		}
+	}
+	// Scripts are named by the model, so their names are checked too
+	err := WriteResults(out, []ArtifactResult{{ID: ArtifactScripts, FilePath: "scripts", Content: "```../../../evil.sh\necho\n```\n"}})
+	if !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("WriteResults of a traversing script name = %v, want ErrUnsafePath", err)
+	}
+	for _, name := range []string{"escaped.md", "absolute.md", "evil.sh"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be written", name)
+		}
+	}
+	if err := WriteResults(out, []ArtifactResult{{ID: ArtifactSkill, FilePath: "a/../SKILL.md", Content: "x"}}); err != nil {
+		t.Errorf("a path that stays inside the directory should be written, got %v", err)
+	}
+}
+
+func TestArtifactPath_SlugsName(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"Test Tool", "acme/test tool"} {
+		p := testPipeline(t)
+		p.Inst.Frontmatter.Name = name
+
+		path := p.artifactPath(ArtifactSkill)
+		slug := instructions.Slug(name)
+		if want := filepath.Join(slug, "SKILL.md"); path != want {
+			t.Errorf("artifactPath(skill) for %q = %q, want %q", name, path, want)
+		}
+
+		_ = os.MkdirAll(filepath.Join(dir, slug), 0o755)
+		_ = os.WriteFile(filepath.Join(dir, path), []byte("prev "+name), 0o644)
+		if got := LoadPreviousArtifacts(dir, name)[ArtifactSkill]; got != "prev "+name {
+			t.Errorf("LoadPreviousArtifacts(%q) skill = %q, want the file written at %s", name, got, path)
+		}
+	}
+}
+
 func TestRelevantSections(t *testing.T) {
 	p := testPipeline(t)
 
@@ -125,6 +237,53 @@ func TestRelevantSections(t *testing.T) {
 	}
 }
 
+func TestExpandOut(t *testing.T) {
+	date := time.Date(2025, 3, 14, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		template string
+		vars     OutVars
+		want     string
+	}{
+		{"./sc-out/", OutVars{Name: "api"}, "./sc-out/"},
+		{"./dist/{name}/{date}", OutVars{Name: "api", Date: date}, "./dist/api/2025-03-14"},
+		{"./dist/{version}", OutVars{Version: "1.2.0"}, "./dist/1.2.0"},
+		{"./dist/{version}", OutVars{}, "./dist/unversioned"},
+	}
+	for _, tt := range tests {
+		if got := ExpandOut(tt.template, tt.vars); got != tt.want {
+			t.Errorf("ExpandOut(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestPreviousOutputDir(t *testing.T) {
+	root := t.TempDir()
+	template := filepath.Join(root, "{name}", "{date}")
+	vars := OutVars{Name: "api", Date: time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)}
+
+	if got, want := PreviousOutputDir(template, vars), filepath.Join(root, "api", "2025-03-14"); got != want {
+		t.Errorf("first build: PreviousOutputDir = %q, want %q", got, want)
+	}
+
+	older := filepath.Join(root, "api", "2025-03-01")
+	newer := filepath.Join(root, "api", "2025-03-10")
+	for i, dir := range []string{older, newer, filepath.Join(root, "other", "2025-03-12")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		mod := time.Now().Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(dir, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := PreviousOutputDir(template, vars); got != newer {
+		t.Errorf("PreviousOutputDir = %q, want latest build %q", got, newer)
+	}
+	if got := LatestOutputDir(template, "api"); got != newer {
+		t.Errorf("LatestOutputDir = %q, want %q", got, newer)
+	}
+}
+
 func TestPrependChangelogEntry_New(t *testing.T) {
 	result := PrependChangelogEntry("### Added\n- Feature X", "")
 	if !strings.HasPrefix(result, "# CHANGELOG") {
@@ -153,11 +312,22 @@ func TestPrependChangelogEntry_Existing(t *testing.T) {
 	}
 }
 
+func TestLatestChangelogEntry(t *testing.T) {
+	existing := "# CHANGELOG\n\n## 2025-01-01 — Wednesday\n\n### Added\n- Old feature"
+	changelog := PrependChangelogEntry("### Added\n- New feature", existing)
+	if got, want := LatestChangelogEntry(changelog), "### Added\n- New feature"; got != want {
+		t.Errorf("LatestChangelogEntry = %q, want %q", got, want)
+	}
+	if got := LatestChangelogEntry("# CHANGELOG\n"); got != "" {
+		t.Errorf("LatestChangelogEntry of an empty changelog = %q, want empty", got)
+	}
+}
+
 func TestWriteScripts(t *testing.T) {
 	dir := t.TempDir()
 	content := "```health-check.sh\n#!/bin/bash\necho \"OK\"\n```\n\n```discover.sh\n#!/bin/bash\nls\n```"
 
-	if err := writeScripts(dir, "scripts", content); err != nil {
+	if err := writeScripts(DirSink(dir), "scripts", content); err != nil {
 		t.Fatalf("writeScripts error: %v", err)
 	}
 
@@ -209,3 +379,924 @@ func TestUserMessage_FirstGeneration(t *testing.T) {
 		t.Error("first-gen changelog should note no previous artifacts")
 	}
 }
+
+func TestUserMessage_SurfacesListedExtensions(t *testing.T) {
+	p := testPipeline(t)
+	p.IR = &ir.IntermediateRepr{Operations: []ir.Operation{{
+		ID:         "listPets",
+		Extensions: map[string]any{"x-rate-limit": "100/min", "x-acme-tier": "gold", "x-internal-owner": "team-pets"},
+		Parameters: []ir.Parameter{{Name: "limit", Extensions: map[string]any{"x-internal-note": "secret"}}},
+	}}}
+	p.Inst.Frontmatter.Extensions = []string{"x-rate-limit", "x-acme-*"}
+
+	msg := p.userMessage(ArtifactReference)
+	for _, want := range []string{"x-rate-limit", "x-acme-tier"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("reference message missing listed extension %s", want)
+		}
+	}
+	for _, id := range []ArtifactID{ArtifactReference, ArtifactSkill, ArtifactExamples} {
+		if msg := p.userMessage(id); strings.Contains(msg, "x-internal") {
+			t.Errorf("%s message leaks an unlisted extension", id)
+		}
+	}
+	if msg := p.userMessage(ArtifactExamples); strings.Contains(msg, "x-rate-limit") {
+		t.Error("examples message should not include extensions")
+	}
+	if len(p.IR.Operations[0].Extensions) != 3 {
+		t.Error("filtering modified the pipeline IR")
+	}
+}
+
+func TestRelevantSections_EmptyFallsBackToRawBody(t *testing.T) {
+	p := testPipeline(t)
+	p.Inst.Sections = map[string]string{"Product": "Product description", "Workflows": ""}
+	p.Inst.RawBody = "# Product\nProduct description\n\n# Workflows\n"
+
+	sections := p.RelevantSections(ArtifactExamples)
+	if !strings.HasPrefix(sections, fullInstructionsSection) {
+		t.Errorf("examples sections should fall back to full body, got %q", sections)
+	}
+	if !strings.Contains(sections, "Product description") {
+		t.Errorf("fallback should include raw body, got %q", sections)
+	}
+	if p.SkipForEmptySections(ArtifactExamples) {
+		t.Error("fallback policy should not skip the artifact")
+	}
+
+	msg := p.userMessage(ArtifactExamples)
+	if !strings.Contains(msg, "## Instructions: "+fullInstructionsSection) {
+		t.Error("user message should include the full instructions block")
+	}
+}
+
+func TestRelevantSections_EmptySkipPolicy(t *testing.T) {
+	p := testPipeline(t)
+	p.Inst.Sections = map[string]string{"Product": "Product description"}
+	p.Inst.RawBody = "# Product\nProduct description"
+	p.Inst.Frontmatter.EmptySections = instructions.EmptySectionsSkip
+
+	if !p.SkipForEmptySections(ArtifactExamples) {
+		t.Error("examples should be skipped when its sections are empty")
+	}
+	if p.SkipForEmptySections(ArtifactLlms) {
+		t.Error("llms has a Product section and should not be skipped")
+	}
+	if p.SkipForEmptySections(ArtifactReference) {
+		t.Error("reference uses no sections and should never be skipped")
+	}
+	if got := p.RelevantSections(ArtifactExamples); got != "" {
+		t.Errorf("skip policy should not fall back, got %q", got)
+	}
+
+	result := p.generateArtifact(context.Background(), ArtifactExamples)
+	if result.Err != nil || result.Content != "" {
+		t.Errorf("skipped artifact should have no content or error, got %+v", result)
+	}
+}
+
+func TestRelevantSections_DocsPolicy(t *testing.T) {
+	p := testPipeline(t)
+	p.Inst.Sections = map[string]string{"Workflows": "", "Common patterns": "Pattern content"}
+	p.Inst.RawBody = "# Workflows\n\n# Common patterns\nPattern content"
+	p.Inst.Frontmatter.EmptySections = instructions.EmptySectionsDocs
+	p.IR = &ir.IntermediateRepr{Structure: &ir.ProjectStructure{Docs: []ir.DocFile{
+		{Path: "README.md", Content: "# Pets\n\nPets tracks your pets.\n\n## Usage\n\nRun `pets add`.\n\n```sh\n# not a heading\n```\n\n## License\n\nMIT"},
+		{Path: "docs/guide.md", Content: "# Examples\n\nAdd a cat: `pets add --kind cat`."},
+	}}}
+
+	derived := DerivedSections(p.Inst, p.IR)
+	if got := derived["Product"]; got != "From README.md:\n\nPets tracks your pets." {
+		t.Errorf("derived Product = %q, want the README introduction", got)
+	}
+	if got := derived["Workflows"]; !strings.Contains(got, "Run `pets add`.") || !strings.Contains(got, "# not a heading") || strings.Contains(got, "MIT") {
+		t.Errorf("derived Workflows = %q, want the Usage block only", got)
+	}
+	if _, ok := derived["Common patterns"]; ok {
+		t.Error("a section the author wrote should not be derived")
+	}
+
+	sections := p.RelevantSections(ArtifactExamples)
+	for _, want := range []string{"Common patterns\nPattern content", "Workflows" + derivedSectionSuffix, "Examples" + derivedSectionSuffix, "pets add --kind cat"} {
+		if !strings.Contains(sections, want) {
+			t.Errorf("examples sections missing %q:\n%s", want, sections)
+		}
+	}
+	if strings.Contains(sections, "Product") || strings.Contains(sections, fullInstructionsSection) {
+		t.Errorf("examples sections should hold only its mapped sections:\n%s", sections)
+	}
+	if !strings.Contains(p.RelevantSections(ArtifactSkill), "Product"+derivedSectionSuffix) {
+		t.Error("SKILL.md should get every derived section")
+	}
+
+	// Without docs, the policy behaves like fallback
+	p.IR = &ir.IntermediateRepr{}
+	if got := p.RelevantSections(ArtifactExamples); strings.Contains(got, derivedSectionSuffix) || strings.HasPrefix(got, fullInstructionsSection) {
+		t.Errorf("sections without docs = %q, want the author's sections", got)
+	}
+	p.Inst.Sections = map[string]string{"Workflows": ""}
+	if got := p.RelevantSections(ArtifactExamples); !strings.HasPrefix(got, fullInstructionsSection) {
+		t.Errorf("empty sections without docs should fall back to the full body, got %q", got)
+	}
+}
+
+func TestRun_OfflineRequiresCache(t *testing.T) {
+	p := testPipeline(t)
+	p.Opts.Offline = true
+	p.Opts.Only = []string{"skill", "llms"}
+	p.Opts.SkipArtifacts = map[ArtifactID]bool{ArtifactSkill: true}
+
+	// Provider is nil: reaching it would panic, so the error must come first
+	_, err := p.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "offline mode: llms not cached") {
+		t.Fatalf("Run error = %v, want offline cache miss for llms", err)
+	}
+
+	p.Opts.SkipArtifacts[ArtifactLlms] = true
+	if pending := p.NeedsGeneration(); len(pending) != 0 {
+		t.Errorf("NeedsGeneration = %v, want none when all cached", pending)
+	}
+}
+
+// stubProvider returns a fixed response and records every request.
+type stubProvider struct {
+	mu       sync.Mutex
+	content  string
+	finish   string
+	requests []provider.GenerateRequest
+}
+
+func (s *stubProvider) Name() string { return "stub" }
+func (s *stubProvider) Generate(_ context.Context, req provider.GenerateRequest) (*provider.GenerateResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = append(s.requests, req)
+	return &provider.GenerateResponse{Content: s.content, FinishReason: s.finish}, nil
+}
+
+func TestGenerateArtifact_WarnsWhenTruncated(t *testing.T) {
+	var log strings.Builder
+	p := testPipeline(t)
+	p.Provider = &stubProvider{content: "cut off mid-", finish: provider.FinishLength}
+	p.Opts.Log = &log
+
+	p.generateArtifact(context.Background(), ArtifactLlms)
+	if !strings.Contains(log.String(), "WARNING: llms was cut off at the output token limit") {
+		t.Errorf("want a truncation warning, got:\n%s", log.String())
+	}
+}
+
+func TestGenerateArtifact_MaxTokens(t *testing.T) {
+	stub := &stubProvider{content: "ok"}
+	p := testPipeline(t)
+	p.Provider = stub
+	p.Inst.Frontmatter.Artifacts["reference"] = instructions.Artifact{MaxTokens: 32000}
+
+	p.generateArtifact(context.Background(), ArtifactReference)
+	p.generateArtifact(context.Background(), ArtifactLlms)
+	p.Opts.MaxTokens = 2000
+	p.generateArtifact(context.Background(), ArtifactLlms)
+	p.generateArtifact(context.Background(), ArtifactReference)
+
+	var got []int
+	for _, req := range stub.requests {
+		got = append(got, req.MaxTokens)
+	}
+	want := []int{32000, 1024, 2000, 32000}
+	if !slices.Equal(got, want) {
+		t.Errorf("MaxTokens = %v, want %v", got, want)
+	}
+}
+
+func TestGenerateArtifact_StopSequencesAndTrim(t *testing.T) {
+	stub := &stubProvider{content: "```markdown\n# Tool\n\nUse it.\n```\n\nLet me know if you want changes!"}
+	p := testPipeline(t)
+	p.Provider = stub
+	p.Inst.Frontmatter.Artifacts["llms"] = instructions.Artifact{StopSequences: []string{"\n\nI hope", " "}}
+
+	r := p.generateArtifact(context.Background(), ArtifactLlms)
+	if want := []string{"\n\nI hope"}; !slices.Equal(stub.requests[0].StopSequences, want) {
+		t.Errorf("StopSequences = %q, want %q without blank entries", stub.requests[0].StopSequences, want)
+	}
+	if r.Content != "# Tool\n\nUse it.\n" {
+		t.Errorf("llms content = %q, want the fence and closing remark trimmed", r.Content)
+	}
+}
+
+func TestTrimResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      ArtifactID
+		content string
+		want    string
+	}{
+		{"untouched", ArtifactSkill, "---\nname: x\n---\n# X\n", "---\nname: x\n---\n# X\n"},
+		{"wrapped skill", ArtifactSkill, "```markdown\n---\nname: x\n---\n# X\n```\n", "---\nname: x\n---\n# X\n"},
+		{"unclosed fence", ArtifactLlms, "```md\n# X\n- [a](a.md)", "# X\n- [a](a.md)\n"},
+		{"inner fences kept", ArtifactSkill, "```\n# X\n\n```bash\nx list\n```\n```", "# X\n\n```bash\nx list\n```\n"},
+		{"fenced reference kept", ArtifactReference, "```bash\nx list\n```\n", "```bash\nx list\n```\n"},
+		{"closing remarks", ArtifactReference, "# Ref\n\nBody.\n\n---\n\n**Let me know** if this works.\n\nHope this helps!\n", "# Ref\n\nBody.\n"},
+		{"remark in a code block", ArtifactExamples, "# Ex\n\n```text\nok\n\nLet me know\n```\n", "# Ex\n\n```text\nok\n\nLet me know\n```\n"},
+		{"content mentioning feedback", ArtifactReference, "# Ref\n\nThe API lets me know the status.\n", "# Ref\n\nThe API lets me know the status.\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimResponse(tt.id, tt.content); got != tt.want {
+				t.Errorf("trimResponse(%s, %q) = %q, want %q", tt.id, tt.content, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnrich_FillsOnlyMissingDescriptions(t *testing.T) {
+	stub := &stubProvider{content: "```json\n" +
+		`{"description": "Lists pets.", "parameters": {"limit": "Maximum pets to return.", "q": "ignored"}}` +
+		"\n```"}
+	p := testPipeline(t)
+	p.Provider = stub
+	p.IR = &ir.IntermediateRepr{Operations: []ir.Operation{
+		{ID: "listPets", Method: "GET", Path: "/pets", Parameters: []ir.Parameter{
+			{Name: "limit"},
+			{Name: "q", Description: "Search query"},
+		}},
+		{ID: "getPet", Description: "Get a pet", Parameters: []ir.Parameter{{Name: "id", Description: "Pet ID"}}},
+	}}
+
+	added, err := p.Enrich(context.Background())
+	if err != nil {
+		t.Fatalf("Enrich error: %v", err)
+	}
+	if added != 2 {
+		t.Errorf("added = %d, want 2", added)
+	}
+	if len(stub.requests) != 1 {
+		t.Errorf("provider calls = %d, want 1 (only the sparse operation)", len(stub.requests))
+	}
+	op := p.IR.Operations[0]
+	if op.Description != "Lists pets." || op.Parameters[0].Description != "Maximum pets to return." {
+		t.Errorf("enriched op = %+v", op)
+	}
+	if op.Parameters[1].Description != "Search query" {
+		t.Errorf("existing description overwritten: %q", op.Parameters[1].Description)
+	}
+}
+
+func TestWriteResultsTo_MemorySink(t *testing.T) {
+	sink := NewMemorySink()
+	results := []ArtifactResult{
+		{ID: ArtifactLlms, FilePath: "llms.txt", Content: "# tool\n"},
+		{ID: ArtifactScripts, FilePath: filepath.Join("tool", "scripts"), Content: "```check.sh\necho ok\n```"},
+		{ID: ArtifactSkill, FilePath: filepath.Join("tool", "SKILL.md")}, // skipped: no content
+	}
+	if err := WriteResultsTo(sink, results); err != nil {
+		t.Fatalf("WriteResultsTo error: %v", err)
+	}
+
+	want := []string{"llms.txt", "tool/scripts/check.sh"}
+	if got := sink.Paths(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("paths = %v, want %v", got, want)
+	}
+	if data, _ := sink.File("tool/scripts/check.sh"); string(data) != "echo ok\n" {
+		t.Errorf("check.sh = %q", data)
+	}
+}
+
+func TestGenerate_ReferenceSplitByGroup(t *testing.T) {
+	stub := &stubProvider{content: "# Group reference"}
+	p := testPipeline(t)
+	p.Provider = stub
+	p.IR = &ir.IntermediateRepr{
+		Operations: []ir.Operation{{ID: "listPets"}, {ID: "getOrder"}},
+		Groups: []ir.Group{
+			{Name: "Pets", Operations: []string{"listPets"}},
+			{Name: "Store Orders", Operations: []string{"getOrder"}},
+		},
+	}
+	p.Inst.Frontmatter.Artifacts["reference"] = instructions.Artifact{Split: instructions.SplitByGroup}
+
+	result := p.generateArtifact(context.Background(), ArtifactReference)
+	if result.Err != nil {
+		t.Fatalf("generate error: %v", result.Err)
+	}
+	var paths []string
+	for _, part := range result.Parts {
+		paths = append(paths, filepath.ToSlash(part.FilePath))
+	}
+	want := []string{"test-tool/references/pets.md", "test-tool/references/store-orders.md"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("parts = %v, want %v", paths, want)
+	}
+	if len(stub.requests) != 2 {
+		t.Fatalf("provider calls = %d, want one per group", len(stub.requests))
+	}
+	if !strings.Contains(stub.requests[0].UserMessage, `"listPets"`) || strings.Contains(stub.requests[0].UserMessage, `"getOrder"`) {
+		t.Errorf("first request should carry only the Pets operations")
+	}
+
+	skillMsg := p.userMessage(ArtifactSkill)
+	if !strings.Contains(skillMsg, "references/store-orders.md (Store Orders)") {
+		t.Errorf("skill message should list split reference files, got:\n%s", skillMsg)
+	}
+
+	// Without the option, the reference stays a single file
+	delete(p.Inst.Frontmatter.Artifacts, "reference")
+	if files := p.referenceFiles(ArtifactReference); files != nil {
+		t.Errorf("referenceFiles = %v, want nil when split is unset", files)
+	}
+}
+
+func TestGenerate_ChunksLargeReference(t *testing.T) {
+	stub := &stubProvider{content: "## part"}
+	p := testPipeline(t)
+	p.Provider = stub
+	desc := strings.Repeat("long description ", 40)
+	p.IR = &ir.IntermediateRepr{
+		Operations: []ir.Operation{
+			{ID: "createPet", Description: desc},
+			{ID: "getOrder", Description: desc},
+			{ID: "listPets", Description: desc},
+		},
+		Groups: []ir.Group{
+			{Name: "Pets", Operations: []string{"listPets", "createPet"}},
+			{Name: "Store", Operations: []string{"getOrder"}},
+		},
+	}
+
+	// Everything fits the default budget: no chunking
+	if chunks := p.chunks(ArtifactReference); chunks != nil {
+		t.Fatalf("chunks = %v, want nil under the default budget", chunks)
+	}
+
+	overhead := p.estimateTokens(p.systemPrompt(ArtifactReference) + ChunkPrompt + p.userMessageFor(ArtifactReference, p.IR.Subset(nil)))
+	p.Opts.TokenBudget = overhead + 250 // room for one operation per chunk
+	chunks := p.chunks(ArtifactReference)
+	var got []string
+	for _, c := range chunks {
+		got = append(got, strings.Join(c, "+"))
+	}
+	want := []string{"listPets", "createPet", "getOrder"} // group order, not IR order
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("chunks = %v, want %v", got, want)
+	}
+	if p.chunks(ArtifactSkill) != nil {
+		t.Error("skill should never be chunked")
+	}
+
+	result := p.generateArtifact(context.Background(), ArtifactReference)
+	if result.Err != nil {
+		t.Fatalf("generate error: %v", result.Err)
+	}
+	if len(stub.requests) != 3 {
+		t.Errorf("provider calls = %d, want 3", len(stub.requests))
+	}
+	if result.Content != "## part\n\n## part\n\n## part\n" {
+		t.Errorf("content = %q", result.Content)
+	}
+	if !strings.Contains(stub.requests[2].UserMessage, "part 3 of 3") {
+		t.Errorf("last request should be labelled part 3 of 3")
+	}
+}
+
+func TestUserMessage_ScriptsIncludeDerivedContext(t *testing.T) {
+	p := testPipeline(t)
+	p.Inst.Frontmatter.Name = "my-app"
+	p.IR = &ir.IntermediateRepr{
+		Metadata: map[string]string{"baseUrl": "https://api.example.com/v1"},
+		Auth: []ir.AuthScheme{
+			{ID: "apiKeyAuth", Type: "apiKey", In: "header", Name: "X-API-Key"},
+			{ID: "basicAuth", Type: "http", Scheme: "basic"},
+		},
+		Operations: []ir.Operation{
+			{ID: "uploadAvatar", RequestBody: &ir.TypeRef{ContentType: "multipart/form-data"}},
+			{ID: "createToken", RequestBody: &ir.TypeRef{
+				ContentType:     "application/x-www-form-urlencoded",
+				AltContentTypes: []string{"text/plain"},
+			}},
+			{ID: "getAdmin", Auth: [][]string{{"apiKeyAuth", "basicAuth"}}},
+			{ID: "getPublic", Auth: [][]string{{"apiKeyAuth"}, {}}},
+			{ID: "getPets", Auth: [][]string{{"apiKeyAuth"}}},
+		},
+	}
+
+	msg := p.userMessage(ArtifactScripts)
+	for _, want := range []string{
+		"## Derived Context",
+		"Environment variable prefix: MY_APP",
+		"Base URL: https://api.example.com/v1 — read from ${MY_APP_API_URL}",
+		`apiKeyAuth: API key in header "X-API-Key" — read from ${MY_APP_API_KEY}`,
+		"basicAuth: HTTP basic authentication — read from ${MY_APP_USERNAME} and ${MY_APP_PASSWORD}",
+		"uploadAvatar: Content-Type multipart/form-data (form parts via -F",
+		"createToken: Content-Type application/x-www-form-urlencoded (form fields via --data-urlencode); also accepts text/plain",
+		"getAdmin: apiKeyAuth AND basicAuth",
+		"getPublic: apiKeyAuth OR none",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("scripts message missing %q, got:\n%s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "getPets:") {
+		t.Error("single-scheme operations should not be listed under auth requirements")
+	}
+	if !strings.Contains(msg, "Rate limits: none documented") {
+		t.Error("scripts message should say no rate limits are documented")
+	}
+
+	p.IR.Operations[4].RateLimit = &ir.RateLimit{Limit: "100 requests per minute", Source: "x-ratelimit", TooManyRequests: true, RetryAfter: true}
+	want := "getPets: 100 requests per minute (from x-ratelimit); may return 429 Too Many Requests; wait for Retry-After before retrying"
+	if msg := p.userMessage(ArtifactSkill); !strings.Contains(msg, want) || strings.Contains(msg, "none documented") {
+		t.Errorf("skill message missing documented rate limit %q, got:\n%s", want, msg)
+	}
+	if strings.Contains(p.userMessage(ArtifactReference), "## Derived Context") {
+		t.Error("reference message should not include derived context")
+	}
+}
+
+func TestLoadSeedExamples(t *testing.T) {
+	dir := t.TempDir()
+	jsonl := filepath.Join(dir, "seeds.jsonl")
+	_ = os.WriteFile(jsonl, []byte(`{"method":"GET","path":"/pets/42","status":200,"responseBody":{"id":42,"name":"Rex"}}
+
+{"method":"POST","path":"/pets","requestBody":{"name":"Rex"}}
+`), 0o644)
+	seeds, err := LoadSeedExamples(jsonl)
+	if err != nil || len(seeds) != 2 || seeds[0].Status != 200 || seeds[1].Method != "POST" {
+		t.Fatalf("jsonl seeds = %+v (err %v)", seeds, err)
+	}
+
+	array := filepath.Join(dir, "seeds.json")
+	_ = os.WriteFile(array, []byte(`[{"method":"GET","path":"/pets"}]`), 0o644)
+	if seeds, err := LoadSeedExamples(array); err != nil || len(seeds) != 1 {
+		t.Errorf("array seeds = %+v (err %v)", seeds, err)
+	}
+
+	bad := filepath.Join(dir, "bad.jsonl")
+	_ = os.WriteFile(bad, []byte("{\"method\":\"GET\",\"path\":\"/pets\"}\nnot json\n"), 0o644)
+	if _, err := LoadSeedExamples(bad); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("error = %v, want line 2", err)
+	}
+}
+
+func TestUserMessage_RecordedExamples(t *testing.T) {
+	p := testPipeline(t)
+	p.IR = &ir.IntermediateRepr{Operations: []ir.Operation{
+		{ID: "getPet", Method: "GET", Path: "/pets/{petId}"},
+		{ID: "getMyPet", Method: "GET", Path: "/pets/mine"},
+		{ID: "deletePet", Method: "DELETE", Path: "/pets/{petId}"},
+	}}
+	p.Opts.SeedExamples = []SeedExample{
+		{Method: "get", Path: "/pets/42?expand=owner", Status: 200, ResponseBody: map[string]any{"name": "Rex"}},
+		{Method: "GET", Path: "/pets/mine", Status: 200},
+		{Method: "GET", Path: "/owners/1"},
+	}
+
+	matched, unmatched := MatchSeedExamples(p.IR.Operations, p.Opts.SeedExamples)
+	if len(matched["getPet"]) != 1 || len(matched["getMyPet"]) != 1 || len(matched["deletePet"]) != 0 || len(unmatched) != 1 {
+		t.Errorf("matched = %v, unmatched = %v", matched, unmatched)
+	}
+
+	msg := p.userMessage(ArtifactExamples)
+	for _, want := range []string{"## Recorded Examples", "### getPet (GET /pets/{petId})", `"name": "Rex"`, "### getMyPet"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("examples message missing %q", want)
+		}
+	}
+	if strings.Contains(msg, "/owners/1") {
+		t.Error("unmatched seed should not be sent")
+	}
+	if strings.Contains(p.userMessage(ArtifactReference), "## Recorded Examples") {
+		t.Error("reference message should not include recorded examples")
+	}
+	if !strings.Contains(p.RelevantSections(ArtifactExamples), "## Recorded Examples") {
+		t.Error("recorded examples should be part of the examples cache input")
+	}
+}
+
+func TestLintSkill(t *testing.T) {
+	skill := func(desc string) string {
+		return "---\nname: pet-store\ndescription: " + desc + "\n---\n# Pet Store\n"
+	}
+	good := "Manage pets in the Pet Store API. Use when the user asks to list, adopt, or update pets."
+	tests := []struct {
+		name    string
+		content string
+		cfg     instructions.DescriptionLint
+		want    []string
+	}{
+		{"good", skill(good), instructions.DescriptionLint{}, nil},
+		{"no frontmatter", "# Pet Store\n", instructions.DescriptionLint{}, []string{"skill-frontmatter"}},
+		{"missing", "---\nname: pet-store\n---\n", instructions.DescriptionLint{}, []string{"description-missing"}},
+		{"short without trigger", skill("Pet store API."), instructions.DescriptionLint{}, []string{"description-too-short", "description-no-trigger"}},
+		{"repeats name", skill("Pet Store"), instructions.DescriptionLint{MinLength: 1, Triggers: []string{}}, []string{"description-repeats-name"}},
+		{"too long", skill(good), instructions.DescriptionLint{MaxLength: 50}, []string{"description-too-long"}},
+		{"custom triggers", skill(good), instructions.DescriptionLint{Triggers: []string{"invoke for"}}, []string{"description-no-trigger"}},
+		{"trigger needs whole words", skill("Manage pets in the Pet Store API, somewhen soon, for everyone."), instructions.DescriptionLint{}, []string{"description-no-trigger"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, d := range LintSkill(tt.content, "SKILL.md", tt.cfg) {
+				got = append(got, d.Code)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("LintSkill codes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintScripts(t *testing.T) {
+	p := testPipeline(t)
+	p.Inst.Frontmatter.Name = "my-app"
+	p.Inst.Frontmatter.Skill.Env = []string{"GITHUB_TOKEN"}
+	content := "```ok.sh\n#!/bin/bash\nRESULT=$(curl -H \"Authorization: Bearer ${MY_APP_TOKEN}\" \"$MY_APP_API_URL/pets\")\nfor ID in $RESULT; do echo \"$ID $HOME $GITHUB_TOKEN\"; done\n```\n\n" +
+		"```stray.sh\n#!/bin/bash\ncurl -H \"X-Key: $API_KEY\" \"${BASE_URL:-http://localhost}\" -H \"X-Other: $API_KEY\"\n```\n"
+
+	diags := LintScripts(content, "out/my-app/scripts", p.Inst)
+	if len(diags) != 1 {
+		t.Fatalf("got %d diagnostics, want 1 for stray.sh: %v", len(diags), diags)
+	}
+	d := diags[0]
+	if d.Code != "script-env" || d.Source != "out/my-app/scripts/stray.sh" || !strings.Contains(d.Message, "MY_APP_* convention: API_KEY, BASE_URL") {
+		t.Errorf("diagnostic = %+v", d)
+	}
+
+	msg := p.userMessage(ArtifactScripts)
+	if !strings.Contains(msg, "Environment variables: MY_APP_API_URL, GITHUB_TOKEN. Name any other variable a script needs MY_APP_<NAME>.") {
+		t.Errorf("scripts message should list the env var names, got:\n%s", msg)
+	}
+}
+
+// failingProvider fails requests whose system prompt starts with failPrompt.
+type failingProvider struct {
+	stubProvider
+	failPrompt string
+}
+
+func (f *failingProvider) Generate(ctx context.Context, req provider.GenerateRequest) (*provider.GenerateResponse, error) {
+	if strings.HasPrefix(req.SystemPrompt, f.failPrompt) {
+		return nil, errors.New("rate limited")
+	}
+	return f.stubProvider.Generate(ctx, req)
+}
+
+func TestRun_ContinueOnError(t *testing.T) {
+	p := testPipeline(t)
+	p.Provider = &failingProvider{stubProvider: stubProvider{content: "ok"}, failPrompt: ExamplesPrompt}
+	p.Opts.Only = []string{"skill", "examples", "llms"}
+
+	if _, err := p.Run(context.Background()); err == nil || !strings.Contains(err.Error(), "generating examples") {
+		t.Fatalf("Run error = %v, want examples failure to abort by default", err)
+	}
+
+	p.Opts.ContinueOnError = true
+	results, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run error = %v, want nil with ContinueOnError", err)
+	}
+	var got []string
+	for _, r := range results {
+		status := "ok"
+		if r.Err != nil {
+			status = "failed"
+		}
+		got = append(got, string(r.ID)+"="+status)
+	}
+	want := "skill=ok,examples=failed,llms=ok"
+	if strings.Join(got, ",") != want {
+		t.Errorf("results = %v, want %s", got, want)
+	}
+}
+
+func TestRun_OnArtifactReportsCompletedBeforeFailure(t *testing.T) {
+	p := testPipeline(t)
+	p.Provider = &failingProvider{stubProvider: stubProvider{content: "ok"}, failPrompt: ExamplesPrompt}
+	p.Opts.Only = []string{"skill", "examples", "llms"}
+
+	var mu sync.Mutex
+	completed := make(map[ArtifactID]bool)
+	p.Opts.OnArtifact = func(r ArtifactResult) {
+		mu.Lock()
+		defer mu.Unlock()
+		completed[r.ID] = true
+	}
+
+	if _, err := p.Run(context.Background()); err == nil {
+		t.Fatal("Run error = nil, want examples failure")
+	}
+	if !completed[ArtifactSkill] || !completed[ArtifactLlms] || completed[ArtifactExamples] {
+		t.Errorf("completed = %v, want skill and llms only", completed)
+	}
+}
+
+func TestDryRun_UsesTokenizer(t *testing.T) {
+	p := testPipeline(t)
+	p.Opts.DryRun = true
+	p.Opts.Tokenizer = provider.TokenizerFunc(func(string) int { return 1234 })
+
+	r := p.generateArtifact(context.Background(), ArtifactSkill)
+	if !strings.Contains(r.Content, "~1234 input tokens") {
+		t.Errorf("dry-run content = %q, want the tokenizer's count", r.Content)
+	}
+}
+
+func TestScriptTemplates(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme ir.AuthScheme
+		want   string
+	}{
+		{"header api key", ir.AuthScheme{ID: "k", Type: "apiKey", In: "header", Name: "X-API-Key"}, `auth=(-H "X-API-Key: ${MY_APP_API_KEY}")`},
+		{"query api key", ir.AuthScheme{ID: "k", Type: "apiKey", In: "query", Name: "api_key"}, `auth=(--get --data-urlencode "api_key=${MY_APP_API_KEY}")`},
+		{"cookie api key", ir.AuthScheme{ID: "k", Type: "apiKey", In: "cookie", Name: "session"}, `auth=(--cookie "session=${MY_APP_API_KEY}")`},
+		{"bearer", ir.AuthScheme{ID: "k", Type: "http", Scheme: "bearer"}, `auth=(-H "Authorization: Bearer ${MY_APP_TOKEN}")`},
+		{"basic", ir.AuthScheme{ID: "k", Type: "http", Scheme: "basic"}, `auth=(-u "${MY_APP_USERNAME}:${MY_APP_PASSWORD}")`},
+		{"oauth2", ir.AuthScheme{ID: "k", Type: "oauth2"}, `auth=(-H "Authorization: Bearer ${MY_APP_TOKEN}")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPipeline(t)
+			p.Inst.Frontmatter.Name = "my-app"
+			p.IR = &ir.IntermediateRepr{
+				Metadata: map[string]string{"baseUrl": "https://api.example.com/v1"},
+				Auth:     []ir.AuthScheme{tt.scheme},
+				Operations: []ir.Operation{
+					{ID: "getPet", Method: "GET", Path: "/pets/{petId}", Auth: [][]string{{"k"}}},
+					{ID: "listPets", Method: "GET", Path: "/pets", Auth: [][]string{{"k"}}},
+					{ID: "search", Method: "GET", Path: "/search", Parameters: []ir.Parameter{{Name: "q", In: "query", Required: true}}},
+					{ID: "whoami", Method: "GET", Path: "/me", Auth: [][]string{{"k"}}},
+				},
+			}
+			templates := p.scriptTemplates(p.IR)
+			if len(templates) != 2 || templates[0].Name != "health-check.sh" || templates[1].Name != "discover.sh" {
+				t.Fatalf("templates = %+v, want health-check.sh and discover.sh", templates)
+			}
+			health, discover := templates[0].Content, templates[1].Content
+			for _, want := range []string{tt.want, `base_url="${MY_APP_API_URL:-https://api.example.com/v1}"`, `"${base_url}/me"`} {
+				if !strings.Contains(health, want) {
+					t.Errorf("health-check.sh missing %q:\n%s", want, health)
+				}
+			}
+			if !strings.Contains(discover, "\"/pets\"\n  \"/me\"\n)") {
+				t.Errorf("discover.sh should list only GETs without path or required params:\n%s", discover)
+			}
+		})
+	}
+
+	p := testPipeline(t)
+	p.IR = &ir.IntermediateRepr{Operations: []ir.Operation{{ID: "list", Path: "tool list"}}}
+	if templates := p.scriptTemplates(p.IR); templates != nil {
+		t.Errorf("CLI specs should get no templates, got %+v", templates)
+	}
+}
+
+func TestGenerateArtifact_ScriptsUseTemplates(t *testing.T) {
+	stub := &stubProvider{content: "```health-check.sh\n#!/bin/bash\ncurl -H 'Authorization: wrong' $URL\n```\n\n```create-pet.sh\n#!/bin/bash\necho create\n```"}
+	p := testPipeline(t)
+	p.Provider = stub
+	p.IR = &ir.IntermediateRepr{
+		Auth:       []ir.AuthScheme{{ID: "bearer", Type: "http", Scheme: "bearer"}},
+		Operations: []ir.Operation{{ID: "listPets", Method: "GET", Path: "/pets"}},
+	}
+
+	r := p.generateArtifact(context.Background(), ArtifactScripts)
+	if !strings.Contains(stub.requests[0].UserMessage, "## Script Templates") {
+		t.Error("scripts request should include the script templates")
+	}
+	sink := NewMemorySink()
+	if err := writeScripts(sink, "scripts", r.Content); err != nil {
+		t.Fatal(err)
+	}
+	health, _ := sink.File("scripts/health-check.sh")
+	if got := string(health); !strings.Contains(got, "Authorization: Bearer ${TEST_TOOL_TOKEN}") || strings.Contains(got, "wrong") {
+		t.Errorf("health-check.sh should be the template, got:\n%s", got)
+	}
+	if _, ok := sink.File("scripts/discover.sh"); !ok {
+		t.Error("discover.sh template missing")
+	}
+	if _, ok := sink.File("scripts/create-pet.sh"); !ok {
+		t.Error("model-written create-pet.sh should be kept")
+	}
+}
+
+func TestUserMessage_ParameterLocations(t *testing.T) {
+	p := testPipeline(t)
+	p.IR = &ir.IntermediateRepr{
+		Operations: []ir.Operation{
+			{ID: "listReports", Method: "GET", Path: "/reports", Parameters: []ir.Parameter{
+				{Name: "limit", In: "query"},
+				{Name: "X-Tenant", In: "header", Required: true},
+				{Name: "session", In: "cookie"},
+			}},
+			{ID: "getReport", Method: "GET", Path: "/reports/{id}", Parameters: []ir.Parameter{{Name: "id", In: "path", Required: true}}},
+		},
+	}
+
+	want := `- listReports: header X-Tenant (-H "X-Tenant: <value>"), required; cookie session (--cookie "session=<value>")`
+	for _, id := range []ArtifactID{ArtifactReference, ArtifactExamples, ArtifactScripts} {
+		msg := p.userMessage(id)
+		if !strings.Contains(msg, "## Parameter Locations") || !strings.Contains(msg, want) {
+			t.Errorf("%s message missing parameter locations, got:\n%s", id, msg)
+		}
+		if strings.Contains(msg, "- getReport:") {
+			t.Errorf("%s message lists an operation without header or cookie parameters", id)
+		}
+	}
+	if strings.Contains(p.userMessage(ArtifactLlms), "## Parameter Locations") {
+		t.Error("llms message should not include parameter locations")
+	}
+}
+
+func TestSystemPrompt_Language(t *testing.T) {
+	p := testPipeline(t)
+	english := p.systemPrompt(ArtifactReference)
+	p.Inst.Frontmatter.Language = "en"
+	if got := p.systemPrompt(ArtifactReference); got != english {
+		t.Error("language en should leave the prompt unchanged")
+	}
+
+	p.Inst.Frontmatter.Language = "es"
+	for _, id := range AllArtifacts {
+		prompt := p.systemPrompt(id)
+		if !strings.HasSuffix(prompt, LanguagePrompt("es")) || !strings.Contains(prompt, "Write all prose in Spanish") {
+			t.Errorf("%s system prompt does not ask for Spanish prose", id)
+		}
+	}
+	p.Inst.Frontmatter.Language = "Klingon"
+	if !strings.Contains(p.systemPrompt(ArtifactSkill), "Write all prose in Klingon") {
+		t.Error("unknown languages should be passed through by name")
+	}
+
+	if cfg := LintConfig(p.Inst.Frontmatter); cfg.Triggers == nil || len(cfg.Triggers) != 0 {
+		t.Errorf("non-English skills should not check English triggers, got %v", cfg.Triggers)
+	}
+	p.Inst.Frontmatter.Skill.DescriptionLint.Triggers = []string{"usar cuando"}
+	if cfg := LintConfig(p.Inst.Frontmatter); len(cfg.Triggers) != 1 {
+		t.Errorf("configured triggers should be kept, got %v", cfg.Triggers)
+	}
+}
+
+// noSystemRoleProvider is a stubProvider whose model has no system role.
+type noSystemRoleProvider struct{ stubProvider }
+
+func (n *noSystemRoleProvider) Capabilities() provider.Capabilities { return provider.Capabilities{} }
+
+func TestGenerateArtifact_AdaptsToCapabilities(t *testing.T) {
+	stub := &noSystemRoleProvider{stubProvider{content: "ok"}}
+	p := testPipeline(t)
+	p.Provider = stub
+
+	p.generateArtifact(context.Background(), ArtifactLlms)
+	req := stub.requests[0]
+	if req.SystemPrompt != "" || !strings.HasPrefix(req.UserMessage, LlmsTxtPrompt) {
+		t.Errorf("request = %+v, want the system prompt folded into the user message", req)
+	}
+}
+
+func TestUserMessage_ExampleLimits(t *testing.T) {
+	p := testPipeline(t)
+	body := func(examples ...ir.Example) *ir.TypeRef { return &ir.TypeRef{TypeName: "Pet", Examples: examples} }
+	p.IR = &ir.IntermediateRepr{Operations: []ir.Operation{
+		{ID: "createPet", RequestBody: body(
+			ir.Example{Name: "long", Value: `{"name":"Rex","tag":"dog","owner":"sam"}`},
+			ir.Example{Name: "short", Value: `{"name":"Rex"}`},
+			ir.Example{Name: "documented", Summary: "A cat", Value: `{"name":"Tom","tag":"cat"}`},
+		), Responses: []ir.Response{{StatusCode: "201", Body: body(ir.Example{Name: "default", Value: `{"id":1,"name":"Rex","tag":"dog"}`})}}},
+		{ID: "getPet", Responses: []ir.Response{{StatusCode: "200", Body: body(ir.Example{Name: "rex", Value: `{"id":1}`})}}},
+	}}
+
+	if msg := p.userMessage(ArtifactExamples); strings.Contains(msg, "spec examples are not shown") {
+		t.Error("no note expected without limits")
+	}
+
+	p.Inst.Frontmatter.Artifacts["examples"] = instructions.Artifact{MaxExamplesPerOperation: 2, MaxExamples: 3}
+	spec, omitted := selectExamples(p.IR, 2, 3)
+	create := spec.Operations[0]
+	if omitted != 2 || len(create.RequestBody.Examples) != 1 || create.RequestBody.Examples[0].Name != "documented" ||
+		create.Responses[0].Body.Examples[0].Name != "default" || len(spec.Operations[1].Responses[0].Body.Examples) != 1 {
+		t.Errorf("selected %+v, omitted %d", spec.Operations, omitted)
+	}
+	if len(p.IR.Operations[0].RequestBody.Examples) != 3 {
+		t.Error("selectExamples should not modify the pipeline's IR")
+	}
+
+	msg := p.userMessage(ArtifactExamples)
+	if !strings.Contains(msg, "2 more spec examples are not shown") || !strings.Contains(msg, "additional examples exist in the reference") {
+		t.Errorf("examples message should note the omitted examples:\n%s", msg)
+	}
+	if strings.Contains(msg, `"short"`) || strings.Contains(p.userMessage(ArtifactReference), "spec examples are not shown") {
+		t.Error("limits should apply to the examples prompt only")
+	}
+	if !strings.Contains(p.RelevantSections(ArtifactExamples), "Example limits") {
+		t.Error("example limits should be part of the examples cache input")
+	}
+}
+
+func TestUserMessage_ChangelogDeprecatedParameters(t *testing.T) {
+	p := testPipeline(t)
+	p.IR = &ir.IntermediateRepr{Operations: []ir.Operation{
+		{ID: "listPets", Method: "GET", Path: "/pets", Parameters: []ir.Parameter{
+			{Name: "limit", In: "query", Deprecated: true, DeprecationNote: "use pageSize", Sunset: "2026-01-01"},
+			{Name: "pageSize", In: "query"},
+		}},
+	}}
+
+	msg := p.userMessage(ArtifactChangelog)
+	if !strings.Contains(msg, "## Deprecated Parameters") ||
+		!strings.Contains(msg, "- query:limit on GET /pets (listPets) — use pageSize (sunset 2026-01-01)") {
+		t.Errorf("changelog message should list deprecated parameters:\n%s", msg)
+	}
+	if strings.Contains(msg, "pageSize on") || strings.Contains(p.userMessage(ArtifactReference), "## Deprecated Parameters") {
+		t.Error("only deprecated parameters belong in the list, and only for the changelog")
+	}
+}
+
+func TestUserMessage_RawHelp(t *testing.T) {
+	p := testPipeline(t)
+	p.IR = &ir.IntermediateRepr{Operations: []ir.Operation{
+		{ID: "mytool deploy", Path: "mytool deploy", RawHelpText: "Usage: mytool deploy [flags]"},
+	}}
+
+	if strings.Contains(p.userMessage(ArtifactReference), "Usage: mytool deploy") {
+		t.Error("raw help should be left out of the reference by default")
+	}
+	base := p.RelevantSections(ArtifactReference)
+
+	p.Inst.Frontmatter.Artifacts["reference"] = instructions.Artifact{IncludeRawHelp: true}
+	if !strings.Contains(p.userMessage(ArtifactReference), "Usage: mytool deploy") {
+		t.Error("include-raw-help should send the reference raw help")
+	}
+	if strings.Contains(p.userMessage(ArtifactSkill), "Usage: mytool deploy") {
+		t.Error("raw help is for the reference only")
+	}
+	if p.RelevantSections(ArtifactReference) == base {
+		t.Error("include-raw-help should change the reference cache input")
+	}
+	if p.IR.Operations[0].RawHelpText == "" {
+		t.Error("the pipeline's IR should keep its raw help")
+	}
+
+	delete(p.Inst.Frontmatter.Artifacts, "reference")
+	p.Opts.IncludeRawHelp = true
+	if !strings.Contains(p.userMessage(ArtifactReference), "Usage: mytool deploy") {
+		t.Error("Options.IncludeRawHelp should send the reference raw help")
+	}
+}
+
+func TestAddFileHeader(t *testing.T) {
+	const header = "Copyright Example Corp.\nSPDX-License-Identifier: MIT"
+	tests := []struct {
+		path, content, want string
+	}{
+		{"pets/SKILL.md", "---\nname: pets\n---\n\n# Pets\n",
+			"---\nname: pets\n---\n\n<!--\nCopyright Example Corp.\nSPDX-License-Identifier: MIT\n-->\n\n# Pets\n"},
+		{"CHANGELOG.md", "# CHANGELOG\n", "<!--\nCopyright Example Corp.\nSPDX-License-Identifier: MIT\n-->\n\n# CHANGELOG\n"},
+		{"pets/references/reference.mdx", "# Ref\n", "{/*\nCopyright Example Corp.\nSPDX-License-Identifier: MIT\n*/}\n\n# Ref\n"},
+		{"pets/scripts/list.sh", "#!/usr/bin/env bash\nset -e\n",
+			"#!/usr/bin/env bash\n# Copyright Example Corp.\n# SPDX-License-Identifier: MIT\nset -e\n"},
+		{"llms.txt", "# Pets\n", "# Pets\n"},
+	}
+	for _, tt := range tests {
+		got := AddFileHeader(tt.path, tt.content, header)
+		if got != tt.want {
+			t.Errorf("AddFileHeader(%s) =\n%s\nwant:\n%s", tt.path, got, tt.want)
+		}
+		if again := AddFileHeader(tt.path, got, header); again != got {
+			t.Errorf("AddFileHeader(%s) should not add the header twice:\n%s", tt.path, again)
+		}
+		if ext := filepath.Ext(tt.path); ext == ".md" || ext == ".mdx" {
+			if stripped := StripFileHeader(got, header); stripped != strings.TrimLeft(tt.content, "\n") {
+				t.Errorf("StripFileHeader(%s) = %q, want %q", tt.path, stripped, tt.content)
+			}
+		}
+	}
+
+	sink := NewMemorySink()
+	_ = HeaderSink{Sink: sink, Header: header}.WriteFile("CHANGELOG.md", []byte("# CHANGELOG\n"), 0o644)
+	if data, _ := sink.File("CHANGELOG.md"); !strings.HasPrefix(string(data), "<!--\nCopyright") {
+		t.Errorf("HeaderSink wrote %q", data)
+	}
+}
+
+func TestUserMessage_SpecInfo(t *testing.T) {
+	p := testPipeline(t)
+	if strings.Contains(p.userMessage(ArtifactLlms), "## Spec Info") {
+		t.Error("a spec without contact, license, or external docs should add no Spec Info")
+	}
+
+	p.IR = &ir.IntermediateRepr{Metadata: map[string]string{
+		"externalDocsUrl": "https://docs.example.com",
+		"license":         "MIT",
+		"contactEmail":    "api@example.com",
+	}}
+	for _, id := range []ArtifactID{ArtifactSkill, ArtifactLlms, ArtifactLlmsAPI, ArtifactLlmsFull} {
+		msg := p.userMessage(id)
+		for _, want := range []string{"- External documentation: https://docs.example.com", "- License: MIT", "- Contact: api@example.com"} {
+			if !strings.Contains(msg, want) {
+				t.Errorf("%s user message should contain %q", id, want)
+			}
+		}
+	}
+	if strings.Contains(p.userMessage(ArtifactReference), "## Spec Info") {
+		t.Error("the reference should not get Spec Info")
+	}
+}
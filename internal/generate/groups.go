@@ -0,0 +1,54 @@
+package generate
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// indexDescriptionGroups caps how many groups the index description names,
+// keeping it under the spec's 1024-character limit.
+const indexDescriptionGroups = 10
+
+// GroupSkill is one mini-skill of a per-group build.
+type GroupSkill struct {
+	Group ir.Group
+	Dir   string // relative to the index SKILL.md's directory
+}
+
+// GroupSkillIndex renders the top-level SKILL.md of a per-group build. It is
+// written without the provider: frontmatter naming the skill, the product
+// section, and a link to each group's SKILL.md.
+func GroupSkillIndex(inst *instructions.Instructions, groups []GroupSkill) string {
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		names = append(names, g.Group.Name)
+	}
+	if len(names) > indexDescriptionGroups {
+		names = append(names[:indexDescriptionGroups], "and more")
+	}
+	description := fmt.Sprintf("Index of the %s skills, one per area of the API. Use when working with %s: %s.",
+		inst.Frontmatter.Name, inst.Frontmatter.Name, strings.Join(names, ", "))
+	if len(description) > defaultDescriptionMax {
+		description = description[:defaultDescriptionMax-3] + "..."
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "---\nname: %s\ndescription: %q\n---\n\n", inst.Slug(), description)
+	fmt.Fprintf(&b, "# %s\n\n", inst.Frontmatter.Name)
+	if product := strings.TrimSpace(inst.Sections["Product"]); product != "" {
+		b.WriteString(product + "\n\n")
+	}
+	b.WriteString("## Skills\n\nRead the SKILL.md of the area the task is about.\n\n")
+	for _, g := range groups {
+		line := fmt.Sprintf("- [%s](%s)", g.Group.Name, path.Join(g.Dir, "SKILL.md"))
+		if desc := strings.Join(strings.Fields(g.Group.Description), " "); desc != "" {
+			line += " — " + desc
+		}
+		fmt.Fprintf(&b, "%s (%d operations)\n", line, len(g.Group.Operations))
+	}
+	return b.String()
+}
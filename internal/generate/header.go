@@ -0,0 +1,104 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// commentStyle is how a file type comments out a file header: either
+// wrapped in open and close lines, or with line before every line.
+type commentStyle struct {
+	open, close string
+	line        string
+}
+
+// headerStyles maps the extensions of generated files to their comment
+// syntax. Files of other types, such as llms.txt, whose first line must be
+// its title, get no header.
+var headerStyles = map[string]commentStyle{
+	".md":   {open: "<!--", close: "-->"},
+	".mdx":  {open: "{/*", close: "*/}"},
+	".adoc": {open: "////", close: "////"},
+	".sh":   {line: "# "},
+	".bash": {line: "# "},
+	".zsh":  {line: "# "},
+	".py":   {line: "# "},
+	".rb":   {line: "# "},
+	".js":   {line: "// "},
+	".mjs":  {line: "// "},
+	".ts":   {line: "// "},
+}
+
+// render comments out header.
+func (c commentStyle) render(header string) string {
+	if c.line == "" {
+		return c.open + "\n" + header + "\n" + c.close
+	}
+	lines := strings.Split(header, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(c.line+l, " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AddFileHeader puts header, commented out for the file type of path, at
+// the top of content: after the frontmatter of markdown files and after
+// the shebang of scripts. Content that already has it is returned as is.
+func AddFileHeader(path, content, header string) string {
+	style, ok := headerStyles[strings.ToLower(filepath.Ext(path))]
+	if header == "" || !ok {
+		return content
+	}
+	comment := style.render(header)
+	if strings.Contains(content, comment) {
+		return content
+	}
+
+	if style.line != "" {
+		if strings.HasPrefix(content, "#!") {
+			shebang, rest, _ := strings.Cut(content, "\n")
+			return shebang + "\n" + comment + "\n" + rest
+		}
+		return comment + "\n" + content
+	}
+	// SKILL.md must start with its frontmatter
+	if rest, ok := strings.CutPrefix(content, "---\n"); ok {
+		if end := strings.Index(rest, "\n---\n"); end >= 0 {
+			end += len("---\n") + len("\n---\n")
+			return content[:end] + "\n" + comment + "\n\n" + strings.TrimLeft(content[end:], "\n")
+		}
+	}
+	return comment + "\n\n" + strings.TrimLeft(content, "\n")
+}
+
+// StripFileHeader removes header as AddFileHeader puts it in a markdown,
+// MDX, or AsciiDoc file, so previous outputs read back without it.
+func StripFileHeader(content, header string) string {
+	if header == "" {
+		return content
+	}
+	for _, ext := range []string{".md", ".mdx", ".adoc"} {
+		content = strings.Replace(content, headerStyles[ext].render(header)+"\n\n", "", 1)
+	}
+	return content
+}
+
+// StripFileHeaders applies StripFileHeader to each artifact.
+func StripFileHeaders(artifacts map[ArtifactID]string, header string) {
+	for id, content := range artifacts {
+		artifacts[id] = StripFileHeader(content, header)
+	}
+}
+
+// HeaderSink adds Header to each file written through it; see
+// AddFileHeader.
+type HeaderSink struct {
+	Sink   Sink
+	Header string
+}
+
+// WriteFile implements Sink.
+func (s HeaderSink) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return s.Sink.WriteFile(path, []byte(AddFileHeader(path, string(data), s.Header)), perm)
+}
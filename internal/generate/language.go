@@ -0,0 +1,39 @@
+package generate
+
+import (
+	"fmt"
+	"strings"
+)
+
+// languageNames maps common language codes to the names used in prompts.
+var languageNames = map[string]string{
+	"de": "German", "es": "Spanish", "fr": "French", "it": "Italian",
+	"ja": "Japanese", "ko": "Korean", "nl": "Dutch", "pl": "Polish",
+	"pt": "Portuguese", "pt-br": "Brazilian Portuguese", "ru": "Russian",
+	"sv": "Swedish", "tr": "Turkish", "uk": "Ukrainian",
+	"zh": "Simplified Chinese", "zh-cn": "Simplified Chinese", "zh-tw": "Traditional Chinese",
+}
+
+// IsEnglish reports whether language is empty or names English, the
+// language the prompts are written for.
+func IsEnglish(language string) bool {
+	switch strings.ToLower(strings.TrimSpace(language)) {
+	case "", "en", "en-us", "en-gb", "english":
+		return true
+	default:
+		return false
+	}
+}
+
+// LanguagePrompt returns the system prompt suffix asking for prose in
+// language, or "" for English.
+func LanguagePrompt(language string) string {
+	if IsEnglish(language) {
+		return ""
+	}
+	name := strings.TrimSpace(language)
+	if n, ok := languageNames[strings.ToLower(name)]; ok {
+		name = n
+	}
+	return fmt.Sprintf(LanguagePromptTemplate, name)
+}
@@ -0,0 +1,181 @@
+package generate
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/diag"
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"gopkg.in/yaml.v3"
+)
+
+// Description length bounds used when DescriptionLint leaves them unset.
+// The Agent Skills spec caps descriptions at 1024 characters.
+const (
+	defaultDescriptionMin = 40
+	defaultDescriptionMax = 1024
+)
+
+// LintConfig returns the description lint settings for a skill. The default
+// trigger phrases are English, so the trigger check is off for skills
+// written in another language unless triggers are configured.
+func LintConfig(fm instructions.Frontmatter) instructions.DescriptionLint {
+	cfg := fm.Skill.DescriptionLint
+	if cfg.Triggers == nil && !IsEnglish(fm.Language) {
+		cfg.Triggers = []string{}
+	}
+	return cfg
+}
+
+// LintSkill checks the frontmatter description of a generated SKILL.md:
+// present, within the length bounds, containing a trigger phrase that says
+// when to use the skill, and saying more than the skill name. source names
+// the file in the diagnostics. Exceeding the maximum length is an error,
+// since the spec rejects it; the rest are warnings.
+func LintSkill(content, source string, cfg instructions.DescriptionLint) []diag.Diagnostic {
+	lintDiag := func(severity diag.Severity, code, format string, args ...any) diag.Diagnostic {
+		return diag.Diagnostic{
+			Severity: severity,
+			Code:     code,
+			Message:  fmt.Sprintf(format, args...) + " — regenerate with `sc generate --only skill --force`",
+			Source:   source,
+		}
+	}
+
+	var fm struct {
+		Name        string `yaml:"name"`
+		Description string `yaml:"description"`
+	}
+	block, ok := skillFrontmatter(content)
+	if ok {
+		ok = yaml.Unmarshal([]byte(block), &fm) == nil
+	}
+	if !ok {
+		return []diag.Diagnostic{lintDiag(diag.SeverityError, "skill-frontmatter", "SKILL.md has no valid YAML frontmatter")}
+	}
+	desc := strings.TrimSpace(fm.Description)
+	if desc == "" {
+		return []diag.Diagnostic{lintDiag(diag.SeverityError, "description-missing", "SKILL.md frontmatter has no description")}
+	}
+
+	minLen, maxLen := cfg.MinLength, cfg.MaxLength
+	if minLen == 0 {
+		minLen = defaultDescriptionMin
+	}
+	if maxLen == 0 {
+		maxLen = defaultDescriptionMax
+	}
+	triggers := cfg.Triggers
+	if triggers == nil {
+		triggers = instructions.DefaultTriggers
+	}
+
+	var diags []diag.Diagnostic
+	n := len([]rune(desc))
+	switch {
+	case n > maxLen:
+		diags = append(diags, lintDiag(diag.SeverityError, "description-too-long",
+			"description is %d characters, over the %d limit", n, maxLen))
+	case n < minLen:
+		diags = append(diags, lintDiag(diag.SeverityWarning, "description-too-short",
+			"description is only %d characters (minimum %d): %q", n, minLen, desc))
+	}
+	words := lintWords(desc)
+	if len(triggers) > 0 && !containsAny(words, triggers) {
+		diags = append(diags, lintDiag(diag.SeverityWarning, "description-no-trigger",
+			"description does not say when to use the skill (expected a phrase like %q)", triggers[0]))
+	}
+	if fm.Name != "" && words == lintWords(fm.Name) {
+		diags = append(diags, lintDiag(diag.SeverityWarning, "description-repeats-name",
+			"description only repeats the skill name %q", fm.Name))
+	}
+	return diags
+}
+
+// skillFrontmatter returns the YAML between a leading pair of --- lines.
+func skillFrontmatter(content string) (string, bool) {
+	content = strings.TrimLeft(strings.TrimPrefix(content, "\uFEFF"), " \t\r\n")
+	rest, ok := strings.CutPrefix(content, "---")
+	if !ok {
+		return "", false
+	}
+	block, _, ok := strings.Cut(rest, "\n---")
+	return block, ok
+}
+
+// lintWords lowercases s and reduces it to space-separated words, so phrases
+// match on word boundaries regardless of punctuation.
+func lintWords(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r > 127)
+	}), " ")
+}
+
+// containsAny reports whether words contains any phrase as whole words.
+func containsAny(words string, phrases []string) bool {
+	padded := " " + words + " "
+	for _, p := range phrases {
+		if p = lintWords(p); p != "" && strings.Contains(padded, " "+p+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	// scriptEnvRef matches an upper-case variable expansion: $NAME or ${NAME...}
+	scriptEnvRef = regexp.MustCompile(`\$\{?([A-Z][A-Z0-9_]*)`)
+	// scriptEnvSet matches variables a script sets itself, by assignment,
+	// for, or read
+	scriptEnvSet = regexp.MustCompile(`(?m)(?:^|[\s;(])(?:(?:export|local|readonly|declare(?:\s+-\w+)*)\s+)?([A-Z][A-Z0-9_]*)=|\bfor\s+([A-Z][A-Z0-9_]*)\s+in\b|\bread\s+(?:-\w+\s+)*([A-Z][A-Z0-9_]*)`)
+)
+
+// shellEnv are variables the shell or the environment provides, which
+// scripts may read without the skill's prefix.
+var shellEnv = []string{
+	"BASH_REMATCH", "BASH_SOURCE", "CI", "EDITOR", "EUID", "FUNCNAME", "HOME", "HOSTNAME",
+	"IFS", "LANG", "LC_ALL", "LINENO", "NO_COLOR", "OLDPWD", "OPTARG", "OPTIND", "PAGER",
+	"PATH", "PIPESTATUS", "PPID", "PWD", "RANDOM", "SECONDS", "SHELL", "TERM", "TMPDIR", "UID", "USER",
+}
+
+// LintScripts checks that the generated scripts read only the skill's
+// environment variables: those named <prefix>_*, the skill's declared env,
+// and standard shell variables. Each script reading others gets a warning,
+// so every script in a skill is configured the same way. scriptsDir names
+// the scripts directory in the diagnostics.
+func LintScripts(content, scriptsDir string, inst *instructions.Instructions) []diag.Diagnostic {
+	prefix := inst.EnvPrefix() + "_"
+	var diags []diag.Diagnostic
+	for _, script := range parseScripts(content) {
+		local := make(map[string]bool)
+		for _, m := range scriptEnvSet.FindAllStringSubmatch(script.Content, -1) {
+			for _, name := range m[1:] {
+				if name != "" {
+					local[name] = true
+				}
+			}
+		}
+		var stray []string
+		for _, m := range scriptEnvRef.FindAllStringSubmatch(script.Content, -1) {
+			name := m[1]
+			if strings.HasPrefix(name, prefix) || local[name] || slices.Contains(shellEnv, name) ||
+				slices.Contains(inst.Frontmatter.Skill.Env, name) || slices.Contains(stray, name) {
+				continue
+			}
+			stray = append(stray, name)
+		}
+		if len(stray) > 0 {
+			diags = append(diags, diag.Diagnostic{
+				Severity: diag.SeverityWarning,
+				Code:     "script-env",
+				Message: fmt.Sprintf("script reads env vars outside the %s* convention: %s — rename them, or list them in skill.env",
+					prefix, strings.Join(stray, ", ")),
+				Source: filepath.Join(scriptsDir, script.Name),
+			})
+		}
+	}
+	return diags
+}
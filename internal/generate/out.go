@@ -0,0 +1,75 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+)
+
+// OutVars are the values substituted into an out template.
+type OutVars struct {
+	Name    string
+	Version string // spec version; empty expands to "unversioned"
+	Date    time.Time
+}
+
+// IsOutTemplate reports whether out contains {name}, {date}, or {version}.
+func IsOutTemplate(out string) bool {
+	return strings.Contains(out, "{name}") || strings.Contains(out, "{date}") || strings.Contains(out, "{version}")
+}
+
+// ExpandOut substitutes placeholders in an out template: {name} (as its
+// slug), {date} (YYYY-MM-DD), and {version}. Other text is left as-is.
+func ExpandOut(template string, vars OutVars) string {
+	version := vars.Version
+	if version == "" {
+		version = "unversioned"
+	}
+	return strings.NewReplacer(
+		"{name}", instructions.Slug(vars.Name),
+		"{date}", vars.Date.Format("2006-01-02"),
+		"{version}", version,
+	).Replace(template)
+}
+
+// LatestOutputDir returns the most recently modified directory produced by an
+// out template for the named skill. The template is returned unchanged when
+// it has no placeholders or nothing has been built yet.
+func LatestOutputDir(template, name string) string {
+	if !IsOutTemplate(template) {
+		return template
+	}
+	pattern := strings.NewReplacer("{name}", instructions.Slug(name), "{date}", "*", "{version}", "*").Replace(template)
+	matches, _ := filepath.Glob(filepath.Clean(pattern))
+
+	latest := template
+	var latestMod time.Time
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		if latestMod.IsZero() || info.ModTime().After(latestMod) {
+			latest, latestMod = m, info.ModTime()
+		}
+	}
+	return latest
+}
+
+// PreviousOutputDir returns where the previous build of a templated output
+// directory landed, so changelog diffing can find it: the expanded directory
+// itself when it already exists (a rebuild on the same day or version),
+// otherwise the latest earlier build, otherwise the expanded directory.
+func PreviousOutputDir(template string, vars OutVars) string {
+	current := ExpandOut(template, vars)
+	if _, err := os.Stat(current); err == nil {
+		return current
+	}
+	if latest := LatestOutputDir(template, vars.Name); latest != template {
+		return latest
+	}
+	return current
+}
@@ -9,32 +9,116 @@ Your output must be a complete SKILL.md file with:
    - name: (provided, must match exactly)
    - description: (max 1024 chars, describe what the skill does and when to use it)
    - Any additional metadata fields provided (license, compatibility, metadata, allowed-tools)
+   - allowed-tools, when provided, copied verbatim as the given comma-separated list
 
 2. Markdown body (UNDER 500 lines) structured for progressive disclosure:
-   - ## Configuration — environment variables, authentication setup
+   - ## Configuration — environment variables, authentication setup (use the names under "Derived Context";
+     where it lists auth requirements, say which credentials must be sent together and which are alternatives)
    - ## Core Concepts — mental model for the tool
    - ## Key Operations — most important operations with brief usage
    - ## Value Formats — important data types and formats
-   - ## Best Practices — guardrails, conventions, common pitfalls
+   - ## Best Practices — guardrails, conventions, common pitfalls (state rate limits and
+     retry behavior only as listed under "Rate limits" in "Derived Context"; never invent a limit)
    - ## File References — pointers to references/ and scripts/ for details
 
 The body should be optimized for an AI agent to quickly understand and use the tool.
 Keep it concise but comprehensive. Use relative file references (e.g., references/reference.md).
 Do NOT include raw API specs — that goes in references/.
-Do NOT exceed 500 lines in the body.`
+Do NOT exceed 500 lines in the body.
+Operations and parameters may carry an "extensions" object of vendor x- fields
+(e.g. rate limits); use them where they inform configuration or best practices.`
 
 const ReferencePrompt = `You are generating a reference.md file — an exhaustive command/endpoint reference.
 
 Your output must be a complete markdown document listing EVERY operation with:
 - Full path/command syntax
-- All parameters, flags, arguments with types and descriptions
+- All parameters, flags, arguments with types and descriptions, grouped by
+  their "in" location (path, query, header, cookie) so an agent never sends a
+  header or cookie as a query parameter
+- Parameter constraints from each parameter's "constraints": list every enum
+  value exactly, plus format, minimum/maximum, length limits, pattern, and default
 - Request/response body shapes (for APIs)
+- Response headers: each response's "headers" with type and description
+  (e.g. Location, Link, rate-limit headers), under the status code they
+  come with
 - Error codes and their meanings
-- Authentication requirements
+- Authentication requirements: each operation's "auth" lists alternatives
+  (any one suffices) and the scheme IDs inside one alternative are all
+  required together; state such combinations exactly (e.g. "API key AND
+  OAuth token"). An empty alternative means anonymous access is allowed
+- Deprecation status: for deprecated operations and parameters, state the
+  deprecationNote (reason and replacement) and sunset date when present
+- Vendor extensions: document each operation's and parameter's "extensions"
+  (x- fields) alongside it
+
+In curl examples, substitute path parameters into the path, put query
+parameters in the URL query string, send header parameters with
+-H "Name: value", and send cookie parameters with --cookie "name=value".
 
 Organize by resource/domain area. Use consistent formatting.
 Be thorough — this is the complete reference an agent loads on demand.`
 
+// ReferenceSplitPrompt is appended to ReferencePrompt when the reference is
+// split into one file per group.
+const ReferenceSplitPrompt = `
+
+This reference is split into one file per group. The spec provided covers a
+single group: document ONLY its operations, starting with a level-1 heading
+naming the group. Other groups are documented in their own files.`
+
+// SkillSplitReferencePrompt is appended to SkillPrompt when the reference is
+// split into one file per group.
+const SkillSplitReferencePrompt = `
+
+The reference is split into one file per group. In ## File References, link
+every file listed under "Reference Files" instead of references/reference.md.`
+
+// SkillReferencePathsPrompt is appended to SkillPrompt when the reference
+// or examples are written in a format other than markdown; the arguments
+// are their paths relative to the skill directory.
+const SkillReferencePathsPrompt = `
+
+The reference is written to %s and the examples to %s. Link those
+paths in ## File References.`
+
+// MDXFormatPrompt is appended to an artifact's system prompt when it is
+// written as MDX. Frontmatter is added and braces are escaped afterwards.
+const MDXFormatPrompt = `
+
+Write the document as MDX for a docs site. Do not start with frontmatter; it
+is added afterwards. Use plain Markdown only: no raw HTML, no JSX components,
+and no import statements. Put paths, placeholders, and samples containing
+{ } or < > in inline code or fenced code blocks.`
+
+// AsciiDocFormatPrompt is appended to an artifact's system prompt when it is
+// written as AsciiDoc.
+const AsciiDocFormatPrompt = `
+
+Write the document as AsciiDoc, not Markdown: start with a "= Title" header,
+use "==" and "===" section titles wherever these instructions mention "#"
+headings, "[source,<lang>]" blocks delimited by "----" for code, and "|==="
+tables.`
+
+// LanguagePromptTemplate is appended to every artifact's system prompt when
+// the frontmatter sets a language other than English; the argument is the
+// language name.
+const LanguagePromptTemplate = `
+
+Write all prose in %s: descriptions, explanations, headings, and comments.
+Keep code, commands, paths, URLs, identifiers, parameter and field names,
+environment variables, and YAML keys in English, exactly as in the spec.`
+
+// ChunkPrompt is appended to the system prompt when a large artifact is
+// generated in batches of operations that are concatenated afterwards.
+const ChunkPrompt = `
+
+The spec is too large for one request, so this document is generated in parts
+that are concatenated in order. The spec provided holds only this part's
+operations. Document exactly those operations. Use "## <group name>" headings
+for groups and "### <METHOD> <path>" (or "### <command>") headings for
+operations so every part is formatted identically. Only part 1 starts with the
+document title and introduction; do not add a closing summary to any part.`
+
 const ExamplesPrompt = `You are generating an examples.md file — worked multi-step workflow examples.
 
 Your output must show realistic, end-to-end workflows that combine multiple operations.
@@ -46,7 +130,14 @@ Each example should:
 - Show expected responses/outputs
 
 Focus on the most common workflows agents would perform.
-Pull from any provided workflow descriptions, common patterns, and domain knowledge.`
+Pull from any provided workflow descriptions, common patterns, and domain knowledge.
+In requests, send header parameters as headers (curl -H) and cookie parameters
+as cookies (curl --cookie), never as query parameters.
+
+When "Recorded Examples" are provided, they are real captured interactions:
+build workflows around them and reuse their paths, request bodies, and
+responses verbatim. Use spec-derived or invented sample data only for
+operations without recordings.`
 
 const ScriptsPrompt = `You are generating executable shell scripts for a skill's scripts/ directory.
 
@@ -54,12 +145,15 @@ Generate as many useful scripts as you can identify from the spec and instructio
 Each script should:
 - Start with #!/bin/bash (or #!/bin/sh)
 - Have a comment header explaining: purpose, required env vars, usage
+- Use exactly the environment variable names given under "Derived Context"
+- Send each request body with the Content-Type and encoding listed under "Request bodies" (never default to JSON for form or multipart endpoints)
+- Send each parameter where its "in" says: path parameters in the path, query parameters in the URL query string, header parameters as -H "Name: value", and cookie parameters as --cookie "name=value"
 - Be directly executable by an agent
 - Combine multiple operations into single scripts where useful
 
 Types of scripts to generate:
-- health-check.sh: Validate connectivity and auth
-- discover.sh: List available resources
+- health-check.sh: Validate connectivity and auth (unless given under "Script Templates")
+- discover.sh: List available resources (unless given under "Script Templates")
 - Custom workflow scripts that save multi-step operations
 
 Output format: Output each script as a code block with the filename as the info string.
@@ -80,7 +174,8 @@ const LlmsTxtPrompt = `You are generating an llms.txt file — a brief product o
 Your output must be a concise description including:
 - What the tool/service does (1-2 sentences)
 - Key capabilities as bullet points
-- Links to other documentation files
+- Links to other documentation files, including the external documentation
+  under "Spec Info" when given
 
 This is the lightest-weight description for quick context.
 Target approximately 500 tokens total.`
@@ -93,6 +188,13 @@ Your output must include:
 - Common patterns (pagination, filtering, error handling)
 - Error codes table
 
+Operations with a "pagination" field declare their paging style (cursor, offset,
+page, or link) and the query params that drive it. Document exactly those
+schemes; if no operation declares pagination, do not invent one.
+
+For parameters with "constraints", give enum values verbatim (never guess an
+allowed set) and note formats such as date-time or uuid.
+
 Be concise but complete — every operation should appear.
 Target approximately 2000-4000 tokens.`
 
@@ -114,7 +216,9 @@ const ChangelogPrompt = `You are generating a CHANGELOG.md entry by comparing pr
 Generate a dated changelog entry with these sections (omit empty sections):
 ### Added — New operations, features, or capabilities
 ### Changed — Modified parameters, updated behavior, changed defaults
-### Deprecated — Operations or features marked for removal
+### Deprecated — Operations, parameters, or features marked for removal, with
+    each one's deprecationNote (replacement) and sunset date from the spec;
+    name each parameter with its operation
 ### Removed — Operations or features that no longer exist
 ### Instructions — Changes to guidance, workflows, or guardrails
 
@@ -124,7 +228,8 @@ If this is the first generation (no previous artifacts), create an "Initial gene
 const InitPrompt = `You are generating a COMPILER_INSTRUCTIONS.md file from a spec.
 
 Your output must be a complete COMPILER_INSTRUCTIONS.md with:
-1. YAML frontmatter (between --- delimiters) with the provided name and spec configuration
+1. YAML frontmatter (between --- delimiters) with the provided name and spec configuration,
+   plus "language: <language>" when a language is provided
 2. Markdown body with draft sections that the user should review and customize:
    - # Product — What the tool does, target users, key value props
    - # Workflows — Common multi-step workflows agents will perform
@@ -133,3 +238,15 @@ Your output must be a complete COMPILER_INSTRUCTIONS.md with:
 
 Base the draft content on what you can infer from the spec.
 Mark sections that need human review with <!-- REVIEW: ... --> comments.`
+
+const EnrichPrompt = `You are drafting missing descriptions for a single API operation.
+
+You will receive the operation (method, path, parameters, request body schema, responses)
+as JSON. Write a concise description (one or two sentences) for the operation if it has
+none, and for each listed parameter that lacks one.
+
+Respond with ONLY a JSON object, no code fences:
+{"description": "...", "parameters": {"<parameter name>": "..."}}
+
+Describe only what the method, path, names, and schema make evident. Do not invent
+behavior, side effects, limits, or permissions. Omit any field you cannot describe.`
@@ -0,0 +1,270 @@
+package generate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// scriptFile is a script sc writes itself rather than asking the model for.
+type scriptFile struct {
+	Name    string
+	Content string
+}
+
+// probeKeywords rank GET operations as health-check probes, best first.
+var probeKeywords = []string{"health", "ping", "status", "whoami", "me", "user", "version"}
+
+// maxDiscoverEndpoints caps the list endpoints discover.sh calls.
+const maxDiscoverEndpoints = 10
+
+// scriptTemplates renders health-check.sh and discover.sh from the spec's
+// auth schemes and base URL, so the scripts every skill depends on send
+// credentials correctly by construction. It is nil for specs without HTTP
+// operations, such as CLIs.
+func (p *Pipeline) scriptTemplates(spec *ir.IntermediateRepr) []scriptFile {
+	var gets []ir.Operation
+	hasHTTP := false
+	for _, op := range spec.Operations {
+		if op.Method == "" {
+			continue
+		}
+		hasHTTP = true
+		if strings.EqualFold(op.Method, "GET") && !op.Deprecated && !strings.Contains(op.Path, "{") && !hasRequiredInput(op) {
+			gets = append(gets, op)
+		}
+	}
+	if !hasHTTP {
+		return nil
+	}
+
+	prefix := p.Inst.EnvPrefix()
+	probe := probeOperation(gets)
+	schemes := probeSchemes(spec, probe)
+	header := scriptHeader(prefix, spec.Metadata["baseUrl"], schemes)
+
+	path := "/"
+	if probe != nil {
+		path = probe.Path
+	}
+	var health strings.Builder
+	health.WriteString("#!/bin/bash\n")
+	health.WriteString("# Purpose: Validate API connectivity and authentication\n")
+	fmt.Fprintf(&health, "# Env vars: %s\n", strings.Join(scriptEnv(prefix, schemes), ", "))
+	health.WriteString("# Usage: ./health-check.sh\n")
+	health.WriteString(header)
+	fmt.Fprintf(&health, "\nstatus=$(curl -sS -o /dev/null -w '%%{http_code}' \"${auth[@]}\" \"${base_url}%s\" || true)\n", path)
+	health.WriteString("case \"$status\" in\n")
+	fmt.Fprintf(&health, "  2??|3??) echo \"OK: GET %s returned $status\" ;;\n", path)
+	health.WriteString("  401|403) echo \"AUTH FAILED: GET " + path + " returned $status; check the credentials\" >&2; exit 1 ;;\n")
+	health.WriteString("  000) echo \"UNREACHABLE: could not connect to ${base_url}\" >&2; exit 1 ;;\n")
+	health.WriteString("  *) echo \"UNEXPECTED: GET " + path + " returned $status\" >&2; exit 1 ;;\n")
+	health.WriteString("esac\n")
+
+	var discover strings.Builder
+	discover.WriteString("#!/bin/bash\n")
+	discover.WriteString("# Purpose: List available resources by calling each collection endpoint\n")
+	fmt.Fprintf(&discover, "# Env vars: %s\n", strings.Join(scriptEnv(prefix, schemes), ", "))
+	discover.WriteString("# Usage: ./discover.sh\n")
+	discover.WriteString(header)
+	discover.WriteString("\nendpoints=(\n")
+	for i, op := range gets {
+		if i == maxDiscoverEndpoints {
+			break
+		}
+		fmt.Fprintf(&discover, "  %q\n", op.Path)
+	}
+	discover.WriteString(")\n")
+	discover.WriteString("for path in \"${endpoints[@]}\"; do\n")
+	discover.WriteString("  echo \"== GET ${path}\"\n")
+	discover.WriteString("  curl -sS \"${auth[@]}\" \"${base_url}${path}\" || echo \"(request failed)\"\n")
+	discover.WriteString("  echo\n")
+	discover.WriteString("done\n")
+
+	return []scriptFile{
+		{Name: "health-check.sh", Content: health.String()},
+		{Name: "discover.sh", Content: discover.String()},
+	}
+}
+
+// hasRequiredInput reports whether an operation needs a body or a required
+// non-path parameter, which a generic probe cannot supply.
+func hasRequiredInput(op ir.Operation) bool {
+	if op.RequestBody != nil && op.RequestBody.ContentType != "" {
+		return true
+	}
+	for _, param := range op.Parameters {
+		if param.Required && param.In != "path" {
+			return true
+		}
+	}
+	return false
+}
+
+// probeOperation picks the GET a health check should call: the first whose
+// path names a health or identity endpoint, else the first one.
+func probeOperation(gets []ir.Operation) *ir.Operation {
+	for _, keyword := range probeKeywords {
+		for i, op := range gets {
+			for _, segment := range strings.Split(strings.ToLower(op.Path), "/") {
+				if segment == keyword {
+					return &gets[i]
+				}
+			}
+		}
+	}
+	if len(gets) > 0 {
+		return &gets[0]
+	}
+	return nil
+}
+
+// probeSchemes returns the auth schemes to send with the probe: its first
+// alternative that needs credentials, or the spec's first scheme when the
+// operation declares none.
+func probeSchemes(spec *ir.IntermediateRepr, probe *ir.Operation) []ir.AuthScheme {
+	byID := make(map[string]ir.AuthScheme, len(spec.Auth))
+	for _, scheme := range spec.Auth {
+		byID[scheme.ID] = scheme
+	}
+	if probe != nil {
+		for _, ids := range probe.Auth {
+			if len(ids) == 0 {
+				continue
+			}
+			var schemes []ir.AuthScheme
+			for _, id := range ids {
+				if scheme, ok := byID[id]; ok {
+					schemes = append(schemes, scheme)
+				}
+			}
+			return schemes
+		}
+		if len(probe.Auth) > 0 {
+			return nil // anonymous only
+		}
+	}
+	if len(spec.Auth) > 0 {
+		return spec.Auth[:1]
+	}
+	return nil
+}
+
+// scriptEnv lists the env vars a script reads, in derivedContext's names.
+func scriptEnv(prefix string, schemes []ir.AuthScheme) []string {
+	env := []string{prefix + "_API_URL"}
+	for _, scheme := range schemes {
+		for _, name := range schemeEnv(prefix, scheme) {
+			if !slices.Contains(env, name) {
+				env = append(env, name)
+			}
+		}
+	}
+	return env
+}
+
+// schemeEnv names the env vars holding a scheme's credentials: _API_KEY for
+// API keys, _USERNAME and _PASSWORD for basic auth, and _TOKEN for bearer
+// tokens and every other scheme.
+func schemeEnv(prefix string, scheme ir.AuthScheme) []string {
+	switch {
+	case scheme.Type == "apiKey":
+		return []string{prefix + "_API_KEY"}
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+		return []string{prefix + "_USERNAME", prefix + "_PASSWORD"}
+	default:
+		return []string{prefix + "_TOKEN"}
+	}
+}
+
+// scriptHeader is the shared preamble: strict mode, the base URL, required
+// credentials, and an auth array of curl options for the schemes.
+func scriptHeader(prefix, baseURL string, schemes []ir.AuthScheme) string {
+	var b strings.Builder
+	b.WriteString("# Generated by sc from the spec's auth scheme and base URL.\n")
+	b.WriteString("set -euo pipefail\n\n")
+	if baseURL != "" && !strings.Contains(baseURL, "{") && strings.Contains(baseURL, "://") {
+		fmt.Fprintf(&b, "base_url=\"${%s_API_URL:-%s}\"\n", prefix, baseURL)
+	} else {
+		fmt.Fprintf(&b, "base_url=\"${%s_API_URL:?set %s_API_URL to the API base URL}\"\n", prefix, prefix)
+	}
+	b.WriteString("base_url=\"${base_url%/}\"\n")
+	for _, name := range scriptEnv(prefix, schemes)[1:] {
+		fmt.Fprintf(&b, ": \"${%s:?set %s}\"\n", name, name)
+	}
+
+	b.WriteString("auth=(")
+	var opts []string
+	for _, scheme := range schemes {
+		opts = append(opts, curlAuth(prefix, scheme))
+	}
+	b.WriteString(strings.Join(opts, " "))
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// curlAuth returns the curl options that send a scheme's credential.
+func curlAuth(prefix string, scheme ir.AuthScheme) string {
+	key := "${" + schemeEnv(prefix, scheme)[0] + "}"
+	switch {
+	case scheme.Type == "apiKey" && scheme.In == "query":
+		return fmt.Sprintf("--get --data-urlencode %q", scheme.Name+"="+key)
+	case scheme.Type == "apiKey" && scheme.In == "cookie":
+		return fmt.Sprintf("--cookie %q", scheme.Name+"="+key)
+	case scheme.Type == "apiKey":
+		return fmt.Sprintf("-H %q", scheme.Name+": "+key)
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+		return fmt.Sprintf("-u %q", "${"+prefix+"_USERNAME}:${"+prefix+"_PASSWORD}")
+	case scheme.Type == "http" && scheme.Scheme != "" && !strings.EqualFold(scheme.Scheme, "bearer"):
+		return fmt.Sprintf("-H %q", "Authorization: "+scheme.Scheme+" "+key)
+	default:
+		// bearer, oauth2, and openIdConnect all send a bearer token
+		return fmt.Sprintf("-H %q", "Authorization: Bearer "+key)
+	}
+}
+
+// scriptTemplatesContext tells the model which scripts sc writes itself and
+// shows them, so other scripts reuse their auth and base URL handling.
+func scriptTemplatesContext(templates []scriptFile) string {
+	parts := []string{"## Script Templates\nsc writes these scripts exactly as shown; do not output them. Handle the base URL and auth in every other script the same way."}
+	for _, s := range templates {
+		parts = append(parts, "```"+s.Name+"\n"+s.Content+"```")
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// withScriptTemplates replaces any scripts the model wrote under the
+// templates' names with the templates themselves.
+func withScriptTemplates(content string, templates []scriptFile) string {
+	names := make(map[string]bool, len(templates))
+	for _, s := range templates {
+		names[s.Name] = true
+	}
+
+	var kept []string
+	skipping, inBlock := false, false
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case strings.HasPrefix(line, "```") && !inBlock:
+			inBlock = true
+			skipping = names[strings.TrimSpace(strings.TrimPrefix(line, "```"))]
+		case line == "```" && inBlock:
+			inBlock = false
+			if skipping {
+				skipping = false
+				continue
+			}
+		}
+		if !skipping {
+			kept = append(kept, line)
+		}
+	}
+
+	out := strings.TrimSpace(strings.Join(kept, "\n"))
+	for _, s := range templates {
+		out += "\n\n```" + s.Name + "\n" + s.Content + "```"
+	}
+	return strings.TrimSpace(out) + "\n"
+}
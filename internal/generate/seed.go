@@ -0,0 +1,140 @@
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// SeedExample is a recorded request/response interaction used as ground
+// truth for examples.md. Bodies are any JSON value; a string is sent as-is.
+type SeedExample struct {
+	Method       string `json:"method"`
+	Path         string `json:"path"` // concrete (/pets/42) or templated (/pets/{id}); a query string is allowed
+	Status       int    `json:"status,omitempty"`
+	RequestBody  any    `json:"requestBody,omitempty"`
+	ResponseBody any    `json:"responseBody,omitempty"`
+}
+
+// LoadSeedExamples reads recorded interactions from a JSON array or a JSONL
+// file with one interaction per line. Blank lines are ignored.
+func LoadSeedExamples(path string) ([]SeedExample, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var seeds []SeedExample
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &seeds); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, len(data)+1)
+		for n := 1; scanner.Scan(); n++ {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var seed SeedExample
+			if err := json.Unmarshal(line, &seed); err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", path, n, err)
+			}
+			seeds = append(seeds, seed)
+		}
+	}
+	for i, seed := range seeds {
+		if seed.Method == "" || seed.Path == "" {
+			return nil, fmt.Errorf("%s: example %d needs a method and path", path, i+1)
+		}
+	}
+	return seeds, nil
+}
+
+// MatchSeedExamples groups seeds by the operation they exercise, matched on
+// method and path; path template segments such as {id} match any value.
+// Seeds matching no operation are returned separately.
+func MatchSeedExamples(ops []ir.Operation, seeds []SeedExample) (matched map[string][]SeedExample, unmatched []SeedExample) {
+	matched = make(map[string][]SeedExample)
+	for _, seed := range seeds {
+		op, ok := seedOperation(ops, seed)
+		if !ok {
+			unmatched = append(unmatched, seed)
+			continue
+		}
+		matched[op.ID] = append(matched[op.ID], seed)
+	}
+	return matched, unmatched
+}
+
+// seedOperation finds the operation a seed exercises, preferring a literal
+// path match over a templated one (/pets/mine over /pets/{id}).
+func seedOperation(ops []ir.Operation, seed SeedExample) (ir.Operation, bool) {
+	path := seed.Path
+	if u, err := url.Parse(path); err == nil && u.Path != "" {
+		path = u.Path
+	}
+	var best ir.Operation
+	bestLiteral := -1
+	for _, op := range ops {
+		if !strings.EqualFold(op.Method, seed.Method) {
+			continue
+		}
+		if literal, ok := matchPathTemplate(op.Path, path); ok && literal > bestLiteral {
+			best, bestLiteral = op, literal
+		}
+	}
+	return best, bestLiteral >= 0
+}
+
+// matchPathTemplate reports whether path fits template and how many
+// segments matched literally.
+func matchPathTemplate(template, path string) (int, bool) {
+	tsegs := strings.Split(strings.Trim(template, "/"), "/")
+	psegs := strings.Split(strings.Trim(path, "/"), "/")
+	if len(tsegs) != len(psegs) {
+		return 0, false
+	}
+	literal := 0
+	for i, t := range tsegs {
+		switch {
+		case strings.HasPrefix(t, "{") && strings.HasSuffix(t, "}"):
+		case t == psegs[i]:
+			literal++
+		default:
+			return 0, false
+		}
+	}
+	return literal, true
+}
+
+// seedExamples renders the recorded interactions for examples.md, grouped
+// by operation in spec order. It is empty when no seed matches.
+func (p *Pipeline) seedExamples(id ArtifactID, spec *ir.IntermediateRepr) string {
+	if id != ArtifactExamples || len(p.Opts.SeedExamples) == 0 {
+		return ""
+	}
+	matched, _ := MatchSeedExamples(spec.Operations, p.Opts.SeedExamples)
+	if len(matched) == 0 {
+		return ""
+	}
+	parts := []string{"## Recorded Examples\nReal interactions captured from the API. Treat them as ground truth: prefer their paths, values, and response shapes over invented data."}
+	for _, op := range spec.Operations {
+		seeds := matched[op.ID]
+		if len(seeds) == 0 {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("### %s (%s %s)", op.ID, op.Method, op.Path))
+		for _, seed := range seeds {
+			data, _ := json.MarshalIndent(seed, "", "  ")
+			parts = append(parts, "```json\n"+string(data)+"\n```")
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,153 @@
+package generate
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+)
+
+// FS is the filesystem a build reads previous outputs from and writes new
+// ones to. OSFS is the default; a MemorySink keeps everything in memory.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	MkdirAll(path string, perm os.FileMode) error
+}
+
+// OSFS is the operating system's filesystem.
+type OSFS struct{}
+
+// ReadFile implements FS.
+func (OSFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+// WriteFile implements FS. The data goes to a temporary file beside name
+// that is then renamed over it, so an interrupted build never leaves a
+// truncated file.
+func (OSFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// MkdirAll implements FS.
+func (OSFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+// Sink receives the files produced by WriteResultsTo. Paths are relative to
+// the output directory.
+type Sink interface {
+	WriteFile(path string, data []byte, perm os.FileMode) error
+}
+
+// DirSink writes files beneath a directory, creating parents as needed.
+type DirSink string
+
+// WriteFile implements Sink.
+func (d DirSink) WriteFile(path string, data []byte, perm os.FileMode) error {
+	return FSSink{FS: OSFS{}, Dir: string(d)}.WriteFile(path, data, perm)
+}
+
+// ErrUnsafePath is returned for a path that would leave the output
+// directory; see instructions.CheckPath.
+var ErrUnsafePath = instructions.ErrUnsafePath
+
+// FSSink writes files beneath Dir in FS, creating parents as needed. Paths
+// that would leave Dir are rejected; see instructions.CheckPath.
+type FSSink struct {
+	FS  FS
+	Dir string
+}
+
+// WriteFile implements Sink.
+func (s FSSink) WriteFile(path string, data []byte, perm os.FileMode) error {
+	if err := instructions.CheckPath(path); err != nil {
+		return err
+	}
+	fullPath := filepath.Join(s.Dir, path)
+	if err := s.FS.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return err
+	}
+	return s.FS.WriteFile(fullPath, data, perm)
+}
+
+// TeeSink writes each file to every sink in turn, stopping at the first error.
+type TeeSink []Sink
+
+// WriteFile implements Sink.
+func (t TeeSink) WriteFile(path string, data []byte, perm os.FileMode) error {
+	for _, s := range t {
+		if err := s.WriteFile(path, data, perm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MemorySink collects files in memory, e.g. for piping to stdout. It is
+// also an FS whose directories exist implicitly, for builds that should
+// not touch the disk.
+type MemorySink struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemorySink returns an empty MemorySink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{files: make(map[string][]byte)}
+}
+
+// WriteFile implements Sink. Later writes to the same path replace earlier ones.
+func (m *MemorySink) WriteFile(path string, data []byte, _ os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[filepath.ToSlash(path)] = append([]byte(nil), data...)
+	return nil
+}
+
+// Paths returns the written paths in sorted order.
+func (m *MemorySink) Paths() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	paths := make([]string, 0, len(m.files))
+	for path := range m.files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// File returns the contents written to path.
+func (m *MemorySink) File(path string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[path]
+	return data, ok
+}
+
+// ReadFile implements FS.
+func (m *MemorySink) ReadFile(name string) ([]byte, error) {
+	data, ok := m.File(filepath.ToSlash(name))
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// MkdirAll implements FS; directories need not be created.
+func (m *MemorySink) MkdirAll(string, os.FileMode) error { return nil }
@@ -0,0 +1,97 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/provider"
+)
+
+// referenceFile is one per-group file of a split reference.
+type referenceFile struct {
+	Group ir.Group
+	Name  string // file name within references/, e.g. "pets.md" or "pets.mdx"
+	Path  string // relative to the output dir
+}
+
+// referenceFiles lists the per-group files for an artifact configured with
+// split: by-group, in IR group order. It returns nil for single-file output,
+// including when the IR has no groups to split by.
+func (p *Pipeline) referenceFiles(id ArtifactID) []referenceFile {
+	if id != ArtifactReference || p.IR == nil || len(p.IR.Groups) == 0 {
+		return nil
+	}
+	if p.Inst.Frontmatter.Artifacts[string(id)].Split != instructions.SplitByGroup {
+		return nil
+	}
+
+	used := make(map[string]bool)
+	files := make([]referenceFile, 0, len(p.IR.Groups))
+	for _, g := range p.IR.Groups {
+		base := slugify(g.Name)
+		if base == "" {
+			base = "group"
+		}
+		slug := base
+		for n := 2; used[slug]; n++ {
+			slug = fmt.Sprintf("%s-%d", base, n)
+		}
+		used[slug] = true
+		name := slug + formatExt(p.outputFormat(id))
+		files = append(files, referenceFile{
+			Group: g,
+			Name:  name,
+			Path:  filepath.Join(p.skillDir(), "references", name),
+		})
+	}
+	return files
+}
+
+// generateSplit generates one file per group, each from the IR subset holding
+// that group's operations. Content joins the parts for caching and display.
+func (p *Pipeline) generateSplit(ctx context.Context, id ArtifactID, files []referenceFile) ArtifactResult {
+	p.logf("  Generating %s (%d files)...\n", id, len(files))
+
+	result := ArtifactResult{ID: id, FilePath: p.artifactPath(id)}
+	total := &provider.GenerateResponse{}
+	contents := make([]string, 0, len(files))
+	systemPrompt := p.systemPrompt(id)
+	for _, f := range files {
+		userMessage := p.userMessageFor(id, p.IR.Subset(f.Group.Operations))
+		resp, err := p.call(ctx, id, f.Path, systemPrompt, userMessage)
+		if err != nil {
+			result.Err = fmt.Errorf("%s: %w", f.Path, err)
+			return result
+		}
+		result.Parts = append(result.Parts, ArtifactPart{FilePath: f.Path, Content: resp.Content})
+		contents = append(contents, resp.Content)
+		total.Provider, total.Model = resp.Provider, resp.Model
+		total.TokensIn += resp.TokensIn
+		total.TokensOut += resp.TokensOut
+	}
+	result.Content = strings.Join(contents, "\n\n")
+	result.Response = total
+	return result
+}
+
+// slugify lowercases s and collapses runs of non-alphanumerics into "-".
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,110 @@
+package generate
+
+import (
+	"strings"
+)
+
+// rawFileArtifacts are written as the model returns them, so a code fence
+// the model wraps around the whole file would end up in it. None of them
+// starts with a fence of its own: the skill opens with frontmatter and the
+// llms files with a heading.
+var rawFileArtifacts = map[ArtifactID]bool{
+	ArtifactSkill:    true,
+	ArtifactLlms:     true,
+	ArtifactLlmsFull: true,
+	ArtifactLlmsAPI:  true,
+}
+
+// trailingBoilerplate opens the closing remarks models add after the
+// requested document, lowercased.
+var trailingBoilerplate = []string{
+	"let me know",
+	"i hope this",
+	"hope this helps",
+	"feel free to",
+	"if you'd like",
+	"if you would like",
+	"would you like me",
+	"is there anything",
+	"i can also",
+	"happy to help",
+}
+
+// trimResponse strips what models add around the document they were asked
+// for: closing remarks after it and, for raw file artifacts, a fence
+// around the whole of it.
+func trimResponse(id ArtifactID, content string) string {
+	content = trimTrailingBoilerplate(content)
+	if rawFileArtifacts[id] {
+		content = unwrapFence(content)
+	}
+	return content
+}
+
+// trimTrailingBoilerplate drops closing paragraphs that open with one of
+// trailingBoilerplate, and a horizontal rule left before them. Paragraphs
+// inside code fences are kept.
+func trimTrailingBoilerplate(content string) string {
+	trimmed := strings.TrimRight(content, " \t\n")
+	for {
+		i := strings.LastIndex(trimmed, "\n\n")
+		if i < 0 || strings.Count(trimmed[:i], "```")%2 != 0 {
+			break
+		}
+		last := strings.ToLower(strings.TrimSpace(trimmed[i:]))
+		last = strings.TrimLeft(last, "*_> ")
+		if !hasAnyPrefix(last, trailingBoilerplate) {
+			break
+		}
+		trimmed = strings.TrimRight(trimmed[:i], " \t\n")
+		if rest, ok := strings.CutSuffix(trimmed, "\n---"); ok {
+			trimmed = strings.TrimRight(rest, " \t\n")
+		}
+	}
+	if len(trimmed) == len(strings.TrimRight(content, " \t\n")) {
+		return content
+	}
+	return trimmed + "\n"
+}
+
+// unwrapFence removes a code fence around the whole content, such as
+// "```markdown" ... "```". A missing closing fence, as when the output
+// token limit cut the response off, leaves the opening one to remove.
+func unwrapFence(content string) string {
+	trimmed := strings.TrimSpace(content)
+	first, body, ok := strings.Cut(trimmed, "\n")
+	if !ok || !isWrappingFence(first) {
+		return content
+	}
+	fence := first[:3]
+	if i := strings.LastIndex(body, "\n"); strings.TrimSpace(body[i+1:]) == fence {
+		if i < 0 {
+			return ""
+		}
+		body = body[:i]
+	}
+	return strings.TrimSpace(body) + "\n"
+}
+
+// isWrappingFence reports whether line opens a fence around a whole
+// markdown or text file: ``` or ~~~, optionally tagged as such.
+func isWrappingFence(line string) bool {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "```") && !strings.HasPrefix(line, "~~~") {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(line[3:])) {
+	case "", "markdown", "md", "text", "txt":
+		return true
+	}
+	return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+// Package gittarget commits build outputs to a git branch through a
+// temporary worktree, leaving the caller's working tree, index, and checked
+// out branch untouched. It also reports which build inputs changed since a
+// commit.
+package gittarget
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrNotRepository is returned when the directory is not inside a git
+// repository.
+var ErrNotRepository = errors.New("not a git repository")
+
+// Target is a branch build outputs are committed to.
+type Target struct {
+	Branch string
+	// Remote, when set, is pushed the branch after each commit.
+	Remote string
+}
+
+// Publish commits the contents of dirs to the target branch, creating the
+// branch (with no history) if it does not exist. Each dir must lie inside
+// the repository containing repoDir and keeps its path there; its previous
+// contents on the branch are replaced. It returns the new commit, or "" when
+// the branch already matched.
+func Publish(ctx context.Context, repoDir string, t Target, dirs []string, message string) (string, error) {
+	if t.Branch == "" {
+		return "", fmt.Errorf("no branch given")
+	}
+	root, err := Root(ctx, repoDir)
+	if err != nil {
+		return "", err
+	}
+	if _, err := git(ctx, root, "check-ref-format", "--branch", t.Branch); err != nil {
+		return "", fmt.Errorf("invalid branch name %q", t.Branch)
+	}
+
+	rels := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return "", err
+		}
+		if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+			abs = resolved
+		}
+		rel, err := filepath.Rel(root, abs)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("output directory %s must be a subdirectory of the repository %s", dir, root)
+		}
+		rels = append(rels, rel)
+	}
+
+	worktree, err := os.MkdirTemp("", "sc-git-target-")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_, _ = git(context.Background(), root, "worktree", "remove", "--force", worktree)
+		_ = os.RemoveAll(worktree)
+	}()
+
+	ref := "refs/heads/" + t.Branch
+	previous, err := git(ctx, root, "rev-parse", "--verify", "--quiet", ref)
+	exists := err == nil
+	if exists {
+		if _, err := git(ctx, root, "worktree", "add", "--detach", worktree, previous); err != nil {
+			return "", err
+		}
+	} else {
+		if _, err := git(ctx, root, "worktree", "add", "--detach", worktree); err != nil {
+			return "", err
+		}
+		if _, err := git(ctx, worktree, "checkout", "--quiet", "--orphan", t.Branch); err != nil {
+			return "", err
+		}
+		if _, err := git(ctx, worktree, "rm", "-r", "-f", "--quiet", "--ignore-unmatch", "."); err != nil {
+			return "", err
+		}
+	}
+
+	for i, rel := range rels {
+		dst := filepath.Join(worktree, rel)
+		if err := os.RemoveAll(dst); err != nil {
+			return "", err
+		}
+		if err := copyTree(dirs[i], dst); err != nil {
+			return "", fmt.Errorf("copying %s: %w", dirs[i], err)
+		}
+	}
+	// Outputs are often gitignored on the source branch
+	if _, err := git(ctx, worktree, append([]string{"add", "-A", "-f", "--"}, rels...)...); err != nil {
+		return "", err
+	}
+	if _, err := git(ctx, worktree, "diff", "--cached", "--quiet"); err == nil && exists {
+		return "", nil
+	}
+	if _, err := git(ctx, worktree, "commit", "--quiet", "--allow-empty", "-m", message); err != nil {
+		return "", err
+	}
+	commit, err := git(ctx, worktree, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		// The worktree is detached, so move the branch to the new commit,
+		// failing if it moved meanwhile
+		if _, err := git(ctx, root, "update-ref", ref, commit, previous); err != nil {
+			return "", err
+		}
+	}
+	if t.Remote != "" {
+		if _, err := git(ctx, root, "push", "--quiet", t.Remote, ref+":"+ref); err != nil {
+			return commit, fmt.Errorf("pushing to %s: %w", t.Remote, err)
+		}
+	}
+	return commit, nil
+}
+
+// Root returns the top directory of the repository containing dir, or
+// ErrNotRepository.
+func Root(ctx context.Context, dir string) (string, error) {
+	root, err := git(ctx, dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", dir, ErrNotRepository)
+	}
+	return root, nil
+}
+
+// Changed returns the paths, relative to the repository root, that differ
+// in the working tree from the commit ref, including staged, unstaged, and
+// untracked changes. paths are files or directories inside the repository
+// containing dir; relative ones resolve against dir.
+func Changed(ctx context.Context, dir, ref string, paths []string) ([]string, error) {
+	if _, err := Root(ctx, dir); err != nil {
+		return nil, err
+	}
+	if _, err := git(ctx, dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown commit %q", ref)
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+	pathspec := append([]string{"--"}, paths...)
+	diff, err := git(ctx, dir, append([]string{"diff", "--name-only", "-z", ref}, pathspec...)...)
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := git(ctx, dir, append([]string{"ls-files", "--others", "--exclude-standard", "--full-name", "-z"}, pathspec...)...)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, name := range strings.Split(diff+"\x00"+untracked, "\x00") {
+		if name != "" && !slices.Contains(changed, name) {
+			changed = append(changed, name)
+		}
+	}
+	slices.Sort(changed)
+	return changed, nil
+}
+
+// git runs a git command in dir and returns its trimmed stdout. Errors quote
+// git's stderr.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// copyTree copies the regular files under src to dst, keeping their modes.
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode().Perm())
+	})
+}
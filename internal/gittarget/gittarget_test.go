@@ -0,0 +1,136 @@
+package gittarget
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testRepo(t *testing.T) string {
+	t.Helper()
+	for _, kv := range [][2]string{
+		{"GIT_AUTHOR_NAME", "test"}, {"GIT_AUTHOR_EMAIL", "test@example.com"},
+		{"GIT_COMMITTER_NAME", "test"}, {"GIT_COMMITTER_EMAIL", "test@example.com"},
+		{"GIT_CONFIG_GLOBAL", os.DevNull}, {"GIT_CONFIG_NOSYSTEM", "1"},
+	} {
+		t.Setenv(kv[0], kv[1])
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("out/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"init", "-q", "-b", "main"}, {"add", "."}, {"commit", "-q", "-m", "init"}} {
+		if _, err := git(context.Background(), dir, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	return dir
+}
+
+func TestPublish(t *testing.T) {
+	ctx := context.Background()
+	repo := testRepo(t)
+	out := filepath.Join(repo, "out")
+	if err := os.MkdirAll(filepath.Join(out, "pets"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(out, "pets", "SKILL.md"), []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	target := Target{Branch: "skills"}
+
+	first, err := Publish(ctx, repo, target, []string{out}, "Update pets skill\n")
+	if err != nil || first == "" {
+		t.Fatalf("Publish = %q, %v", first, err)
+	}
+	if got, _ := git(ctx, repo, "show", "skills:out/pets/SKILL.md"); got != "v1" {
+		t.Errorf("skills:out/pets/SKILL.md = %q, want v1", got)
+	}
+	if got, _ := git(ctx, repo, "ls-tree", "--name-only", "skills"); got != "out" {
+		t.Errorf("a new branch should hold only the outputs, got %q", got)
+	}
+	if got, _ := git(ctx, repo, "branch", "--show-current"); got != "main" {
+		t.Errorf("current branch = %q, want main left checked out", got)
+	}
+
+	// Unchanged outputs make no commit; changed ones extend the branch
+	if again, err := Publish(ctx, repo, target, []string{out}, "Update pets skill\n"); err != nil || again != "" {
+		t.Errorf("Publish of unchanged outputs = %q, %v, want no commit", again, err)
+	}
+	if err := os.WriteFile(filepath.Join(out, "pets", "SKILL.md"), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := Publish(ctx, repo, target, []string{out}, "Update pets skill\n")
+	if err != nil || second == "" {
+		t.Fatalf("Publish = %q, %v", second, err)
+	}
+	if parent, _ := git(ctx, repo, "rev-parse", "skills~1"); parent != first {
+		t.Errorf("skills~1 = %s, want the first commit %s", parent, first)
+	}
+	if got, _ := git(ctx, repo, "worktree", "list"); strings.Count(got, "\n") != 0 {
+		t.Errorf("the temporary worktree should be removed, got:\n%s", got)
+	}
+}
+
+func TestPublish_Errors(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	outside := t.TempDir()
+	if _, err := Publish(ctx, outside, Target{Branch: "skills"}, []string{outside}, "m"); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Publish outside a repository = %v, want ErrNotRepository", err)
+	}
+
+	repo := testRepo(t)
+	if _, err := Publish(ctx, repo, Target{Branch: "skills"}, []string{t.TempDir()}, "m"); err == nil {
+		t.Error("Publish of a directory outside the repository should fail")
+	}
+}
+
+func TestChanged(t *testing.T) {
+	ctx := context.Background()
+	repo := testRepo(t)
+	for _, name := range []string{"spec.yaml", "other.yaml"} {
+		if err := os.WriteFile(filepath.Join(repo, name), []byte("v1"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"add", "."}, {"commit", "-q", "-m", "specs"}} {
+		if _, err := git(ctx, repo, args...); err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+	}
+	base, _ := git(ctx, repo, "rev-parse", "HEAD")
+	paths := []string{"spec.yaml", filepath.Join(repo, "docs")}
+
+	if changed, err := Changed(ctx, repo, base, paths); err != nil || len(changed) != 0 {
+		t.Errorf("Changed with nothing changed = %q, %v", changed, err)
+	}
+
+	// An unrelated change is ignored; committed and untracked changes count
+	if err := os.WriteFile(filepath.Join(repo, "other.yaml"), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "spec.yaml"), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := git(ctx, repo, "commit", "-q", "-am", "update"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(repo, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "docs", "guide.md"), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := Changed(ctx, repo, base, paths)
+	if want := "docs/guide.md spec.yaml"; err != nil || strings.Join(changed, " ") != want {
+		t.Errorf("Changed = %q, %v, want %q", changed, err, want)
+	}
+
+	if _, err := Changed(ctx, repo, "no-such-ref", paths); err == nil || !strings.Contains(err.Error(), "unknown commit") {
+		t.Errorf("Changed with an unknown ref = %v", err)
+	}
+}
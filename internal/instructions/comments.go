@@ -0,0 +1,72 @@
+package instructions
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ReviewMarker is an unresolved <!-- REVIEW: ... --> comment, as left by
+// `sc init` for a human to address before building.
+type ReviewMarker struct {
+	Section string // H1 heading the marker sits under; empty before the first
+	Text    string // first line of the marker's text
+}
+
+var (
+	htmlComment  = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+	reviewPrefix = regexp.MustCompile(`(?i)^REVIEW\b:?\s*`)
+)
+
+// findReviewMarkers lists the REVIEW comments in body in document order.
+func findReviewMarkers(body string) []ReviewMarker {
+	var markers []ReviewMarker
+	for _, m := range htmlComment.FindAllStringSubmatchIndex(body, -1) {
+		inner := strings.TrimSpace(body[m[2]:m[3]])
+		loc := reviewPrefix.FindStringIndex(inner)
+		if loc == nil {
+			continue
+		}
+		text, _, _ := strings.Cut(inner[loc[1]:], "\n")
+		markers = append(markers, ReviewMarker{
+			Section: headingBefore(body[:m[0]]),
+			Text:    strings.TrimSpace(text),
+		})
+	}
+	return markers
+}
+
+// headingBefore returns the last H1 heading in text.
+func headingBefore(text string) string {
+	lines := strings.Split(text, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		if strings.HasPrefix(lines[i], "# ") {
+			return strings.TrimSpace(lines[i][2:])
+		}
+	}
+	return ""
+}
+
+// stripComments removes HTML comments so authoring notes never reach the
+// model. A comment alone on its lines is removed with those lines.
+func stripComments(s string) string {
+	if !strings.Contains(s, "<!--") {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, m := range htmlComment.FindAllStringIndex(s, -1) {
+		start, end := m[0], m[1]
+		lineStart := strings.LastIndexByte(s[:start], '\n') + 1
+		lineEnd := len(s)
+		if i := strings.IndexByte(s[end:], '\n'); i >= 0 {
+			lineEnd = end + i + 1
+		}
+		if lineStart >= last && strings.TrimSpace(s[lineStart:start]) == "" && strings.TrimSpace(s[end:lineEnd]) == "" {
+			start, end = lineStart, lineEnd
+		}
+		b.WriteString(s[last:start])
+		last = end
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
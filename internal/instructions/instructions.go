@@ -1,10 +1,16 @@
 package instructions
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/roberthamel/skill-compiler/internal/diag"
 	"gopkg.in/yaml.v3"
 )
 
@@ -13,26 +19,140 @@ type Instructions struct {
 	Frontmatter Frontmatter
 	Sections    map[string]string // H1 heading -> content
 	RawBody     string
+	// Reviews are the REVIEW comments found in the body; comments are
+	// stripped from Sections and RawBody.
+	Reviews []ReviewMarker
+	// Dir is the instructions file's directory, against which relative spec
+	// paths resolve. Empty means the working directory (e.g. for stdin).
+	Dir string
 }
 
 // Frontmatter holds all YAML frontmatter fields.
 type Frontmatter struct {
 	Name      string              `yaml:"name"`
 	Spec      yaml.Node           `yaml:"spec"`      // string, object, or array
-	Out       string              `yaml:"out"`       // default: ./sc-out/
+	Out       string              `yaml:"out"`       // default: ./sc-out/; may use {name}, {date}, {version}
 	Artifacts map[string]Artifact `yaml:"artifacts"` // per-artifact toggles
 	Skill     SkillConfig         `yaml:"skill"`
 	Provider  ProviderConfig      `yaml:"provider"`
+	// ArtifactsDefault is the baseline for artifacts without an explicit
+	// enabled toggle: "enabled" (default) or "disabled".
+	ArtifactsDefault string `yaml:"artifacts-default,omitempty"`
+	// EmptySections controls what happens when every section mapped to an
+	// artifact is empty: "fallback" (default), "skip", or "docs", which
+	// first fills empty sections from a codebase source's docs.
+	EmptySections string `yaml:"empty-sections,omitempty"`
+	// EmptySpec controls what happens when the spec sources yield no
+	// operations, types, or codebase structure: "skip" (default) warns and
+	// skips the artifacts built from operations, and "error" fails.
+	EmptySpec string `yaml:"empty-spec,omitempty"`
+	// Extensions lists the spec's vendor "x-" extensions surfaced in the
+	// reference and SKILL.md prompts; a trailing "*" matches a prefix. All
+	// others are kept out of prompts.
+	Extensions []string `yaml:"extensions,omitempty"`
+	// Variant lists the active variant tags (comma-separated) that select
+	// <!-- if:tag --> blocks in the body; see stripConditionals.
+	Variant string `yaml:"variant,omitempty"`
+	// Skills builds several related skills from one file; see Instructions.Skills.
+	Skills []SkillEntry `yaml:"skills,omitempty"`
+	// Version is the instructions format version; files without one predate
+	// versioning. sc upgrade-instructions sets it to CurrentVersion.
+	Version int `yaml:"version,omitempty"`
+	// Language is the language generated prose is written in, as a code
+	// such as "es" or a name such as "Spanish". Code, paths, and identifiers
+	// stay in English. Empty means English.
+	Language string `yaml:"language,omitempty"`
+	// Mode is how the skill is laid out: "single" (default) or "per-group",
+	// which builds a mini-skill per IR group under an index SKILL.md.
+	Mode string `yaml:"mode,omitempty"`
+	// FileHeader is a notice, such as a license header, put at the top of
+	// every generated markdown file and script; see Instructions.FileHeader.
+	FileHeader string `yaml:"file-header,omitempty"`
+	// Extra holds frontmatter keys sc does not know, such as other tools'
+	// metadata. They are kept as parsed and re-emitted when the frontmatter
+	// is marshaled, so rewriting the file does not drop them.
+	Extra map[string]yaml.Node `yaml:",inline"`
 }
 
+// SkillEntry is one skill in a multi-skill instructions file. Sections are
+// keyed by H1 heading and override the shared markdown body's sections.
+type SkillEntry struct {
+	Name      string              `yaml:"name"`
+	Spec      yaml.Node           `yaml:"spec"`
+	Sections  map[string]string   `yaml:"sections,omitempty"`
+	Artifacts map[string]Artifact `yaml:"artifacts,omitempty"`
+}
+
+// Layouts for Frontmatter.Mode.
+const (
+	ModeSingle   = "single"
+	ModePerGroup = "per-group"
+)
+
+// Baselines for Frontmatter.ArtifactsDefault.
+const (
+	ArtifactsEnabled  = "enabled"
+	ArtifactsDisabled = "disabled"
+)
+
+// ArtifactEnabled reports whether the named artifact should be generated:
+// its explicit enabled toggle if set, otherwise the artifacts-default baseline.
+func (fm Frontmatter) ArtifactEnabled(name string) bool {
+	if toggle, ok := fm.Artifacts[name]; ok && toggle.Enabled != nil {
+		return *toggle.Enabled
+	}
+	return fm.ArtifactsDefault != ArtifactsDisabled
+}
+
+// ExtensionSurfaced reports whether the named vendor extension is listed in
+// Extensions, exactly or by a trailing-"*" prefix pattern.
+func (fm Frontmatter) ExtensionSurfaced(name string) bool {
+	for _, pattern := range fm.Extensions {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Policies for artifacts whose mapped instruction sections are all empty.
+const (
+	EmptySectionsFallback = "fallback" // send the full markdown body instead
+	EmptySectionsSkip     = "skip"     // warn and skip the artifact
+	EmptySectionsDocs     = "docs"     // derive empty sections from the project docs, else fall back
+)
+
+// Policies for spec sources that yield an empty IR.
+const (
+	EmptySpecSkip  = "skip"  // warn and skip the artifacts built from operations
+	EmptySpecError = "error" // fail the build
+)
+
 // SpecSource represents a resolved spec source.
 type SpecSource struct {
 	// For file paths
 	Path string `yaml:"path,omitempty"`
 	// For URLs
 	URL string `yaml:"url,omitempty"`
-	// For shell commands
+	// Version selects one of several published versions, e.g. "2024-06-01".
+	// It fills a {version} placeholder in Path or URL; without one, Path
+	// must be a directory and its entry named Version is used. "latest"
+	// picks the entry that sorts last.
+	Version string `yaml:"version,omitempty"`
+	// Headers and Auth apply to URL fetches; values may reference ${ENV} vars
+	Headers map[string]string `yaml:"headers,omitempty"`
+	Auth    *SpecAuth         `yaml:"auth,omitempty"`
+	// Offline is set by the registry when network access is forbidden
+	Offline bool `yaml:"-"`
+	// CacheDir is set by the registry to cache URL fetches; empty means none
+	CacheDir string `yaml:"-"`
+	// For shell commands: stdout is parsed by the plugin named by Type
 	Command string `yaml:"command,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"` // e.g. "30s"; default 2m
 	// Type: openapi, cli, codebase
 	Type string `yaml:"type,omitempty"`
 	// CLI-specific
@@ -43,14 +163,84 @@ type SpecSource struct {
 	// Codebase-specific
 	MaxFiles int      `yaml:"max-files,omitempty"`
 	Include  []string `yaml:"include,omitempty"`
+	// DocsGlobs selects the docs included verbatim in prompts (default:
+	// README*, CONTRIBUTING.md, AGENTS.md, CLAUDE.md, docs/**/*.md);
+	// DocsMaxBytes caps their combined size (default 100000).
+	DocsGlobs    []string `yaml:"docs-globs,omitempty"`
+	DocsMaxBytes int      `yaml:"docs-max-bytes,omitempty"`
+	// Annotations names a style of API annotations in code comments to
+	// extract operations from; only "swaggo" is supported.
+	Annotations string `yaml:"annotations,omitempty"`
+	// SortParameters sorts each operation's parameters by location and
+	// name instead of keeping the spec's order. Positional CLI arguments
+	// keep their order.
+	SortParameters bool `yaml:"sort-parameters,omitempty"`
+}
+
+// SpecAuth holds credentials for fetching a URL spec source. Set either
+// Bearer or Username/Password.
+type SpecAuth struct {
+	Bearer   string `yaml:"bearer,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+}
+
+// String describes the source for logs and error messages.
+func (s SpecSource) String() string {
+	var where string
+	switch {
+	case s.Path != "":
+		where = s.Path
+	case s.URL != "":
+		where = s.URL
+	case s.Command != "":
+		where = "command " + strconv.Quote(s.Command)
+	case s.Binary != "":
+		where = "binary " + s.Binary
+	default:
+		where = "(default)"
+	}
+	if s.Type != "" {
+		return s.Type + ":" + where
+	}
+	return where
 }
 
 // Artifact controls per-artifact settings.
 type Artifact struct {
 	Enabled  *bool  `yaml:"enabled,omitempty"`
 	Filename string `yaml:"filename,omitempty"`
+	// Split divides the reference artifact into several files; see SplitByGroup.
+	Split string `yaml:"split,omitempty"`
+	// MaxTokens overrides the artifact's output token limit per request.
+	MaxTokens int `yaml:"max-tokens,omitempty"`
+	// StopSequences end the artifact's generation when the model writes
+	// one of them, such as a closing phrase it tends to add.
+	StopSequences []string `yaml:"stop-sequences,omitempty"`
+	// Format is the reference or examples output format: markdown
+	// (default), mdx, or asciidoc.
+	Format string `yaml:"format,omitempty"`
+	// MaxExamplesPerOperation and MaxExamples cap the spec examples the
+	// examples artifact is prompted with, per operation and in total.
+	// Zero means no limit.
+	MaxExamplesPerOperation int `yaml:"max-examples-per-operation,omitempty"`
+	MaxExamples             int `yaml:"max-examples,omitempty"`
+	// IncludeRawHelp sends the reference each CLI command's verbatim --help
+	// output alongside the parsed structure.
+	IncludeRawHelp bool `yaml:"include-raw-help,omitempty"`
 }
 
+// SplitByGroup writes one reference file per IR group instead of a single
+// references/reference.md.
+const SplitByGroup = "by-group"
+
+// Output formats for Artifact.Format.
+const (
+	FormatMarkdown = "markdown"
+	FormatMDX      = "mdx"
+	FormatAsciiDoc = "asciidoc"
+)
+
 // IsEnabled returns whether this artifact is enabled (default true).
 func (a Artifact) IsEnabled() bool {
 	if a.Enabled == nil {
@@ -66,14 +256,38 @@ type SkillConfig struct {
 	Metadata      map[string]string `yaml:"metadata,omitempty"`
 	Env           []string          `yaml:"env,omitempty"`
 	AllowedTools  string            `yaml:"allowed-tools,omitempty"`
+	// DescriptionLint tunes the checks run on the generated SKILL.md
+	// description.
+	DescriptionLint DescriptionLint `yaml:"description-lint,omitempty"`
+}
+
+// DescriptionLint holds thresholds for linting a generated skill
+// description. Zero values use the defaults: 40 to 1024 characters and the
+// DefaultTriggers phrases. An explicitly empty triggers list turns the
+// trigger check off.
+type DescriptionLint struct {
+	MinLength int      `yaml:"min-length,omitempty"`
+	MaxLength int      `yaml:"max-length,omitempty"`
+	Triggers  []string `yaml:"triggers"`
 }
 
+// DefaultTriggers are phrases that tell an agent when to use a skill; a
+// description should contain at least one.
+var DefaultTriggers = []string{"when", "use for", "use to", "use this", "use it"}
+
 // ProviderConfig holds per-project LLM provider overrides.
 type ProviderConfig struct {
 	Provider string `yaml:"provider,omitempty"`
 	Model    string `yaml:"model,omitempty"`
 	APIKey   string `yaml:"api-key,omitempty"`
 	BaseURL  string `yaml:"base-url,omitempty"`
+	// Tokenizer names the token counter used for budgets and estimates
+	// (heuristic or tiktoken); empty picks one for the provider and model.
+	Tokenizer string `yaml:"tokenizer,omitempty"`
+	// FallbackProviders are tried in order when the provider is
+	// unavailable, replacing any in the sc config file. Their tokenizer
+	// and fallback-providers are ignored.
+	FallbackProviders []ProviderConfig `yaml:"fallback-providers,omitempty"`
 }
 
 // Parse reads and parses a COMPILER_INSTRUCTIONS.md file.
@@ -82,12 +296,24 @@ func Parse(path string) (*Instructions, error) {
 	if err != nil {
 		return nil, fmt.Errorf("reading instructions file: %w", err)
 	}
-	return ParseBytes(data)
+	inst, err := ParseBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	inst.Dir = filepath.Dir(path)
+	return inst, nil
 }
 
-// ParseBytes parses instructions from raw bytes.
+// ParseBytes parses instructions from raw bytes, keeping the conditional
+// blocks that match the frontmatter variant.
 func ParseBytes(data []byte) (*Instructions, error) {
-	content := string(data)
+	return ParseBytesVariants(data, nil)
+}
+
+// ParseBytesVariants is ParseBytes with the active variants given
+// explicitly, e.g. by --variant; nil falls back to the frontmatter variant.
+func ParseBytesVariants(data []byte, variants []string) (*Instructions, error) {
+	content := normalizeText(data)
 
 	fm, body, err := extractFrontmatter(content)
 	if err != nil {
@@ -107,23 +333,243 @@ func ParseBytes(data []byte) (*Instructions, error) {
 		frontmatter.Out = "./sc-out/"
 	}
 
+	seen := make(map[string]string) // slug -> name
+	for i, entry := range frontmatter.Skills {
+		if entry.Name == "" {
+			return nil, fmt.Errorf("skills[%d] missing required field: name", i)
+		}
+		slug := Slug(entry.Name)
+		if prev, ok := seen[slug]; ok {
+			if prev == entry.Name {
+				return nil, fmt.Errorf("skills[%d]: duplicate skill name %q", i, entry.Name)
+			}
+			return nil, fmt.Errorf("skills[%d]: skill name %q collides with %q (both build into %s/)", i, entry.Name, prev, slug)
+		}
+		seen[slug] = entry.Name
+	}
+
+	if variants == nil {
+		variants = frontmatter.Variants()
+	} else {
+		frontmatter.Variant = strings.Join(variants, ",")
+	}
+	body, err = stripConditionals(body, variants)
+	if err != nil {
+		return nil, err
+	}
+	reviews := findReviewMarkers(body)
+	body = strings.TrimSpace(stripComments(body))
 	sections := extractSections(body)
 
 	return &Instructions{
 		Frontmatter: frontmatter,
 		Sections:    sections,
 		RawBody:     body,
+		Reviews:     reviews,
 	}, nil
 }
 
+// Skills expands a multi-skill file into one Instructions per skills: entry,
+// each building into <out>/<slug>/ with the shared frontmatter (provider,
+// skill config, policies) and the shared body sections overlaid with the
+// entry's own. A file without skills: yields just itself.
+func (inst *Instructions) Skills() []*Instructions {
+	if len(inst.Frontmatter.Skills) == 0 {
+		return []*Instructions{inst}
+	}
+
+	out := make([]*Instructions, 0, len(inst.Frontmatter.Skills))
+	for _, entry := range inst.Frontmatter.Skills {
+		fm := inst.Frontmatter
+		fm.Name = entry.Name
+		fm.Spec = entry.Spec
+		fm.Out = filepath.Join(inst.Frontmatter.Out, Slug(entry.Name))
+		if strings.Contains(inst.Frontmatter.Out, "{name}") {
+			fm.Out = inst.Frontmatter.Out // the template already separates skills
+		}
+		fm.Skills = nil
+		if entry.Artifacts != nil {
+			fm.Artifacts = entry.Artifacts
+		}
+
+		sections := make(map[string]string, len(inst.Sections)+len(entry.Sections))
+		for k, v := range inst.Sections {
+			sections[k] = v
+		}
+		for k, v := range entry.Sections {
+			sections[k] = strings.TrimSpace(stripComments(v))
+		}
+
+		out = append(out, &Instructions{
+			Frontmatter: fm,
+			Sections:    sections,
+			RawBody:     renderSections(sections),
+			Reviews:     inst.Reviews,
+			Dir:         inst.Dir,
+		})
+	}
+	return out
+}
+
+// SkillError names the failing skill sk in errors from multi-skill files.
+func SkillError(multi bool, sk *Instructions, err error) error {
+	if multi {
+		return fmt.Errorf("skill %s: %w", sk.Frontmatter.Name, err)
+	}
+	return err
+}
+
+// renderSections rebuilds a markdown body from sections in heading order.
+func renderSections(sections map[string]string) string {
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "# %s\n\n%s\n\n", name, sections[name])
+	}
+	return b.String()
+}
+
 // ResolveSpecSources converts the raw YAML spec node into typed SpecSource(s).
 func (inst *Instructions) ResolveSpecSources() ([]SpecSource, error) {
+	return inst.ResolveSpecSourcesAt("")
+}
+
+// ResolveSpecSourcesAt is ResolveSpecSources with version, when set,
+// replacing the version of each source that selects one.
+func (inst *Instructions) ResolveSpecSourcesAt(version string) ([]SpecSource, error) {
 	node := &inst.Frontmatter.Spec
-	if node.IsZero() {
-		// Default to openapi.yaml
-		return []SpecSource{{Path: "./openapi.yaml"}}, nil
+	sources := []SpecSource{{Path: "./openapi.yaml"}} // default
+	if !node.IsZero() {
+		var err error
+		sources, err = resolveSpecNode(node)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if inst.Dir != "" {
+		for i := range sources {
+			sources[i].Path = inst.resolvePath(sources[i].Path)
+			// Bare binary names are looked up in PATH; only paths are relative
+			if strings.ContainsRune(sources[i].Binary, '/') {
+				sources[i].Binary = inst.resolvePath(sources[i].Binary)
+			}
+		}
+	}
+	for i := range sources {
+		if version != "" && sources[i].Versioned() {
+			sources[i].Version = version
+		}
+		if err := sources[i].selectVersion(); err != nil {
+			return nil, err
+		}
 	}
-	return resolveSpecNode(node)
+	return sources, nil
+}
+
+// versionPlaceholder is replaced by SpecSource.Version in paths and URLs.
+const versionPlaceholder = "{version}"
+
+// Versioned reports whether the source selects one of several versions.
+func (s SpecSource) Versioned() bool {
+	return s.Version != "" || s.hasVersionPlaceholder()
+}
+
+func (s SpecSource) hasVersionPlaceholder() bool {
+	return strings.Contains(s.Path, versionPlaceholder) || strings.Contains(s.URL, versionPlaceholder)
+}
+
+// selectVersion points the source at its Version.
+func (s *SpecSource) selectVersion() error {
+	placeholder := s.hasVersionPlaceholder()
+	switch {
+	case s.Version == "" && placeholder:
+		return fmt.Errorf("spec %s has a %s placeholder but no version", s, versionPlaceholder)
+	case s.Version == "":
+		return nil
+	case s.Version == "latest" && s.URL != "":
+		return fmt.Errorf("spec %s: version latest needs a directory to list; name a version", s)
+	case placeholder && s.Version != "latest":
+		s.Path = strings.ReplaceAll(s.Path, versionPlaceholder, s.Version)
+		s.URL = strings.ReplaceAll(s.URL, versionPlaceholder, s.Version)
+		return nil
+	case s.Path == "":
+		return fmt.Errorf("spec %s: version needs a %s placeholder in the URL", s, versionPlaceholder)
+	}
+
+	// A directory of versions, each a subdirectory or a file named for the
+	// version with any extension
+	dir := s.Path
+	if placeholder {
+		dir = filepath.Dir(s.Path[:strings.Index(s.Path, versionPlaceholder)] + "x")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("spec %s: listing versions: %w", s, err)
+	}
+	versions := make(map[string]string) // version -> entry name
+	var names []string
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		v := e.Name()
+		if !e.IsDir() {
+			v = strings.TrimSuffix(v, filepath.Ext(v))
+		}
+		versions[v] = e.Name()
+		names = append(names, v)
+	}
+	sort.Strings(names)
+
+	version := s.Version
+	if version == "latest" {
+		if len(names) == 0 {
+			return fmt.Errorf("spec %s: no versions in %s", s, dir)
+		}
+		version = names[len(names)-1]
+	}
+	if entry, ok := versions[version]; ok {
+		s.Version = version
+		if placeholder {
+			s.Path = strings.ReplaceAll(s.Path, versionPlaceholder, version)
+		} else {
+			s.Path = filepath.Join(dir, entry)
+		}
+		return nil
+	}
+	return fmt.Errorf("spec %s: version %s not found in %s (have %s)", s, version, dir, strings.Join(names, ", "))
+}
+
+// FileHeader returns the text of the file-header frontmatter key. A
+// single-line value naming an existing file, relative to the instructions
+// file, is replaced by that file's contents; any other value is the header
+// itself.
+func (inst *Instructions) FileHeader() (string, error) {
+	header := strings.TrimSpace(inst.Frontmatter.FileHeader)
+	if header == "" || strings.Contains(header, "\n") {
+		return header, nil
+	}
+	path := inst.resolvePath(header)
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return header, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("file-header: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// resolvePath joins a relative path onto the instructions file's directory.
+func (inst *Instructions) resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) || inst.Dir == "" || inst.Dir == "." {
+		return path
+	}
+	return filepath.Join(inst.Dir, path)
 }
 
 func resolveSpecNode(node *yaml.Node) ([]SpecSource, error) {
@@ -152,29 +598,47 @@ func resolveSpecNode(node *yaml.Node) ([]SpecSource, error) {
 		}
 		return sources, nil
 
+	case yaml.AliasNode:
+		// *anchor reference to a spec defined elsewhere in the frontmatter
+		return resolveSpecNode(node.Alias)
+
 	default:
 		return nil, fmt.Errorf("unsupported spec format (YAML kind: %d)", node.Kind)
 	}
 }
 
+// normalizeText strips a leading UTF-8 byte order mark and converts CRLF
+// line endings to LF, as some Windows editors save files with both.
+func normalizeText(data []byte) string {
+	content := strings.TrimPrefix(string(data), "\ufeff")
+	return strings.ReplaceAll(content, "\r\n", "\n")
+}
+
 // extractFrontmatter splits on --- delimiters and returns frontmatter YAML and body.
 func extractFrontmatter(content string) (string, string, error) {
-	// Must start with ---
+	// Must start with a --- line
 	trimmed := strings.TrimSpace(content)
-	if !strings.HasPrefix(trimmed, "---") {
+	lines := strings.SplitAfter(trimmed, "\n")
+	if !isDelimiter(lines[0]) {
 		return "", "", fmt.Errorf("instructions file must start with YAML frontmatter (---)")
 	}
 
-	// Find second ---
-	rest := trimmed[3:]
-	idx := strings.Index(rest, "\n---")
-	if idx < 0 {
-		return "", "", fmt.Errorf("instructions file missing closing frontmatter delimiter (---)")
+	// The terminator is the next line that is exactly --- at column 0, so
+	// indented block scalars and longer rules like ---- don't end it early
+	for i := 1; i < len(lines); i++ {
+		if isDelimiter(lines[i]) {
+			fm := strings.TrimSpace(strings.Join(lines[1:i], ""))
+			body := strings.TrimSpace(strings.Join(lines[i+1:], ""))
+			return fm, body, nil
+		}
 	}
+	return "", "", fmt.Errorf("instructions file missing closing frontmatter delimiter (---)")
+}
 
-	fm := strings.TrimSpace(rest[:idx])
-	body := strings.TrimSpace(rest[idx+4:])
-	return fm, body, nil
+// isDelimiter reports whether line is a frontmatter delimiter: --- with
+// nothing else but trailing whitespace.
+func isDelimiter(line string) bool {
+	return strings.TrimRight(line, " \t\r\n") == "---"
 }
 
 // extractSections splits the markdown body on H1 headings into named sections.
@@ -210,14 +674,205 @@ func extractSections(body string) map[string]string {
 }
 
 // Validate checks the instructions for common issues, returning warnings.
-func (inst *Instructions) Validate() []string {
-	var warnings []string
+func (inst *Instructions) Validate() []diag.Diagnostic {
+	var warnings []diag.Diagnostic
 	if _, ok := inst.Sections["Product"]; !ok {
-		warnings = append(warnings, "missing recommended section: # Product")
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.SeverityWarning,
+			Code:     "missing-section",
+			Message:  "missing recommended section: # Product",
+		})
+	}
+	for i, r := range inst.Reviews {
+		where := "# " + r.Section
+		if r.Section == "" {
+			where = "the preamble"
+		}
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.SeverityWarning,
+			Code:     "unresolved-review",
+			Message:  fmt.Sprintf("unresolved REVIEW marker %d of %d in %s: %s", i+1, len(inst.Reviews), where, r.Text),
+		})
+	}
+	if v := inst.Frontmatter.Version; v > CurrentVersion {
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.SeverityWarning,
+			Code:     "invalid-frontmatter",
+			Message:  fmt.Sprintf("version %d is newer than this sc supports (%d); upgrade sc", v, CurrentVersion),
+		})
+	}
+	switch inst.Frontmatter.EmptySections {
+	case "", EmptySectionsFallback, EmptySectionsSkip, EmptySectionsDocs:
+	default:
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.SeverityWarning,
+			Code:     "invalid-frontmatter",
+			Message: fmt.Sprintf("unknown empty-sections value %q (expected %s, %s, or %s)",
+				inst.Frontmatter.EmptySections, EmptySectionsFallback, EmptySectionsSkip, EmptySectionsDocs),
+		})
+	}
+	switch inst.Frontmatter.EmptySpec {
+	case "", EmptySpecSkip, EmptySpecError:
+	default:
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.SeverityWarning,
+			Code:     "invalid-frontmatter",
+			Message: fmt.Sprintf("unknown empty-spec value %q (expected %s or %s)",
+				inst.Frontmatter.EmptySpec, EmptySpecSkip, EmptySpecError),
+		})
+	}
+	switch inst.Frontmatter.Mode {
+	case "", ModeSingle, ModePerGroup:
+	default:
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.SeverityWarning,
+			Code:     "invalid-frontmatter",
+			Message:  fmt.Sprintf("unknown mode %q (expected %s or %s)", inst.Frontmatter.Mode, ModeSingle, ModePerGroup),
+		})
+	}
+	switch inst.Frontmatter.ArtifactsDefault {
+	case "", ArtifactsEnabled, ArtifactsDisabled:
+	default:
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.SeverityWarning,
+			Code:     "invalid-frontmatter",
+			Message: fmt.Sprintf("unknown artifacts-default value %q (expected %s or %s)",
+				inst.Frontmatter.ArtifactsDefault, ArtifactsEnabled, ArtifactsDisabled),
+		})
+	}
+	artifactNames := make([]string, 0, len(inst.Frontmatter.Artifacts))
+	for name := range inst.Frontmatter.Artifacts {
+		artifactNames = append(artifactNames, name)
+	}
+	sort.Strings(artifactNames)
+	for _, name := range artifactNames {
+		if n := inst.Frontmatter.Artifacts[name].MaxTokens; n < 0 {
+			warnings = append(warnings, diag.Diagnostic{
+				Severity: diag.SeverityWarning,
+				Code:     "invalid-frontmatter",
+				Message:  fmt.Sprintf("artifacts.%s.max-tokens: %d is negative; the default is used", name, n),
+			})
+		}
+		a := inst.Frontmatter.Artifacts[name]
+		if err := CheckPath(a.Filename); err != nil {
+			warnings = append(warnings, diag.Diagnostic{
+				Severity: diag.SeverityError,
+				Code:     "invalid-frontmatter",
+				Message:  fmt.Sprintf("artifacts.%s.filename: %s", name, err),
+			})
+		}
+		for _, limit := range []struct {
+			key string
+			n   int
+		}{{"max-examples-per-operation", a.MaxExamplesPerOperation}, {"max-examples", a.MaxExamples}} {
+			if limit.n < 0 {
+				warnings = append(warnings, diag.Diagnostic{
+					Severity: diag.SeverityWarning,
+					Code:     "invalid-frontmatter",
+					Message:  fmt.Sprintf("artifacts.%s.%s: %d is negative; there is no limit", name, limit.key, limit.n),
+				})
+			}
+		}
+		if slices.ContainsFunc(a.StopSequences, func(s string) bool { return strings.TrimSpace(s) == "" }) {
+			warnings = append(warnings, diag.Diagnostic{
+				Severity: diag.SeverityWarning,
+				Code:     "invalid-frontmatter",
+				Message:  fmt.Sprintf("artifacts.%s.stop-sequences: blank entries are ignored", name),
+			})
+		}
+		switch format := inst.Frontmatter.Artifacts[name].Format; {
+		case format == "":
+		case format != FormatMarkdown && format != FormatMDX && format != FormatAsciiDoc:
+			warnings = append(warnings, diag.Diagnostic{
+				Severity: diag.SeverityWarning,
+				Code:     "invalid-frontmatter",
+				Message: fmt.Sprintf("artifacts.%s.format: unknown format %q (expected %s, %s, or %s)",
+					name, format, FormatMarkdown, FormatMDX, FormatAsciiDoc),
+			})
+		case name != "reference" && name != "examples":
+			warnings = append(warnings, diag.Diagnostic{
+				Severity: diag.SeverityWarning,
+				Code:     "invalid-frontmatter",
+				Message:  fmt.Sprintf("artifacts.%s.format: only reference and examples support a format; it is ignored", name),
+			})
+		}
+	}
+	for _, ext := range inst.Frontmatter.Extensions {
+		if !strings.HasPrefix(ext, "x-") {
+			warnings = append(warnings, diag.Diagnostic{
+				Severity: diag.SeverityWarning,
+				Code:     "invalid-frontmatter",
+				Message:  fmt.Sprintf("extensions: %q is not a vendor extension (expected an x- prefix)", ext),
+			})
+		}
+	}
+	if tools := inst.Frontmatter.Skill.AllowedTools; tools != "" {
+		_, unknown := NormalizeAllowedTools(tools)
+		for _, name := range unknown {
+			warnings = append(warnings, diag.Diagnostic{
+				Severity: diag.SeverityWarning,
+				Code:     "unknown-tool",
+				Message:  fmt.Sprintf("skill.allowed-tools: unknown tool %q", name),
+			})
+		}
+	}
+	if lint := inst.Frontmatter.Skill.DescriptionLint; lint.MinLength < 0 || lint.MaxLength < 0 ||
+		(lint.MaxLength > 0 && lint.MinLength > lint.MaxLength) {
+		warnings = append(warnings, diag.Diagnostic{
+			Severity: diag.SeverityWarning,
+			Code:     "invalid-frontmatter",
+			Message:  fmt.Sprintf("skill.description-lint: min-length %d and max-length %d do not form a valid range", lint.MinLength, lint.MaxLength),
+		})
 	}
 	return warnings
 }
 
+// ErrUnsafePath is returned for an absolute path, or one whose ".."
+// elements climb out of the output directory.
+var ErrUnsafePath = errors.New("path must stay inside the output directory")
+
+// CheckPath returns ErrUnsafePath unless path is relative and stays inside
+// the directory it is joined to. Artifact filenames and every path a sink
+// writes are held to it. An empty path is fine.
+func CheckPath(path string) error {
+	clean := filepath.Clean(path)
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") || filepath.VolumeName(path) != "" ||
+		clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s: %w", path, ErrUnsafePath)
+	}
+	return nil
+}
+
+// Slug is the filesystem-safe form of the name field, used for the skill
+// directory. The original name is kept everywhere else (e.g. SKILL.md
+// frontmatter).
+func (inst *Instructions) Slug() string {
+	return Slug(inst.Frontmatter.Name)
+}
+
+// Slug lowercases name and collapses every run of characters other than
+// ASCII letters and digits into a single hyphen, so "My API/v2" becomes
+// "my-api-v2". A name with no usable characters slugs to "skill".
+func Slug(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	if b.Len() == 0 {
+		return "skill"
+	}
+	return b.String()
+}
+
 // EnvPrefix derives the env var prefix from the name field.
 // e.g., "my-app" -> "MY_APP"
 func (inst *Instructions) EnvPrefix() string {
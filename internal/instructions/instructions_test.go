@@ -5,6 +5,9 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/roberthamel/skill-compiler/internal/diag"
+	"gopkg.in/yaml.v3"
 )
 
 func readTestdata(t *testing.T, name string) []byte {
@@ -122,6 +125,60 @@ func TestResolveSpecSources_Array(t *testing.T) {
 	}
 }
 
+func TestResolveSpecSources_Version(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"specs/2024-01-01.yaml", "specs/2024-06-01.yaml", "dated/2024-01-01/openapi.yaml", "dated/2024-06-01/openapi.yaml"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		spec     string
+		override string
+		want     string // Path or URL, relative to dir for paths
+		wantErr  string
+	}{
+		{spec: "{path: ./specs, version: 2024-01-01}", want: "specs/2024-01-01.yaml"},
+		{spec: "{path: ./specs, version: latest}", want: "specs/2024-06-01.yaml"},
+		{spec: "{path: ./specs, version: latest}", override: "2024-01-01", want: "specs/2024-01-01.yaml"},
+		{spec: "{path: './dated/{version}/openapi.yaml', version: latest}", want: "dated/2024-06-01/openapi.yaml"},
+		{spec: "{url: 'https://api.example.com/{version}/openapi.json', version: 2024-06-01}", want: "https://api.example.com/2024-06-01/openapi.json"},
+		{spec: "'./specs/{version}.yaml'", override: "2024-06-01", want: "specs/2024-06-01.yaml"},
+		{spec: "./openapi.yaml", override: "2024-06-01", want: "openapi.yaml"},
+		{spec: "{path: ./specs, version: 2023-01-01}", wantErr: "have 2024-01-01, 2024-06-01"},
+		{spec: "'./specs/{version}.yaml'", wantErr: "no version"},
+	}
+	for _, tt := range tests {
+		inst := &Instructions{Dir: dir}
+		if err := yaml.Unmarshal([]byte(tt.spec), &inst.Frontmatter.Spec); err != nil {
+			t.Fatal(err)
+		}
+		inst.Frontmatter.Spec = *inst.Frontmatter.Spec.Content[0]
+		sources, err := inst.ResolveSpecSourcesAt(tt.override)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.spec, err)
+			continue
+		}
+		got := sources[0].URL
+		if got == "" {
+			got, _ = filepath.Rel(dir, sources[0].Path)
+		}
+		if got != tt.want {
+			t.Errorf("%s (override %q) = %s, want %s", tt.spec, tt.override, got, tt.want)
+		}
+	}
+}
+
 func TestValidate_MissingProduct(t *testing.T) {
 	data := []byte("---\nname: test\n---\n# Workflows\nSomething")
 	inst, err := ParseBytes(data)
@@ -131,7 +188,7 @@ func TestValidate_MissingProduct(t *testing.T) {
 	warnings := inst.Validate()
 	found := false
 	for _, w := range warnings {
-		if strings.Contains(w, "missing recommended section: # Product") {
+		if strings.Contains(w.Message, "missing recommended section: # Product") {
 			found = true
 		}
 	}
@@ -157,3 +214,573 @@ func TestEnvPrefix(t *testing.T) {
 		}
 	}
 }
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"my-app", "my-app"},
+		{"My App", "my-app"},
+		{"acme/billing v2", "acme-billing-v2"},
+		{"  ../Petstore API!  ", "petstore-api"},
+		{"日本語", "skill"},
+	}
+	for _, tt := range tests {
+		if got := Slug(tt.name); got != tt.want {
+			t.Errorf("Slug(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSkills_MultiSkillExpansion(t *testing.T) {
+	data := []byte(`---
+name: suite
+out: ./dist/
+provider:
+  model: shared-model
+skills:
+  - name: billing
+    spec: ./billing.yaml
+    sections:
+      Product: Billing API
+  - name: users
+    spec:
+      type: cli
+      binary: users-cli
+---
+# Product
+Shared product text
+
+# Guardrails
+Be careful
+`)
+	inst, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	skills := inst.Skills()
+	if len(skills) != 2 {
+		t.Fatalf("got %d skills, want 2", len(skills))
+	}
+
+	billing := skills[0]
+	if billing.Frontmatter.Name != "billing" || billing.Frontmatter.Out != "dist/billing" {
+		t.Errorf("billing name/out = %q/%q", billing.Frontmatter.Name, billing.Frontmatter.Out)
+	}
+	if billing.Frontmatter.Provider.Model != "shared-model" {
+		t.Errorf("provider config not shared: %+v", billing.Frontmatter.Provider)
+	}
+	if billing.Sections["Product"] != "Billing API" || billing.Sections["Guardrails"] != "Be careful" {
+		t.Errorf("billing sections = %v, want entry override plus shared", billing.Sections)
+	}
+	sources, err := skills[1].ResolveSpecSources()
+	if err != nil || len(sources) != 1 || sources[0].Binary != "users-cli" {
+		t.Errorf("users sources = %+v (err %v)", sources, err)
+	}
+	if skills[1].Sections["Product"] != "Shared product text" {
+		t.Errorf("users Product = %q, want shared text", skills[1].Sections["Product"])
+	}
+}
+
+func TestSkills_DuplicateNames(t *testing.T) {
+	data := []byte("---\nname: suite\nskills:\n  - name: a\n  - name: a\n---\n")
+	if _, err := ParseBytes(data); err == nil || !strings.Contains(err.Error(), "duplicate skill name") {
+		t.Errorf("error = %v, want duplicate skill name", err)
+	}
+
+	data = []byte("---\nname: suite\nskills:\n  - name: Billing API\n  - name: billing/api\n---\n")
+	if _, err := ParseBytes(data); err == nil || !strings.Contains(err.Error(), "collides with") {
+		t.Errorf("error = %v, want slug collision", err)
+	}
+
+	data = []byte("---\nname: suite\nout: ./dist/\nskills:\n  - name: Billing API\n---\n")
+	inst, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if sk := inst.Skills()[0]; sk.Frontmatter.Name != "Billing API" || sk.Frontmatter.Out != "dist/billing-api" {
+		t.Errorf("name/out = %q/%q, want original name and slugged out", sk.Frontmatter.Name, sk.Frontmatter.Out)
+	}
+}
+
+func TestNormalizeAllowedTools(t *testing.T) {
+	tests := []struct {
+		in          string
+		want        string
+		wantUnknown []string
+	}{
+		{"read, grep,  Glob", "Read, Grep, Glob", nil},
+		{"Read Grep read", "Read, Grep", nil},
+		{"Bash(git log:*), bash(git log:*), WebFetch", "Bash(git log:*), WebFetch", nil},
+		{"Read, mcp__github__list_issues", "Read, mcp__github__list_issues", nil},
+		{"Read, Shell", "Read, Shell", []string{"Shell"}},
+	}
+	for _, tt := range tests {
+		got, unknown := NormalizeAllowedTools(tt.in)
+		if got != tt.want {
+			t.Errorf("NormalizeAllowedTools(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if strings.Join(unknown, ",") != strings.Join(tt.wantUnknown, ",") {
+			t.Errorf("NormalizeAllowedTools(%q) unknown = %v, want %v", tt.in, unknown, tt.wantUnknown)
+		}
+	}
+}
+
+func TestValidate_UnknownAllowedTool(t *testing.T) {
+	data := []byte("---\nname: test\nskill:\n  allowed-tools: Read, Shel\n---\n# Product\nSomething")
+	inst, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	warnings := inst.Validate()
+	if len(warnings) != 1 || warnings[0].Code != "unknown-tool" || !strings.Contains(warnings[0].Message, `"Shel"`) {
+		t.Errorf("warnings = %v, want one unknown-tool warning for Shel", warnings)
+	}
+}
+
+func TestValidate_UnsafeArtifactFilename(t *testing.T) {
+	data := []byte("---\nname: test\nartifacts:\n  reference:\n    filename: ../../etc/ref.md\n  skill:\n    filename: /tmp/SKILL.md\n  examples:\n    filename: more/examples.md\n---\n# Product\nSomething")
+	inst, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	warnings := inst.Validate()
+	if len(warnings) != 2 || warnings[0].Severity != diag.SeverityError ||
+		!strings.Contains(warnings[0].Message, "artifacts.reference.filename") || !strings.Contains(warnings[1].Message, "artifacts.skill.filename") {
+		t.Errorf("warnings = %v, want errors for the reference and skill filenames only", warnings)
+	}
+}
+
+func TestParseBytes_BodyWithHorizontalRules(t *testing.T) {
+	data := []byte(`---
+name: rules
+skill:
+  compatibility: |
+    Uses a rule inside a block scalar
+    ---
+---
+# Product
+Intro
+---
+More product text
+----
+# Workflows
+Step one
+`)
+	inst, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if inst.Frontmatter.Name != "rules" {
+		t.Errorf("Name = %q, want %q", inst.Frontmatter.Name, "rules")
+	}
+	if !strings.Contains(inst.Frontmatter.Skill.Compatibility, "---") {
+		t.Errorf("Skill.Compatibility = %q, want indented --- kept", inst.Frontmatter.Skill.Compatibility)
+	}
+	if got := inst.Sections["Product"]; !strings.Contains(got, "Intro\n---\nMore product text\n----") {
+		t.Errorf("Product = %q, want rules preserved", got)
+	}
+	if inst.Sections["Workflows"] != "Step one" {
+		t.Errorf("Workflows = %q, want %q", inst.Sections["Workflows"], "Step one")
+	}
+}
+
+func TestParseBytes_FrontmatterDelimiters(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"opening not on own line", "---name: x\n---\n", "must start with YAML frontmatter"},
+		{"longer rule is not a terminator", "---\nname: x\n----\n", "missing closing frontmatter delimiter"},
+		{"indented rule is not a terminator", "---\nname: x\n  ---\n", "missing closing frontmatter delimiter"},
+		{"trailing whitespace and CRLF", "--- \r\nname: x\r\n---\t\r\n# Product\r\nHi\r\n", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseBytes([]byte(tt.content))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseBytes_BOMAndCRLF(t *testing.T) {
+	lf := readTestdata(t, "valid.md")
+	want, err := ParseBytes(lf)
+	if err != nil {
+		t.Fatalf("parsing LF file: %v", err)
+	}
+	crlf := []byte(strings.ReplaceAll(string(lf), "\n", "\r\n"))
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"BOM", append([]byte("\ufeff"), lf...)},
+		{"CRLF", crlf},
+		{"BOM, blank lines, and CRLF", append([]byte("\ufeff\r\n\r\n"), crlf...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBytes(tt.data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Frontmatter.Name != want.Frontmatter.Name || got.Frontmatter.Out != want.Frontmatter.Out || got.Frontmatter.Skill.License != want.Frontmatter.Skill.License {
+				t.Errorf("frontmatter = %+v, want %+v", got.Frontmatter, want.Frontmatter)
+			}
+			if got.RawBody != want.RawBody {
+				t.Errorf("RawBody = %q, want %q", got.RawBody, want.RawBody)
+			}
+			if len(got.Sections) != len(want.Sections) {
+				t.Errorf("got %d sections, want %d", len(got.Sections), len(want.Sections))
+			}
+			for name, content := range want.Sections {
+				if got.Sections[name] != content {
+					t.Errorf("section %q = %q, want %q", name, got.Sections[name], content)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBytes_YAMLAnchors(t *testing.T) {
+	data := []byte(`---
+name: suite
+out: ./dist/
+x-defaults: &defaults
+  spec: &spec
+    type: openapi
+    path: ./shared.yaml
+  sections:
+    Product: Shared via anchor
+skills:
+  - <<: *defaults
+    name: billing
+  - name: users
+    spec: *spec
+---
+# Product
+Top-level product
+`)
+	inst, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	skills := inst.Skills()
+	if len(skills) != 2 {
+		t.Fatalf("got %d skills, want 2", len(skills))
+	}
+	for _, s := range skills {
+		sources, err := s.ResolveSpecSources()
+		if err != nil || len(sources) != 1 || sources[0].Path != "./shared.yaml" || sources[0].Type != "openapi" {
+			t.Errorf("%s sources = %+v (err %v)", s.Frontmatter.Name, sources, err)
+		}
+	}
+	if skills[0].Sections["Product"] != "Shared via anchor" {
+		t.Errorf("billing Product = %q, want merged from anchor", skills[0].Sections["Product"])
+	}
+	if skills[1].Sections["Product"] != "Top-level product" {
+		t.Errorf("users Product = %q, want top-level text", skills[1].Sections["Product"])
+	}
+}
+
+func TestParseBytesVariants(t *testing.T) {
+	data := []byte(`---
+name: tool
+variant: saas
+---
+# Product
+
+Common text.
+<!-- if:onprem -->
+Connect to your own host.
+<!-- else -->
+Connect to api.example.com.
+<!-- if:!eu -->
+Data is stored in the US.
+<!-- endif -->
+<!-- endif -->
+
+<!-- if:onprem,eu -->
+# Compliance
+
+Self-managed or EU data residency.
+<!-- endif -->
+`)
+
+	inst, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if got, want := inst.Sections["Product"], "Common text.\nConnect to api.example.com.\nData is stored in the US."; got != want {
+		t.Errorf("saas Product = %q, want %q", got, want)
+	}
+	if _, ok := inst.Sections["Compliance"]; ok {
+		t.Error("saas build should not include the Compliance section")
+	}
+
+	inst, err = ParseBytesVariants(data, []string{"onprem"})
+	if err != nil {
+		t.Fatalf("ParseBytesVariants: %v", err)
+	}
+	if got, want := inst.Sections["Product"], "Common text.\nConnect to your own host."; got != want {
+		t.Errorf("onprem Product = %q, want %q", got, want)
+	}
+	if _, ok := inst.Sections["Compliance"]; !ok || inst.Frontmatter.Variant != "onprem" {
+		t.Errorf("onprem build: sections %v, variant %q", inst.Sections, inst.Frontmatter.Variant)
+	}
+	if strings.Contains(inst.RawBody, "<!--") {
+		t.Errorf("RawBody still has markers:\n%s", inst.RawBody)
+	}
+
+	for _, body := range []string{"<!-- if:a -->\nx\n", "x\n<!-- endif -->\n", "<!-- if:a -->\n<!-- else -->\n<!-- else -->\n<!-- endif -->\n"} {
+		if _, err := ParseBytes([]byte("---\nname: tool\n---\n" + body)); err == nil {
+			t.Errorf("ParseBytes(%q) succeeded, want an unbalanced-block error", body)
+		}
+	}
+}
+
+func TestParseBytes_ReviewMarkersAndComments(t *testing.T) {
+	data := []byte(`---
+name: tool
+---
+# Product
+
+<!-- REVIEW: What the tool does,
+and who uses it. -->
+Pets API. <!-- internal: ask sales --> For shops.
+
+# Workflows
+
+<!-- review workflows -->
+<!-- plain authoring note -->
+List pets.
+`)
+	inst, err := ParseBytes(data)
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if got, want := inst.Sections["Product"], "Pets API.  For shops."; got != want {
+		t.Errorf("Product = %q, want %q", got, want)
+	}
+	if got, want := inst.Sections["Workflows"], "List pets."; got != want {
+		t.Errorf("Workflows = %q, want %q", got, want)
+	}
+	if strings.Contains(inst.RawBody, "<!--") {
+		t.Errorf("RawBody still has comments:\n%s", inst.RawBody)
+	}
+
+	want := []ReviewMarker{
+		{Section: "Product", Text: "What the tool does,"},
+		{Section: "Workflows", Text: "workflows"},
+	}
+	if len(inst.Reviews) != len(want) || inst.Reviews[0] != want[0] || inst.Reviews[1] != want[1] {
+		t.Fatalf("Reviews = %+v, want %+v", inst.Reviews, want)
+	}
+	var messages []string
+	for _, w := range inst.Validate() {
+		if w.Code == "unresolved-review" {
+			messages = append(messages, w.Message)
+		}
+	}
+	if len(messages) != 2 || messages[0] != "unresolved REVIEW marker 1 of 2 in # Product: What the tool does," {
+		t.Errorf("unresolved-review warnings = %q", messages)
+	}
+}
+
+func TestResolveSpecSources_RelativeToInstructionsFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "project")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	content := `---
+name: tool
+spec:
+  - ./openapi.yaml
+  - /abs/spec.yaml
+  - url: https://example.com/spec.yaml
+  - {type: codebase, path: .}
+  - {type: cli, binary: ./bin/tool}
+  - {type: cli, binary: kubectl}
+---
+# Product
+`
+	path := filepath.Join(dir, "COMPILER_INSTRUCTIONS.md")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	inst, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	sources, err := inst.ResolveSpecSources()
+	if err != nil {
+		t.Fatalf("ResolveSpecSources: %v", err)
+	}
+	want := []SpecSource{
+		{Path: filepath.Join(dir, "openapi.yaml")},
+		{Path: "/abs/spec.yaml"},
+		{URL: "https://example.com/spec.yaml"},
+		{Type: "codebase", Path: dir},
+		{Type: "cli", Binary: filepath.Join(dir, "bin", "tool")},
+		{Type: "cli", Binary: "kubectl"},
+	}
+	for i, w := range want {
+		if got := sources[i]; got.Path != w.Path || got.URL != w.URL || got.Binary != w.Binary {
+			t.Errorf("source %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	// Instructions read from stdin resolve against the working directory
+	inst, err = ParseBytes([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sources, _ = inst.ResolveSpecSources()
+	if sources[0].Path != "./openapi.yaml" {
+		t.Errorf("stdin source path = %q, want it unchanged", sources[0].Path)
+	}
+}
+
+func TestFileHeader(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "HEADER.txt"), []byte("Copyright Example Corp.\nSPDX-License-Identifier: MIT\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct{ value, want string }{
+		{"", ""},
+		{"Generated by sc. Do not edit.", "Generated by sc. Do not edit."},
+		{"HEADER.txt", "Copyright Example Corp.\nSPDX-License-Identifier: MIT"},
+		{"missing.txt", "missing.txt"},
+		{"Line one\nLine two\n", "Line one\nLine two"},
+	}
+	for _, tt := range tests {
+		inst := &Instructions{Frontmatter: Frontmatter{FileHeader: tt.value}, Dir: dir}
+		if got, err := inst.FileHeader(); err != nil || got != tt.want {
+			t.Errorf("FileHeader(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	input := `---
+# Provider settings are shared with CI
+provider:
+    name: anthropic
+skill:
+    allowed-tools: read, bash grep
+name: petstore # the public name
+spec: ./openapi.yaml
+---
+
+# Product
+
+Body   with odd   spacing.
+<!-- keep this comment -->
+`
+	out, changes, err := Upgrade([]byte(input))
+	if err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	want := `---
+version: 1
+name: petstore # the public name
+spec: ./openapi.yaml
+out: ./sc-out/
+skill:
+  allowed-tools: Read, Bash, Grep
+# Provider settings are shared with CI
+provider:
+  name: anthropic
+---
+
+# Product
+
+Body   with odd   spacing.
+<!-- keep this comment -->
+`
+	if string(out) != want {
+		t.Errorf("Upgrade output:\n%s\nwant:\n%s", out, want)
+	}
+	if len(changes) != 4 {
+		t.Errorf("changes = %q, want version, out, allowed-tools, and reorder", changes)
+	}
+
+	inst, err := ParseBytes(out)
+	if err != nil {
+		t.Fatalf("upgraded file does not parse: %v", err)
+	}
+	if inst.Frontmatter.Version != CurrentVersion {
+		t.Errorf("Version = %d, want %d", inst.Frontmatter.Version, CurrentVersion)
+	}
+
+	again, changes, err := Upgrade(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 0 || string(again) != string(out) {
+		t.Errorf("second Upgrade changed the file: %q", changes)
+	}
+
+	crlf := "\ufeff" + strings.ReplaceAll(input, "\n", "\r\n")
+	if out, _, err := Upgrade([]byte(crlf)); err != nil || string(out) != want {
+		t.Errorf("Upgrade of a BOM and CRLF file = %q, %v; want the LF output", out, err)
+	}
+
+	if _, _, err := Upgrade([]byte("---\nname: x\nversion: 99\n---\n")); err == nil {
+		t.Error("expected an error for a version newer than CurrentVersion")
+	}
+}
+
+func TestParseBytes_PreservesUnknownFields(t *testing.T) {
+	content := `---
+name: test-tool
+spec: ./openapi.yaml
+x-team:
+  owner: platform # on call
+  tier: 1
+docs-site: internal
+---
+
+# Product
+`
+	inst, err := ParseBytes([]byte(content))
+	if err != nil {
+		t.Fatalf("ParseBytes: %v", err)
+	}
+	if len(inst.Frontmatter.Extra) != 2 {
+		t.Fatalf("Extra = %v, want x-team and docs-site", inst.Frontmatter.Extra)
+	}
+	if site := inst.Frontmatter.Extra["docs-site"]; site.Value != "internal" {
+		t.Errorf("docs-site = %q, want internal", site.Value)
+	}
+
+	data, err := yaml.Marshal(inst.Frontmatter)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	for _, want := range []string{"docs-site: internal", "owner: platform # on call", "tier: 1"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("marshaled frontmatter missing %q:\n%s", want, data)
+		}
+	}
+
+	out, _, err := Upgrade([]byte(content))
+	if err != nil {
+		t.Fatalf("Upgrade: %v", err)
+	}
+	if !strings.Contains(string(out), "x-team:\n  owner: platform # on call\n  tier: 1\ndocs-site: internal\n") {
+		t.Errorf("Upgrade dropped or reordered unknown keys:\n%s", out)
+	}
+}
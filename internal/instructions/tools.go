@@ -0,0 +1,75 @@
+package instructions
+
+import (
+	"strings"
+)
+
+// KnownTools lists the agent tool names accepted in skill.allowed-tools, in
+// their canonical casing.
+var KnownTools = []string{
+	"Agent", "Bash", "Edit", "Glob", "Grep", "LS", "MultiEdit", "NotebookEdit",
+	"NotebookRead", "Read", "Skill", "Task", "TodoWrite", "WebFetch",
+	"WebSearch", "Write",
+}
+
+// NormalizeAllowedTools canonicalizes a skill.allowed-tools value: entries
+// may be separated by commas or whitespace, and are trimmed, deduplicated,
+// re-cased to their KnownTools spelling, and joined with ", ". Scoped entries
+// such as "Bash(git log:*)" keep their scope verbatim. Entries that are
+// neither known tools nor MCP tools (mcp__server__tool) are kept as written
+// and returned in unknown.
+func NormalizeAllowedTools(s string) (normalized string, unknown []string) {
+	canonical := make(map[string]string, len(KnownTools))
+	for _, tool := range KnownTools {
+		canonical[strings.ToLower(tool)] = tool
+	}
+
+	seen := make(map[string]bool)
+	var out []string
+	for _, entry := range splitTools(s) {
+		name, scope := entry, ""
+		if i := strings.Index(entry, "("); i >= 0 {
+			name, scope = strings.TrimSpace(entry[:i]), entry[i:]
+		}
+		if tool, ok := canonical[strings.ToLower(name)]; ok {
+			name = tool
+		} else if !strings.HasPrefix(name, "mcp__") {
+			unknown = append(unknown, name)
+		}
+		entry = name + scope
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		out = append(out, entry)
+	}
+	return strings.Join(out, ", "), unknown
+}
+
+// splitTools splits on commas and whitespace outside parentheses, so scopes
+// like "Bash(npm run:*)" stay intact.
+func splitTools(s string) []string {
+	var entries []string
+	var cur strings.Builder
+	depth := 0
+	flush := func() {
+		if entry := strings.TrimSpace(cur.String()); entry != "" {
+			entries = append(entries, entry)
+		}
+		cur.Reset()
+	}
+	for _, r := range s {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0 && (r == ',' || r == ' ' || r == '\t' || r == '\n'):
+			flush()
+			continue
+		}
+		cur.WriteRune(r)
+	}
+	flush()
+	return entries
+}
@@ -0,0 +1,145 @@
+package instructions
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the instructions format version this sc writes.
+const CurrentVersion = 1
+
+// frontmatterOrder is the canonical order of the top-level frontmatter keys.
+// Keys not listed keep their relative order after these.
+var frontmatterOrder = []string{
+	"version", "name", "spec", "out", "mode", "language", "variant", "artifacts-default",
+	"empty-sections", "empty-spec", "artifacts", "extensions", "file-header", "skill", "provider", "skills",
+}
+
+// Upgrade migrates an instructions file to CurrentVersion: it makes defaults
+// explicit, normalizes values, and puts the top-level frontmatter keys in
+// canonical order. Frontmatter comments are kept and the markdown body is
+// copied byte for byte. It returns the rewritten file and a description of
+// each change; upgrading an up-to-date file returns it unchanged.
+func Upgrade(data []byte) ([]byte, []string, error) {
+	inst, err := ParseBytes(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v := inst.Frontmatter.Version; v > CurrentVersion {
+		return nil, nil, fmt.Errorf("version %d is newer than this sc supports (%d)", v, CurrentVersion)
+	}
+
+	// ParseBytes has checked both delimiters exist. The rewrite drops any
+	// BOM and CRLF line endings, as ParseBytes does.
+	lines := strings.SplitAfter(strings.TrimLeft(normalizeText(data), " \t\r\n"), "\n")
+	end := 1
+	for !isDelimiter(lines[end]) {
+		end++
+	}
+	body := strings.Join(lines[end+1:], "")
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(strings.Join(lines[1:end], "")), &doc); err != nil {
+		return nil, nil, fmt.Errorf("parsing frontmatter YAML: %w", err)
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("frontmatter must be a YAML mapping")
+	}
+
+	var changes []string
+	if v := inst.Frontmatter.Version; v < CurrentVersion {
+		setScalar(root, "version", "!!int", strconv.Itoa(CurrentVersion))
+		was := "unversioned"
+		if v > 0 {
+			was = fmt.Sprintf("version %d", v)
+		}
+		changes = append(changes, fmt.Sprintf("version: set to %d (was %s)", CurrentVersion, was))
+	}
+	if mappingValue(root, "out") == nil {
+		setScalar(root, "out", "!!str", "./sc-out/")
+		changes = append(changes, "out: made the default ./sc-out/ explicit")
+	}
+	if skill := mappingValue(root, "skill"); skill != nil && skill.Kind == yaml.MappingNode {
+		if tools := mappingValue(skill, "allowed-tools"); tools != nil && tools.Kind == yaml.ScalarNode {
+			if normalized, _ := NormalizeAllowedTools(tools.Value); normalized != tools.Value {
+				tools.Value = normalized
+				changes = append(changes, fmt.Sprintf("skill.allowed-tools: normalized to %q", normalized))
+			}
+		}
+	}
+	if reorderKeys(root, frontmatterOrder) {
+		changes = append(changes, "reordered frontmatter keys")
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("encoding frontmatter: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("encoding frontmatter: %w", err)
+	}
+	buf.WriteString("---\n")
+	buf.WriteString(body)
+
+	out := buf.Bytes()
+	if len(changes) == 0 && !bytes.Equal(out, data) {
+		changes = append(changes, "reformatted frontmatter")
+	}
+	return out, changes, nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setScalar sets key to a scalar value in a mapping node, appending the key
+// if it is missing.
+func setScalar(node *yaml.Node, key, tag, value string) {
+	if v := mappingValue(node, key); v != nil {
+		*v = yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value, LineComment: v.LineComment}
+		return
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value})
+}
+
+// reorderKeys sorts a mapping node's keys into order, stably, with unlisted
+// keys last. It reports whether anything moved.
+func reorderKeys(node *yaml.Node, order []string) bool {
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		pairs = append(pairs, pair{node.Content[i], node.Content[i+1]})
+	}
+	rank := func(p pair) int {
+		if i := slices.Index(order, p.key.Value); i >= 0 {
+			return i
+		}
+		return len(order)
+	}
+	if slices.IsSortedFunc(pairs, func(a, b pair) int { return rank(a) - rank(b) }) {
+		return false
+	}
+	slices.SortStableFunc(pairs, func(a, b pair) int { return rank(a) - rank(b) })
+	node.Content = node.Content[:0]
+	for _, p := range pairs {
+		node.Content = append(node.Content, p.key, p.value)
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+package instructions
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Conditional blocks in the markdown body keep their content only when the
+// condition matches the active variants:
+//
+//	<!-- if:onprem -->
+//	Connect to https://<your-host>/api.
+//	<!-- else -->
+//	Connect to https://api.example.com.
+//	<!-- endif -->
+//
+// A condition lists variant tags separated by commas and matches when any of
+// them is active; a tag prefixed with ! matches when that variant is not
+// active. Blocks may nest. Markers must sit on their own lines.
+
+// Variants returns the active variant tags from the frontmatter variant
+// field, a comma-separated list.
+func (fm Frontmatter) Variants() []string {
+	return splitVariants(fm.Variant)
+}
+
+func splitVariants(s string) []string {
+	var tags []string
+	for _, tag := range strings.Split(s, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// conditional is one open if block while stripping.
+type conditional struct {
+	line    int  // 1-based body line of the if marker
+	parent  bool // whether the enclosing block is kept
+	matched bool // whether the if branch matched
+	hasElse bool
+}
+
+// stripConditionals evaluates the conditional blocks in body against the
+// active variant tags, dropping unmatched branches and all markers.
+func stripConditionals(body string, variants []string) (string, error) {
+	if !strings.Contains(body, "<!--") {
+		return body, nil
+	}
+	active := make(map[string]bool, len(variants))
+	for _, v := range variants {
+		active[v] = true
+	}
+
+	var out []string
+	var stack []conditional
+	keep := true
+	for i, line := range strings.Split(body, "\n") {
+		marker, arg, ok := conditionalMarker(line)
+		if !ok {
+			if keep {
+				out = append(out, line)
+			}
+			continue
+		}
+		switch marker {
+		case "if":
+			matched := matchVariants(arg, active)
+			stack = append(stack, conditional{line: i + 1, parent: keep, matched: matched})
+			keep = keep && matched
+		case "else":
+			if len(stack) == 0 {
+				return "", fmt.Errorf("instructions body line %d: <!-- else --> without <!-- if:... -->", i+1)
+			}
+			top := &stack[len(stack)-1]
+			if top.hasElse {
+				return "", fmt.Errorf("instructions body line %d: second <!-- else --> in one block", i+1)
+			}
+			top.hasElse = true
+			keep = top.parent && !top.matched
+		case "endif":
+			if len(stack) == 0 {
+				return "", fmt.Errorf("instructions body line %d: <!-- endif --> without <!-- if:... -->", i+1)
+			}
+			keep = stack[len(stack)-1].parent
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if len(stack) > 0 {
+		return "", fmt.Errorf("instructions body line %d: <!-- if:... --> is missing <!-- endif -->", stack[len(stack)-1].line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n")), nil
+}
+
+// conditionalMarker recognizes a line holding only an if, else, or endif
+// marker, returning the marker and, for if, its condition.
+func conditionalMarker(line string) (marker, arg string, ok bool) {
+	line = strings.TrimSpace(line)
+	inner, found := strings.CutPrefix(line, "<!--")
+	if !found {
+		return "", "", false
+	}
+	inner, found = strings.CutSuffix(inner, "-->")
+	if !found {
+		return "", "", false
+	}
+	inner = strings.TrimSpace(inner)
+	switch {
+	case inner == "else" || inner == "endif":
+		return inner, "", true
+	case strings.HasPrefix(inner, "if:"):
+		return "if", strings.TrimPrefix(inner, "if:"), true
+	}
+	return "", "", false
+}
+
+// matchVariants reports whether any tag in the comma-separated condition
+// matches the active variants.
+func matchVariants(cond string, active map[string]bool) bool {
+	for _, tag := range splitVariants(cond) {
+		if negated, ok := strings.CutPrefix(tag, "!"); ok {
+			if !active[negated] {
+				return true
+			}
+		} else if active[tag] {
+			return true
+		}
+	}
+	return false
+}
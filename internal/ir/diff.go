@@ -0,0 +1,201 @@
+package ir
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Changes is the structured difference between two IRs of the same
+// interface, such as two published versions of an API. Operations are
+// matched by method and path, or by command path for CLIs, so a renamed
+// operation ID is a change rather than a removal and an addition.
+type Changes struct {
+	Added      []Operation
+	Removed    []Operation
+	Changed    []OperationChange
+	Deprecated []Operation // deprecated in the new IR but not in the old
+	// DeprecatedParameters are parameters of operations in both IRs that
+	// are deprecated in the new IR but not in the old.
+	DeprecatedParameters []ParameterDeprecation
+}
+
+// ParameterDeprecation is a newly deprecated parameter and its operation.
+type ParameterDeprecation struct {
+	Operation Operation // as in the new IR
+	Parameter Parameter
+}
+
+// Label names the parameter within its operation, e.g.
+// "query:limit on GET /pets (listPets)".
+func (d ParameterDeprecation) Label() string {
+	return d.Parameter.In + ":" + d.Parameter.Name + " on " + d.Operation.Label()
+}
+
+// OperationChange is an operation present in both IRs that differs.
+type OperationChange struct {
+	Operation Operation // as in the new IR
+	Details   []string  // e.g. "added parameter query:limit"
+}
+
+// Empty reports whether the IRs describe the same operations.
+func (c Changes) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0 && len(c.Deprecated) == 0 &&
+		len(c.DeprecatedParameters) == 0
+}
+
+// Diff compares old and new operation by operation. Each list is in the
+// order of the IR it comes from.
+func Diff(old, new *IntermediateRepr) Changes {
+	var c Changes
+	oldOps := make(map[string]Operation, len(old.Operations))
+	for _, op := range old.Operations {
+		oldOps[operationKey(op)] = op
+	}
+	newKeys := make(map[string]bool, len(new.Operations))
+	for _, op := range new.Operations {
+		key := operationKey(op)
+		newKeys[key] = true
+		prev, ok := oldOps[key]
+		if !ok {
+			c.Added = append(c.Added, op)
+			continue
+		}
+		if op.Deprecated && !prev.Deprecated {
+			c.Deprecated = append(c.Deprecated, op)
+		}
+		for _, param := range deprecatedParameters(prev, op) {
+			c.DeprecatedParameters = append(c.DeprecatedParameters, ParameterDeprecation{Operation: op, Parameter: param})
+		}
+		if details := operationDetails(prev, op); len(details) > 0 {
+			c.Changed = append(c.Changed, OperationChange{Operation: op, Details: details})
+		}
+	}
+	for _, op := range old.Operations {
+		if !newKeys[operationKey(op)] {
+			c.Removed = append(c.Removed, op)
+		}
+	}
+	return c
+}
+
+// Label names an operation for people: "GET /pets (listPets)" for HTTP, the
+// command path for CLIs.
+func (op Operation) Label() string {
+	if op.Method == "" {
+		if op.Path != "" {
+			return op.Path
+		}
+		return op.ID
+	}
+	return fmt.Sprintf("%s %s (%s)", op.Method, op.Path, op.ID)
+}
+
+func operationKey(op Operation) string {
+	if op.Path == "" {
+		return "id:" + op.ID
+	}
+	return strings.ToUpper(op.Method) + " " + op.Path
+}
+
+// operationDetails lists how an operation changed. Changes it cannot name,
+// such as an edited description, read as "changed".
+func operationDetails(old, new Operation) []string {
+	var details []string
+	if old.ID != new.ID {
+		details = append(details, fmt.Sprintf("operation ID %s renamed to %s", old.ID, new.ID))
+	}
+
+	oldParams := make(map[string]Parameter, len(old.Parameters))
+	for _, p := range old.Parameters {
+		oldParams[p.In+":"+p.Name] = p
+	}
+	newParams := make(map[string]bool, len(new.Parameters))
+	for _, p := range new.Parameters {
+		key := p.In + ":" + p.Name
+		newParams[key] = true
+		prev, ok := oldParams[key]
+		switch {
+		case !ok && p.Required:
+			details = append(details, "added required parameter "+key)
+		case !ok:
+			details = append(details, "added parameter "+key)
+		case p.Required && !prev.Required:
+			details = append(details, "parameter "+key+" is now required")
+		case !p.Required && prev.Required:
+			details = append(details, "parameter "+key+" is now optional")
+		case p.Type != prev.Type:
+			details = append(details, fmt.Sprintf("parameter %s type changed from %s to %s", key, prev.Type, p.Type))
+		}
+	}
+	for _, p := range old.Parameters {
+		if key := p.In + ":" + p.Name; !newParams[key] {
+			details = append(details, "removed parameter "+key)
+		}
+	}
+
+	oldCodes := make([]string, 0, len(old.Responses))
+	for _, r := range old.Responses {
+		oldCodes = append(oldCodes, r.StatusCode)
+	}
+	newCodes := make([]string, 0, len(new.Responses))
+	for _, r := range new.Responses {
+		newCodes = append(newCodes, r.StatusCode)
+		if !slices.Contains(oldCodes, r.StatusCode) {
+			details = append(details, "added response "+r.StatusCode)
+		}
+	}
+	for _, code := range oldCodes {
+		if !slices.Contains(newCodes, code) {
+			details = append(details, "removed response "+code)
+		}
+	}
+
+	if !sameJSON(canonicalTypeRef(old.RequestBody), canonicalTypeRef(new.RequestBody)) {
+		details = append(details, "request body changed")
+	}
+	// Deprecation is reported in Changes.Deprecated and
+	// Changes.DeprecatedParameters, not as a change
+	old.Deprecated, old.DeprecationNote, old.Sunset = new.Deprecated, new.DeprecationNote, new.Sunset
+	old.Parameters = slices.Clone(old.Parameters)
+	for i, p := range old.Parameters {
+		for _, np := range new.Parameters {
+			if np.In == p.In && np.Name == p.Name {
+				old.Parameters[i].Deprecated, old.Parameters[i].DeprecationNote, old.Parameters[i].Sunset = np.Deprecated, np.DeprecationNote, np.Sunset
+			}
+		}
+	}
+	if len(details) == 0 && !sameJSON(canonicalOperation(old), canonicalOperation(new)) {
+		details = append(details, "changed")
+	}
+	return details
+}
+
+// deprecatedParameters returns the parameters of new that are deprecated
+// but were present and not deprecated in old.
+func deprecatedParameters(old, new Operation) []Parameter {
+	var params []Parameter
+	for _, p := range new.Parameters {
+		if !p.Deprecated {
+			continue
+		}
+		i := slices.IndexFunc(old.Parameters, func(o Parameter) bool { return o.In == p.In && o.Name == p.Name })
+		if i >= 0 && !old.Parameters[i].Deprecated {
+			params = append(params, p)
+		}
+	}
+	return params
+}
+
+// canonicalOperation is op as CanonicalJSON encodes it, so order-only
+// differences do not count as changes.
+func canonicalOperation(op Operation) Operation {
+	return (&IntermediateRepr{Operations: []Operation{op}}).canonical().Operations[0]
+}
+
+func sameJSON(a, b any) bool {
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	return string(aj) == string(bj)
+}
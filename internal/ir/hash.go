@@ -0,0 +1,106 @@
+package ir
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+)
+
+// CanonicalJSON encodes the IR for content hashing. Collections whose order
+// carries no meaning (operations, parameters other than positional
+// arguments, responses, types, fields, tags, enums, ...) are sorted first, so reformatting or reordering a spec
+// without changing what it describes yields the same bytes. Map keys are
+// sorted by encoding/json. The IR itself is not modified.
+func (ir *IntermediateRepr) CanonicalJSON() ([]byte, error) {
+	return json.Marshal(ir.canonical())
+}
+
+func (ir *IntermediateRepr) canonical() *IntermediateRepr {
+	c := *ir
+
+	c.Operations = slices.Clone(ir.Operations)
+	for i := range c.Operations {
+		op := &c.Operations[i]
+		op.Parameters = slices.Clone(op.Parameters)
+		slices.SortStableFunc(op.Parameters, compareParameters)
+		for j := range op.Parameters {
+			if c := op.Parameters[j].Constraints; c != nil {
+				cc := *c
+				cc.Enum = sortedStrings(c.Enum)
+				op.Parameters[j].Constraints = &cc
+			}
+		}
+		op.Responses = slices.SortedFunc(slices.Values(op.Responses), func(a, b Response) int {
+			return cmp.Compare(a.StatusCode, b.StatusCode)
+		})
+		for j := range op.Responses {
+			op.Responses[j].Body = canonicalTypeRef(op.Responses[j].Body)
+		}
+		op.RequestBody = canonicalTypeRef(op.RequestBody)
+		op.Tags = sortedStrings(op.Tags)
+		op.Auth = sortedAuth(op.Auth)
+		op.Aliases = sortedStrings(op.Aliases)
+		if op.Pagination != nil {
+			pg := *op.Pagination
+			pg.Params = sortedStrings(pg.Params)
+			op.Pagination = &pg
+		}
+	}
+	slices.SortFunc(c.Operations, func(a, b Operation) int {
+		return cmp.Or(cmp.Compare(a.ID, b.ID), cmp.Compare(a.Path, b.Path), cmp.Compare(a.Method, b.Method))
+	})
+
+	c.Types = slices.Clone(ir.Types)
+	for i := range c.Types {
+		td := &c.Types[i]
+		td.Fields = slices.SortedFunc(slices.Values(td.Fields), func(a, b TypeField) int {
+			return cmp.Compare(a.Name, b.Name)
+		})
+		td.Enum = sortedStrings(td.Enum)
+	}
+	slices.SortFunc(c.Types, func(a, b TypeDef) int { return cmp.Compare(a.Name, b.Name) })
+
+	c.Auth = slices.SortedFunc(slices.Values(ir.Auth), func(a, b AuthScheme) int { return cmp.Compare(a.ID, b.ID) })
+
+	c.Groups = slices.Clone(ir.Groups)
+	for i := range c.Groups {
+		c.Groups[i].Operations = sortedStrings(c.Groups[i].Operations)
+	}
+	slices.SortFunc(c.Groups, func(a, b Group) int { return cmp.Compare(a.Name, b.Name) })
+
+	return &c
+}
+
+// canonicalTypeRef sorts a body's alternate content types and examples.
+func canonicalTypeRef(ref *TypeRef) *TypeRef {
+	if ref == nil {
+		return nil
+	}
+	c := *ref
+	c.AltContentTypes = sortedStrings(ref.AltContentTypes)
+	c.Examples = slices.SortedFunc(slices.Values(ref.Examples), func(a, b Example) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Value, b.Value))
+	})
+	return &c
+}
+
+func sortedStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return slices.Sorted(slices.Values(s))
+}
+
+// sortedAuth sorts the schemes within each auth alternative and then the
+// alternatives themselves; neither order carries meaning.
+func sortedAuth(auth [][]string) [][]string {
+	if auth == nil {
+		return nil
+	}
+	out := make([][]string, len(auth))
+	for i, ids := range auth {
+		out[i] = slices.Sorted(slices.Values(ids))
+	}
+	slices.SortFunc(out, slices.Compare)
+	return out
+}
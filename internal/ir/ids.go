@@ -0,0 +1,97 @@
+package ir
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// OperationID derives a stable operation ID from an HTTP method and path, or
+// from a command path when method is empty. Path parameters lose their
+// braces and any other punctuation becomes an underscore:
+//
+//	OperationID("GET", "/pets/{petId}") == "get_pets_petId"
+//	OperationID("", "kubectl get pods") == "kubectl_get_pods"
+func OperationID(method, path string) string {
+	var b strings.Builder
+	if method != "" {
+		b.WriteString(strings.ToLower(method))
+	}
+	pendingSep := method != ""
+	for _, r := range path {
+		switch {
+		case r == '{' || r == '}' || r == ':':
+			// Path parameter markers carry no meaning in an ID
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			if pendingSep && b.Len() > 0 {
+				b.WriteByte('_')
+			}
+			pendingSep = false
+			b.WriteRune(r)
+		default:
+			pendingSep = true
+		}
+	}
+	if b.Len() == 0 {
+		return "operation"
+	}
+	return b.String()
+}
+
+// NormalizeOperationIDs fills in missing operation IDs and disambiguates
+// duplicates with a numeric suffix (_2, _3, ...), rewriting group references
+// to match.
+func (ir *IntermediateRepr) NormalizeOperationIDs() {
+	ir.normalizeOperationIDs(make(map[string]bool))
+}
+
+// normalizeOperationIDs is NormalizeOperationIDs against a set of IDs that are
+// already taken, so sources merged later never reuse an earlier source's IDs.
+func (ir *IntermediateRepr) normalizeOperationIDs(taken map[string]bool) {
+	// Original ID -> indexes of the operations that carried it
+	renamed := make(map[string][]int)
+	for i := range ir.Operations {
+		op := &ir.Operations[i]
+		if op.ID == "" {
+			op.ID = OperationID(op.Method, op.Path)
+		}
+		orig := op.ID
+		id := orig
+		for n := 2; taken[id]; n++ {
+			id = fmt.Sprintf("%s_%d", orig, n)
+		}
+		taken[id] = true
+		op.ID = id
+		renamed[orig] = append(renamed[orig], i)
+	}
+
+	// A reference to a duplicated ID maps to an operation tagged with the
+	// group's name that the group does not reference yet. Without one, the
+	// k-th reference maps to the k-th operation that carried the ID, and
+	// extra references stick to the last one.
+	seen := make(map[string]int)
+	for gi := range ir.Groups {
+		group := &ir.Groups[gi]
+		used := make(map[int]bool)
+		for ri, ref := range group.Operations {
+			idxs := renamed[ref]
+			if len(idxs) == 0 {
+				continue
+			}
+			pick := -1
+			for _, i := range idxs {
+				if !used[i] && slices.Contains(ir.Operations[i].Tags, group.Name) {
+					pick = i
+					break
+				}
+			}
+			if pick < 0 {
+				k := min(seen[ref], len(idxs)-1)
+				pick = idxs[k]
+				seen[ref]++
+			}
+			used[pick] = true
+			group.Operations[ri] = ir.Operations[pick].ID
+		}
+	}
+}
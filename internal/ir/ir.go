@@ -1,5 +1,13 @@
 package ir
 
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
 // IntermediateRepr is the normalized representation all spec plugins parse into.
 type IntermediateRepr struct {
 	Operations []Operation       `json:"operations,omitempty"`
@@ -10,7 +18,9 @@ type IntermediateRepr struct {
 	Metadata   map[string]string `json:"metadata,omitempty"`
 }
 
-// Operation represents an endpoint, command, or RPC.
+// Operation represents an endpoint, command, or RPC. Its Parameters are in
+// declaration order: the spec's for HTTP, the usage line's and then the
+// help output's for CLI commands.
 type Operation struct {
 	ID          string      `json:"id"`
 	Name        string      `json:"name"`
@@ -22,21 +32,121 @@ type Operation struct {
 	Responses   []Response  `json:"responses,omitempty"`
 	Tags        []string    `json:"tags,omitempty"`
 	Deprecated  bool        `json:"deprecated,omitempty"`
-	Auth        []string    `json:"auth,omitempty"` // references to AuthScheme IDs
+	Auth        [][]string  `json:"auth,omitempty"` // alternatives (OR), each listing AuthScheme IDs required together (AND)
+	Pagination  *Pagination `json:"pagination,omitempty"`
+	RateLimit   *RateLimit  `json:"rateLimit,omitempty"`
+	// DeprecationNote explains why and what replaces it; Sunset is the
+	// removal date as given by the spec (e.g. 2025-06-30).
+	DeprecationNote string `json:"deprecationNote,omitempty"`
+	Sunset          string `json:"sunset,omitempty"`
+	// Extensions holds the spec's vendor "x-" fields, keyed by name.
+	Extensions map[string]any `json:"extensions,omitempty"`
 	// CLI-specific
 	Aliases     []string `json:"aliases,omitempty"`
 	RawHelpText string   `json:"rawHelpText,omitempty"`
 }
 
+// AuthText states an operation's auth requirement, e.g. "apiKey AND oauth2
+// OR bearer". An empty alternative reads as "none", meaning the operation
+// may also be called anonymously. AuthText is empty when no auth is declared.
+func (op Operation) AuthText() string {
+	alts := make([]string, 0, len(op.Auth))
+	for _, ids := range op.Auth {
+		if len(ids) == 0 {
+			alts = append(alts, "none")
+			continue
+		}
+		alts = append(alts, strings.Join(ids, " AND "))
+	}
+	return strings.Join(alts, " OR ")
+}
+
 // Parameter represents a flag, query param, path param, or header.
 type Parameter struct {
 	Name        string `json:"name"`
-	In          string `json:"in,omitempty"` // query, path, header, cookie, flag, argument
+	In          string `json:"in,omitempty"` // query, path, header, cookie, form, flag, argument
 	Description string `json:"description,omitempty"`
 	Required    bool   `json:"required,omitempty"`
 	Type        string `json:"type,omitempty"`
 	Default     string `json:"default,omitempty"`
 	Shorthand   string `json:"shorthand,omitempty"` // CLI short flag
+	// Deprecated marks parameters slated for removal; see
+	// Operation.DeprecationNote for the note and sunset
+	Deprecated      bool   `json:"deprecated,omitempty"`
+	DeprecationNote string `json:"deprecationNote,omitempty"`
+	Sunset          string `json:"sunset,omitempty"`
+	// Extensions holds the parameter's vendor "x-" fields, keyed by name.
+	Extensions map[string]any `json:"extensions,omitempty"`
+	// Constraints limit the values the parameter accepts.
+	Constraints *Constraints `json:"constraints,omitempty"`
+}
+
+// Constraints are the validation rules from a parameter's schema.
+type Constraints struct {
+	Enum      []string `json:"enum,omitempty"`   // the complete set of allowed values
+	Format    string   `json:"format,omitempty"` // e.g. date-time, uuid
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+	MinLength *int     `json:"minLength,omitempty"`
+	MaxLength *int     `json:"maxLength,omitempty"`
+	Pattern   string   `json:"pattern,omitempty"`
+}
+
+// String summarizes the constraints, e.g. "one of asc|desc, 1..100".
+func (c *Constraints) String() string {
+	var parts []string
+	if len(c.Enum) > 0 {
+		parts = append(parts, "one of "+strings.Join(c.Enum, "|"))
+	}
+	if c.Minimum != nil || c.Maximum != nil {
+		parts = append(parts, rangeText("", c.Minimum, c.Maximum))
+	}
+	if c.MinLength != nil || c.MaxLength != nil {
+		var lo, hi *float64
+		if c.MinLength != nil {
+			lo = ptr(float64(*c.MinLength))
+		}
+		if c.MaxLength != nil {
+			hi = ptr(float64(*c.MaxLength))
+		}
+		parts = append(parts, rangeText("length ", lo, hi))
+	}
+	if c.Pattern != "" {
+		parts = append(parts, "pattern "+c.Pattern)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func rangeText(label string, lo, hi *float64) string {
+	num := func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+	switch {
+	case lo != nil && hi != nil:
+		return label + num(*lo) + ".." + num(*hi)
+	case lo != nil:
+		return label + ">= " + num(*lo)
+	default:
+		return label + "<= " + num(*hi)
+	}
+}
+
+func ptr[T any](v T) *T { return &v }
+
+// Pagination describes how a list operation pages through results.
+type Pagination struct {
+	Style  string   `json:"style"`            // cursor, offset, page, link
+	Params []string `json:"params,omitempty"` // query params that drive paging
+}
+
+// RateLimit is what a spec states about an operation's rate limits or
+// quotas. It is only set from explicit signals; limits are never estimated.
+type RateLimit struct {
+	Limit  string `json:"limit,omitempty"`  // as documented, e.g. "100 requests per minute"
+	Source string `json:"source,omitempty"` // where Limit came from, e.g. "x-ratelimit"
+	// TooManyRequests is set when the operation documents a 429 response,
+	// and RetryAfter when that response carries a Retry-After header.
+	TooManyRequests bool     `json:"tooManyRequests,omitempty"`
+	RetryAfter      bool     `json:"retryAfter,omitempty"`
+	Headers         []string `json:"headers,omitempty"` // rate-limit response headers
 }
 
 // TypeDef represents a schema, message type, or complex value type.
@@ -57,9 +167,19 @@ type TypeField struct {
 
 // TypeRef references a type by name, used for request/response bodies.
 type TypeRef struct {
-	TypeName    string `json:"typeName,omitempty"`
-	Description string `json:"description,omitempty"`
-	ContentType string `json:"contentType,omitempty"`
+	TypeName    string    `json:"typeName,omitempty"`
+	Description string    `json:"description,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Examples    []Example `json:"examples,omitempty"`
+	// AltContentTypes lists other accepted content types; ContentType is primary.
+	AltContentTypes []string `json:"altContentTypes,omitempty"`
+}
+
+// Example is a sample payload captured from a spec or collection.
+type Example struct {
+	Name    string `json:"name,omitempty"`
+	Summary string `json:"summary,omitempty"`
+	Value   string `json:"value"`
 }
 
 // Response represents an HTTP response or command output.
@@ -67,6 +187,16 @@ type Response struct {
 	StatusCode  string   `json:"statusCode"`
 	Description string   `json:"description,omitempty"`
 	Body        *TypeRef `json:"body,omitempty"`
+	// Headers are the response headers the spec declares, such as
+	// Location, Link, or rate-limit headers, sorted by name.
+	Headers []Header `json:"headers,omitempty"`
+}
+
+// Header is a response header.
+type Header struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 // AuthScheme represents an authentication method.
@@ -131,6 +261,13 @@ type KeyFile struct {
 	Role    string `json:"role,omitempty"` // entrypoint, routes, schema, test-setup
 }
 
+// Empty reports whether the IR holds nothing to document: no operations, no
+// types, and no codebase structure. A spec at the wrong path or in a format
+// its plugin does not recognize can parse to an empty IR.
+func (ir *IntermediateRepr) Empty() bool {
+	return len(ir.Operations) == 0 && len(ir.Types) == 0 && ir.Structure == nil
+}
+
 // Merge combines another IR into this one.
 func (ir *IntermediateRepr) Merge(other *IntermediateRepr) {
 	if other == nil {
@@ -158,3 +295,144 @@ func (ir *IntermediateRepr) Merge(other *IntermediateRepr) {
 		ir.Metadata[k] = v
 	}
 }
+
+// Subset returns a copy of the IR limited to the given operations, in IR
+// order. Types, auth schemes, structure, and metadata are shared; groups are
+// trimmed to the kept operations and dropped when empty.
+func (ir *IntermediateRepr) Subset(opIDs []string) *IntermediateRepr {
+	keep := make(map[string]bool, len(opIDs))
+	for _, id := range opIDs {
+		keep[id] = true
+	}
+	sub := &IntermediateRepr{
+		Types:     ir.Types,
+		Auth:      ir.Auth,
+		Structure: ir.Structure,
+		Metadata:  ir.Metadata,
+	}
+	for _, op := range ir.Operations {
+		if keep[op.ID] {
+			sub.Operations = append(sub.Operations, op)
+		}
+	}
+	for _, g := range ir.Groups {
+		var ops []string
+		for _, id := range g.Operations {
+			if keep[id] {
+				ops = append(ops, id)
+			}
+		}
+		if len(ops) > 0 {
+			g.Operations = ops
+			sub.Groups = append(sub.Groups, g)
+		}
+	}
+	return sub
+}
+
+// WithUsedTypes returns a copy of the IR keeping only the types its
+// operations use: body and parameter types, and the types those reach
+// through their fields. Everything else is shared.
+func (ir *IntermediateRepr) WithUsedTypes() *IntermediateRepr {
+	byName := make(map[string]TypeDef, len(ir.Types))
+	for _, td := range ir.Types {
+		byName[td.Name] = td
+	}
+	used := make(map[string]bool)
+	var visit func(typ string)
+	visit = func(typ string) {
+		for _, name := range typeNameRe.FindAllString(typ, -1) {
+			td, ok := byName[name]
+			if !ok || used[name] {
+				continue
+			}
+			used[name] = true
+			for _, f := range td.Fields {
+				visit(f.Type)
+			}
+		}
+	}
+	for _, op := range ir.Operations {
+		if op.RequestBody != nil {
+			visit(op.RequestBody.TypeName)
+		}
+		for _, r := range op.Responses {
+			if r.Body != nil {
+				visit(r.Body.TypeName)
+			}
+		}
+		for _, param := range op.Parameters {
+			visit(param.Type)
+		}
+	}
+
+	out := *ir
+	out.Types = nil
+	for _, td := range ir.Types {
+		if used[td.Name] {
+			out.Types = append(out.Types, td)
+		}
+	}
+	return &out
+}
+
+// typeNameRe matches the type names in a type expression such as "[]Pet"
+// or "map[string]Order".
+var typeNameRe = regexp.MustCompile(`[A-Za-z_][\w.-]*`)
+
+// SortParameters sorts each operation's parameters by location and then
+// name. Positional arguments keep their order, which is their meaning, and
+// come first. Plugins keep declaration order; this runs only when a spec
+// source sets sort-parameters.
+func (ir *IntermediateRepr) SortParameters() {
+	for i := range ir.Operations {
+		slices.SortStableFunc(ir.Operations[i].Parameters, compareParameters)
+	}
+}
+
+// compareParameters orders parameters by location and name, except that
+// arguments compare equal to each other so a stable sort keeps their order.
+func compareParameters(a, b Parameter) int {
+	switch {
+	case a.In == "argument" && b.In == "argument":
+		return 0
+	case a.In == "argument":
+		return -1
+	case b.In == "argument":
+		return 1
+	}
+	return cmp.Or(cmp.Compare(a.In, b.In), cmp.Compare(a.Name, b.Name))
+}
+
+// FilterExtensions returns a copy of the IR whose operations and parameters
+// keep only the vendor extensions keep accepts. Everything else is shared.
+func (ir *IntermediateRepr) FilterExtensions(keep func(name string) bool) *IntermediateRepr {
+	out := *ir
+	out.Operations = make([]Operation, len(ir.Operations))
+	for i, op := range ir.Operations {
+		op.Extensions = filterExtensions(op.Extensions, keep)
+		if len(op.Parameters) > 0 {
+			params := make([]Parameter, len(op.Parameters))
+			for j, param := range op.Parameters {
+				param.Extensions = filterExtensions(param.Extensions, keep)
+				params[j] = param
+			}
+			op.Parameters = params
+		}
+		out.Operations[i] = op
+	}
+	return &out
+}
+
+func filterExtensions(ext map[string]any, keep func(name string) bool) map[string]any {
+	var kept map[string]any
+	for name, v := range ext {
+		if keep(name) {
+			if kept == nil {
+				kept = make(map[string]any)
+			}
+			kept[name] = v
+		}
+	}
+	return kept
+}
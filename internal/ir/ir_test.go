@@ -1,7 +1,12 @@
 package ir
 
 import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/roberthamel/skill-compiler/internal/instructions"
 )
@@ -55,7 +60,7 @@ type mockPlugin struct {
 
 func (m *mockPlugin) Name() string                          { return m.name }
 func (m *mockPlugin) Detect(s instructions.SpecSource) bool { return m.detectFn(s) }
-func (m *mockPlugin) Fetch(_ instructions.SpecSource) ([]byte, error) {
+func (m *mockPlugin) Fetch(_ context.Context, _ instructions.SpecSource) ([]byte, error) {
 	return m.fetchData, nil
 }
 func (m *mockPlugin) Parse(_ []byte, _ instructions.SpecSource) (*IntermediateRepr, error) {
@@ -75,7 +80,7 @@ func TestRegistry_ProcessSources(t *testing.T) {
 	reg.Register(plugin)
 
 	sources := []instructions.SpecSource{{Type: "mock"}}
-	result, warnings, err := reg.ProcessSources(sources)
+	result, warnings, err := reg.ProcessSources(context.Background(), sources)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -113,4 +118,395 @@ func TestRegistry_Detect(t *testing.T) {
 	if err == nil {
 		t.Error("expected error for unknown source type")
 	}
+
+	_, err = reg.Detect(instructions.SpecSource{Command: "./gen-openapi.sh"})
+	if err == nil || !strings.Contains(err.Error(), "require a type") {
+		t.Errorf("error = %v, want command sources to require a type", err)
+	}
+}
+
+// rankedPlugin is a mockPlugin with an explicit detection priority.
+type rankedPlugin struct {
+	mockPlugin
+	priority int
+}
+
+func (r *rankedPlugin) Priority() int { return r.priority }
+
+func TestRegistry_DetectPriorityAndConflicts(t *testing.T) {
+	anyJSON := func(s instructions.SpecSource) bool { return strings.HasSuffix(s.Path, ".json") }
+	openapi := &rankedPlugin{
+		mockPlugin: mockPlugin{name: "openapi", detectFn: anyJSON, ir: &IntermediateRepr{}},
+		priority:   PriorityExtension,
+	}
+	postman := &mockPlugin{name: "postman", detectFn: anyJSON, ir: &IntermediateRepr{}}
+
+	reg := NewRegistry()
+	reg.Register(openapi)
+	reg.Register(postman)
+
+	matches := reg.DetectAll(instructions.SpecSource{Path: "api.json"})
+	if len(matches) != 2 || matches[0].Name() != "postman" {
+		t.Fatalf("DetectAll order = %v, want postman first", matches)
+	}
+
+	_, warnings, err := reg.ProcessSources(context.Background(), []instructions.SpecSource{{Path: "api.json"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Message, "multiple plugins match (postman, openapi)") {
+		t.Errorf("warnings = %v, want a conflict warning", warnings)
+	}
+
+	// An explicit type bypasses Detect entirely
+	p, err := reg.Detect(instructions.SpecSource{Path: "api.json", Type: "openapi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Name() != "openapi" {
+		t.Errorf("forced plugin = %q, want openapi", p.Name())
+	}
+	_, warnings, _ = reg.ProcessSources(context.Background(), []instructions.SpecSource{{Path: "api.json", Type: "openapi"}})
+	if len(warnings) != 0 {
+		t.Errorf("forced type produced warnings: %v", warnings)
+	}
+}
+
+// slowPlugin records peak concurrency and echoes the source path as an op ID.
+type slowPlugin struct {
+	mu      sync.Mutex
+	active  int
+	peak    int
+	failFor string
+}
+
+func (s *slowPlugin) Name() string                        { return "slow" }
+func (s *slowPlugin) Detect(instructions.SpecSource) bool { return true }
+func (s *slowPlugin) Fetch(_ context.Context, src instructions.SpecSource) ([]byte, error) {
+	s.mu.Lock()
+	s.active++
+	if s.active > s.peak {
+		s.peak = s.active
+	}
+	s.mu.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	if src.Path == s.failFor {
+		return nil, errors.New("boom")
+	}
+	return []byte(src.Path), nil
+}
+func (s *slowPlugin) Parse(raw []byte, _ instructions.SpecSource) (*IntermediateRepr, error) {
+	return &IntermediateRepr{Operations: []Operation{{ID: string(raw)}}}, nil
+}
+func (s *slowPlugin) Validate(_ *IntermediateRepr) []Warning {
+	return []Warning{{Message: "checked"}}
+}
+
+func TestRegistry_ProcessSourcesConcurrent(t *testing.T) {
+	plugin := &slowPlugin{}
+	reg := NewRegistry()
+	reg.MaxWorkers = 2
+	reg.Register(plugin)
+
+	var sources []instructions.SpecSource
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		sources = append(sources, instructions.SpecSource{Path: name})
+	}
+	result, warnings, err := reg.ProcessSources(context.Background(), sources)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []string
+	for _, op := range result.Operations {
+		got = append(got, op.ID)
+	}
+	if strings.Join(got, ",") != "a,b,c,d,e" {
+		t.Errorf("operations merged as %v, want source order a..e", got)
+	}
+	if plugin.peak > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", plugin.peak)
+	}
+	if len(warnings) != 5 || warnings[2].Source != "c" {
+		t.Errorf("warnings = %+v, want one per source tagged with its path", warnings)
+	}
+}
+
+func TestRegistry_ProcessSourcesReportsFailingSource(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(&slowPlugin{failFor: "broken.yaml"})
+
+	sources := []instructions.SpecSource{{Path: "ok.yaml"}, {Path: "broken.yaml"}}
+	_, _, err := reg.ProcessSources(context.Background(), sources)
+	if err == nil {
+		t.Fatal("expected error from failing source")
+	}
+	if !strings.Contains(err.Error(), "spec source 2 (broken.yaml)") {
+		t.Errorf("error = %q, want it to name the failing source", err)
+	}
+}
+
+func TestOperationID(t *testing.T) {
+	tests := []struct {
+		method, path, want string
+	}{
+		{"GET", "/pets/{petId}", "get_pets_petId"},
+		{"POST", "/v1/user-accounts", "post_v1_user-accounts"},
+		{"DELETE", "/", "delete"},
+		{"", "kubectl get pods", "kubectl_get_pods"},
+		{"", "", "operation"},
+	}
+	for _, tt := range tests {
+		if got := OperationID(tt.method, tt.path); got != tt.want {
+			t.Errorf("OperationID(%q, %q) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeOperationIDs(t *testing.T) {
+	result := &IntermediateRepr{
+		Operations: []Operation{
+			{ID: "list"},
+			{ID: "list"},
+			{Method: "GET", Path: "/pets/{id}"},
+			{Path: "tool sub"},
+		},
+		Groups: []Group{
+			{Name: "a", Operations: []string{"list"}},
+			{Name: "b", Operations: []string{"list"}},
+		},
+	}
+	result.NormalizeOperationIDs()
+
+	want := []string{"list", "list_2", "get_pets_id", "tool_sub"}
+	seen := make(map[string]bool)
+	for i, op := range result.Operations {
+		if op.ID != want[i] {
+			t.Errorf("Operations[%d].ID = %q, want %q", i, op.ID, want[i])
+		}
+		if seen[op.ID] {
+			t.Errorf("duplicate ID %q", op.ID)
+		}
+		seen[op.ID] = true
+	}
+	if result.Groups[0].Operations[0] != "list" || result.Groups[1].Operations[0] != "list_2" {
+		t.Errorf("group refs = %v / %v, want list / list_2", result.Groups[0].Operations, result.Groups[1].Operations)
+	}
+}
+
+func TestNormalizeOperationIDs_MultiTagDuplicates(t *testing.T) {
+	result := &IntermediateRepr{
+		Operations: []Operation{
+			{ID: "x", Tags: []string{"t1", "t2"}},
+			{ID: "x", Tags: []string{"t1"}},
+		},
+		Groups: []Group{
+			{Name: "t1", Operations: []string{"x", "x"}},
+			{Name: "t2", Operations: []string{"x"}},
+		},
+	}
+	result.NormalizeOperationIDs()
+
+	if got := strings.Join(result.Groups[0].Operations, " "); got != "x x_2" {
+		t.Errorf("t1 refs = %q, want %q", got, "x x_2")
+	}
+	if got := strings.Join(result.Groups[1].Operations, " "); got != "x" {
+		t.Errorf("t2 refs = %q, want %q (the operation tagged t2)", got, "x")
+	}
+}
+
+func TestRegistry_ProcessSourcesDedupesAcrossSources(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(&slowPlugin{})
+
+	// slowPlugin uses the source path as the op ID, so both sources yield "list"
+	result, _, err := reg.ProcessSources(context.Background(), []instructions.SpecSource{{Path: "list"}, {Path: "list"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Operations) != 2 || result.Operations[0].ID != "list" || result.Operations[1].ID != "list_2" {
+		t.Errorf("operations = %+v, want list and list_2", result.Operations)
+	}
+}
+
+func TestCanonicalJSON_DoesNotReorderIR(t *testing.T) {
+	ir := &IntermediateRepr{Operations: []Operation{
+		{ID: "b", Parameters: []Parameter{{Name: "z"}, {Name: "a"}}},
+		{ID: "a"},
+	}}
+	reordered := &IntermediateRepr{Operations: []Operation{
+		{ID: "a"},
+		{ID: "b", Parameters: []Parameter{{Name: "a"}, {Name: "z"}}},
+	}}
+	got, _ := ir.CanonicalJSON()
+	want, _ := reordered.CanonicalJSON()
+	if string(got) != string(want) {
+		t.Errorf("CanonicalJSON differs by order:\n%s\n%s", got, want)
+	}
+	if ir.Operations[0].ID != "b" || ir.Operations[0].Parameters[0].Name != "z" {
+		t.Errorf("CanonicalJSON reordered the IR itself: %+v", ir.Operations)
+	}
+}
+
+func TestCanonicalJSON_KeepsArgumentOrder(t *testing.T) {
+	cp := func(first, second string) *IntermediateRepr {
+		return &IntermediateRepr{Operations: []Operation{{ID: "cp", Parameters: []Parameter{
+			{Name: "--force", In: "flag"}, {Name: first, In: "argument"}, {Name: second, In: "argument"},
+		}}}}
+	}
+	a, _ := cp("src", "dest").CanonicalJSON()
+	b, _ := cp("dest", "src").CanonicalJSON()
+	if string(a) == string(b) {
+		t.Error("swapping positional arguments should change the canonical JSON")
+	}
+}
+
+func TestSortParameters(t *testing.T) {
+	ir := &IntermediateRepr{Operations: []Operation{{ID: "cp", Parameters: []Parameter{
+		{Name: "--verbose", In: "flag"}, {Name: "src", In: "argument"}, {Name: "--force", In: "flag"}, {Name: "dest", In: "argument"},
+	}}}}
+	ir.SortParameters()
+	var got []string
+	for _, p := range ir.Operations[0].Parameters {
+		got = append(got, p.Name)
+	}
+	if want := "src dest --force --verbose"; strings.Join(got, " ") != want {
+		t.Errorf("SortParameters = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := &IntermediateRepr{Operations: []Operation{
+		{ID: "listPets", Method: "GET", Path: "/pets", Parameters: []Parameter{{Name: "limit", In: "query"}}},
+		{ID: "getPet", Method: "GET", Path: "/pets/{id}", Responses: []Response{{StatusCode: "200"}}},
+		{ID: "deletePet", Method: "DELETE", Path: "/pets/{id}"},
+		{ID: "feed", Method: "POST", Path: "/feed"},
+	}}
+	new := &IntermediateRepr{Operations: []Operation{
+		{ID: "listPets", Method: "GET", Path: "/pets", Parameters: []Parameter{{Name: "cursor", In: "query", Required: true}}},
+		{ID: "fetchPet", Method: "GET", Path: "/pets/{id}", Responses: []Response{{StatusCode: "200"}, {StatusCode: "404"}}},
+		{ID: "createPet", Method: "POST", Path: "/pets"},
+		{ID: "feed", Method: "POST", Path: "/feed", Deprecated: true},
+	}}
+
+	c := Diff(old, new)
+	if len(c.Added) != 1 || c.Added[0].ID != "createPet" {
+		t.Errorf("Added = %+v, want createPet", c.Added)
+	}
+	if len(c.Removed) != 1 || c.Removed[0].ID != "deletePet" {
+		t.Errorf("Removed = %+v, want deletePet", c.Removed)
+	}
+	if len(c.Deprecated) != 1 || c.Deprecated[0].ID != "feed" {
+		t.Errorf("Deprecated = %+v, want feed", c.Deprecated)
+	}
+	var changed []string
+	for _, ch := range c.Changed {
+		changed = append(changed, ch.Operation.ID+": "+strings.Join(ch.Details, "; "))
+	}
+	want := []string{
+		"listPets: added required parameter query:cursor; removed parameter query:limit",
+		"fetchPet: operation ID getPet renamed to fetchPet; added response 404",
+	}
+	if strings.Join(changed, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Changed:\n%s\nwant:\n%s", strings.Join(changed, "\n"), strings.Join(want, "\n"))
+	}
+	if !Diff(new, new).Empty() {
+		t.Error("an IR should not differ from itself")
+	}
+}
+
+func TestDiff_DeprecatedParameters(t *testing.T) {
+	old := &IntermediateRepr{Operations: []Operation{
+		{ID: "listPets", Method: "GET", Path: "/pets", Parameters: []Parameter{{Name: "limit", In: "query"}, {Name: "sort", In: "query", Deprecated: true}}},
+	}}
+	new := &IntermediateRepr{Operations: []Operation{
+		{ID: "listPets", Method: "GET", Path: "/pets", Parameters: []Parameter{
+			{Name: "limit", In: "query", Deprecated: true, DeprecationNote: "use pageSize"},
+			{Name: "sort", In: "query", Deprecated: true},
+			{Name: "pageSize", In: "query", Deprecated: true},
+		}},
+	}}
+
+	c := Diff(old, new)
+	if len(c.DeprecatedParameters) != 1 || c.DeprecatedParameters[0].Label() != "query:limit on GET /pets (listPets)" {
+		t.Errorf("DeprecatedParameters = %+v, want query:limit on listPets", c.DeprecatedParameters)
+	}
+	if len(c.Changed) != 1 || strings.Join(c.Changed[0].Details, "; ") != "added parameter query:pageSize" {
+		t.Errorf("Changed = %+v, want only the added parameter", c.Changed)
+	}
+	if old.Operations[0].Parameters[0].Deprecated {
+		t.Error("Diff should not modify its inputs")
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ir := &IntermediateRepr{Operations: []Operation{
+		{ID: "listPets", Name: "List all pets", Method: "GET", Path: "/pets", Tags: []string{"pets"}},
+		{ID: "createPet", Name: "Create a pet", Method: "POST", Path: "/pets", Tags: []string{"pets"}},
+		{ID: "getOwner", Name: "Get an owner", Method: "GET", Path: "/owners/{id}", Tags: []string{"owners"},
+			Description: "Returns the owner of a pet."},
+	}}
+	ids := func(ops []Operation) string {
+		var out []string
+		for _, op := range ops {
+			out = append(out, op.ID)
+		}
+		return strings.Join(out, " ")
+	}
+	tests := []struct{ query, want string }{
+		{"CREATEPET", "createPet"},
+		{"get /owners/{id}", "getOwner"},
+		{"pet", "listPets createPet getOwner"},
+		{"all pets", "listPets"},
+		{"owner pet", "getOwner"},
+		{"create owner", ""},
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		if got := ids(ir.Search(tt.query)); got != tt.want {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+	if got := ir.Operations[2].MatchScore("pet"); got != MatchDescription {
+		t.Errorf("MatchScore(pet) on getOwner = %d, want MatchDescription", got)
+	}
+}
+
+func TestWithUsedTypes(t *testing.T) {
+	ir := &IntermediateRepr{
+		Operations: []Operation{{
+			ID:        "listPets",
+			Responses: []Response{{StatusCode: "200", Body: &TypeRef{TypeName: "[]Pet"}}},
+		}},
+		Types: []TypeDef{
+			{Name: "Pet", Fields: []TypeField{{Name: "owner", Type: "Owner"}}},
+			{Name: "Owner"},
+			{Name: "Order"},
+		},
+	}
+	var names []string
+	for _, td := range ir.WithUsedTypes().Types {
+		names = append(names, td.Name)
+	}
+	if want := "Pet Owner"; strings.Join(names, " ") != want {
+		t.Errorf("types = %q, want %q", strings.Join(names, " "), want)
+	}
+	if len(ir.Types) != 3 {
+		t.Errorf("WithUsedTypes modified the IR's types: %v", ir.Types)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	if !(&IntermediateRepr{Metadata: map[string]string{"title": "Pets"}}).Empty() {
+		t.Error("IR with only metadata should be empty")
+	}
+	if (&IntermediateRepr{Types: []TypeDef{{Name: "Pet"}}}).Empty() {
+		t.Error("IR with types should not be empty")
+	}
+	if (&IntermediateRepr{Structure: &ProjectStructure{}}).Empty() {
+		t.Error("IR with a codebase structure should not be empty")
+	}
 }
@@ -1,29 +1,29 @@
 package ir
 
 import (
+	"context"
 	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
 
+	"github.com/roberthamel/skill-compiler/internal/diag"
 	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/sniff"
 )
 
-// Warning represents a non-fatal issue found during parsing or validation.
-type Warning struct {
-	Message string
-	Path    string // optional: file/location context
-}
-
-func (w Warning) String() string {
-	if w.Path != "" {
-		return fmt.Sprintf("%s: %s", w.Path, w.Message)
-	}
-	return w.Message
-}
+// Warning represents an issue found during parsing or validation. Plugins
+// typically set only Message; the registry fills in severity, code, and source.
+type Warning = diag.Diagnostic
 
 // SpecPlugin is the interface all spec plugins implement.
 type SpecPlugin interface {
 	Name() string
 	Detect(source instructions.SpecSource) bool
-	Fetch(source instructions.SpecSource) ([]byte, error)
+	// Fetch reads the source; cancelling ctx aborts URL fetches, spec
+	// commands, and help crawls.
+	Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error)
 	Parse(raw []byte, source instructions.SpecSource) (*IntermediateRepr, error)
 	Validate(ir *IntermediateRepr) []Warning
 }
@@ -31,6 +31,15 @@ type SpecPlugin interface {
 // Registry holds registered spec plugins.
 type Registry struct {
 	plugins []SpecPlugin
+	// MaxWorkers bounds how many sources are processed concurrently
+	// (default: number of CPUs).
+	MaxWorkers int
+	// Offline forbids network access: URL sources fail without being fetched
+	// and plugins are told not to follow remote references.
+	Offline bool
+	// SpecCacheDir, when set, is where URL sources are cached between runs
+	// so unchanged specs are revalidated rather than downloaded again.
+	SpecCacheDir string
 }
 
 // NewRegistry creates a new empty plugin registry.
@@ -43,48 +52,178 @@ func (r *Registry) Register(p SpecPlugin) {
 	r.plugins = append(r.plugins, p)
 }
 
-// Detect finds the plugin that handles the given spec source.
-func (r *Registry) Detect(source instructions.SpecSource) (SpecPlugin, error) {
+// Detection priorities. When several plugins claim a source, the highest
+// priority wins; ties go to the plugin registered first.
+const (
+	// PriorityExtension is for plugins that match on file extension alone.
+	PriorityExtension = 0
+	// PriorityContent is the default, for plugins that sniff document content
+	// or match on fields specific to them.
+	PriorityContent = 10
+)
+
+// Prioritized is implemented by plugins whose matches should rank differently
+// from PriorityContent.
+type Prioritized interface {
+	Priority() int
+}
+
+func priority(p SpecPlugin) int {
+	if pp, ok := p.(Prioritized); ok {
+		return pp.Priority()
+	}
+	return PriorityContent
+}
+
+// DetectAll returns every plugin that claims the source, best match first.
+// A source with an explicit Type is routed to the plugin of that name without
+// consulting Detect, so a type can override what the file extension suggests.
+func (r *Registry) DetectAll(source instructions.SpecSource) []SpecPlugin {
+	if source.Type != "" {
+		for _, p := range r.plugins {
+			if p.Name() == source.Type {
+				return []SpecPlugin{p}
+			}
+		}
+		return nil
+	}
+	var matches []SpecPlugin
 	for _, p := range r.plugins {
 		if p.Detect(source) {
-			return p, nil
+			matches = append(matches, p)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return priority(matches[i]) > priority(matches[j])
+	})
+	return matches
+}
+
+// Detect finds the plugin that handles the given spec source.
+func (r *Registry) Detect(source instructions.SpecSource) (SpecPlugin, error) {
+	plugin, _, err := r.detect(source)
+	return plugin, err
+}
+
+// detect picks the best plugin for a source and warns if others also claimed it.
+func (r *Registry) detect(source instructions.SpecSource) (SpecPlugin, []Warning, error) {
+	if source.Command != "" && source.Type == "" {
+		return nil, nil, fmt.Errorf("command spec sources require a type (e.g. type: openapi)")
+	}
+	matches := r.DetectAll(source)
+	if len(matches) == 0 {
+		names := make([]string, len(r.plugins))
+		for i, p := range r.plugins {
+			names[i] = p.Name()
 		}
+		if source.Type != "" {
+			return nil, nil, fmt.Errorf("unknown spec type %q (registered: %v)", source.Type, names)
+		}
+		if format := sniff.File(source.Path); source.Path != "" && format != sniff.Unknown {
+			return nil, nil, fmt.Errorf("%s looks like a %s document, but no registered plugin handles it (registered: %v)", source.Path, format, names)
+		}
+		return nil, nil, fmt.Errorf("no plugin can handle spec source (registered: %v)", names)
 	}
-	names := make([]string, len(r.plugins))
-	for i, p := range r.plugins {
-		names[i] = p.Name()
+	var warnings []Warning
+	if len(matches) > 1 {
+		names := make([]string, len(matches))
+		for i, p := range matches {
+			names[i] = p.Name()
+		}
+		warnings = append(warnings, Warning{
+			Severity: diag.SeverityWarning,
+			Code:     "plugin-conflict",
+			Message: fmt.Sprintf("multiple plugins match (%s); using %s — set type to choose explicitly",
+				strings.Join(names, ", "), matches[0].Name()),
+			Source: source.String(),
+		})
 	}
-	return nil, fmt.Errorf("no plugin can handle spec source (registered: %v)", names)
+	return matches[0], warnings, nil
+}
+
+// sourceResult is the outcome of processing a single spec source.
+type sourceResult struct {
+	ir       *IntermediateRepr
+	warnings []Warning
+	err      error
 }
 
 // ProcessSources resolves, fetches, parses, and merges all spec sources into a single IR.
-func (r *Registry) ProcessSources(sources []instructions.SpecSource) (*IntermediateRepr, []Warning, error) {
+// Sources are processed concurrently but merged in their declared order, so the
+// result is deterministic regardless of which source finishes first.
+// Cancelling ctx aborts fetches still in progress.
+func (r *Registry) ProcessSources(ctx context.Context, sources []instructions.SpecSource) (*IntermediateRepr, []Warning, error) {
+	workers := r.MaxWorkers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	results := make([]sourceResult, len(sources))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, src := range sources {
+		wg.Add(1)
+		go func(i int, src instructions.SpecSource) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			src.Offline = r.Offline
+			src.CacheDir = r.SpecCacheDir
+			results[i] = r.processSource(ctx, src)
+		}(i, src)
+	}
+	wg.Wait()
+
 	merged := &IntermediateRepr{
 		Metadata: make(map[string]string),
 	}
 	var allWarnings []Warning
-
-	for _, src := range sources {
-		plugin, err := r.Detect(src)
-		if err != nil {
-			return nil, nil, err
+	taken := make(map[string]bool)
+	for i, res := range results {
+		if res.err != nil {
+			return nil, nil, fmt.Errorf("spec source %d (%s): %w", i+1, sources[i], res.err)
 		}
-
-		raw, err := plugin.Fetch(src)
-		if err != nil {
-			return nil, nil, fmt.Errorf("[%s] fetch: %w", plugin.Name(), err)
+		allWarnings = append(allWarnings, res.warnings...)
+		if res.ir != nil {
+			res.ir.normalizeOperationIDs(taken)
 		}
+		merged.Merge(res.ir)
+	}
 
-		parsed, err := plugin.Parse(raw, src)
-		if err != nil {
-			return nil, nil, fmt.Errorf("[%s] parse: %w", plugin.Name(), err)
-		}
+	return merged, allWarnings, nil
+}
 
-		warnings := plugin.Validate(parsed)
-		allWarnings = append(allWarnings, warnings...)
+// processSource runs detect, fetch, parse, and validate for one source.
+func (r *Registry) processSource(ctx context.Context, src instructions.SpecSource) sourceResult {
+	plugin, detectWarnings, err := r.detect(src)
+	if err != nil {
+		return sourceResult{err: err}
+	}
 
-		merged.Merge(parsed)
+	raw, err := plugin.Fetch(ctx, src)
+	if err != nil {
+		return sourceResult{err: fmt.Errorf("[%s] fetch: %w", plugin.Name(), err)}
 	}
 
-	return merged, allWarnings, nil
+	parsed, err := plugin.Parse(raw, src)
+	if err != nil {
+		return sourceResult{err: fmt.Errorf("[%s] parse: %w", plugin.Name(), err)}
+	}
+	if src.SortParameters {
+		parsed.SortParameters()
+	}
+
+	warnings := plugin.Validate(parsed)
+	for i := range warnings {
+		if warnings[i].Severity == "" {
+			warnings[i].Severity = diag.SeverityWarning
+		}
+		if warnings[i].Code == "" {
+			warnings[i].Code = plugin.Name()
+		}
+		if warnings[i].Source == "" {
+			warnings[i].Source = src.String()
+		}
+	}
+	return sourceResult{ir: parsed, warnings: append(detectWarnings, warnings...)}
 }
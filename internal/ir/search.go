@@ -0,0 +1,80 @@
+package ir
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// Match scores returned by Operation.MatchScore, best first. Zero means no
+// match.
+const (
+	MatchExact       = 100 // the ID, name, path, or "METHOD /path" is the query
+	MatchRoute       = 60  // the ID or "METHOD /path" contains the query
+	MatchName        = 50  // the name contains the query
+	MatchTag         = 40  // a tag contains the query
+	MatchDescription = 20  // the description contains the query
+	MatchTokens      = 10  // every word of the query appears somewhere
+)
+
+// MatchScore scores how well op matches query, case-insensitively: see the
+// Match constants. Each word of a multi-word query may match a different
+// field, so "create pet" finds createPet.
+func (op Operation) MatchScore(query string) int {
+	q := strings.ToLower(strings.TrimSpace(query))
+	if q == "" {
+		return 0
+	}
+	id := strings.ToLower(op.ID)
+	name := strings.ToLower(op.Name)
+	path := strings.ToLower(op.Path)
+	route := strings.ToLower(strings.TrimSpace(op.Method + " " + op.Path))
+	tags := strings.ToLower(strings.Join(op.Tags, "\n"))
+	description := strings.ToLower(op.Description)
+
+	switch {
+	case q == id || q == route || (name != "" && q == name) || (path != "" && q == path):
+		return MatchExact
+	case strings.Contains(id, q) || strings.Contains(route, q):
+		return MatchRoute
+	case strings.Contains(name, q):
+		return MatchName
+	case strings.Contains(tags, q):
+		return MatchTag
+	case strings.Contains(description, q):
+		return MatchDescription
+	}
+
+	words := strings.Fields(q)
+	if len(words) < 2 {
+		return 0
+	}
+	all := strings.Join([]string{id, name, route, tags, description}, "\n")
+	for _, w := range words {
+		if !strings.Contains(all, w) {
+			return 0
+		}
+	}
+	return MatchTokens
+}
+
+// Search returns the operations matching query, best match first; see
+// Operation.MatchScore. Equally good matches keep the IR's order.
+func (ir *IntermediateRepr) Search(query string) []Operation {
+	type scored struct {
+		op    Operation
+		score int
+	}
+	var matches []scored
+	for _, op := range ir.Operations {
+		if score := op.MatchScore(query); score > 0 {
+			matches = append(matches, scored{op, score})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int { return cmp.Compare(b.score, a.score) })
+	ops := make([]Operation, len(matches))
+	for i, m := range matches {
+		ops[i] = m.op
+	}
+	return ops
+}
@@ -0,0 +1,515 @@
+package asyncapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/fetch"
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/sniff"
+	"gopkg.in/yaml.v3"
+)
+
+// Plugin handles AsyncAPI 2.x and 3.x spec sources for event-driven APIs.
+type Plugin struct{}
+
+func New() *Plugin { return &Plugin{} }
+
+func (p *Plugin) Name() string { return "asyncapi" }
+
+func (p *Plugin) Detect(source instructions.SpecSource) bool {
+	if source.Type == "asyncapi" {
+		return true
+	}
+	if source.Type != "" || source.Path == "" {
+		return false
+	}
+	return sniff.File(source.Path) == sniff.AsyncAPI
+}
+
+func (p *Plugin) Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error) {
+	if source.Path != "" {
+		return os.ReadFile(source.Path)
+	}
+	if source.URL != "" {
+		return fetch.URL(ctx, source)
+	}
+	if source.Command != "" {
+		return fetch.Command(ctx, source)
+	}
+	return nil, fmt.Errorf("asyncapi plugin: no path, url, or command in spec source")
+}
+
+// asyncAPIDoc is a minimal representation covering both 2.x and 3.x layouts.
+type asyncAPIDoc struct {
+	AsyncAPI   string                        `yaml:"asyncapi"`
+	Info       asyncAPIInfo                  `yaml:"info"`
+	Servers    map[string]asyncAPIServer     `yaml:"servers"`
+	Channels   map[string]*asyncAPIChannel   `yaml:"channels"`
+	Operations map[string]*asyncAPIOperation `yaml:"operations"` // 3.x only
+	Components *asyncAPIComponents           `yaml:"components"`
+}
+
+type asyncAPIInfo struct {
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Version     string `yaml:"version"`
+}
+
+type asyncAPIServer struct {
+	URL         string `yaml:"url"`      // 2.x
+	Host        string `yaml:"host"`     // 3.x
+	Pathname    string `yaml:"pathname"` // 3.x
+	Protocol    string `yaml:"protocol"`
+	Description string `yaml:"description"`
+}
+
+type asyncAPIChannel struct {
+	Ref         string                      `yaml:"$ref"`
+	Address     string                      `yaml:"address"` // 3.x
+	Description string                      `yaml:"description"`
+	Parameters  map[string]asyncAPIParam    `yaml:"parameters"`
+	Publish     *asyncAPIOperation          `yaml:"publish"`   // 2.x
+	Subscribe   *asyncAPIOperation          `yaml:"subscribe"` // 2.x
+	Messages    map[string]*asyncAPIMessage `yaml:"messages"`  // 3.x
+}
+
+type asyncAPIParam struct {
+	Description string          `yaml:"description"`
+	Schema      *asyncAPISchema `yaml:"schema"`
+}
+
+type asyncAPIOperation struct {
+	OperationID string             `yaml:"operationId"` // 2.x
+	Action      string             `yaml:"action"`      // 3.x: send, receive
+	Channel     *asyncAPIChannel   `yaml:"channel"`     // 3.x: $ref to a channel
+	Summary     string             `yaml:"summary"`
+	Description string             `yaml:"description"`
+	Tags        []asyncAPITag      `yaml:"tags"`
+	Message     *asyncAPIMessage   `yaml:"message"`  // 2.x
+	Messages    []*asyncAPIMessage `yaml:"messages"` // 3.x
+}
+
+type asyncAPITag struct {
+	Name string `yaml:"name"`
+}
+
+type asyncAPIMessage struct {
+	Ref         string             `yaml:"$ref"`
+	Name        string             `yaml:"name"`
+	Title       string             `yaml:"title"`
+	Summary     string             `yaml:"summary"`
+	Description string             `yaml:"description"`
+	ContentType string             `yaml:"contentType"`
+	Payload     *asyncAPISchema    `yaml:"payload"`
+	OneOf       []*asyncAPIMessage `yaml:"oneOf"`
+}
+
+type asyncAPISchema struct {
+	Ref         string                     `yaml:"$ref"`
+	Type        string                     `yaml:"type"`
+	Format      string                     `yaml:"format"`
+	Description string                     `yaml:"description"`
+	Properties  map[string]*asyncAPISchema `yaml:"properties"`
+	Items       *asyncAPISchema            `yaml:"items"`
+	Required    []string                   `yaml:"required"`
+	Enum        []string                   `yaml:"enum"`
+}
+
+type asyncAPIComponents struct {
+	Messages map[string]*asyncAPIMessage `yaml:"messages"`
+	Schemas  map[string]*asyncAPISchema  `yaml:"schemas"`
+}
+
+// parser carries the document and accumulated types while building the IR.
+type parser struct {
+	doc   *asyncAPIDoc
+	types map[string]ir.TypeDef
+}
+
+func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.IntermediateRepr, error) {
+	var doc asyncAPIDoc
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		// Try JSON
+		if err2 := json.Unmarshal(raw, &doc); err2 != nil {
+			return nil, fmt.Errorf("parsing AsyncAPI document: %w", err)
+		}
+	}
+
+	if !strings.HasPrefix(doc.AsyncAPI, "2.") && !strings.HasPrefix(doc.AsyncAPI, "3.") {
+		return nil, fmt.Errorf("unsupported AsyncAPI version: %q (only 2.x and 3.x supported)", doc.AsyncAPI)
+	}
+
+	ps := &parser{doc: &doc, types: make(map[string]ir.TypeDef)}
+	result := &ir.IntermediateRepr{
+		Metadata: map[string]string{
+			"title":       doc.Info.Title,
+			"description": doc.Info.Description,
+			"version":     doc.Info.Version,
+			"type":        "asyncapi",
+		},
+	}
+	ps.serverMetadata(result.Metadata)
+
+	// Named component schemas become types up front so payload refs resolve to them
+	if doc.Components != nil {
+		for _, name := range sortedKeys(doc.Components.Schemas) {
+			ps.addType(name, doc.Components.Schemas[name])
+		}
+	}
+
+	if strings.HasPrefix(doc.AsyncAPI, "2.") {
+		ps.parseV2(result)
+	} else {
+		ps.parseV3(result)
+	}
+
+	// Build groups from tags (sorted for deterministic output)
+	groupOps := make(map[string][]string)
+	for _, op := range result.Operations {
+		for _, tag := range op.Tags {
+			groupOps[tag] = append(groupOps[tag], op.ID)
+		}
+	}
+	for _, name := range sortedKeys(groupOps) {
+		result.Groups = append(result.Groups, ir.Group{Name: name, Operations: groupOps[name]})
+	}
+
+	for _, name := range sortedKeys(ps.types) {
+		result.Types = append(result.Types, ps.types[name])
+	}
+
+	return result, nil
+}
+
+// serverMetadata records server URLs and protocols in the IR metadata.
+func (ps *parser) serverMetadata(meta map[string]string) {
+	var servers, protocols []string
+	seen := make(map[string]bool)
+	for _, name := range sortedKeys(ps.doc.Servers) {
+		srv := ps.doc.Servers[name]
+		url := srv.URL
+		if url == "" {
+			url = srv.Host + srv.Pathname
+		}
+		servers = append(servers, fmt.Sprintf("%s=%s://%s", name, srv.Protocol, strings.TrimPrefix(url, srv.Protocol+"://")))
+		if srv.Protocol != "" && !seen[srv.Protocol] {
+			seen[srv.Protocol] = true
+			protocols = append(protocols, srv.Protocol)
+		}
+	}
+	if len(servers) > 0 {
+		meta["servers"] = strings.Join(servers, ", ")
+	}
+	if len(protocols) > 0 {
+		meta["protocol"] = strings.Join(protocols, ", ")
+	}
+}
+
+func (ps *parser) parseV2(result *ir.IntermediateRepr) {
+	for _, name := range sortedKeys(ps.doc.Channels) {
+		ch := ps.doc.Channels[name]
+		if ch == nil {
+			continue
+		}
+		if ch.Publish != nil {
+			result.Operations = append(result.Operations, ps.operation("PUB", name, "", ch, ch.Publish, []*asyncAPIMessage{ch.Publish.Message}))
+		}
+		if ch.Subscribe != nil {
+			result.Operations = append(result.Operations, ps.operation("SUB", name, "", ch, ch.Subscribe, []*asyncAPIMessage{ch.Subscribe.Message}))
+		}
+	}
+}
+
+func (ps *parser) parseV3(result *ir.IntermediateRepr) {
+	for _, opName := range sortedKeys(ps.doc.Operations) {
+		op := ps.doc.Operations[opName]
+		if op == nil {
+			continue
+		}
+		method := "SUB"
+		if op.Action == "send" {
+			method = "PUB"
+		}
+		chName, ch := ps.resolveChannel(op.Channel)
+		path := chName
+		if ch != nil && ch.Address != "" {
+			path = ch.Address
+		}
+		messages := op.Messages
+		if len(messages) == 0 && ch != nil {
+			for _, key := range sortedKeys(ch.Messages) {
+				messages = append(messages, ch.Messages[key])
+			}
+		}
+		result.Operations = append(result.Operations, ps.operation(method, path, opName, ch, op, messages))
+	}
+}
+
+// operation converts a channel operation and its messages into an IR operation.
+func (ps *parser) operation(method, path, id string, ch *asyncAPIChannel, op *asyncAPIOperation, messages []*asyncAPIMessage) ir.Operation {
+	if op.OperationID != "" {
+		id = op.OperationID
+	}
+	if id == "" {
+		id = strings.ToLower(method) + "_" + sanitize(path)
+	}
+	desc := op.Description
+	if desc == "" {
+		desc = op.Summary
+	}
+	if desc == "" && ch != nil {
+		desc = ch.Description
+	}
+
+	irOp := ir.Operation{
+		ID:          id,
+		Name:        op.Summary,
+		Description: desc,
+		Method:      method,
+		Path:        path,
+	}
+	for _, tag := range op.Tags {
+		irOp.Tags = append(irOp.Tags, tag.Name)
+	}
+
+	if ch != nil {
+		for _, name := range sortedKeys(ch.Parameters) {
+			param := ch.Parameters[name]
+			irOp.Parameters = append(irOp.Parameters, ir.Parameter{
+				Name:        name,
+				In:          "channel",
+				Description: param.Description,
+				Required:    true,
+				Type:        schemaType(param.Schema),
+			})
+		}
+	}
+
+	// Flatten oneOf so every alternative message is recorded as a type
+	var flat []*asyncAPIMessage
+	for _, m := range messages {
+		if m == nil {
+			continue
+		}
+		key, msg := ps.resolveMessage(m)
+		if msg == nil {
+			continue
+		}
+		if len(msg.OneOf) > 0 {
+			for _, alt := range msg.OneOf {
+				if altKey, altMsg := ps.resolveMessage(alt); altMsg != nil {
+					flat = append(flat, ps.named(altKey, altMsg))
+				}
+			}
+			continue
+		}
+		flat = append(flat, ps.named(key, msg))
+	}
+
+	var typeNames []string
+	contentType := ""
+	for _, msg := range flat {
+		typeNames = append(typeNames, ps.messageType(id, msg))
+		if contentType == "" {
+			contentType = msg.ContentType
+		}
+	}
+	if len(flat) > 0 {
+		irOp.RequestBody = &ir.TypeRef{
+			TypeName:    strings.Join(typeNames, " | "),
+			Description: messageDescription(flat[0]),
+			ContentType: contentType,
+		}
+	}
+
+	return irOp
+}
+
+// named returns a copy of msg whose Name falls back to its component key.
+func (ps *parser) named(key string, msg *asyncAPIMessage) *asyncAPIMessage {
+	if msg.Name != "" || key == "" {
+		return msg
+	}
+	cp := *msg
+	cp.Name = key
+	return &cp
+}
+
+// messageType records a message payload as a TypeDef and returns its name.
+func (ps *parser) messageType(opID string, msg *asyncAPIMessage) string {
+	if msg.Payload != nil && msg.Payload.Ref != "" {
+		name := refName(msg.Payload.Ref)
+		if _, ok := ps.types[name]; !ok {
+			if schema := ps.lookupSchema(msg.Payload.Ref); schema != nil {
+				ps.addType(name, schema)
+			}
+		}
+		return name
+	}
+	name := msg.Name
+	if name == "" {
+		name = opID + "Message"
+	}
+	if _, ok := ps.types[name]; !ok {
+		td := ir.TypeDef{Name: name, Description: messageDescription(msg)}
+		if msg.Payload != nil {
+			td = buildTypeDef(name, msg.Payload)
+			if td.Description == "" {
+				td.Description = messageDescription(msg)
+			}
+		}
+		ps.types[name] = td
+	}
+	return name
+}
+
+func (ps *parser) addType(name string, schema *asyncAPISchema) {
+	if schema == nil {
+		return
+	}
+	ps.types[name] = buildTypeDef(name, schema)
+}
+
+// resolveChannel follows a 3.x operation's channel $ref.
+func (ps *parser) resolveChannel(ch *asyncAPIChannel) (string, *asyncAPIChannel) {
+	if ch == nil {
+		return "", nil
+	}
+	if ch.Ref == "" {
+		return ch.Address, ch
+	}
+	name := refName(ch.Ref)
+	return name, ps.doc.Channels[name]
+}
+
+// resolveMessage follows a message $ref into channel or component messages.
+func (ps *parser) resolveMessage(m *asyncAPIMessage) (string, *asyncAPIMessage) {
+	if m == nil || m.Ref == "" {
+		return "", m
+	}
+	parts := strings.Split(strings.TrimPrefix(m.Ref, "#/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "components" && parts[1] == "messages":
+		if ps.doc.Components == nil {
+			return parts[2], nil
+		}
+		return parts[2], ps.doc.Components.Messages[parts[2]]
+	case len(parts) == 4 && parts[0] == "channels" && parts[2] == "messages":
+		ch := ps.doc.Channels[parts[1]]
+		if ch == nil {
+			return parts[3], nil
+		}
+		// Channel messages frequently point at component messages themselves
+		key, msg := ps.resolveMessage(ch.Messages[parts[3]])
+		if key == "" {
+			key = parts[3]
+		}
+		return key, msg
+	default:
+		return refName(m.Ref), nil
+	}
+}
+
+func (ps *parser) lookupSchema(ref string) *asyncAPISchema {
+	if ps.doc.Components == nil || !strings.HasPrefix(ref, "#/components/schemas/") {
+		return nil
+	}
+	return ps.doc.Components.Schemas[refName(ref)]
+}
+
+func (p *Plugin) Validate(parsed *ir.IntermediateRepr) []ir.Warning {
+	var warnings []ir.Warning
+	for _, op := range parsed.Operations {
+		if op.Description == "" && op.Name == "" {
+			warnings = append(warnings, ir.Warning{
+				Message: fmt.Sprintf("operation %s has no description or summary", op.ID),
+			})
+		}
+		if op.RequestBody == nil {
+			warnings = append(warnings, ir.Warning{
+				Message: fmt.Sprintf("operation %s on channel %s has no message", op.ID, op.Path),
+			})
+		}
+	}
+	return warnings
+}
+
+func buildTypeDef(name string, schema *asyncAPISchema) ir.TypeDef {
+	td := ir.TypeDef{
+		Name:        name,
+		Description: schema.Description,
+		Enum:        schema.Enum,
+	}
+	for _, fieldName := range sortedKeys(schema.Properties) {
+		fieldSchema := schema.Properties[fieldName]
+		required := false
+		for _, req := range schema.Required {
+			if req == fieldName {
+				required = true
+				break
+			}
+		}
+		desc := ""
+		if fieldSchema != nil {
+			desc = fieldSchema.Description
+		}
+		td.Fields = append(td.Fields, ir.TypeField{
+			Name:        fieldName,
+			Type:        schemaType(fieldSchema),
+			Description: desc,
+			Required:    required,
+		})
+	}
+	return td
+}
+
+func messageDescription(msg *asyncAPIMessage) string {
+	for _, s := range []string{msg.Description, msg.Summary, msg.Title} {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func schemaType(s *asyncAPISchema) string {
+	if s == nil {
+		return ""
+	}
+	if s.Ref != "" {
+		return refName(s.Ref)
+	}
+	if s.Type == "array" && s.Items != nil {
+		return "[]" + schemaType(s.Items)
+	}
+	if s.Format != "" {
+		return s.Type + "(" + s.Format + ")"
+	}
+	return s.Type
+}
+
+func refName(ref string) string {
+	parts := strings.Split(ref, "/")
+	return parts[len(parts)-1]
+}
+
+// sanitize turns a channel address into an identifier-friendly string.
+func sanitize(path string) string {
+	r := strings.NewReplacer("/", "_", ".", "_", "{", "", "}", "", "-", "_")
+	return strings.Trim(r.Replace(path), "_")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
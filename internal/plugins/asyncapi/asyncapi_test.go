@@ -0,0 +1,172 @@
+package asyncapi
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading testdata/%s: %v", name, err)
+	}
+	return data
+}
+
+func TestDetect(t *testing.T) {
+	p := New()
+
+	tests := []struct {
+		name   string
+		source instructions.SpecSource
+		want   bool
+	}{
+		{"explicit type", instructions.SpecSource{Type: "asyncapi", URL: "http://example.com"}, true},
+		{"asyncapi document", instructions.SpecSource{Path: filepath.Join("testdata", "streetlights.yaml")}, true},
+		{"openapi document", instructions.SpecSource{Path: filepath.Join("..", "openapi", "testdata", "petstore.yaml")}, false},
+		{"openapi type", instructions.SpecSource{Type: "openapi", Path: "api.yaml"}, false},
+		{"missing file", instructions.SpecSource{Path: "nope.yaml"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Detect(tt.source)
+			if got != tt.want {
+				t.Errorf("Detect(%+v) = %v, want %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_V2(t *testing.T) {
+	p := New()
+	data := readTestdata(t, "streetlights.yaml")
+
+	result, err := p.Parse(data, instructions.SpecSource{Path: "testdata/streetlights.yaml"})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if len(result.Operations) != 2 {
+		t.Fatalf("got %d operations, want 2", len(result.Operations))
+	}
+	ops := map[string]int{}
+	for i, op := range result.Operations {
+		ops[op.ID] = i
+	}
+
+	turn := result.Operations[ops["turnLight"]]
+	if turn.Method != "PUB" {
+		t.Errorf("turnLight method = %q, want PUB", turn.Method)
+	}
+	if turn.Path != "smartylighting/streetlights/{streetlightId}/command/turn" {
+		t.Errorf("turnLight path = %q", turn.Path)
+	}
+	if len(turn.Parameters) != 1 || turn.Parameters[0].In != "channel" {
+		t.Errorf("turnLight parameters = %+v, want one channel parameter", turn.Parameters)
+	}
+	if turn.RequestBody == nil || turn.RequestBody.TypeName != "turnCommand" {
+		t.Errorf("turnLight body = %+v, want turnCommand", turn.RequestBody)
+	}
+
+	measured := result.Operations[ops["receiveLightMeasurement"]]
+	if measured.Method != "SUB" {
+		t.Errorf("receiveLightMeasurement method = %q, want SUB", measured.Method)
+	}
+	if measured.RequestBody == nil || measured.RequestBody.TypeName != "lightMeasuredPayload" {
+		t.Errorf("receiveLightMeasurement body = %+v, want lightMeasuredPayload", measured.RequestBody)
+	}
+
+	types := map[string]bool{}
+	for _, td := range result.Types {
+		types[td.Name] = true
+	}
+	for _, want := range []string{"turnCommand", "lightMeasuredPayload"} {
+		if !types[want] {
+			t.Errorf("missing type %q, got %+v", want, result.Types)
+		}
+	}
+
+	if result.Metadata["protocol"] != "kafka, mqtt" {
+		t.Errorf("protocol = %q, want %q", result.Metadata["protocol"], "kafka, mqtt")
+	}
+	if !strings.Contains(result.Metadata["servers"], "production=kafka://broker.example.com:9092") {
+		t.Errorf("servers = %q, want production kafka server", result.Metadata["servers"])
+	}
+	if len(result.Groups) != 2 {
+		t.Errorf("got %d groups, want 2", len(result.Groups))
+	}
+}
+
+func TestParse_V3(t *testing.T) {
+	p := New()
+	spec := `asyncapi: 3.0.0
+info:
+  title: Accounts
+  version: "1.0"
+servers:
+  prod:
+    host: rabbit.example.com
+    protocol: amqp
+channels:
+  userSignedUp:
+    address: user/signedup
+    messages:
+      UserSignedUp:
+        $ref: "#/components/messages/UserSignedUp"
+operations:
+  onUserSignedUp:
+    action: receive
+    summary: A user signed up
+    channel:
+      $ref: "#/channels/userSignedUp"
+  publishUserSignedUp:
+    action: send
+    channel:
+      $ref: "#/channels/userSignedUp"
+    messages:
+      - $ref: "#/channels/userSignedUp/messages/UserSignedUp"
+components:
+  messages:
+    UserSignedUp:
+      payload:
+        type: object
+        properties:
+          email:
+            type: string
+            format: email`
+
+	result, err := p.Parse([]byte(spec), instructions.SpecSource{Type: "asyncapi"})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(result.Operations) != 2 {
+		t.Fatalf("got %d operations, want 2", len(result.Operations))
+	}
+	for _, op := range result.Operations {
+		if op.Path != "user/signedup" {
+			t.Errorf("%s path = %q, want user/signedup", op.ID, op.Path)
+		}
+		if op.RequestBody == nil || op.RequestBody.TypeName != "UserSignedUp" {
+			t.Errorf("%s body = %+v, want UserSignedUp", op.ID, op.RequestBody)
+		}
+	}
+	if result.Operations[0].Method != "SUB" || result.Operations[1].Method != "PUB" {
+		t.Errorf("methods = %s/%s, want SUB/PUB", result.Operations[0].Method, result.Operations[1].Method)
+	}
+	if result.Metadata["protocol"] != "amqp" {
+		t.Errorf("protocol = %q, want amqp", result.Metadata["protocol"])
+	}
+}
+
+func TestParse_UnsupportedVersion(t *testing.T) {
+	p := New()
+	_, err := p.Parse([]byte("asyncapi: 1.2.0\ninfo:\n  title: Old\n"), instructions.SpecSource{})
+	if err == nil || !strings.Contains(err.Error(), "unsupported AsyncAPI version") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+}
@@ -23,7 +23,7 @@ func (p *Plugin) Detect(source instructions.SpecSource) bool {
 	return source.Type == "cli" && source.Binary != ""
 }
 
-func (p *Plugin) Fetch(source instructions.SpecSource) ([]byte, error) {
+func (p *Plugin) Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error) {
 	binary := source.Binary
 	if _, err := exec.LookPath(binary); err != nil {
 		return nil, fmt.Errorf("binary %q not found in PATH", binary)
@@ -53,11 +53,14 @@ func (p *Plugin) Fetch(source instructions.SpecSource) ([]byte, error) {
 	queue := []cmdEntry{{path: nil, depth: 0}}
 
 	for len(queue) > 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("crawling %s help: %w", binary, err)
+		}
 		entry := queue[0]
 		queue = queue[1:]
 
 		args := append(entry.path, helpFlag)
-		output, err := runWithTimeout(binary, args, 5*time.Second)
+		output, err := runWithTimeout(ctx, binary, args, 5*time.Second)
 		if err != nil {
 			// Log warning but continue
 			results = append(results, crawlResult{
@@ -112,10 +115,17 @@ type crawlResult struct {
 type parsedHelp struct {
 	description string
 	subcommands []string
+	args        []parsedArg // positional arguments, in usage-line order
 	flags       []parsedFlag
 	aliases     []string
 }
 
+type parsedArg struct {
+	name     string
+	required bool
+	variadic bool
+}
+
 type parsedFlag struct {
 	name      string
 	shorthand string
@@ -142,7 +152,7 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 		helpText := block.text
 		parsed := parseHelpOutput(helpText)
 
-		opID := strings.ReplaceAll(cmdPath, " ", "_")
+		opID := ir.OperationID("", cmdPath)
 		op := ir.Operation{
 			ID:          opID,
 			Name:        cmdPath,
@@ -152,6 +162,19 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 			RawHelpText: helpText,
 		}
 
+		// Arguments come first, in usage-line order, which is their meaning
+		for _, a := range parsed.args {
+			typ := "string"
+			if a.variadic {
+				typ = "strings"
+			}
+			op.Parameters = append(op.Parameters, ir.Parameter{
+				Name:     a.name,
+				In:       "argument",
+				Required: a.required,
+				Type:     typ,
+			})
+		}
 		for _, f := range parsed.flags {
 			op.Parameters = append(op.Parameters, ir.Parameter{
 				Name:        f.name,
@@ -220,11 +243,15 @@ func splitCommandBlocks(content string) []commandBlock {
 	return blocks
 }
 
-func runWithTimeout(binary string, args []string, timeout time.Duration) (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+// runWithTimeout runs one help command, bounded by timeout and by parent.
+func runWithTimeout(parent context.Context, binary string, args []string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(parent, timeout)
 	defer cancel()
 	cmd := exec.CommandContext(ctx, binary, args...)
 	out, err := cmd.CombinedOutput()
+	if err := parent.Err(); err != nil {
+		return "", err
+	}
 	if ctx.Err() == context.DeadlineExceeded {
 		return "", fmt.Errorf("command timed out after %s", timeout)
 	}
@@ -247,8 +274,55 @@ var (
 	longFlagRe = regexp.MustCompile(`^\s+(--[\w-]+)\s+(\S+)?\s*(.*)$`)
 	// Matches aliases line like "Aliases:\n  cmd, c"
 	aliasRe = regexp.MustCompile(`(?i)aliases?:\s*\n?\s*(.+)`)
+	// Matches the words of a usage line, keeping "<a b>" and "[a b]" whole
+	usageTokenRe = regexp.MustCompile(`<[^>]*>(?:\.\.\.)?|\[[^\]]*\](?:\.\.\.)?|\S+`)
 )
 
+// usagePlaceholders are bracketed usage words that stand for flags or
+// subcommands rather than arguments.
+var usagePlaceholders = map[string]bool{
+	"flags": true, "options": true, "global flags": true, "global options": true,
+	"command": true, "subcommand": true,
+}
+
+// parseUsageArgs returns the positional arguments of a usage line such as
+// "mytool cp <src> [dest...] [flags]", in order. Plain words are the command
+// path; <name> and NAME are required, [name] is optional, and a trailing
+// "..." takes several values.
+func parseUsageArgs(line string) []parsedArg {
+	var args []parsedArg
+	for _, tok := range usageTokenRe.FindAllString(line, -1) {
+		arg := parsedArg{required: true}
+		if strings.HasSuffix(tok, "...") {
+			arg.variadic = true
+			tok = strings.TrimSuffix(tok, "...")
+		}
+		switch {
+		case strings.HasPrefix(tok, "<") && strings.HasSuffix(tok, ">"):
+			arg.name = tok[1 : len(tok)-1]
+		case strings.HasPrefix(tok, "[") && strings.HasSuffix(tok, "]"):
+			arg.name, arg.required = tok[1:len(tok)-1], false
+		case tok != strings.ToLower(tok) && tok == strings.ToUpper(tok):
+			arg.name = tok
+		default:
+			continue // the command path, a flag, or a literal word
+		}
+		arg.name = strings.TrimSpace(arg.name)
+		if strings.HasSuffix(arg.name, "...") { // "[name...]"
+			arg.variadic = true
+			arg.name = strings.TrimSuffix(arg.name, "...")
+		}
+		if strings.HasPrefix(arg.name, "<") && strings.HasSuffix(arg.name, ">") {
+			arg.name = arg.name[1 : len(arg.name)-1] // "[<name>]"
+		}
+		if arg.name == "" || strings.HasPrefix(arg.name, "-") || usagePlaceholders[strings.ToLower(arg.name)] {
+			continue
+		}
+		args = append(args, arg)
+	}
+	return args
+}
+
 func parseHelpOutput(text string) parsedHelp {
 	var result parsedHelp
 	lines := strings.Split(text, "\n")
@@ -258,9 +332,21 @@ func parseHelpOutput(text string) parsedHelp {
 	inDesc := true
 
 	section := ""
+	usageSeen := false
 	for _, line := range lines {
 		lower := strings.ToLower(strings.TrimSpace(line))
 
+		// Usage may share its line with the header: "Usage: mytool <file>"
+		if strings.HasPrefix(lower, "usage:") && len(lower) > len("usage:") && !strings.HasPrefix(line, " ") {
+			if !usageSeen {
+				result.args = parseUsageArgs(strings.TrimSpace(line)[len("usage:"):])
+				usageSeen = true
+			}
+			inDesc = false
+			section = ""
+			continue
+		}
+
 		// Detect sections
 		if strings.HasSuffix(lower, ":") && !strings.HasPrefix(line, " ") {
 			inDesc = false
@@ -280,6 +366,12 @@ func parseHelpOutput(text string) parsedHelp {
 		}
 
 		switch section {
+		case "usage":
+			// Only the first form is used; alternatives may reorder arguments
+			if !usageSeen {
+				result.args = parseUsageArgs(line)
+				usageSeen = true
+			}
 		case "available commands", "commands", "subcommands":
 			if m := subcommandRe.FindStringSubmatch(line); m != nil {
 				result.subcommands = append(result.subcommands, m[1])
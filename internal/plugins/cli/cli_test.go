@@ -1,6 +1,8 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -115,3 +117,57 @@ func TestParse_CommandBlocks(t *testing.T) {
 		t.Error("should have parsed flags into parameters")
 	}
 }
+
+func TestParseUsageArgs(t *testing.T) {
+	tests := []struct {
+		usage string
+		want  string
+	}{
+		{"mytool [command]", ""},
+		{"mytool cp <src> <dest> [flags]", "src! dest!"},
+		{"mytool cp SOURCE DEST", "SOURCE! DEST!"},
+		{"mytool add [-f FILE] <name> [tags...]", "name! tags..."},
+		{"mytool run <script> [<args>...] [options]", "script! args..."},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, a := range parseUsageArgs(tt.usage) {
+			s := a.name
+			if a.variadic {
+				s += "..."
+			}
+			if a.required {
+				s += "!"
+			}
+			got = append(got, s)
+		}
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("parseUsageArgs(%q) = %q, want %q", tt.usage, strings.Join(got, " "), tt.want)
+		}
+	}
+}
+
+func TestParse_ArgumentsBeforeFlagsInOrder(t *testing.T) {
+	input := "=== COMMAND: mytool cp ===\nCopy a file\n\nUsage:\n  mytool cp <src> <dest> [flags]\n\nFlags:\n  -r, --recursive   bool   Copy directories\n      --force       bool   Overwrite\n=== END ==="
+	result, err := New().Parse([]byte(input), instructions.SpecSource{Type: "cli", Binary: "mytool"})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	var got []string
+	for _, param := range result.Operations[0].Parameters {
+		got = append(got, param.In+":"+param.Name)
+	}
+	want := "argument:src argument:dest flag:--recursive flag:--force"
+	if strings.Join(got, " ") != want {
+		t.Errorf("parameters = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestFetch_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := New().Fetch(ctx, instructions.SpecSource{Binary: "sh"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Fetch with a cancelled context = %v, want context.Canceled", err)
+	}
+}
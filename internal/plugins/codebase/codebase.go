@@ -1,6 +1,8 @@
 package codebase
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -8,6 +10,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/roberthamel/skill-compiler/internal/instructions"
 	"github.com/roberthamel/skill-compiler/internal/ir"
@@ -24,7 +27,7 @@ func (p *Plugin) Detect(source instructions.SpecSource) bool {
 	return source.Type == "codebase"
 }
 
-func (p *Plugin) Fetch(source instructions.SpecSource) ([]byte, error) {
+func (p *Plugin) Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error) {
 	root := source.Path
 	if root == "" {
 		root = "."
@@ -216,12 +219,6 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 			readConfigFile(fullPath, e.rel, structure)
 		}
 
-		// Agent instruction files
-		switch base {
-		case "CLAUDE.md", "AGENTS.md", "CONTRIBUTING.md", "README.md":
-			readDocFile(fullPath, e.rel, structure)
-		}
-
 		// Key source files
 		if isKeyFile(e.rel) {
 			content := readFileContent(fullPath, 50000)
@@ -237,14 +234,25 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 	}
 
 	structure.Stack = stack
+	structure.Docs = readDocs(scan.Root, scan.Entries, source)
 
-	return &ir.IntermediateRepr{
+	result := &ir.IntermediateRepr{
 		Structure: structure,
 		Metadata: map[string]string{
 			"type": "codebase",
 			"root": scan.Root,
 		},
-	}, nil
+	}
+	if source.Annotations != "" {
+		ops, groups, err := annotationOperations(source.Annotations, scan.Root, scan.Entries)
+		if err != nil {
+			return nil, err
+		}
+		result.Operations = ops
+		result.Groups = groups
+		result.Metadata["annotations"] = source.Annotations
+	}
+	return result, nil
 }
 
 func (p *Plugin) Validate(parsed *ir.IntermediateRepr) []ir.Warning {
@@ -254,6 +262,9 @@ func (p *Plugin) Validate(parsed *ir.IntermediateRepr) []ir.Warning {
 	} else if parsed.Structure.Stack == nil {
 		warnings = append(warnings, ir.Warning{Message: "could not detect technology stack"})
 	}
+	if style := parsed.Metadata["annotations"]; style != "" && len(parsed.Operations) == 0 {
+		warnings = append(warnings, ir.Warning{Message: fmt.Sprintf("no %s annotations with a route found", style)})
+	}
 	return warnings
 }
 
@@ -317,27 +328,41 @@ func readConfigFile(fullPath, relPath string, structure *ir.ProjectStructure) {
 	}
 }
 
-func readDocFile(fullPath, relPath string, structure *ir.ProjectStructure) {
-	content := readFileContent(fullPath, 100000)
-	if content != "" {
-		structure.Docs = append(structure.Docs, ir.DocFile{
-			Path:    relPath,
-			Content: content,
-		})
-	}
-}
-
+// readFileContent reads up to maxBytes of a text file. Binary files read as
+// empty, so their bytes never reach a prompt; they are still listed in the
+// file tree.
 func readFileContent(path string, maxBytes int) string {
 	data, err := os.ReadFile(path)
-	if err != nil {
+	if err != nil || isBinary(data) {
 		return ""
 	}
 	if len(data) > maxBytes {
-		data = data[:maxBytes]
+		data = trimPartialRune(data[:maxBytes])
 	}
 	return string(data)
 }
 
+// binarySniffBytes is how much of a file isBinary looks at, as in git.
+const binarySniffBytes = 8000
+
+// isBinary reports whether data looks like a binary file: a NUL byte or
+// invalid UTF-8 in its first 8000 bytes.
+func isBinary(data []byte) bool {
+	sample := data
+	if len(sample) > binarySniffBytes {
+		sample = trimPartialRune(sample[:binarySniffBytes])
+	}
+	return bytes.IndexByte(sample, 0) >= 0 || !utf8.Valid(sample)
+}
+
+// trimPartialRune drops a UTF-8 sequence cut off at the end of data.
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i < utf8.UTFMax && len(data) > 0 && !utf8.Valid(data); i++ {
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
 func isKeyFile(rel string) bool {
 	base := filepath.Base(rel)
 	lower := strings.ToLower(base)
@@ -413,40 +438,89 @@ func matchesAny(rel string, patterns []string) bool {
 	return false
 }
 
+// prioritizeFiles keeps at most maxFiles entries when a scan finds more. Files
+// are ranked by role (manifests, entrypoints, config, docs, other key files,
+// then the rest), then shallower paths first, then by path, so the same tree
+// always keeps the same files. A kept file's parent directories are kept and
+// count toward the limit; the result is in scan order.
 func prioritizeFiles(entries []fileInfo, maxFiles int) []fileInfo {
-	// Score files by importance
-	type scored struct {
-		entry fileInfo
-		score int
-	}
-	var scored2 []scored
+	var files []fileInfo
 	for _, e := range entries {
-		s := 0
-		base := strings.ToLower(filepath.Base(e.rel))
-		switch {
-		case base == "package.json" || base == "go.mod" || base == "cargo.toml" || base == "pyproject.toml":
-			s = 100
-		case base == "readme.md" || base == "claude.md" || base == "agents.md" || base == "contributing.md":
-			s = 90
-		case strings.Contains(base, "config") || base == "dockerfile" || base == "tsconfig.json":
-			s = 80
-		case isKeyFile(e.rel):
-			s = 70
-		default:
-			s = 10
+		if !e.isDir {
+			files = append(files, e)
 		}
-		scored2 = append(scored2, scored{entry: e, score: s})
 	}
-	sort.Slice(scored2, func(i, j int) bool {
-		return scored2[i].score > scored2[j].score
+	sort.SliceStable(files, func(i, j int) bool {
+		ri, rj := fileRank(files[i].rel), fileRank(files[j].rel)
+		if ri != rj {
+			return ri > rj
+		}
+		di, dj := strings.Count(files[i].rel, string(filepath.Separator)), strings.Count(files[j].rel, string(filepath.Separator))
+		if di != dj {
+			return di < dj
+		}
+		return files[i].rel < files[j].rel
 	})
-	result := make([]fileInfo, 0, maxFiles)
-	for i := 0; i < maxFiles && i < len(scored2); i++ {
-		result = append(result, scored2[i].entry)
+
+	keep := make(map[string]bool)
+	for _, f := range files {
+		added := []string{f.rel}
+		for dir := filepath.Dir(f.rel); dir != "." && !keep[dir]; dir = filepath.Dir(dir) {
+			added = append(added, dir)
+		}
+		if len(keep)+len(added) > maxFiles {
+			continue
+		}
+		for _, rel := range added {
+			keep[rel] = true
+		}
+	}
+
+	result := make([]fileInfo, 0, len(keep))
+	for _, e := range entries {
+		if keep[e.rel] {
+			result = append(result, e)
+		}
 	}
 	return result
 }
 
+// fileRank scores a file's importance for prioritizeFiles; higher is kept
+// first.
+func fileRank(rel string) int {
+	base := strings.ToLower(filepath.Base(rel))
+	switch {
+	case manifestFiles[base]:
+		return 100
+	case classifyFile(rel) == "entrypoint":
+		return 90
+	case strings.Contains(base, "config") || base == "dockerfile" || base == "tsconfig.json" || strings.HasPrefix(base, ".eslintrc"):
+		return 80
+	case isDoc(rel):
+		return 70
+	case isKeyFile(rel):
+		return 60
+	default:
+		return 10
+	}
+}
+
+// manifestFiles are the lowercased names of package manifests.
+var manifestFiles = map[string]bool{
+	"package.json": true, "go.mod": true, "cargo.toml": true, "pyproject.toml": true,
+	"requirements.txt": true, "pom.xml": true, "build.gradle": true, "gemfile": true, "composer.json": true,
+}
+
+// isDoc reports whether rel is matched by the default docs globs.
+func isDoc(rel string) bool {
+	for _, g := range defaultDocsGlobs {
+		if matchDocGlob(g, filepath.ToSlash(rel)) {
+			return true
+		}
+	}
+	return false
+}
+
 func appendUniq(slice []string, val string) []string {
 	for _, s := range slice {
 		if s == val {
@@ -1,8 +1,10 @@
 package codebase
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/roberthamel/skill-compiler/internal/instructions"
@@ -56,7 +58,7 @@ func TestParse_FileTree(t *testing.T) {
 	p := New()
 
 	source := instructions.SpecSource{Type: "codebase", Path: dir}
-	raw, err := p.Fetch(source)
+	raw, err := p.Fetch(context.Background(), source)
 	if err != nil {
 		t.Fatalf("fetch error: %v", err)
 	}
@@ -94,7 +96,7 @@ func TestParse_GoMod(t *testing.T) {
 	p := New()
 
 	source := instructions.SpecSource{Type: "codebase", Path: dir}
-	raw, err := p.Fetch(source)
+	raw, err := p.Fetch(context.Background(), source)
 	if err != nil {
 		t.Fatalf("fetch error: %v", err)
 	}
@@ -119,6 +121,46 @@ func TestParse_GoMod(t *testing.T) {
 	}
 }
 
+func TestParse_BinaryFilesListedButNotRead(t *testing.T) {
+	dir := setupTestDir(t)
+	_ = os.WriteFile(filepath.Join(dir, "models.db"), []byte("SQLite format 3\x00\x10\x00\xff\xfe"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "schema.go"), []byte("package main\n\n// Schema héllo\n"), 0o644)
+	p := New()
+
+	source := instructions.SpecSource{Type: "codebase", Path: dir}
+	raw, err := p.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("fetch error: %v", err)
+	}
+	result, err := p.Parse(raw, source)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	listed := false
+	for _, f := range result.Structure.FileTree {
+		listed = listed || f.Path == "models.db"
+	}
+	if !listed {
+		t.Error("models.db should be in the file tree")
+	}
+	var keyFiles []string
+	for _, f := range result.Structure.KeyFiles {
+		keyFiles = append(keyFiles, f.Path)
+	}
+	if got := strings.Join(keyFiles, " "); strings.Contains(got, "models.db") || !strings.Contains(got, "schema.go") {
+		t.Errorf("key files = %q, want schema.go but not the binary models.db", got)
+	}
+}
+
+func TestReadFileContent_TruncatesOnRuneBoundary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "README.md")
+	_ = os.WriteFile(path, []byte("ab\u00e9cd"), 0o644) // é is two bytes
+	if got := readFileContent(path, 3); got != "ab" {
+		t.Errorf("readFileContent = %q, want %q", got, "ab")
+	}
+}
+
 func TestParse_MaxFiles(t *testing.T) {
 	dir := t.TempDir()
 
@@ -129,7 +171,7 @@ func TestParse_MaxFiles(t *testing.T) {
 
 	p := New()
 	source := instructions.SpecSource{Type: "codebase", Path: dir, MaxFiles: 5}
-	raw, err := p.Fetch(source)
+	raw, err := p.Fetch(context.Background(), source)
 	if err != nil {
 		t.Fatalf("fetch error: %v", err)
 	}
@@ -143,3 +185,172 @@ func TestParse_MaxFiles(t *testing.T) {
 		t.Errorf("got %d files, want at most 5 (max-files limit)", len(result.Structure.FileTree))
 	}
 }
+
+func TestParse_MaxFilesKeepsImportantFiles(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 20; i++ {
+		_ = os.WriteFile(filepath.Join(dir, "aaa"+string(rune('a'+i))+".txt"), []byte("content"), 0o644)
+	}
+	_ = os.MkdirAll(filepath.Join(dir, "cmd", "app"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "cmd", "app", "main.go"), []byte("package main\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "README.md"), []byte("# App\n"), 0o644)
+
+	p := New()
+	source := instructions.SpecSource{Type: "codebase", Path: dir, MaxFiles: 6}
+	raw, err := p.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("fetch error: %v", err)
+	}
+	result, err := p.Parse(raw, source)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	var paths []string
+	for _, f := range result.Structure.FileTree {
+		paths = append(paths, filepath.ToSlash(f.Path))
+	}
+	// go.mod, then main.go and its two directories, then the README, then
+	// the first other file by name
+	want := "README.md aaaa.txt cmd cmd/app cmd/app/main.go go.mod"
+	if got := strings.Join(paths, " "); got != want {
+		t.Errorf("file tree = %q, want %q", got, want)
+	}
+}
+
+func TestParse_DocsPrioritizedAndCapped(t *testing.T) {
+	dir := setupTestDir(t)
+	_ = os.MkdirAll(filepath.Join(dir, "docs", "guides"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "docs", "guides", "setup.md"), []byte("setup guide"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "docs", "notes.txt"), []byte("not markdown"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "CONTRIBUTING.md"), []byte("contributing"), 0o644)
+	_ = os.WriteFile(filepath.Join(dir, "README.md"), []byte("readme"), 0o644)
+
+	p := New()
+	docPaths := func(source instructions.SpecSource) ([]string, string) {
+		t.Helper()
+		raw, err := p.Fetch(context.Background(), source)
+		if err != nil {
+			t.Fatalf("fetch error: %v", err)
+		}
+		result, err := p.Parse(raw, source)
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		var paths []string
+		for _, d := range result.Structure.Docs {
+			paths = append(paths, d.Path)
+		}
+		last := ""
+		if n := len(result.Structure.Docs); n > 0 {
+			last = result.Structure.Docs[n-1].Content
+		}
+		return paths, last
+	}
+
+	paths, _ := docPaths(instructions.SpecSource{Type: "codebase", Path: dir})
+	if got, want := strings.Join(paths, ","), "README.md,CONTRIBUTING.md,docs/guides/setup.md"; got != want {
+		t.Errorf("docs = %s, want %s", got, want)
+	}
+
+	paths, _ = docPaths(instructions.SpecSource{Type: "codebase", Path: dir, DocsGlobs: []string{"docs/**"}})
+	if got, want := strings.Join(paths, ","), "docs/notes.txt,docs/guides/setup.md"; got != want {
+		t.Errorf("docs with docs-globs = %s, want %s", got, want)
+	}
+
+	paths, last := docPaths(instructions.SpecSource{Type: "codebase", Path: dir, DocsMaxBytes: 8})
+	if len(paths) != 2 || !strings.HasPrefix(last, "co") || !strings.Contains(last, "[truncated") {
+		t.Errorf("capped docs = %v, last %q; want README then truncated CONTRIBUTING", paths, last)
+	}
+}
+
+func TestParse_SwaggoAnnotations(t *testing.T) {
+	dir := setupTestDir(t)
+	handlers := `package handlers
+
+// GetPet returns a pet.
+//
+//	@Summary		Get a pet
+//	@Description	Returns the pet with the given ID.
+//	@ID				getPet
+//	@Tags			pets
+//	@Produce		json
+//	@Param			id		path	int		true	"Pet ID"
+//	@Param			fields	query	string	false	"Fields to return"	enums(name,tag)	default(name)
+//	@Success		200		{object}	model.Pet
+//	@Failure		404		{object}	model.Error	"not found"
+//	@Router			/pets/{id} [get]
+func GetPet() {}
+
+// CreatePet adds a pet.
+//
+//	@Summary	Create a pet
+//	@Tags		pets
+//	@Accept		json
+//	@Param		pet	body	model.Pet	true	"The pet"
+//	@Success	201	{array}	model.Pet
+//	@Deprecated
+//	@Router		/pets [post]
+func CreatePet() {}
+
+// helper has no route.
+//
+//	@Summary	Not an operation
+func helper() {}
+`
+	_ = os.MkdirAll(filepath.Join(dir, "handlers"), 0o755)
+	_ = os.WriteFile(filepath.Join(dir, "handlers", "pets.go"), []byte(handlers), 0o644)
+	p := New()
+
+	source := instructions.SpecSource{Type: "codebase", Path: dir, Annotations: AnnotationsSwaggo}
+	raw, err := p.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("fetch error: %v", err)
+	}
+	result, err := p.Parse(raw, source)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(result.Operations) != 2 {
+		t.Fatalf("operations = %+v, want 2", result.Operations)
+	}
+
+	get := result.Operations[0]
+	if get.ID != "getPet" || get.Method != "GET" || get.Path != "/pets/{id}" || get.Name != "Get a pet" {
+		t.Errorf("get = %s %s %s %q, want getPet GET /pets/{id} \"Get a pet\"", get.ID, get.Method, get.Path, get.Name)
+	}
+	if len(get.Parameters) != 2 {
+		t.Fatalf("get parameters = %+v, want 2", get.Parameters)
+	}
+	if id := get.Parameters[0]; id.Name != "id" || id.In != "path" || id.Type != "int" || !id.Required || id.Description != "Pet ID" {
+		t.Errorf("id parameter = %+v", id)
+	}
+	if fields := get.Parameters[1]; fields.Required || fields.Default != "name" || fields.Constraints == nil ||
+		strings.Join(fields.Constraints.Enum, ",") != "name,tag" || fields.Description != "Fields to return" {
+		t.Errorf("fields parameter = %+v", fields)
+	}
+	if len(get.Responses) != 2 || get.Responses[0].Body.TypeName != "model.Pet" ||
+		get.Responses[0].Body.ContentType != "application/json" || get.Responses[1].Description != "not found" {
+		t.Errorf("get responses = %+v", get.Responses)
+	}
+
+	create := result.Operations[1]
+	if create.ID == "" || create.Method != "POST" || create.Path != "/pets" {
+		t.Errorf("create = %s %s %s, want a generated ID for POST /pets", create.ID, create.Method, create.Path)
+	}
+	if create.RequestBody == nil || create.RequestBody.TypeName != "model.Pet" || create.RequestBody.ContentType != "application/json" {
+		t.Errorf("create request body = %+v, want model.Pet as application/json", create.RequestBody)
+	}
+	if !create.Deprecated || len(create.Parameters) != 0 || create.Responses[0].Body.TypeName != "[]model.Pet" {
+		t.Errorf("create = %+v, want deprecated, no parameters, and an array response", create)
+	}
+	if len(result.Groups) != 1 || result.Groups[0].Name != "pets" || len(result.Groups[0].Operations) != 2 {
+		t.Errorf("groups = %+v, want one pets group with both operations", result.Groups)
+	}
+
+	source.Annotations = "protobuf"
+	if _, err := p.Parse(raw, source); err == nil {
+		t.Error("an unknown annotations style should fail")
+	}
+}
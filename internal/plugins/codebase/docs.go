@@ -0,0 +1,125 @@
+package codebase
+
+import (
+	"log"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// defaultDocsGlobs select the project docs included verbatim in prompts.
+// Patterns without a slash match file names at any depth; others match the
+// path from the root, where ** spans any number of directories.
+var defaultDocsGlobs = []string{"README*", "CONTRIBUTING.md", "AGENTS.md", "CLAUDE.md", "docs/**/*.md"}
+
+// defaultDocsMaxBytes caps the combined size of the docs.
+const defaultDocsMaxBytes = 100000
+
+// readDocs reads the docs matched by the source's docs-globs, most important
+// first (the root README, then other root docs, nested READMEs, and the
+// rest), truncating once the combined size reaches the cap.
+func readDocs(root string, entries []fileInfo, source instructions.SpecSource) []ir.DocFile {
+	globs := source.DocsGlobs
+	if len(globs) == 0 {
+		globs = defaultDocsGlobs
+	}
+	maxBytes := source.DocsMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultDocsMaxBytes
+	}
+	budget := maxBytes
+
+	var paths []string
+	for _, e := range entries {
+		if e.isDir {
+			continue
+		}
+		rel := filepath.ToSlash(e.rel)
+		for _, g := range globs {
+			if matchDocGlob(g, rel) {
+				paths = append(paths, rel)
+				break
+			}
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		pi, pj := docPriority(paths[i]), docPriority(paths[j])
+		if pi != pj {
+			return pi < pj
+		}
+		di, dj := strings.Count(paths[i], "/"), strings.Count(paths[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return paths[i] < paths[j]
+	})
+
+	var docs []ir.DocFile
+	for i, rel := range paths {
+		if budget <= 0 {
+			log.Printf("WARNING: codebase docs exceed the %d-byte cap; omitting %d file(s) starting with %s", maxBytes, len(paths)-i, rel)
+			break
+		}
+		content := readFileContent(filepath.Join(root, filepath.FromSlash(rel)), budget)
+		if content == "" {
+			continue
+		}
+		budget -= len(content)
+		if budget <= 0 {
+			content += "\n\n[truncated: docs size cap reached]"
+		}
+		docs = append(docs, ir.DocFile{Path: rel, Content: content})
+	}
+	return docs
+}
+
+// docPriority ranks a doc path; lower comes first.
+func docPriority(rel string) int {
+	base := path.Base(rel)
+	isReadme := strings.HasPrefix(strings.ToUpper(base), "README")
+	atRoot := !strings.Contains(rel, "/")
+	switch {
+	case isReadme && atRoot:
+		return 0
+	case atRoot:
+		return 1
+	case isReadme:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// matchDocGlob matches a slash-separated relative path against a docs glob.
+func matchDocGlob(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], parts[1:])
+}
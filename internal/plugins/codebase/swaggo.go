@@ -0,0 +1,295 @@
+package codebase
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// AnnotationsSwaggo extracts operations from swaggo comments (// @Router,
+// // @Param, ...) on Go handlers.
+const AnnotationsSwaggo = "swaggo"
+
+// maxAnnotatedFileBytes caps how much of each Go file is scanned for
+// annotations.
+const maxAnnotatedFileBytes = 1 << 20
+
+// annotationOperations extracts operations from the code annotations named
+// by style, and groups them by tag.
+func annotationOperations(style, root string, entries []fileInfo) ([]ir.Operation, []ir.Group, error) {
+	if style != AnnotationsSwaggo {
+		return nil, nil, fmt.Errorf("unknown annotations style %q (expected %s)", style, AnnotationsSwaggo)
+	}
+	var ops []ir.Operation
+	for _, e := range entries {
+		if e.isDir || filepath.Ext(e.rel) != ".go" || strings.HasSuffix(e.rel, "_test.go") {
+			continue
+		}
+		content := readFileContent(filepath.Join(root, e.rel), maxAnnotatedFileBytes)
+		for _, block := range commentBlocks(content) {
+			ops = append(ops, swaggoOperations(block)...)
+		}
+	}
+
+	groupOps := make(map[string][]string)
+	for _, op := range ops {
+		for _, tag := range op.Tags {
+			groupOps[tag] = append(groupOps[tag], op.ID)
+		}
+	}
+	names := make([]string, 0, len(groupOps))
+	for name := range groupOps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	groups := make([]ir.Group, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, ir.Group{Name: name, Operations: groupOps[name]})
+	}
+	return ops, groups, nil
+}
+
+// commentBlocks splits Go source into runs of consecutive // comment lines,
+// with the comment markers removed.
+func commentBlocks(content string) [][]string {
+	var blocks [][]string
+	var block []string
+	for _, line := range strings.Split(content, "\n") {
+		text, ok := strings.CutPrefix(strings.TrimSpace(line), "//")
+		if !ok {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, strings.TrimSpace(text))
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// swaggoOperations returns an operation for each @Router in a comment
+// block; blocks without one yield none. The other annotations apply to
+// every route of the block.
+func swaggoOperations(block []string) []ir.Operation {
+	var (
+		op              ir.Operation
+		routes          [][2]string // method, path
+		id              string
+		descriptions    []string
+		accept, produce string
+		body            *ir.TypeRef
+	)
+	for _, line := range block {
+		if !strings.HasPrefix(line, "@") {
+			continue
+		}
+		key, value := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			key, value = line[:i], strings.TrimSpace(line[i:])
+		}
+		switch strings.ToLower(key) {
+		case "@summary":
+			op.Name = value
+		case "@description":
+			descriptions = append(descriptions, value)
+		case "@id":
+			id = value
+		case "@tags":
+			for _, tag := range strings.Split(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					op.Tags = append(op.Tags, tag)
+				}
+			}
+		case "@accept":
+			accept = swaggoMIMEType(value)
+		case "@produce":
+			produce = swaggoMIMEType(value)
+		case "@deprecated":
+			op.Deprecated = true
+		case "@param":
+			param, in, ok := swaggoParam(value)
+			switch {
+			case !ok:
+			case in == "body":
+				body = &ir.TypeRef{TypeName: param.Type, Description: param.Description}
+			default:
+				op.Parameters = append(op.Parameters, param)
+			}
+		case "@success", "@failure", "@response":
+			if resp, ok := swaggoResponse(value); ok {
+				op.Responses = append(op.Responses, resp)
+			}
+		case "@router":
+			fields := strings.Fields(value)
+			if len(fields) == 2 && strings.HasPrefix(fields[1], "[") && strings.HasSuffix(fields[1], "]") {
+				routes = append(routes, [2]string{strings.ToUpper(strings.Trim(fields[1], "[]")), fields[0]})
+			}
+		}
+	}
+	if len(routes) == 0 {
+		return nil
+	}
+
+	op.Description = strings.Join(descriptions, "\n")
+	if op.Description == "" {
+		op.Description = op.Name
+	}
+	if body != nil {
+		body.ContentType = accept
+	}
+	for _, resp := range op.Responses {
+		if resp.Body != nil {
+			resp.Body.ContentType = produce
+		}
+	}
+
+	ops := make([]ir.Operation, 0, len(routes))
+	for _, route := range routes {
+		o := op
+		o.Method, o.Path = route[0], route[1]
+		o.ID = id
+		if o.ID == "" || len(routes) > 1 {
+			o.ID = ir.OperationID(o.Method, o.Path)
+		}
+		// Each route gets its own copies, as later passes edit operations
+		// in place
+		o.Parameters = slices.Clone(op.Parameters)
+		o.Responses = slices.Clone(op.Responses)
+		for i := range o.Responses {
+			if b := o.Responses[i].Body; b != nil {
+				o.Responses[i].Body = &ir.TypeRef{TypeName: b.TypeName, ContentType: b.ContentType}
+			}
+		}
+		if body != nil {
+			o.RequestBody = &ir.TypeRef{TypeName: body.TypeName, Description: body.Description, ContentType: body.ContentType}
+		}
+		ops = append(ops, o)
+	}
+	return ops
+}
+
+// swaggoParam parses the value of a @Param annotation:
+//
+//	name in type required "description" attribute(...)...
+//
+// returning the parameter and its location ("body" for the request body).
+func swaggoParam(value string) (ir.Parameter, string, bool) {
+	fields := swaggoFields(value)
+	if len(fields) < 4 {
+		return ir.Parameter{}, "", false
+	}
+	in := fields[1]
+	if in == "formData" {
+		in = "form"
+	}
+	param := ir.Parameter{
+		Name:     fields[0],
+		In:       in,
+		Type:     fields[2],
+		Required: strings.EqualFold(fields[3], "true"),
+	}
+	for _, field := range fields[4:] {
+		attr, arg, ok := strings.Cut(field, "(")
+		if !ok {
+			if param.Description == "" {
+				param.Description = field
+			}
+			continue
+		}
+		arg = strings.TrimSuffix(arg, ")")
+		switch strings.ToLower(attr) {
+		case "default":
+			param.Default = arg
+		case "enums":
+			param.Constraints = &ir.Constraints{Enum: strings.Split(arg, ",")}
+		}
+	}
+	return param, fields[1], true
+}
+
+// swaggoResponse parses the value of a @Success, @Failure, or @Response
+// annotation: code {kind} type "description", where everything after the
+// code is optional.
+func swaggoResponse(value string) (ir.Response, bool) {
+	fields := swaggoFields(value)
+	if len(fields) == 0 {
+		return ir.Response{}, false
+	}
+	resp := ir.Response{StatusCode: fields[0]}
+	kind := ""
+	for _, field := range fields[1:] {
+		switch {
+		case strings.HasPrefix(field, "{") && strings.HasSuffix(field, "}"):
+			kind = strings.Trim(field, "{}")
+		case kind != "" && resp.Body == nil:
+			typeName := field
+			if kind == "array" {
+				typeName = "[]" + typeName
+			}
+			resp.Body = &ir.TypeRef{TypeName: typeName}
+		default:
+			resp.Description = field
+		}
+	}
+	return resp, true
+}
+
+// swaggoFields splits an annotation value on spaces, keeping "quoted"
+// descriptions and attribute(...) arguments whole and unquoted.
+func swaggoFields(value string) []string {
+	var fields []string
+	var b strings.Builder
+	quoted, depth := false, 0
+	for _, r := range value {
+		switch {
+		case r == '"' && depth == 0:
+			quoted = !quoted
+			continue
+		case r == '(' && !quoted:
+			depth++
+		case r == ')' && !quoted && depth > 0:
+			depth--
+		case (r == ' ' || r == '\t') && !quoted && depth == 0:
+			if b.Len() > 0 {
+				fields = append(fields, b.String())
+				b.Reset()
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() > 0 {
+		fields = append(fields, b.String())
+	}
+	return fields
+}
+
+// swaggoMIMETypes maps swaggo's @Accept and @Produce aliases to MIME types.
+var swaggoMIMETypes = map[string]string{
+	"json":                  "application/json",
+	"xml":                   "application/xml",
+	"plain":                 "text/plain",
+	"html":                  "text/html",
+	"mpfd":                  "multipart/form-data",
+	"x-www-form-urlencoded": "application/x-www-form-urlencoded",
+	"octet-stream":          "application/octet-stream",
+}
+
+// swaggoMIMEType returns the first MIME type of an @Accept or @Produce
+// list, resolving aliases.
+func swaggoMIMEType(value string) string {
+	first, _, _ := strings.Cut(value, ",")
+	first = strings.TrimSpace(first)
+	if mime, ok := swaggoMIMETypes[first]; ok {
+		return mime
+	}
+	return first
+}
@@ -0,0 +1,651 @@
+// Package har bootstraps an IR from captured traffic: an HTTP Archive (.har)
+// exported by a browser or a proxy such as Charles. Requests are grouped into
+// operations by method and path template, and parameters and body shapes are
+// inferred from the samples, so the IR covers only what was captured.
+package har
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/roberthamel/skill-compiler/internal/fetch"
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// Plugin handles HTTP Archive 1.2 captures.
+type Plugin struct{}
+
+func New() *Plugin { return &Plugin{} }
+
+func (p *Plugin) Name() string { return "har" }
+
+// Priority ranks HAR below content-sniffing plugins, as it matches on the
+// file extension alone.
+func (p *Plugin) Priority() int { return ir.PriorityExtension }
+
+func (p *Plugin) Detect(source instructions.SpecSource) bool {
+	if source.Type == "har" {
+		return true
+	}
+	if source.Type != "" || source.Path == "" {
+		return false
+	}
+	return strings.HasSuffix(strings.ToLower(source.Path), ".har")
+}
+
+func (p *Plugin) Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error) {
+	if source.Path != "" {
+		return os.ReadFile(source.Path)
+	}
+	if source.URL != "" {
+		return fetch.URL(ctx, source)
+	}
+	if source.Command != "" {
+		return fetch.Command(ctx, source)
+	}
+	return nil, fmt.Errorf("har plugin: no path, url, or command in spec source")
+}
+
+type archive struct {
+	Log struct {
+		Version string  `json:"version"`
+		Creator creator `json:"creator"`
+		Entries []entry `json:"entries"`
+	} `json:"log"`
+}
+
+type creator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type entry struct {
+	Request  request  `json:"request"`
+	Response response `json:"response"`
+}
+
+type request struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	Headers     []nameValue `json:"headers"`
+	QueryString []nameValue `json:"queryString"`
+	PostData    *postData   `json:"postData"`
+}
+
+type postData struct {
+	MimeType string      `json:"mimeType"`
+	Text     string      `json:"text"`
+	Params   []nameValue `json:"params"`
+}
+
+type response struct {
+	Status     int         `json:"status"`
+	StatusText string      `json:"statusText"`
+	Headers    []nameValue `json:"headers"`
+	Content    content     `json:"content"`
+}
+
+type content struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding"`
+}
+
+type nameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// sample is a captured request and response, with its URL parsed.
+type sample struct {
+	entry
+	url *url.URL
+	ids []string // the path segments collapsed into parameters
+}
+
+// endpoint collects the samples of one operation.
+type endpoint struct {
+	method  string
+	path    string // template, e.g. /pets/{id}
+	samples []sample
+}
+
+func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.IntermediateRepr, error) {
+	var har archive
+	if err := json.Unmarshal(raw, &har); err != nil {
+		return nil, fmt.Errorf("parsing HAR: %w", err)
+	}
+
+	// Keep the API calls of the origin the capture talks to most; captures
+	// also hold page loads, assets, and third-party requests
+	var samples []sample
+	origins := make(map[string]int)
+	for _, e := range har.Log.Entries {
+		u, err := url.Parse(e.Request.URL)
+		if err != nil || u.Host == "" || isAsset(e) {
+			continue
+		}
+		samples = append(samples, sample{entry: e, url: u})
+		origins[u.Scheme+"://"+u.Host]++
+	}
+	origin := ""
+	for o, n := range origins {
+		if n > origins[origin] || (n == origins[origin] && o < origin) {
+			origin = o
+		}
+	}
+
+	result := &ir.IntermediateRepr{
+		Metadata: map[string]string{
+			"title": strings.TrimPrefix(strings.TrimPrefix(origin, "https://"), "http://"),
+			"type":  "har",
+		},
+	}
+	if origin == "" {
+		return result, nil
+	}
+	result.Metadata["baseUrl"] = origin
+	if name := strings.TrimSpace(har.Log.Creator.Name + " " + har.Log.Creator.Version); name != "" {
+		result.Metadata["description"] = "Inferred from traffic captured with " + name
+	}
+
+	endpoints := make(map[string]*endpoint)
+	for _, s := range samples {
+		if s.url.Scheme+"://"+s.url.Host != origin {
+			continue
+		}
+		method := strings.ToUpper(s.Request.Method)
+		var path string
+		path, s.ids = pathTemplate(s.url.Path)
+		key := method + " " + path
+		ep := endpoints[key]
+		if ep == nil {
+			ep = &endpoint{method: method, path: path}
+			endpoints[key] = ep
+		}
+		ep.samples = append(ep.samples, s)
+	}
+	keys := make([]string, 0, len(endpoints))
+	for key := range endpoints {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := endpoints[keys[i]], endpoints[keys[j]]
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		return a.method < b.method
+	})
+
+	ps := &parser{result: result, auth: make(map[string]bool)}
+	groups := make(map[string]int)
+	for _, key := range keys {
+		op := ps.operation(endpoints[key])
+		if group := groupName(op.Path); group != "" {
+			op.Tags = []string{group}
+			i, ok := groups[group]
+			if !ok {
+				i = len(result.Groups)
+				groups[group] = i
+				result.Groups = append(result.Groups, ir.Group{Name: group})
+			}
+			result.Groups[i].Operations = append(result.Groups[i].Operations, op.ID)
+		}
+		result.Operations = append(result.Operations, op)
+	}
+	return result, nil
+}
+
+// parser accumulates types and auth schemes while building operations.
+type parser struct {
+	result *ir.IntermediateRepr
+	auth   map[string]bool
+}
+
+func (ps *parser) operation(ep *endpoint) ir.Operation {
+	id := ir.OperationID(ep.method, ep.path)
+	op := ir.Operation{
+		ID:     id,
+		Name:   ep.method + " " + ep.path,
+		Method: ep.method,
+		Path:   ep.path,
+	}
+
+	for i := range ep.samples[0].ids {
+		values := make([]string, 0, len(ep.samples))
+		for _, s := range ep.samples {
+			values = append(values, s.ids[i])
+		}
+		op.Parameters = append(op.Parameters, ir.Parameter{
+			Name:     paramName(i),
+			In:       "path",
+			Required: true,
+			Type:     valueType(values),
+		})
+	}
+
+	// Query parameters are required when every sample sends them
+	sent := make(map[string]int)
+	values := make(map[string][]string)
+	for _, s := range ep.samples {
+		for name, vs := range s.url.Query() {
+			sent[name]++
+			for _, v := range vs {
+				if v != "" {
+					values[name] = append(values[name], v)
+				}
+			}
+		}
+	}
+	names := make([]string, 0, len(sent))
+	for name := range sent {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		op.Parameters = append(op.Parameters, ir.Parameter{
+			Name:     name,
+			In:       "query",
+			Required: sent[name] == len(ep.samples),
+			Type:     valueType(values[name]),
+		})
+	}
+
+	op.RequestBody = ps.requestBody(id, ep.samples)
+	op.Responses = ps.responses(id, ep.samples)
+	if scheme := ps.authScheme(ep.samples); scheme != "" {
+		op.Auth = [][]string{{scheme}}
+	}
+	return op
+}
+
+// requestBody infers the request body from the samples that send one.
+func (ps *parser) requestBody(opID string, samples []sample) *ir.TypeRef {
+	var ref *ir.TypeRef
+	var bodies []string
+	form := ir.TypeDef{Name: opID + "Form"}
+	formFields := make(map[string]bool)
+	for _, s := range samples {
+		pd := s.Request.PostData
+		if pd == nil || (pd.Text == "" && len(pd.Params) == 0) {
+			continue
+		}
+		if ref == nil {
+			ref = &ir.TypeRef{ContentType: mediaType(pd.MimeType)}
+		}
+		for _, param := range pd.Params {
+			if !formFields[param.Name] {
+				formFields[param.Name] = true
+				form.Fields = append(form.Fields, ir.TypeField{Name: param.Name, Type: "string"})
+			}
+		}
+		if len(pd.Params) == 0 {
+			bodies = append(bodies, pd.Text)
+		}
+	}
+	if ref == nil {
+		return nil
+	}
+	if len(form.Fields) > 0 {
+		ref.TypeName = form.Name
+		ps.result.Types = append(ps.result.Types, form)
+		return ref
+	}
+	ref.TypeName, ref.Examples = ps.bodyShape(opID+"Request", ref.ContentType, bodies)
+	return ref
+}
+
+// responses returns one response per captured status code, in code order.
+func (ps *parser) responses(opID string, samples []sample) []ir.Response {
+	byStatus := make(map[int][]sample)
+	for _, s := range samples {
+		if s.Response.Status > 0 {
+			byStatus[s.Response.Status] = append(byStatus[s.Response.Status], s)
+		}
+	}
+	codes := make([]int, 0, len(byStatus))
+	for code := range byStatus {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	var out []ir.Response
+	named := false
+	for _, code := range codes {
+		first := byStatus[code][0].Response
+		resp := ir.Response{StatusCode: strconv.Itoa(code), Description: first.StatusText}
+		var bodies []string
+		for _, s := range byStatus[code] {
+			if text := s.Response.Content.text(); text != "" {
+				bodies = append(bodies, text)
+			}
+		}
+		if len(bodies) > 0 {
+			contentType := mediaType(first.Content.MimeType)
+			// The first shaped response is the operation's Response type;
+			// others are named for their status
+			name := opID + "Response"
+			if named {
+				name += resp.StatusCode
+			}
+			ref := &ir.TypeRef{ContentType: contentType}
+			ref.TypeName, ref.Examples = ps.bodyShape(name, contentType, bodies)
+			named = named || ref.TypeName != ""
+			resp.Body = ref
+		}
+		out = append(out, resp)
+	}
+	return out
+}
+
+// maxExampleBytes caps the size of a captured body kept as an example.
+const maxExampleBytes = 4 << 10
+
+// bodyShape infers a type named name from JSON bodies, registering it and
+// the types of its nested objects, and returns the body type with the
+// smallest body as an example. Non-JSON bodies have no type.
+func (ps *parser) bodyShape(name, contentType string, bodies []string) (string, []ir.Example) {
+	if len(bodies) == 0 {
+		return "", nil
+	}
+	smallest := bodies[0]
+	for _, b := range bodies[1:] {
+		if len(b) < len(smallest) {
+			smallest = b
+		}
+	}
+	var examples []ir.Example
+	if len(smallest) <= maxExampleBytes {
+		examples = []ir.Example{{Value: smallest}}
+	}
+	if contentType != "" && contentType != "application/json" && !strings.HasSuffix(contentType, "+json") {
+		return "", examples
+	}
+	var values []any
+	for _, b := range bodies {
+		var v any
+		if err := json.Unmarshal([]byte(b), &v); err == nil {
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return "", examples
+	}
+	return ps.shape(name, values), examples
+}
+
+// shape returns the type of the sampled values, registering a TypeDef for
+// objects. Fields are required when every sample has them set.
+func (ps *parser) shape(name string, values []any) string {
+	var objects []map[string]any
+	var elems []any
+	kind := ""
+	for _, v := range values {
+		switch val := v.(type) {
+		case nil:
+			continue
+		case map[string]any:
+			objects = append(objects, val)
+		case []any:
+			elems = append(elems, val...)
+		}
+		if kind == "" {
+			kind = jsonType(v)
+		}
+	}
+	switch kind {
+	case "":
+		return "any"
+	case "array":
+		if len(elems) == 0 {
+			return "array"
+		}
+		return "[]" + ps.shape(name, elems)
+	case "object":
+	default:
+		return kind
+	}
+
+	var keys []string
+	fieldValues := make(map[string][]any)
+	for _, obj := range objects {
+		for k, v := range obj {
+			if _, ok := fieldValues[k]; !ok {
+				keys = append(keys, k)
+			}
+			if v != nil {
+				fieldValues[k] = append(fieldValues[k], v)
+			} else if fieldValues[k] == nil {
+				fieldValues[k] = []any{}
+			}
+		}
+	}
+	sort.Strings(keys)
+	td := ir.TypeDef{Name: name}
+	for _, k := range keys {
+		td.Fields = append(td.Fields, ir.TypeField{
+			Name:     k,
+			Type:     ps.shape(name+exported(k), fieldValues[k]),
+			Required: len(fieldValues[k]) == len(objects),
+		})
+	}
+	ps.result.Types = append(ps.result.Types, td)
+	return name
+}
+
+// authScheme registers the credentials the samples send, if any, and
+// returns the scheme's ID.
+func (ps *parser) authScheme(samples []sample) string {
+	for _, s := range samples {
+		for _, h := range s.Request.Headers {
+			scheme := ir.AuthScheme{Type: "http"}
+			name := strings.ToLower(h.Name)
+			switch {
+			case name == "authorization":
+				kind, _, _ := strings.Cut(h.Value, " ")
+				scheme.ID = strings.ToLower(kind)
+				scheme.Scheme = scheme.ID
+				if scheme.ID != "bearer" && scheme.ID != "basic" {
+					continue
+				}
+			case name == "x-api-key" || name == "api-key" || name == "apikey":
+				scheme = ir.AuthScheme{ID: "apiKey", Type: "apiKey", Name: h.Name, In: "header"}
+			default:
+				continue
+			}
+			if !ps.auth[scheme.ID] {
+				ps.auth[scheme.ID] = true
+				ps.result.Auth = append(ps.result.Auth, scheme)
+			}
+			return scheme.ID
+		}
+	}
+	return ""
+}
+
+func (p *Plugin) Validate(parsed *ir.IntermediateRepr) []ir.Warning {
+	if len(parsed.Operations) == 0 {
+		return []ir.Warning{{Message: "no API requests found in the capture"}}
+	}
+	return []ir.Warning{{
+		Message: fmt.Sprintf("%d operations were inferred from captured traffic; parameters and types cover only the requests captured, so review them before publishing",
+			len(parsed.Operations)),
+	}}
+}
+
+// text returns the response body, decoding base64 content.
+func (c content) text() string {
+	if c.Encoding != "base64" {
+		return c.Text
+	}
+	decoded, err := base64.StdEncoding.DecodeString(c.Text)
+	if err != nil {
+		return ""
+	}
+	return string(decoded)
+}
+
+// isAsset reports whether an entry fetched a page or static asset rather
+// than calling an API.
+func isAsset(e entry) bool {
+	mt := mediaType(e.Response.Content.MimeType)
+	switch {
+	case strings.HasPrefix(mt, "image/"), strings.HasPrefix(mt, "font/"), strings.HasPrefix(mt, "audio/"), strings.HasPrefix(mt, "video/"):
+		return true
+	case mt == "text/html", mt == "text/css", strings.Contains(mt, "javascript"):
+		return true
+	}
+	return false
+}
+
+// idSegmentRe matches numbers, UUIDs, and long hex strings.
+var idSegmentRe = regexp.MustCompile(`^(\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,})$`)
+
+// isID reports whether a path segment identifies a resource rather than
+// naming one: a number, UUID, or hex string, or a long token such as
+// "cus_9s6XKzkNRiz8i3" that mixes letters with several digits.
+func isID(seg string) bool {
+	if idSegmentRe.MatchString(seg) {
+		return true
+	}
+	if len(seg) < 16 {
+		return false
+	}
+	digits := 0
+	for _, r := range seg {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		default:
+			return false
+		}
+	}
+	return digits >= 2
+}
+
+// pathTemplate collapses the ID segments of a path into parameters named
+// by paramName. It returns the template and the collapsed segments.
+func pathTemplate(path string) (string, []string) {
+	var ids, out []string
+	for _, seg := range strings.Split(path, "/") {
+		if seg == "" {
+			continue
+		}
+		if unescaped, err := url.PathUnescape(seg); err == nil && isID(unescaped) {
+			seg = "{" + paramName(len(ids)) + "}"
+			ids = append(ids, unescaped)
+		}
+		out = append(out, seg)
+	}
+	return "/" + strings.Join(out, "/"), ids
+}
+
+// paramName names the i-th path parameter of a template: id, then id2,
+// id3, ...
+func paramName(i int) string {
+	if i == 0 {
+		return "id"
+	}
+	return "id" + strconv.Itoa(i+1)
+}
+
+// versionSegmentRe matches path prefixes such as v1 or v2beta.
+var versionSegmentRe = regexp.MustCompile(`^v\d+\w*$`)
+
+// groupName names an operation's group after its resource: the first path
+// segment that is not "api", a version, or a parameter.
+func groupName(path string) string {
+	for _, seg := range strings.Split(strings.Trim(path, "/"), "/") {
+		if seg == "" || seg == "api" || versionSegmentRe.MatchString(seg) || strings.HasPrefix(seg, "{") {
+			continue
+		}
+		return seg
+	}
+	return ""
+}
+
+// valueType infers a parameter type from its sampled values.
+func valueType(values []string) string {
+	if len(values) == 0 {
+		return "string"
+	}
+	typ := ""
+	for _, v := range values {
+		t := "string"
+		if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+			t = "integer"
+		} else if _, err := strconv.ParseFloat(v, 64); err == nil {
+			t = "number"
+		} else if v == "true" || v == "false" {
+			t = "boolean"
+		}
+		switch {
+		case typ == "" || typ == t:
+			typ = t
+		case (typ == "integer" && t == "number") || (typ == "number" && t == "integer"):
+			typ = "number"
+		default:
+			return "string"
+		}
+	}
+	return typ
+}
+
+// mediaType strips parameters such as charset from a MIME type.
+func mediaType(mimeType string) string {
+	if mt, _, err := mime.ParseMediaType(mimeType); err == nil {
+		return mt
+	}
+	return strings.TrimSpace(mimeType)
+}
+
+func jsonType(v any) string {
+	switch val := v.(type) {
+	case string:
+		return "string"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return ""
+	}
+}
+
+// exported turns a JSON key such as "owner_id" into a type name part
+// ("OwnerId").
+func exported(key string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package har
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+func parseFixture(t *testing.T) *ir.IntermediateRepr {
+	t.Helper()
+	p := New()
+	source := instructions.SpecSource{Path: filepath.Join("testdata", "petstore.har")}
+	raw, err := p.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("fetch error: %v", err)
+	}
+	result, err := p.Parse(raw, source)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	return result
+}
+
+func TestDetect(t *testing.T) {
+	p := New()
+	tests := []struct {
+		name   string
+		source instructions.SpecSource
+		want   bool
+	}{
+		{"explicit type", instructions.SpecSource{Type: "har", URL: "http://example.com/capture"}, true},
+		{"har extension", instructions.SpecSource{Path: "session.HAR"}, true},
+		{"json file", instructions.SpecSource{Path: "session.json"}, false},
+		{"other type", instructions.SpecSource{Type: "postman", Path: "session.har"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Detect(tt.source); got != tt.want {
+				t.Errorf("Detect(%+v) = %v, want %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Operations(t *testing.T) {
+	result := parseFixture(t)
+
+	var got []string
+	ops := map[string]ir.Operation{}
+	for _, op := range result.Operations {
+		got = append(got, op.Method+" "+op.Path)
+		ops[op.ID] = op
+	}
+	want := []string{
+		"GET /v1/owners/{id}/pets/{id2}",
+		"GET /v1/pets",
+		"POST /v1/pets",
+		"GET /v1/pets/{id}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("operations = %q, want %q (pages, assets, and other origins skipped)", got, want)
+	}
+	if result.Metadata["baseUrl"] != "https://api.example.com" {
+		t.Errorf("baseUrl = %q", result.Metadata["baseUrl"])
+	}
+
+	list := ops["get_v1_pets"]
+	wantParams := []ir.Parameter{
+		{Name: "limit", In: "query", Required: true, Type: "integer"},
+		{Name: "sort", In: "query", Type: "string"},
+	}
+	if !reflect.DeepEqual(list.Parameters, wantParams) {
+		t.Errorf("get_v1_pets parameters = %+v, want %+v", list.Parameters, wantParams)
+	}
+	if len(list.Tags) != 1 || list.Tags[0] != "pets" {
+		t.Errorf("get_v1_pets tags = %v, want [pets]", list.Tags)
+	}
+	if !reflect.DeepEqual(list.Auth, [][]string{{"bearer"}}) || len(result.Auth) != 1 || result.Auth[0].Scheme != "bearer" {
+		t.Errorf("auth = %v, schemes %+v, want bearer", list.Auth, result.Auth)
+	}
+
+	nested := ops["get_v1_owners_id_pets_id2"]
+	if len(nested.Parameters) != 2 || nested.Parameters[0].Type != "string" || nested.Parameters[1].Type != "integer" {
+		t.Errorf("nested path parameters = %+v, want a string id and an integer id2", nested.Parameters)
+	}
+	if b := nested.Responses[0].Body; b == nil || b.TypeName != "[]get_v1_owners_id_pets_id2Response" {
+		t.Errorf("base64 body = %+v, want an inferred array type", b)
+	}
+}
+
+func TestParse_Shapes(t *testing.T) {
+	result := parseFixture(t)
+	types := map[string]ir.TypeDef{}
+	for _, td := range result.Types {
+		types[td.Name] = td
+	}
+	ops := map[string]ir.Operation{}
+	for _, op := range result.Operations {
+		ops[op.ID] = op
+	}
+
+	create := ops["post_v1_pets"]
+	if create.RequestBody == nil || create.RequestBody.TypeName != "post_v1_petsRequest" || create.RequestBody.ContentType != "application/json" {
+		t.Fatalf("request body = %+v", create.RequestBody)
+	}
+	if len(create.RequestBody.Examples) != 1 {
+		t.Errorf("request body examples = %+v, want the captured body", create.RequestBody.Examples)
+	}
+
+	// tag is null in one sample, so it is optional
+	item := types["get_v1_petsResponse"]
+	wantFields := []ir.TypeField{
+		{Name: "id", Type: "integer", Required: true},
+		{Name: "name", Type: "string", Required: true},
+		{Name: "tag", Type: "string"},
+	}
+	if !reflect.DeepEqual(item.Fields, wantFields) {
+		t.Errorf("list item fields = %+v, want %+v", item.Fields, wantFields)
+	}
+	if b := ops["get_v1_pets"].Responses[0].Body; b.TypeName != "[]get_v1_petsResponse" || b.Examples[0].Value != "[]" {
+		t.Errorf("list response = %+v, want an array type with the smallest body as example", b)
+	}
+
+	get := ops["get_v1_pets_id"]
+	if len(get.Responses) != 2 || get.Responses[0].StatusCode != "200" || get.Responses[1].StatusCode != "404" {
+		t.Fatalf("responses = %+v, want 200 and 404", get.Responses)
+	}
+	if get.Responses[1].Body.TypeName != "get_v1_pets_idResponse404" || get.Responses[1].Description != "Not Found" {
+		t.Errorf("404 response = %+v", get.Responses[1])
+	}
+	if owner := types["get_v1_pets_idResponseOwner"]; len(owner.Fields) != 2 {
+		t.Errorf("nested owner type = %+v, want id and email", owner)
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+		ids  []string
+	}{
+		{"/v1/pets", "/v1/pets", nil},
+		{"/pets/42/photos/7", "/pets/{id}/photos/{id2}", []string{"42", "7"}},
+		{"/customers/cus_9s6XKzkNRiz8i3", "/customers/{id}", []string{"cus_9s6XKzkNRiz8i3"}},
+		{"/oauth2/authorize_callback", "/oauth2/authorize_callback", nil},
+		{"/blobs/5f4dcc3b5aa765d61d8327deb882cf99", "/blobs/{id}", []string{"5f4dcc3b5aa765d61d8327deb882cf99"}},
+	}
+	for _, tt := range tests {
+		got, ids := pathTemplate(tt.path)
+		if got != tt.want || !reflect.DeepEqual(ids, tt.ids) {
+			t.Errorf("pathTemplate(%q) = %q, %q, want %q, %q", tt.path, got, ids, tt.want, tt.ids)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if w := New().Validate(&ir.IntermediateRepr{}); len(w) != 1 || w[0].Message != "no API requests found in the capture" {
+		t.Errorf("Validate of an empty capture = %+v", w)
+	}
+}
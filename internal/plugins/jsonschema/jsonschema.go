@@ -0,0 +1,382 @@
+package jsonschema
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/roberthamel/skill-compiler/internal/fetch"
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/sniff"
+)
+
+// Plugin handles bundles of JSON Schema files describing pure data contracts.
+type Plugin struct{}
+
+func New() *Plugin { return &Plugin{} }
+
+func (p *Plugin) Name() string { return "jsonschema" }
+
+const schemaSuffix = ".schema.json"
+
+func (p *Plugin) Detect(source instructions.SpecSource) bool {
+	if source.Type == "jsonschema" {
+		return true
+	}
+	if source.Type != "" || source.Path == "" {
+		return false
+	}
+	if strings.HasSuffix(strings.ToLower(source.Path), schemaSuffix) {
+		return true
+	}
+	if info, err := os.Stat(source.Path); err == nil && info.IsDir() {
+		return false // directories need an explicit type
+	}
+	if strings.ToLower(filepath.Ext(source.Path)) != ".json" {
+		return false
+	}
+	// An OpenAPI or other recognized document may carry $schema too
+	return sniff.File(source.Path) == sniff.Unknown && hasSchemaKey(source.Path)
+}
+
+// hasSchemaKey reports whether a JSON file declares a top-level $schema key.
+func hasSchemaKey(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(data, &top); err != nil {
+		return false
+	}
+	_, ok := top["$schema"]
+	return ok
+}
+
+// bundleFile is one schema document within the fetched bundle.
+type bundleFile struct {
+	Path   string          `json:"path"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+func (p *Plugin) Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error) {
+	if source.Path != "" {
+		files, err := schemaFiles(source.Path)
+		if err != nil {
+			return nil, err
+		}
+		var bundle []bundleFile
+		for _, f := range files {
+			data, err := os.ReadFile(f)
+			if err != nil {
+				return nil, err
+			}
+			bundle = append(bundle, bundleFile{Path: f, Schema: data})
+		}
+		return json.Marshal(bundle)
+	}
+	if source.URL != "" {
+		return fetch.URL(ctx, source)
+	}
+	if source.Command != "" {
+		return fetch.Command(ctx, source)
+	}
+	return nil, fmt.Errorf("jsonschema plugin: no path, url, or command in spec source")
+}
+
+// schemaFiles expands a file, directory, or glob into sorted schema file paths.
+func schemaFiles(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err == nil && !info.IsDir() {
+		return []string{path}, nil
+	}
+	pattern := path
+	if err == nil && info.IsDir() {
+		pattern = filepath.Join(path, "*"+schemaSuffix)
+	}
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("matching schema files %s: %w", pattern, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no %s files found at %s", schemaSuffix, path)
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
+
+type schema struct {
+	Schema      string             `json:"$schema"`
+	Ref         string             `json:"$ref"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Type        json.RawMessage    `json:"type"` // string or array of strings
+	Format      string             `json:"format"`
+	Properties  map[string]*schema `json:"properties"`
+	Items       *schema            `json:"items"`
+	Required    []string           `json:"required"`
+	Enum        []any              `json:"enum"`
+	Definitions map[string]*schema `json:"definitions"`
+	Defs        map[string]*schema `json:"$defs"`
+	OneOf       []*schema          `json:"oneOf"`
+	AnyOf       []*schema          `json:"anyOf"`
+}
+
+// types returns the schema's declared type(s), ignoring "null".
+func (s *schema) types() []string {
+	if len(s.Type) == 0 {
+		return nil
+	}
+	var single string
+	if err := json.Unmarshal(s.Type, &single); err == nil {
+		return []string{single}
+	}
+	var multi []string
+	_ = json.Unmarshal(s.Type, &multi)
+	var out []string
+	for _, t := range multi {
+		if t != "null" {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+func (s *schema) isObject() bool {
+	for _, t := range s.types() {
+		if t == "object" {
+			return true
+		}
+	}
+	return len(s.types()) == 0 && len(s.Properties) > 0
+}
+
+// parser accumulates flattened types across all schema files.
+type parser struct {
+	types map[string]ir.TypeDef
+}
+
+func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.IntermediateRepr, error) {
+	var bundle []bundleFile
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(raw, &bundle); err != nil {
+			return nil, fmt.Errorf("parsing schema bundle: %w", err)
+		}
+	} else {
+		// A single schema document from a URL or command
+		name := source.Path
+		if name == "" {
+			name = source.URL
+		}
+		bundle = []bundleFile{{Path: name, Schema: raw}}
+	}
+
+	ps := &parser{types: make(map[string]ir.TypeDef)}
+	var titles []string
+	for _, f := range bundle {
+		var s schema
+		if err := json.Unmarshal(f.Schema, &s); err != nil {
+			return nil, fmt.Errorf("parsing JSON Schema %s: %w", f.Path, err)
+		}
+		name := s.Title
+		if name == "" {
+			name = fileStem(f.Path)
+		}
+		if name == "" {
+			name = "Root"
+		}
+		name = typeName(name)
+		titles = append(titles, name)
+
+		for _, defName := range sortedKeys(s.Definitions) {
+			ps.addType(typeName(defName), s.Definitions[defName])
+		}
+		for _, defName := range sortedKeys(s.Defs) {
+			ps.addType(typeName(defName), s.Defs[defName])
+		}
+		if s.isObject() || len(s.Enum) > 0 {
+			ps.addType(name, &s)
+		}
+	}
+
+	result := &ir.IntermediateRepr{
+		Metadata: map[string]string{
+			"type":    "jsonschema",
+			"schemas": strings.Join(titles, ", "),
+		},
+	}
+	if len(titles) == 1 {
+		result.Metadata["title"] = titles[0]
+	}
+	for _, name := range sortedKeys(ps.types) {
+		result.Types = append(result.Types, ps.types[name])
+	}
+	return result, nil
+}
+
+// addType records a schema as a TypeDef, flattening nested objects and
+// inline enums into their own named types.
+func (ps *parser) addType(name string, s *schema) {
+	if s == nil {
+		return
+	}
+	if _, exists := ps.types[name]; exists {
+		return
+	}
+	td := ir.TypeDef{
+		Name:        name,
+		Description: s.Description,
+		Enum:        enumStrings(s.Enum),
+	}
+	// Reserve the name before recursing so self-references terminate
+	ps.types[name] = td
+
+	for _, fieldName := range sortedKeys(s.Properties) {
+		field := s.Properties[fieldName]
+		required := false
+		for _, req := range s.Required {
+			if req == fieldName {
+				required = true
+				break
+			}
+		}
+		desc := ""
+		if field != nil {
+			desc = field.Description
+		}
+		td.Fields = append(td.Fields, ir.TypeField{
+			Name:        fieldName,
+			Type:        ps.fieldType(name, fieldName, field),
+			Description: desc,
+			Required:    required,
+		})
+	}
+	ps.types[name] = td
+}
+
+// fieldType returns the IR type string for a property, registering any
+// nested object or enum as a separate referenced type.
+func (ps *parser) fieldType(parent, field string, s *schema) string {
+	if s == nil {
+		return ""
+	}
+	if s.Ref != "" {
+		return refTypeName(s.Ref)
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		alts := append(append([]*schema{}, s.OneOf...), s.AnyOf...)
+		var names []string
+		for i, alt := range alts {
+			names = append(names, ps.fieldType(parent, fmt.Sprintf("%s%d", field, i+1), alt))
+		}
+		return strings.Join(names, " | ")
+	}
+	types := s.types()
+	if len(types) == 1 && types[0] == "array" && s.Items != nil {
+		return "[]" + ps.fieldType(parent, singular(field), s.Items)
+	}
+	if s.isObject() || len(s.Enum) > 0 {
+		name := s.Title
+		if name == "" {
+			name = parent + typeName(field)
+		}
+		name = typeName(name)
+		ps.addType(name, s)
+		return name
+	}
+	t := strings.Join(types, "|")
+	if s.Format != "" {
+		return t + "(" + s.Format + ")"
+	}
+	return t
+}
+
+func (p *Plugin) Validate(parsed *ir.IntermediateRepr) []ir.Warning {
+	var warnings []ir.Warning
+	if len(parsed.Types) == 0 {
+		warnings = append(warnings, ir.Warning{Message: "JSON Schema bundle produced no types"})
+	}
+	for _, td := range parsed.Types {
+		if td.Description == "" {
+			warnings = append(warnings, ir.Warning{
+				Message: fmt.Sprintf("type %s has no description", td.Name),
+			})
+		}
+	}
+	return warnings
+}
+
+// refTypeName maps a $ref (local pointer or another file) to a type name.
+func refTypeName(ref string) string {
+	if idx := strings.Index(ref, "#"); idx >= 0 {
+		if frag := ref[idx+1:]; frag != "" && frag != "/" {
+			parts := strings.Split(frag, "/")
+			return typeName(parts[len(parts)-1])
+		}
+		ref = ref[:idx]
+	}
+	return typeName(fileStem(ref))
+}
+
+func fileStem(path string) string {
+	base := filepath.Base(path)
+	lower := strings.ToLower(base)
+	switch {
+	case strings.HasSuffix(lower, schemaSuffix):
+		return base[:len(base)-len(schemaSuffix)]
+	case strings.HasSuffix(lower, ".json"):
+		return base[:len(base)-len(".json")]
+	}
+	return base
+}
+
+// typeName converts names like "order-item" or "line_items" to "OrderItem".
+func typeName(s string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if r == '-' || r == '_' || r == ' ' || r == '.' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteRune(unicode.ToUpper(r))
+			upper = false
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// singular trims a trailing "s" so array items get a natural type name.
+func singular(s string) string {
+	if len(s) > 1 && strings.HasSuffix(s, "s") && !strings.HasSuffix(s, "ss") {
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+func enumStrings(values []any) []string {
+	var out []string
+	for _, v := range values {
+		out = append(out, fmt.Sprint(v))
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
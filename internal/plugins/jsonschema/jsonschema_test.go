@@ -0,0 +1,112 @@
+package jsonschema
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+func TestDetect(t *testing.T) {
+	p := New()
+	dir := t.TempDir()
+	withSchema := filepath.Join(dir, "thing.json")
+	_ = os.WriteFile(withSchema, []byte(`{"$schema": "http://json-schema.org/draft-07/schema#"}`), 0o644)
+	plain := filepath.Join(dir, "api.json")
+	_ = os.WriteFile(plain, []byte(`{"openapi": "3.0.0"}`), 0o644)
+
+	tests := []struct {
+		name   string
+		source instructions.SpecSource
+		want   bool
+	}{
+		{"explicit type", instructions.SpecSource{Type: "jsonschema", Path: "./schemas"}, true},
+		{"schema suffix", instructions.SpecSource{Path: "order.schema.json"}, true},
+		{"$schema key", instructions.SpecSource{Path: withSchema}, true},
+		{"plain json", instructions.SpecSource{Path: plain}, false},
+		{"openapi type", instructions.SpecSource{Type: "openapi", Path: "order.schema.json"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Detect(tt.source)
+			if got != tt.want {
+				t.Errorf("Detect(%+v) = %v, want %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Bundle(t *testing.T) {
+	p := New()
+	source := instructions.SpecSource{Type: "jsonschema", Path: "testdata"}
+	raw, err := p.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("fetch error: %v", err)
+	}
+	result, err := p.Parse(raw, source)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	if len(result.Operations) != 0 {
+		t.Errorf("got %d operations, want 0", len(result.Operations))
+	}
+
+	types := map[string]ir.TypeDef{}
+	for _, td := range result.Types {
+		types[td.Name] = td
+	}
+	for _, want := range []string{"Order", "Customer", "Address", "OrderStatus", "OrderItem"} {
+		if _, ok := types[want]; !ok {
+			t.Errorf("missing type %q (got %v)", want, result.Types)
+		}
+	}
+
+	if got := types["OrderStatus"].Enum; len(got) != 3 || got[0] != "pending" {
+		t.Errorf("OrderStatus enum = %v, want pending/shipped/delivered", got)
+	}
+
+	fields := map[string]ir.TypeField{}
+	for _, f := range types["Order"].Fields {
+		fields[f.Name] = f
+	}
+	checks := map[string]string{
+		"id":       "string(uuid)",
+		"status":   "OrderStatus",
+		"items":    "[]OrderItem",
+		"customer": "Customer",
+		"shipping": "Address",
+	}
+	for name, want := range checks {
+		if fields[name].Type != want {
+			t.Errorf("Order.%s type = %q, want %q", name, fields[name].Type, want)
+		}
+	}
+	if !fields["id"].Required || fields["customer"].Required {
+		t.Error("required flags not applied from the schema's required list")
+	}
+
+	for _, f := range types["Address"].Fields {
+		if f.Name == "country" && f.Type != "string" {
+			t.Errorf("nullable country type = %q, want string", f.Type)
+		}
+	}
+}
+
+func TestParse_SingleDocument(t *testing.T) {
+	p := New()
+	raw := []byte(`{"$schema": "x", "title": "Ping", "type": "object", "properties": {"ok": {"type": "boolean"}}}`)
+	result, err := p.Parse(raw, instructions.SpecSource{Type: "jsonschema", URL: "http://example.com/ping.json"})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if len(result.Types) != 1 || result.Types[0].Name != "Ping" {
+		t.Errorf("types = %+v, want single Ping type", result.Types)
+	}
+	if result.Metadata["title"] != "Ping" {
+		t.Errorf("title = %q, want Ping", result.Metadata["title"])
+	}
+}
@@ -0,0 +1,201 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"gopkg.in/yaml.v3"
+)
+
+// Bundle handles openapi-bundle spec sources: a directory of OpenAPI
+// fragments joined by cross-file $refs. The entrypoint and every file it
+// references are bundled into one document, which is parsed like any other
+// OpenAPI spec.
+type Bundle struct {
+	Plugin
+}
+
+func NewBundle() *Bundle { return &Bundle{} }
+
+func (b *Bundle) Name() string { return "openapi-bundle" }
+
+// Detect claims only sources typed openapi-bundle; nothing about a
+// directory says it is one.
+func (b *Bundle) Detect(source instructions.SpecSource) bool {
+	return source.Type == "openapi-bundle"
+}
+
+func (b *Bundle) Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error) {
+	if source.Path == "" {
+		return nil, fmt.Errorf("openapi-bundle: path to the bundle directory is required")
+	}
+	doc, err := bundleDir(source.Path)
+	if err != nil {
+		return nil, fmt.Errorf("openapi-bundle %s: %w", source.Path, err)
+	}
+	return yaml.Marshal(doc)
+}
+
+// bundleEntrypoints are the file names tried, in order, as a bundle's root
+// document.
+var bundleEntrypoints = []string{"openapi.yaml", "openapi.yml", "openapi.json", "index.yaml", "index.yml", "index.json"}
+
+// bundler inlines cross-file $refs within one bundle directory.
+type bundler struct {
+	root  string                    // absolute bundle directory
+	entry string                    // absolute entrypoint path
+	files map[string]map[string]any // parsed documents by absolute path
+	stack []string                  // file#pointer targets being inlined
+}
+
+// bundleDir loads a bundle's entrypoint and inlines every $ref to another
+// file. Local refs in the entrypoint are left for Parse to resolve.
+func bundleDir(dir string) (map[string]any, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(root); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory")
+	}
+	b := &bundler{root: root, files: make(map[string]map[string]any)}
+	for _, name := range bundleEntrypoints {
+		if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+			b.entry = filepath.Join(root, name)
+			break
+		}
+	}
+	if b.entry == "" {
+		return nil, fmt.Errorf("no entrypoint (expected one of %s)", strings.Join(bundleEntrypoints, ", "))
+	}
+
+	doc, err := b.load(b.entry)
+	if err != nil {
+		return nil, err
+	}
+	out, err := b.inline(doc, b.entry)
+	if err != nil {
+		return nil, err
+	}
+	return out.(map[string]any), nil
+}
+
+func (b *bundler) load(path string) (map[string]any, error) {
+	if doc, ok := b.files[path]; ok {
+		return doc, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", b.rel(path), err)
+	}
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", b.rel(path), err)
+	}
+	b.files[path] = doc
+	return doc, nil
+}
+
+// inline returns a copy of node, found in file, with its $refs to other
+// files replaced by their targets. Local refs in files other than the
+// entrypoint point into that file, so they are inlined too.
+func (b *bundler) inline(node any, file string) (any, error) {
+	switch v := node.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok && !isRemoteRef(ref) {
+			filePart, pointer, _ := strings.Cut(ref, "#")
+			if filePart != "" || file != b.entry {
+				return b.inlineRef(v, ref, filePart, pointer, file)
+			}
+		}
+		out := make(map[string]any, len(v))
+		for key, val := range v {
+			resolved, err := b.inline(val, file)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := b.inline(item, file)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+// inlineRef replaces the $ref in node with its target. Keys beside $ref
+// (e.g. a description) override the target's.
+func (b *bundler) inlineRef(node map[string]any, ref, filePart, pointer, file string) (any, error) {
+	target := file
+	if filePart != "" {
+		target = filepath.Join(filepath.Dir(file), filepath.FromSlash(filePart))
+		if rel, err := filepath.Rel(b.root, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("$ref %s in %s escapes the bundle directory", ref, b.rel(file))
+		}
+	}
+
+	key := target + "#" + pointer
+	if slices.Contains(b.stack, key) {
+		// A recursive structure; stop rather than inline forever
+		return map[string]any{"type": "object", "description": "recursive reference to " + ref}, nil
+	}
+	doc, err := b.load(target)
+	if err != nil {
+		return nil, fmt.Errorf("$ref %s in %s: %w", ref, b.rel(file), err)
+	}
+	var found any = doc
+	if pointer != "" && pointer != "/" {
+		if found = lookupRef("#"+pointer, doc); found == nil {
+			// Fragments often mean the entrypoint by "#/components/...";
+			// leave those refs for Parse to resolve in the bundled document
+			if filePart == "" && lookupRef("#"+pointer, b.files[b.entry]) != nil {
+				return maps.Clone(node), nil
+			}
+			return nil, fmt.Errorf("$ref %s in %s: %s has no %s", ref, b.rel(file), b.rel(target), pointer)
+		}
+	}
+
+	b.stack = append(b.stack, key)
+	resolved, err := b.inline(found, target)
+	b.stack = b.stack[:len(b.stack)-1]
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := resolved.(map[string]any); ok {
+		for k, val := range node {
+			if k != "$ref" {
+				m[k] = val
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// rel names a bundle file relative to the bundle directory for messages.
+func (b *bundler) rel(path string) string {
+	if rel, err := filepath.Rel(b.root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+func isRemoteRef(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
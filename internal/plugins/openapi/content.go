@@ -0,0 +1,118 @@
+package openapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// bodyRef builds a TypeRef from a requestBody or response content map. The
+// primary content type is the one scripts should send by default: JSON,
+// then url-encoded forms, then multipart, then anything else alphabetically.
+// The rest are recorded as alternatives. A body without a named type and
+// without a description is described by its shape; see bodyShape.
+func bodyRef(content map[string]openAPIMediaType, description string) *ir.TypeRef {
+	if len(content) == 0 {
+		return nil
+	}
+	types := make([]string, 0, len(content))
+	for ct := range content {
+		types = append(types, ct)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		ri, rj := contentTypeRank(types[i]), contentTypeRank(types[j])
+		if ri != rj {
+			return ri < rj
+		}
+		return types[i] < types[j]
+	})
+
+	primary := types[0]
+	typeName := ""
+	schema := content[primary].Schema
+	if schema != nil && schema.Ref != "" {
+		typeName = refName(schema.Ref)
+	} else if description == "" {
+		description = bodyShape(primary, schema)
+	}
+	ref := &ir.TypeRef{
+		TypeName:    typeName,
+		Description: description,
+		ContentType: primary,
+	}
+	if len(types) > 1 {
+		ref.AltContentTypes = types[1:]
+	}
+	return ref
+}
+
+// bodyShape describes a body whose schema leaves its shape open, so the
+// reference still says what a response carries when there is no type to
+// show. It is empty for bodies with a structured inline schema.
+func bodyShape(contentType string, s *openAPISchema) string {
+	switch {
+	case isBinaryContent(contentType) || (s != nil && s.Type == "string" && s.Format == "binary"):
+		return "binary/stream"
+	case contentTypeRank(contentType) > 1:
+		if s == nil {
+			return "unstructured body (no schema)"
+		}
+		return ""
+	case s == nil:
+		return "unstructured JSON (no schema)"
+	case (s.Type == "" || s.Type == "object") && len(s.Properties) == 0 && s.Items == nil:
+		if values := schemaName(s.AdditionalProperties); values != "" {
+			return fmt.Sprintf("JSON object with arbitrary keys; values are %s", values)
+		}
+		return "unstructured JSON object"
+	}
+	return ""
+}
+
+// schemaName names the type of a raw additionalProperties schema: its $ref
+// target or its type. It is empty for true, {}, and other open schemas.
+func schemaName(raw any) string {
+	m, ok := raw.(map[string]any)
+	if !ok {
+		return ""
+	}
+	if ref, _ := m["$ref"].(string); ref != "" {
+		return refName(ref)
+	}
+	typ, _ := m["type"].(string)
+	return typ
+}
+
+// isBinaryContent reports whether a media type carries raw bytes or a
+// stream rather than a structured document.
+func isBinaryContent(ct string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(ct, ";", 2)[0]))
+	switch mediaType {
+	case "application/octet-stream", "application/pdf", "application/zip", "text/event-stream":
+		return true
+	}
+	for _, prefix := range []string{"image/", "audio/", "video/"} {
+		if strings.HasPrefix(mediaType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func contentTypeRank(ct string) int {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.SplitN(ct, ";", 2)[0]))
+	switch {
+	case mediaType == "application/json":
+		return 0
+	case strings.HasSuffix(mediaType, "+json"):
+		return 1
+	case mediaType == "application/x-www-form-urlencoded":
+		return 2
+	case mediaType == "multipart/form-data":
+		return 3
+	default:
+		return 4
+	}
+}
@@ -0,0 +1,34 @@
+package openapi
+
+import "strings"
+
+// deprecation resolves an operation's or parameter's deprecation metadata.
+// The x-deprecation-note and x-sunset extensions win; otherwise the note is
+// taken from a "Deprecated: ..." line in the description. Either extension
+// marks the element deprecated even without deprecated: true.
+func deprecation(deprecated bool, description, note, sunset string) (bool, string, string) {
+	note = strings.TrimSpace(note)
+	// Unquoted YAML dates come back from ref resolution as timestamps
+	sunset = strings.TrimSuffix(strings.TrimSpace(sunset), "T00:00:00Z")
+	deprecated = deprecated || note != "" || sunset != ""
+	if deprecated && note == "" {
+		note = descriptionDeprecationNote(description)
+	}
+	return deprecated, note, sunset
+}
+
+// descriptionDeprecationNote returns the text after a leading "Deprecated"
+// marker on any description line, e.g. "**Deprecated:** use listPetsV2".
+func descriptionDeprecationNote(description string) string {
+	for _, line := range strings.Split(description, "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "*_> ")
+		if len(line) < len("deprecated") || !strings.EqualFold(line[:len("deprecated")], "deprecated") {
+			continue
+		}
+		rest := strings.TrimLeft(line[len("deprecated"):], "*_:.-—– ")
+		if rest != "" {
+			return rest
+		}
+	}
+	return ""
+}
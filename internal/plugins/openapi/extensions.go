@@ -0,0 +1,20 @@
+package openapi
+
+import "strings"
+
+// extensions returns the vendor "x-" keys of an object's unmodeled fields,
+// or nil when there are none. x-deprecation-note and x-sunset are modeled
+// separately and never appear here.
+func extensions(extra map[string]any) map[string]any {
+	var ext map[string]any
+	for key, v := range extra {
+		if !strings.HasPrefix(key, "x-") {
+			continue
+		}
+		if ext == nil {
+			ext = make(map[string]any)
+		}
+		ext[key] = v
+	}
+	return ext
+}
@@ -1,18 +1,22 @@
 package openapi
 
 import (
+	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 
+	"github.com/roberthamel/skill-compiler/internal/diag"
+	"github.com/roberthamel/skill-compiler/internal/fetch"
 	"github.com/roberthamel/skill-compiler/internal/instructions"
 	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/sniff"
 	"gopkg.in/yaml.v3"
 )
 
@@ -23,13 +27,26 @@ func New() *Plugin { return &Plugin{} }
 
 func (p *Plugin) Name() string { return "openapi" }
 
+// Priority ranks openapi below content-sniffing plugins, since any .yaml or
+// .json path matches its Detect.
+func (p *Plugin) Priority() int { return ir.PriorityExtension }
+
 func (p *Plugin) Detect(source instructions.SpecSource) bool {
 	if source.Type == "openapi" {
 		return true
 	}
 	if source.Path != "" {
-		ext := strings.ToLower(filepath.Ext(source.Path))
-		return ext == ".yaml" || ext == ".yml" || ext == ".json"
+		// Content decides when it is recognizable; defer to the plugin for
+		// another format even if the extension would match
+		switch sniff.File(source.Path) {
+		case sniff.OpenAPI:
+			return true
+		case sniff.Unknown:
+			ext := strings.ToLower(filepath.Ext(source.Path))
+			return ext == ".yaml" || ext == ".yml" || ext == ".json"
+		default:
+			return false
+		}
 	}
 	if source.URL != "" || source.Command != "" {
 		// URL and command sources need explicit type
@@ -38,45 +55,119 @@ func (p *Plugin) Detect(source instructions.SpecSource) bool {
 	return false
 }
 
-func (p *Plugin) Fetch(source instructions.SpecSource) ([]byte, error) {
+func (p *Plugin) Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error) {
 	if source.Path != "" {
 		return os.ReadFile(source.Path)
 	}
 	if source.URL != "" {
-		resp, err := http.Get(source.URL)
-		if err != nil {
-			return nil, fmt.Errorf("fetching URL %s: %w", source.URL, err)
-		}
-		defer func() { _ = resp.Body.Close() }()
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("fetching URL %s: HTTP %d", source.URL, resp.StatusCode)
-		}
-		return io.ReadAll(resp.Body)
+		return fetch.URL(ctx, source)
 	}
 	if source.Command != "" {
-		parts := strings.Fields(source.Command)
-		cmd := exec.Command(parts[0], parts[1:]...)
-		out, err := cmd.Output()
-		if err != nil {
-			return nil, fmt.Errorf("running command %q: %w", source.Command, err)
-		}
-		return out, nil
+		return fetch.Command(ctx, source)
 	}
 	return nil, fmt.Errorf("openapi plugin: no path, url, or command in spec source")
 }
 
 // openAPIDoc is a minimal representation for parsing.
 type openAPIDoc struct {
-	OpenAPI    string                          `yaml:"openapi" json:"openapi"`
-	Info       openAPIInfo                     `yaml:"info" json:"info"`
-	Paths      map[string]map[string]openAPIOp `yaml:"paths" json:"paths"`
-	Components *openAPIComponents              `yaml:"components" json:"components"`
+	OpenAPI    string                     `yaml:"openapi" json:"openapi"`
+	Info       openAPIInfo                `yaml:"info" json:"info"`
+	Paths      map[string]openAPIPathItem `yaml:"paths" json:"paths"`
+	Components *openAPIComponents         `yaml:"components" json:"components"`
+	Tags       []openAPITag               `yaml:"tags" json:"tags"`
+	Servers    []openAPIServer            `yaml:"servers" json:"servers"`
+	// ExternalDocs links the API's documentation outside the spec
+	ExternalDocs *openAPIExternalDocs `yaml:"externalDocs" json:"externalDocs"`
+	// Security applies to operations that declare none of their own
+	Security []map[string][]string `yaml:"security" json:"security"`
+}
+
+// httpMethods are the path item keys that hold operations.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIPathItem holds a path's operations keyed by lowercase method, and
+// the parameters shared by all of them. Other path item keys (summary,
+// servers, extensions) are ignored.
+type openAPIPathItem struct {
+	Parameters []openAPIParam
+	Operations map[string]openAPIOp
+}
+
+func (pi *openAPIPathItem) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]yaml.Node
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	if params, ok := raw["parameters"]; ok {
+		if err := params.Decode(&pi.Parameters); err != nil {
+			return err
+		}
+	}
+	pi.Operations = make(map[string]openAPIOp)
+	for key, value := range raw {
+		if !slices.Contains(httpMethods, strings.ToLower(key)) {
+			continue
+		}
+		var op openAPIOp
+		if err := value.Decode(&op); err != nil {
+			return err
+		}
+		pi.Operations[strings.ToLower(key)] = op
+	}
+	return nil
+}
+
+// mergeParams returns an operation's parameters with the path-level ones it
+// does not override (same name and location) prepended.
+func mergeParams(pathParams, opParams []openAPIParam) []openAPIParam {
+	if len(pathParams) == 0 {
+		return opParams
+	}
+	merged := make([]openAPIParam, 0, len(pathParams)+len(opParams))
+	for _, pp := range pathParams {
+		overridden := slices.ContainsFunc(opParams, func(op openAPIParam) bool {
+			return op.Name == pp.Name && op.In == pp.In
+		})
+		if !overridden {
+			merged = append(merged, pp)
+		}
+	}
+	return append(merged, opParams...)
+}
+
+type openAPIServer struct {
+	URL         string `yaml:"url" json:"url"`
+	Description string `yaml:"description" json:"description"`
+}
+
+type openAPITag struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
 }
 
 type openAPIInfo struct {
-	Title       string `yaml:"title" json:"title"`
+	Title       string          `yaml:"title" json:"title"`
+	Description string          `yaml:"description" json:"description"`
+	Version     string          `yaml:"version" json:"version"`
+	Contact     *openAPIContact `yaml:"contact" json:"contact"`
+	License     *openAPILicense `yaml:"license" json:"license"`
+}
+
+type openAPIContact struct {
+	Name  string `yaml:"name" json:"name"`
+	URL   string `yaml:"url" json:"url"`
+	Email string `yaml:"email" json:"email"`
+}
+
+type openAPILicense struct {
+	Name       string `yaml:"name" json:"name"`
+	Identifier string `yaml:"identifier" json:"identifier"` // SPDX, OpenAPI 3.1
+	URL        string `yaml:"url" json:"url"`
+}
+
+type openAPIExternalDocs struct {
 	Description string `yaml:"description" json:"description"`
-	Version     string `yaml:"version" json:"version"`
+	URL         string `yaml:"url" json:"url"`
 }
 
 type openAPIOp struct {
@@ -85,10 +176,15 @@ type openAPIOp struct {
 	Description string                 `yaml:"description" json:"description"`
 	Tags        []string               `yaml:"tags" json:"tags"`
 	Deprecated  bool                   `yaml:"deprecated" json:"deprecated"`
-	Security    []map[string][]string  `yaml:"security" json:"security"`
+	Security    *[]map[string][]string `yaml:"security" json:"security"` // nil when omitted: the document's applies
 	Parameters  []openAPIParam         `yaml:"parameters" json:"parameters"`
 	RequestBody *openAPIReqBody        `yaml:"requestBody" json:"requestBody"`
 	Responses   map[string]openAPIResp `yaml:"responses" json:"responses"`
+	// Vendor extensions carrying deprecation details
+	DeprecationNote string `yaml:"x-deprecation-note" json:"x-deprecation-note"`
+	Sunset          string `yaml:"x-sunset" json:"x-sunset"`
+	// Remaining keys; vendor extensions are picked out by extensions()
+	Extra map[string]any `yaml:",inline" json:"-"`
 }
 
 type openAPIParam struct {
@@ -96,8 +192,14 @@ type openAPIParam struct {
 	In          string         `yaml:"in" json:"in"`
 	Description string         `yaml:"description" json:"description"`
 	Required    bool           `yaml:"required" json:"required"`
+	Deprecated  bool           `yaml:"deprecated" json:"deprecated"`
 	Schema      *openAPISchema `yaml:"schema" json:"schema"`
 	Ref         string         `yaml:"$ref" json:"$ref"`
+	// Vendor extensions carrying deprecation details
+	DeprecationNote string `yaml:"x-deprecation-note" json:"x-deprecation-note"`
+	Sunset          string `yaml:"x-sunset" json:"x-sunset"`
+	// Remaining keys; vendor extensions are picked out by extensions()
+	Extra map[string]any `yaml:",inline" json:"-"`
 }
 
 type openAPIReqBody struct {
@@ -113,6 +215,12 @@ type openAPIMediaType struct {
 type openAPIResp struct {
 	Description string                      `yaml:"description" json:"description"`
 	Content     map[string]openAPIMediaType `yaml:"content" json:"content"`
+	Headers     map[string]openAPIHeader    `yaml:"headers" json:"headers"`
+}
+
+type openAPIHeader struct {
+	Description string         `yaml:"description" json:"description"`
+	Schema      *openAPISchema `yaml:"schema" json:"schema"`
 }
 
 type openAPISchema struct {
@@ -124,6 +232,15 @@ type openAPISchema struct {
 	Items       *openAPISchema            `yaml:"items" json:"items"`
 	Required    []string                  `yaml:"required" json:"required"`
 	Enum        []string                  `yaml:"enum" json:"enum"`
+	// Validation keywords surfaced as parameter constraints
+	Default   any      `yaml:"default" json:"default"`
+	Minimum   *float64 `yaml:"minimum" json:"minimum"`
+	Maximum   *float64 `yaml:"maximum" json:"maximum"`
+	MinLength *int     `yaml:"minLength" json:"minLength"`
+	MaxLength *int     `yaml:"maxLength" json:"maxLength"`
+	Pattern   string   `yaml:"pattern" json:"pattern"`
+	// AdditionalProperties is true, false, or a schema for map values
+	AdditionalProperties any `yaml:"additionalProperties" json:"additionalProperties"`
 }
 
 type openAPIComponents struct {
@@ -149,6 +266,11 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 			return nil, fmt.Errorf("parsing OpenAPI document: %w", err)
 		}
 	}
+	if source.Offline {
+		if ref := findRemoteRef(rawDoc); ref != "" {
+			return nil, fmt.Errorf("remote $ref %s: %w", ref, fetch.ErrOffline)
+		}
+	}
 	resolveRefs(rawDoc, rawDoc)
 
 	// Re-marshal and unmarshal into typed struct
@@ -173,16 +295,22 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 			"version":     doc.Info.Version,
 		},
 	}
+	if len(doc.Servers) > 0 && doc.Servers[0].URL != "" {
+		result.Metadata["baseUrl"] = doc.Servers[0].URL
+	}
+	infoMetadata(result.Metadata, doc)
 
 	// Parse operations from paths (sorted for deterministic output)
 	groupOps := make(map[string][]string)
+	var ungrouped []string
 	sortedPaths := make([]string, 0, len(doc.Paths))
 	for path := range doc.Paths {
 		sortedPaths = append(sortedPaths, path)
 	}
 	sort.Strings(sortedPaths)
 	for _, path := range sortedPaths {
-		methods := doc.Paths[path]
+		item := doc.Paths[path]
+		methods := item.Operations
 		sortedMethods := make([]string, 0, len(methods))
 		for method := range methods {
 			sortedMethods = append(sortedMethods, method)
@@ -192,7 +320,7 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 			op := methods[method]
 			opID := op.OperationID
 			if opID == "" {
-				opID = strings.ToLower(method) + "_" + strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")
+				opID = ir.OperationID(method, path)
 			}
 
 			desc := op.Description
@@ -200,41 +328,41 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 				desc = op.Summary
 			}
 
+			deprecated, note, sunset := deprecation(op.Deprecated, desc, op.DeprecationNote, op.Sunset)
 			irOp := ir.Operation{
-				ID:          opID,
-				Name:        op.Summary,
-				Description: desc,
-				Method:      strings.ToUpper(method),
-				Path:        path,
-				Tags:        op.Tags,
-				Deprecated:  op.Deprecated,
+				ID:              opID,
+				Name:            op.Summary,
+				Description:     desc,
+				Method:          strings.ToUpper(method),
+				Path:            path,
+				Tags:            op.Tags,
+				Deprecated:      deprecated,
+				DeprecationNote: note,
+				Sunset:          sunset,
+				Extensions:      extensions(op.Extra),
 			}
 
-			// Parameters
-			for _, param := range op.Parameters {
+			// Parameters, including those shared by every method on the path
+			for _, param := range mergeParams(item.Parameters, op.Parameters) {
+				deprecated, note, sunset := deprecation(param.Deprecated, param.Description, param.DeprecationNote, param.Sunset)
 				irOp.Parameters = append(irOp.Parameters, ir.Parameter{
-					Name:        param.Name,
-					In:          param.In,
-					Description: param.Description,
-					Required:    param.Required,
-					Type:        schemaType(param.Schema),
+					Name:            param.Name,
+					In:              param.In,
+					Description:     param.Description,
+					Required:        param.Required,
+					Type:            schemaType(param.Schema),
+					Default:         schemaDefault(param.Schema),
+					Constraints:     schemaConstraints(param.Schema),
+					Deprecated:      deprecated,
+					DeprecationNote: note,
+					Sunset:          sunset,
+					Extensions:      extensions(param.Extra),
 				})
 			}
 
 			// Request body
 			if op.RequestBody != nil {
-				for ct, mt := range op.RequestBody.Content {
-					typeName := ""
-					if mt.Schema != nil && mt.Schema.Ref != "" {
-						typeName = refName(mt.Schema.Ref)
-					}
-					irOp.RequestBody = &ir.TypeRef{
-						TypeName:    typeName,
-						Description: op.RequestBody.Description,
-						ContentType: ct,
-					}
-					break // take first content type
-				}
+				irOp.RequestBody = bodyRef(op.RequestBody.Content, op.RequestBody.Description)
 			}
 
 			// Responses
@@ -249,36 +377,48 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 					StatusCode:  code,
 					Description: resp.Description,
 				}
-				for ct, mt := range resp.Content {
-					typeName := ""
-					if mt.Schema != nil && mt.Schema.Ref != "" {
-						typeName = refName(mt.Schema.Ref)
-					}
-					irResp.Body = &ir.TypeRef{
-						TypeName:    typeName,
-						ContentType: ct,
-					}
-					break
+				irResp.Body = bodyRef(resp.Content, "")
+				if irResp.Body == nil {
+					// Record that the response has no body rather than
+					// leaving it indistinguishable from an unparsed one
+					irResp.Body = &ir.TypeRef{Description: "empty body"}
 				}
+				irResp.Headers = responseHeaders(resp.Headers)
 				irOp.Responses = append(irOp.Responses, irResp)
 			}
 
-			// Auth references (sorted for deterministic output)
-			for _, sec := range op.Security {
+			// Auth requirements: each security object is an alternative, and
+			// the schemes within one are all required (sorted for
+			// deterministic output). An empty object allows anonymous calls,
+			// and so does an empty list, overriding the document's default.
+			security := doc.Security
+			if op.Security != nil {
+				security = *op.Security
+				if len(security) == 0 {
+					irOp.Auth = [][]string{{}}
+				}
+			}
+			for _, sec := range security {
 				secNames := make([]string, 0, len(sec))
 				for name := range sec {
 					secNames = append(secNames, name)
 				}
 				sort.Strings(secNames)
-				irOp.Auth = append(irOp.Auth, secNames...)
+				irOp.Auth = append(irOp.Auth, secNames)
 			}
 
+			irOp.Pagination = detectPagination(irOp.Parameters, op.Responses)
+			irOp.RateLimit = detectRateLimit(op.Extra, desc, op.Responses)
+
 			result.Operations = append(result.Operations, irOp)
 
 			// Group by tags
 			for _, tag := range op.Tags {
 				groupOps[tag] = append(groupOps[tag], opID)
 			}
+			if len(op.Tags) == 0 {
+				ungrouped = append(ungrouped, opID)
+			}
 		}
 	}
 
@@ -339,39 +479,107 @@ func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.Intermed
 		}
 	}
 
-	// Build groups (sorted for deterministic output)
-	sortedGroups := make([]string, 0, len(groupOps))
+	result.Groups = buildGroups(doc.Tags, groupOps, ungrouped)
+	if styles := paginationStyles(result.Operations); styles != "" {
+		result.Metadata["pagination"] = styles
+	}
+
+	return result, nil
+}
+
+// ungroupedName is the group collecting operations without tags.
+const ungroupedName = "Other"
+
+// buildGroups orders groups as the root tags array declares them, followed by
+// undeclared tags alphabetically, with untagged operations in a final group.
+func buildGroups(tags []openAPITag, groupOps map[string][]string, ungrouped []string) []ir.Group {
+	var groups []ir.Group
+	declared := make(map[string]bool)
+	for _, tag := range tags {
+		if declared[tag.Name] {
+			continue
+		}
+		declared[tag.Name] = true
+		if ops, ok := groupOps[tag.Name]; ok {
+			groups = append(groups, ir.Group{
+				Name:        tag.Name,
+				Description: tag.Description,
+				Operations:  ops,
+			})
+		}
+	}
+
+	undeclared := make([]string, 0, len(groupOps))
 	for name := range groupOps {
-		sortedGroups = append(sortedGroups, name)
+		if !declared[name] {
+			undeclared = append(undeclared, name)
+		}
 	}
-	sort.Strings(sortedGroups)
-	for _, name := range sortedGroups {
-		ops := groupOps[name]
-		result.Groups = append(result.Groups, ir.Group{
+	sort.Strings(undeclared)
+	for _, name := range undeclared {
+		groups = append(groups, ir.Group{
 			Name:       name,
-			Operations: ops,
+			Operations: groupOps[name],
 		})
 	}
 
-	return result, nil
+	if len(ungrouped) > 0 {
+		// A tag literally named "Other" absorbs the untagged operations
+		for i := range groups {
+			if groups[i].Name == ungroupedName {
+				groups[i].Operations = append(groups[i].Operations, ungrouped...)
+				return groups
+			}
+		}
+		groups = append(groups, ir.Group{
+			Name:        ungroupedName,
+			Description: "Operations without a tag",
+			Operations:  ungrouped,
+		})
+	}
+	return groups
 }
 
+// Validate reports missing documentation, most important first: operations
+// without a summary or description, then undocumented responses, then
+// undocumented parameters, followed by a coverage summary line.
 func (p *Plugin) Validate(parsed *ir.IntermediateRepr) []ir.Warning {
-	var warnings []ir.Warning
+	var opWarnings, respWarnings, paramWarnings []ir.Warning
+	undocumented := 0
 	for _, op := range parsed.Operations {
 		if op.Description == "" && op.Name == "" {
-			warnings = append(warnings, ir.Warning{
-				Message: fmt.Sprintf("operation %s has no description or summary", op.ID),
+			undocumented++
+			opWarnings = append(opWarnings, ir.Warning{
+				Code:    "undocumented-operation",
+				Message: fmt.Sprintf("operation %s (%s %s) has no description or summary", op.ID, op.Method, op.Path),
 			})
 		}
+		for _, resp := range op.Responses {
+			if resp.Description == "" {
+				respWarnings = append(respWarnings, ir.Warning{
+					Code:    "undocumented-response",
+					Message: fmt.Sprintf("response %s of operation %s has no description", resp.StatusCode, op.ID),
+				})
+			}
+		}
 		for _, param := range op.Parameters {
 			if param.Description == "" {
-				warnings = append(warnings, ir.Warning{
-					Message: fmt.Sprintf("parameter %s in %s %s has no description", param.Name, op.Method, op.Path),
+				paramWarnings = append(paramWarnings, ir.Warning{
+					Code:    "undocumented-parameter",
+					Message: fmt.Sprintf("parameter %s of operation %s (%s %s) has no description", param.Name, op.ID, op.Method, op.Path),
 				})
 			}
 		}
 	}
+
+	warnings := append(append(opWarnings, respWarnings...), paramWarnings...)
+	if undocumented > 0 {
+		warnings = append(warnings, ir.Warning{
+			Severity: diag.SeverityInfo,
+			Code:     "documentation-coverage",
+			Message:  fmt.Sprintf("%d of %d operations undocumented", undocumented, len(parsed.Operations)),
+		})
+	}
 	return warnings
 }
 
@@ -401,6 +609,34 @@ func resolveRefs(node interface{}, root map[string]interface{}) {
 	}
 }
 
+// findRemoteRef returns the first $ref pointing at an http(s) URL, if any.
+func findRemoteRef(node interface{}) string {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok &&
+			(strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")) {
+			return ref
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if ref := findRemoteRef(v[key]); ref != "" {
+				return ref
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if ref := findRemoteRef(item); ref != "" {
+				return ref
+			}
+		}
+	}
+	return ""
+}
+
 // lookupRef resolves a JSON pointer like #/components/schemas/Foo.
 func lookupRef(ref string, root map[string]interface{}) interface{} {
 	if !strings.HasPrefix(ref, "#/") {
@@ -413,7 +649,8 @@ func lookupRef(ref string, root map[string]interface{}) interface{} {
 		if !ok {
 			return nil
 		}
-		current = m[part]
+		// JSON pointer escapes: ~1 is "/" (as in path keys), ~0 is "~"
+		current = m[strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")]
 	}
 	return current
 }
@@ -431,7 +668,181 @@ func schemaType(s *openAPISchema) string {
 	return s.Type
 }
 
+// schemaConstraints collects a parameter schema's validation keywords; for
+// arrays, the item schema's enum and format apply. It returns nil if there
+// are none.
+func schemaConstraints(s *openAPISchema) *ir.Constraints {
+	if s == nil {
+		return nil
+	}
+	c := &ir.Constraints{
+		Enum:      s.Enum,
+		Format:    s.Format,
+		Minimum:   s.Minimum,
+		Maximum:   s.Maximum,
+		MinLength: s.MinLength,
+		MaxLength: s.MaxLength,
+		Pattern:   s.Pattern,
+	}
+	if s.Type == "array" && s.Items != nil {
+		if len(c.Enum) == 0 {
+			c.Enum = s.Items.Enum
+		}
+		if c.Format == "" {
+			c.Format = s.Items.Format
+		}
+	}
+	if len(c.Enum) == 0 && c.Format == "" && c.Minimum == nil && c.Maximum == nil &&
+		c.MinLength == nil && c.MaxLength == nil && c.Pattern == "" {
+		return nil
+	}
+	return c
+}
+
+// infoMetadata records the spec's contact, license, and external docs in
+// metadata, skipping fields the spec leaves empty.
+func infoMetadata(metadata map[string]string, doc openAPIDoc) {
+	set := func(key, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			metadata[key] = value
+		}
+	}
+	if c := doc.Info.Contact; c != nil {
+		set("contactName", c.Name)
+		set("contactUrl", c.URL)
+		set("contactEmail", c.Email)
+	}
+	if l := doc.Info.License; l != nil {
+		set("license", cmp.Or(l.Identifier, l.Name))
+		set("licenseUrl", l.URL)
+	}
+	if d := doc.ExternalDocs; d != nil {
+		set("externalDocsUrl", d.URL)
+		set("externalDocsDescription", d.Description)
+	}
+}
+
+// responseHeaders converts a response's headers, sorted by name.
+func responseHeaders(headers map[string]openAPIHeader) []ir.Header {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var out []ir.Header
+	for _, name := range names {
+		h := headers[name]
+		out = append(out, ir.Header{Name: name, Type: schemaType(h.Schema), Description: h.Description})
+	}
+	return out
+}
+
+// schemaDefault renders a schema's default value, or "" if it has none.
+func schemaDefault(s *openAPISchema) string {
+	if s == nil || s.Default == nil {
+		return ""
+	}
+	return fmt.Sprint(s.Default)
+}
+
 func refName(ref string) string {
 	parts := strings.Split(ref, "/")
 	return parts[len(parts)-1]
 }
+
+// ApplyDescriptions writes operation and parameter descriptions from ops back
+// into the YAML OpenAPI document at path. Only operations with neither a
+// summary nor a description, and parameters without a description, are
+// filled. It returns how many descriptions were written.
+func ApplyDescriptions(path string, ops []ir.Operation) (int, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" {
+		return 0, fmt.Errorf("writing descriptions to %s: only YAML specs are supported", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return 0, fmt.Errorf("parsing OpenAPI document: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return 0, nil
+	}
+	paths := mappingValue(doc.Content[0], "paths")
+	if paths == nil {
+		return 0, nil
+	}
+
+	written := 0
+	for _, op := range ops {
+		if op.Method == "" || op.Path == "" {
+			continue
+		}
+		pathNode := mappingValue(paths, op.Path)
+		opNode := mappingValue(pathNode, strings.ToLower(op.Method))
+		if opNode == nil || opNode.Kind != yaml.MappingNode {
+			continue
+		}
+		if op.Description != "" && mappingValue(opNode, "description") == nil && mappingValue(opNode, "summary") == nil {
+			setMappingValue(opNode, "description", op.Description)
+			written++
+		}
+		// Path-level parameters are shared; the first operation drafting a
+		// description for one writes it
+		var paramNodes []*yaml.Node
+		for _, params := range []*yaml.Node{mappingValue(opNode, "parameters"), mappingValue(pathNode, "parameters")} {
+			if params != nil && params.Kind == yaml.SequenceNode {
+				paramNodes = append(paramNodes, params.Content...)
+			}
+		}
+		for _, paramNode := range paramNodes {
+			name := mappingValue(paramNode, "name")
+			if name == nil || mappingValue(paramNode, "description") != nil {
+				continue
+			}
+			for _, param := range op.Parameters {
+				if param.Name == name.Value && param.Description != "" {
+					setMappingValue(paramNode, "description", param.Description)
+					written++
+					break
+				}
+			}
+		}
+	}
+	if written == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return 0, fmt.Errorf("encoding OpenAPI document: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// mappingValue returns the value node for key in a YAML mapping, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(node *yaml.Node, key, value string) {
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Value: value},
+	)
+}
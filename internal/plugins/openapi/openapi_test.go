@@ -1,12 +1,15 @@
 package openapi
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
 	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
 )
 
 func readTestdata(t *testing.T, name string) []byte {
@@ -20,6 +23,15 @@ func readTestdata(t *testing.T, name string) []byte {
 
 func TestDetect(t *testing.T) {
 	p := New()
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		_ = os.WriteFile(path, []byte(content), 0o644)
+		return path
+	}
+	asyncJSON := write("events.json", `{"asyncapi": "2.6.0", "info": {"title": "Events"}}`)
+	graphqlJSON := write("schema.json", `{"data": {"__schema": {"types": []}}}`)
+	openapiNoExt := write("spec", "openapi: 3.0.0\ninfo: {title: T, version: '1'}\n")
 
 	tests := []struct {
 		name   string
@@ -32,6 +44,9 @@ func TestDetect(t *testing.T) {
 		{"explicit type", instructions.SpecSource{Type: "openapi", URL: "http://example.com"}, true},
 		{"cli type", instructions.SpecSource{Type: "cli", Binary: "kubectl"}, false},
 		{"go file", instructions.SpecSource{Path: "main.go"}, false},
+		{"asyncapi json", instructions.SpecSource{Path: asyncJSON}, false},
+		{"graphql introspection", instructions.SpecSource{Path: graphqlJSON}, false},
+		{"openapi without extension", instructions.SpecSource{Path: openapiNoExt}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -118,6 +133,130 @@ func TestParse_RefResolution(t *testing.T) {
 	}
 }
 
+func TestParse_OfflineRejectsRemoteRef(t *testing.T) {
+	p := New()
+	doc := `openapi: 3.0.0
+info: {title: Remote, version: "1"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                $ref: https://example.com/schemas/pet.yaml
+`
+	_, err := p.Parse([]byte(doc), instructions.SpecSource{Offline: true})
+	if err == nil || !strings.Contains(err.Error(), "remote $ref https://example.com/schemas/pet.yaml") {
+		t.Fatalf("error = %v, want remote $ref rejected offline", err)
+	}
+
+	if _, err := p.Parse([]byte(doc), instructions.SpecSource{}); err != nil {
+		t.Errorf("online parse error: %v", err)
+	}
+}
+
+func TestParse_SchemalessResponses(t *testing.T) {
+	p := New()
+	doc := `openapi: 3.0.0
+info: {title: Shapes, version: "1"}
+paths:
+  /things:
+    get:
+      operationId: getThings
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                type: object
+                additionalProperties: true
+        "201":
+          description: created
+          content:
+            application/json: {}
+        "202":
+          description: labels
+          content:
+            application/json:
+              schema:
+                type: object
+                additionalProperties: {type: string}
+        "204":
+          description: no content
+        "206":
+          description: part of the file
+          content:
+            application/octet-stream:
+              schema: {type: string, format: binary}
+`
+	result, err := p.Parse([]byte(doc), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	want := map[string]string{
+		"200": "unstructured JSON object",
+		"201": "unstructured JSON (no schema)",
+		"202": "JSON object with arbitrary keys; values are string",
+		"204": "empty body",
+		"206": "binary/stream",
+	}
+	op := result.Operations[0]
+	if len(op.Responses) != len(want) {
+		t.Fatalf("got %d responses, want %d", len(op.Responses), len(want))
+	}
+	for _, r := range op.Responses {
+		if r.Body == nil {
+			t.Errorf("response %s has no body", r.StatusCode)
+			continue
+		}
+		if r.Body.Description != want[r.StatusCode] {
+			t.Errorf("response %s body = %q, want %q", r.StatusCode, r.Body.Description, want[r.StatusCode])
+		}
+	}
+}
+
+func TestParse_PathLevelParameters(t *testing.T) {
+	p := New()
+	data := readTestdata(t, "shared-params.yaml")
+	result, err := p.Parse(data, instructions.SpecSource{Path: "testdata/shared-params.yaml"})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	params := make(map[string][]string) // operation ID -> "in:name: description"
+	for _, op := range result.Operations {
+		for _, param := range op.Parameters {
+			params[op.ID] = append(params[op.ID], param.In+":"+param.Name+": "+param.Description)
+		}
+	}
+	want := map[string][]string{
+		"getMember": {
+			"path:orgId: Organization ID",
+			"path:memberId: Member ID",
+			"header:X-Trace: Trace header sent with every member request",
+		},
+		"removeMember": {
+			"path:orgId: Organization ID",
+			"header:X-Trace: Trace header sent with every member request",
+			"path:memberId: Member to remove; must not be the last owner",
+			"query:memberId: Unrelated query parameter with the same name",
+		},
+	}
+	for id, w := range want {
+		if got := params[id]; strings.Join(got, "\n") != strings.Join(w, "\n") {
+			t.Errorf("%s parameters:\n%s\nwant:\n%s", id, strings.Join(got, "\n"), strings.Join(w, "\n"))
+		}
+	}
+	if len(result.Operations) != 2 {
+		t.Errorf("got %d operations, want 2 (path item keys are not methods)", len(result.Operations))
+	}
+}
+
 func TestValidate_MissingDescriptions(t *testing.T) {
 	p := New()
 	// Create a minimal spec with an undocumented parameter
@@ -156,3 +295,857 @@ paths:
 		t.Errorf("expected warning about parameter 'limit' missing description, got %v", warnings)
 	}
 }
+
+func TestParse_TagOrderAndDescriptions(t *testing.T) {
+	p := New()
+	doc := `openapi: 3.0.0
+info: {title: Tags, version: "1"}
+tags:
+  - name: users
+    description: Manage user accounts
+  - name: admin
+    description: Administrative operations
+paths:
+  /users:
+    get: {operationId: listUsers, tags: [users]}
+  /admin:
+    get: {operationId: adminStats, tags: [admin]}
+  /billing:
+    get: {operationId: getInvoice, tags: [billing]}
+  /health:
+    get: {operationId: health}
+`
+	result, err := p.Parse([]byte(doc), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	var names []string
+	for _, g := range result.Groups {
+		names = append(names, g.Name)
+	}
+	if strings.Join(names, ",") != "users,admin,billing,Other" {
+		t.Fatalf("group order = %v, want declared tags, undeclared, then Other", names)
+	}
+	if result.Groups[0].Description != "Manage user accounts" {
+		t.Errorf("users description = %q", result.Groups[0].Description)
+	}
+	if other := result.Groups[3]; len(other.Operations) != 1 || other.Operations[0] != "health" {
+		t.Errorf("Other group = %+v, want [health]", other)
+	}
+}
+
+func TestApplyDescriptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api.yaml")
+	doc := `openapi: 3.0.0
+info: {title: Sparse, version: "1"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: limit
+          in: query
+    post:
+      operationId: createPet
+      summary: Create a pet
+`
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ops := []ir.Operation{
+		{Method: "GET", Path: "/pets", Description: "Lists pets.",
+			Parameters: []ir.Parameter{{Name: "limit", Description: "Page size."}}},
+		{Method: "POST", Path: "/pets", Description: "Create a pet"},
+	}
+	n, err := ApplyDescriptions(path, ops)
+	if err != nil {
+		t.Fatalf("ApplyDescriptions error: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("written = %d, want 2 (summary-only op left alone)", n)
+	}
+
+	updated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := New().Parse(updated, instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("re-parse error: %v", err)
+	}
+	for _, op := range result.Operations {
+		if op.ID == "listPets" && (op.Description != "Lists pets." || op.Parameters[0].Description != "Page size.") {
+			t.Errorf("listPets after write-back = %+v", op)
+		}
+	}
+}
+
+func TestValidate_UndocumentedOperationsAndResponses(t *testing.T) {
+	p := New()
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+paths:
+  /items:
+    get:
+      operationId: listItems
+      summary: List items
+      responses:
+        "200": {description: OK}
+    delete:
+      operationId: purgeItems
+      parameters:
+        - name: force
+          in: query
+      responses:
+        "204": {}
+`
+	result, err := p.Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	warnings := p.Validate(result)
+	var messages []string
+	for _, w := range warnings {
+		messages = append(messages, w.Message)
+	}
+	want := []string{
+		"operation purgeItems (DELETE /items) has no description or summary",
+		"response 204 of operation purgeItems has no description",
+		"parameter force of operation purgeItems (DELETE /items) has no description",
+		"1 of 2 operations undocumented",
+	}
+	if strings.Join(messages, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings =\n%s\nwant\n%s", strings.Join(messages, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestParse_Pagination(t *testing.T) {
+	p := New()
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+paths:
+  /events:
+    get:
+      operationId: listEvents
+      parameters:
+        - {name: limit, in: query}
+        - {name: cursor, in: query}
+      responses:
+        "200": {description: OK}
+  /users:
+    get:
+      operationId: listUsers
+      parameters:
+        - {name: offset, in: query}
+        - {name: limit, in: query}
+      responses:
+        "200": {description: OK}
+  /repos:
+    get:
+      operationId: listRepos
+      parameters:
+        - {name: per_page, in: query}
+      responses:
+        "200":
+          description: OK
+          headers:
+            Link: {description: Next and previous page URLs}
+  /users/{id}:
+    get:
+      operationId: getUser
+      parameters:
+        - {name: id, in: path, required: true}
+      responses:
+        "200": {description: OK}
+`
+	result, err := p.Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	tests := []struct {
+		opID   string
+		style  string
+		params string
+	}{
+		{"listEvents", "cursor", "cursor,limit"},
+		{"listUsers", "offset", "offset,limit"},
+		{"listRepos", "link", "per_page"},
+		{"getUser", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.opID, func(t *testing.T) {
+			var op *ir.Operation
+			for i := range result.Operations {
+				if result.Operations[i].ID == tt.opID {
+					op = &result.Operations[i]
+				}
+			}
+			if op == nil {
+				t.Fatalf("operation %s not found", tt.opID)
+			}
+			if tt.style == "" {
+				if op.Pagination != nil {
+					t.Errorf("pagination = %+v, want none", op.Pagination)
+				}
+				return
+			}
+			if op.Pagination == nil || op.Pagination.Style != tt.style || strings.Join(op.Pagination.Params, ",") != tt.params {
+				t.Errorf("pagination = %+v, want %s (%s)", op.Pagination, tt.style, tt.params)
+			}
+		})
+	}
+
+	if got := result.Metadata["pagination"]; got != "cursor, link, offset" {
+		t.Errorf("metadata pagination = %q", got)
+	}
+}
+
+func TestParse_BaseURLFromServers(t *testing.T) {
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+servers:
+  - url: https://api.example.com/v1
+  - url: https://staging.example.com/v1
+paths: {}
+`
+	result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if got := result.Metadata["baseUrl"]; got != "https://api.example.com/v1" {
+		t.Errorf("baseUrl = %q, want first server URL", got)
+	}
+}
+
+func TestParse_InfoContactLicenseExternalDocs(t *testing.T) {
+	spec := `openapi: "3.1.0"
+info:
+  title: Test
+  version: "1.0"
+  contact: {name: API Team, email: api@example.com, url: https://example.com/support}
+  license: {name: Apache 2.0, identifier: Apache-2.0, url: https://www.apache.org/licenses/LICENSE-2.0}
+externalDocs: {description: Developer guide, url: https://docs.example.com}
+paths: {}
+`
+	result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	want := map[string]string{
+		"contactName":             "API Team",
+		"contactEmail":            "api@example.com",
+		"contactUrl":              "https://example.com/support",
+		"license":                 "Apache-2.0",
+		"licenseUrl":              "https://www.apache.org/licenses/LICENSE-2.0",
+		"externalDocsUrl":         "https://docs.example.com",
+		"externalDocsDescription": "Developer guide",
+	}
+	for key, value := range want {
+		if got := result.Metadata[key]; got != value {
+			t.Errorf("Metadata[%q] = %q, want %q", key, got, value)
+		}
+	}
+
+	// Absent fields leave no empty keys behind
+	result, err = New().Parse([]byte("openapi: \"3.0.0\"\ninfo: {title: Test, version: \"1\", license: {name: MIT}}\npaths: {}\n"), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	if result.Metadata["license"] != "MIT" {
+		t.Errorf("license = %q, want the name when there is no identifier", result.Metadata["license"])
+	}
+	for _, key := range []string{"contactName", "licenseUrl", "externalDocsUrl"} {
+		if _, ok := result.Metadata[key]; ok {
+			t.Errorf("Metadata[%q] should be absent", key)
+		}
+	}
+}
+
+func TestParse_RequestBodyContentTypes(t *testing.T) {
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+paths:
+  /avatar:
+    put:
+      operationId: uploadAvatar
+      requestBody:
+        content:
+          multipart/form-data:
+            schema: {type: object}
+      responses:
+        "204": {description: Uploaded}
+  /token:
+    post:
+      operationId: createToken
+      requestBody:
+        content:
+          multipart/form-data:
+            schema: {type: object}
+          application/x-www-form-urlencoded:
+            schema: {type: object}
+          application/json:
+            schema: {$ref: "#/components/schemas/TokenRequest"}
+      responses:
+        "200":
+          description: Token
+          content:
+            text/plain: {schema: {type: string}}
+            application/problem+json: {schema: {type: object}}
+components:
+  schemas:
+    TokenRequest: {type: object}
+`
+	result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	ops := make(map[string]ir.Operation)
+	for _, op := range result.Operations {
+		ops[op.ID] = op
+	}
+
+	if got := ops["uploadAvatar"].RequestBody; got == nil || got.ContentType != "multipart/form-data" || len(got.AltContentTypes) != 0 {
+		t.Errorf("uploadAvatar body = %+v, want multipart/form-data only", got)
+	}
+	body := ops["createToken"].RequestBody
+	if body == nil || body.ContentType != "application/json" {
+		t.Fatalf("createToken body = %+v, want application/json primary", body)
+	}
+	if want := []string{"application/x-www-form-urlencoded", "multipart/form-data"}; strings.Join(body.AltContentTypes, ",") != strings.Join(want, ",") {
+		t.Errorf("AltContentTypes = %v, want %v", body.AltContentTypes, want)
+	}
+	if resp := ops["createToken"].Responses[0].Body; resp == nil || resp.ContentType != "application/problem+json" {
+		t.Errorf("response body = %+v, want +json preferred over text/plain", resp)
+	}
+}
+
+func TestParse_Deprecation(t *testing.T) {
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+paths:
+  /v1/pets:
+    get:
+      operationId: listPetsV1
+      deprecated: true
+      description: |
+        Lists pets.
+
+        **Deprecated:** use listPets instead.
+      parameters:
+        - name: sort
+          in: query
+          deprecated: true
+          description: "Deprecated: use order."
+        - name: limit
+          in: query
+      responses:
+        "200": {description: OK}
+  /v1/owners:
+    get:
+      operationId: listOwnersV1
+      x-sunset: 2025-06-30
+      x-deprecation-note: Replaced by /v2/owners
+      responses:
+        "200": {description: OK}
+  /pets:
+    get:
+      operationId: listPets
+      description: Not deprecated, despite mentioning deprecated fields.
+      responses:
+        "200": {description: OK}
+`
+	result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	ops := make(map[string]ir.Operation)
+	for _, op := range result.Operations {
+		ops[op.ID] = op
+	}
+
+	v1 := ops["listPetsV1"]
+	if !v1.Deprecated || v1.DeprecationNote != "use listPets instead." || v1.Sunset != "" {
+		t.Errorf("listPetsV1 = deprecated %v, note %q, sunset %q", v1.Deprecated, v1.DeprecationNote, v1.Sunset)
+	}
+	if sort := v1.Parameters[0]; !sort.Deprecated || sort.DeprecationNote != "use order." {
+		t.Errorf("sort = deprecated %v, note %q, want deprecated with %q", sort.Deprecated, sort.DeprecationNote, "use order.")
+	}
+	if limit := v1.Parameters[1]; limit.Deprecated || limit.DeprecationNote != "" {
+		t.Errorf("limit = deprecated %v, note %q, want neither", limit.Deprecated, limit.DeprecationNote)
+	}
+
+	owners := ops["listOwnersV1"]
+	if !owners.Deprecated || owners.DeprecationNote != "Replaced by /v2/owners" || owners.Sunset != "2025-06-30" {
+		t.Errorf("listOwnersV1 = deprecated %v, note %q, sunset %q", owners.Deprecated, owners.DeprecationNote, owners.Sunset)
+	}
+
+	if pets := ops["listPets"]; pets.Deprecated || pets.DeprecationNote != "" {
+		t.Errorf("listPets = deprecated %v, note %q, want neither", pets.Deprecated, pets.DeprecationNote)
+	}
+}
+
+func TestParse_Extensions(t *testing.T) {
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      x-rate-limit: {requests: 100, per: minute}
+      x-internal-owner: team-pets
+      x-sunset: 2025-06-30
+      externalDocs: {url: "https://example.com"}
+      parameters:
+        - name: limit
+          in: query
+          x-example-cli: "--limit 10"
+      responses:
+        "200": {description: OK}
+  /owners:
+    get:
+      operationId: listOwners
+      responses:
+        "200": {description: OK}
+`
+	result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	ops := make(map[string]ir.Operation)
+	for _, op := range result.Operations {
+		ops[op.ID] = op
+	}
+
+	pets := ops["listPets"]
+	if len(pets.Extensions) != 2 || pets.Extensions["x-internal-owner"] != "team-pets" {
+		t.Errorf("listPets Extensions = %v, want x-rate-limit and x-internal-owner only", pets.Extensions)
+	}
+	if limit, ok := pets.Extensions["x-rate-limit"].(map[string]any); !ok || limit["per"] != "minute" {
+		t.Errorf("x-rate-limit = %#v, want its object value", pets.Extensions["x-rate-limit"])
+	}
+	if got := pets.Parameters[0].Extensions["x-example-cli"]; got != "--limit 10" {
+		t.Errorf("limit x-example-cli = %v, want %q", got, "--limit 10")
+	}
+	if owners := ops["listOwners"]; owners.Extensions != nil {
+		t.Errorf("listOwners Extensions = %v, want nil", owners.Extensions)
+	}
+}
+
+func TestParse_CanonicalJSONIgnoresReformatting(t *testing.T) {
+	a := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      tags: [pets, read]
+      parameters:
+        - {name: limit, in: query}
+        - {name: q, in: query}
+      responses:
+        "200": {description: OK}
+        "404": {description: Missing}
+`
+	b := `info:
+    version: "1.0"
+    title: Test
+openapi: "3.0.0"
+paths:
+    /pets:
+        get:
+            responses:
+                "404":
+                    description: Missing
+                "200":
+                    description: OK
+            parameters:
+                - in: query
+                  name: q
+                - in: query
+                  name: limit
+            tags:
+                - read
+                - pets
+            operationId: listPets
+`
+	canonical := func(spec string) string {
+		result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+		if err != nil {
+			t.Fatalf("parse error: %v", err)
+		}
+		data, err := result.CanonicalJSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	if ca, cb := canonical(a), canonical(b); ca != cb {
+		t.Errorf("reformatted spec changed the canonical IR:\n%s\n%s", ca, cb)
+	}
+	if canonical(a) == canonical(strings.Replace(a, "Missing", "Not found", 1)) {
+		t.Error("a description change should change the canonical IR")
+	}
+}
+
+func TestParse_ParameterConstraints(t *testing.T) {
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      parameters:
+        - name: sort
+          in: query
+          schema: {type: string, enum: [asc, desc], default: asc}
+        - name: limit
+          in: query
+          schema: {type: integer, minimum: 1, maximum: 100}
+        - name: since
+          in: query
+          schema: {type: string, format: date-time}
+        - name: code
+          in: query
+          schema: {type: string, minLength: 3, maxLength: 3, pattern: "^[A-Z]+$"}
+        - name: status
+          in: query
+          schema: {type: array, items: {type: string, enum: [available, sold]}}
+        - name: q
+          in: query
+          schema: {type: string}
+      responses:
+        "200": {description: OK}
+`
+	result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	params := make(map[string]ir.Parameter)
+	for _, p := range result.Operations[0].Parameters {
+		params[p.Name] = p
+	}
+
+	if sort := params["sort"]; sort.Default != "asc" || sort.Constraints.String() != "one of asc|desc" {
+		t.Errorf("sort = default %q, constraints %q", sort.Default, sort.Constraints)
+	}
+	if got := params["limit"].Constraints.String(); got != "1..100" {
+		t.Errorf("limit constraints = %q, want 1..100", got)
+	}
+	if got := params["since"].Constraints; got == nil || got.Format != "date-time" {
+		t.Errorf("since constraints = %+v, want format date-time", got)
+	}
+	if got := params["code"].Constraints.String(); got != "length 3..3, pattern ^[A-Z]+$" {
+		t.Errorf("code constraints = %q", got)
+	}
+	if got := params["status"].Constraints.String(); got != "one of available|sold" {
+		t.Errorf("status constraints = %q, want the item enum", got)
+	}
+	if got := params["q"].Constraints; got != nil {
+		t.Errorf("q constraints = %+v, want nil", got)
+	}
+}
+
+func TestParse_SecurityRequirements(t *testing.T) {
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+paths:
+  /admin:
+    get:
+      operationId: getAdmin
+      security:
+        - {oauth: [admin], apiKey: []}
+        - {bearer: []}
+      responses:
+        "200": {description: OK}
+  /status:
+    get:
+      operationId: getStatus
+      security:
+        - {apiKey: []}
+        - {}
+      responses:
+        "200": {description: OK}
+`
+	result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	want := map[string]string{
+		"getAdmin":  "apiKey AND oauth OR bearer",
+		"getStatus": "apiKey OR none",
+	}
+	for _, op := range result.Operations {
+		if got := op.AuthText(); got != want[op.ID] {
+			t.Errorf("%s auth = %q (%v), want %q", op.ID, got, op.Auth, want[op.ID])
+		}
+	}
+}
+
+func TestParse_SecurityInheritance(t *testing.T) {
+	spec := `openapi: "3.0.0"
+info: {title: Test, version: "1.0"}
+security:
+  - {apiKey: []}
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200": {description: OK}
+    post:
+      operationId: createPet
+      security:
+        - {bearer: []}
+      responses:
+        "201": {description: Created}
+  /health:
+    get:
+      operationId: health
+      security: []
+      responses:
+        "200": {description: OK}
+`
+	result, err := New().Parse([]byte(spec), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	want := map[string]string{
+		"listPets":  "apiKey", // inherited from the document
+		"createPet": "bearer", // overridden
+		"health":    "none",   // explicitly anonymous
+	}
+	for _, op := range result.Operations {
+		if got := op.AuthText(); got != want[op.ID] {
+			t.Errorf("%s auth = %q (%v), want %q", op.ID, got, op.Auth, want[op.ID])
+		}
+	}
+}
+
+func TestBundle(t *testing.T) {
+	b := NewBundle()
+	source := instructions.SpecSource{Path: "testdata/bundle", Type: "openapi-bundle"}
+	if !b.Detect(source) || b.Detect(instructions.SpecSource{Path: "testdata/bundle"}) {
+		t.Error("Detect should claim only sources typed openapi-bundle")
+	}
+	data, err := b.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("fetch error: %v", err)
+	}
+	result, err := b.Parse(data, source)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	params := make(map[string][]string) // operation ID -> "in:name"
+	for _, op := range result.Operations {
+		params[op.ID] = nil
+		for _, param := range op.Parameters {
+			params[op.ID] = append(params[op.ID], param.In+":"+param.Name)
+		}
+	}
+	want := map[string]string{"listPets": "query:limit", "createPet": "", "showPetById": "path:petId"}
+	if len(params) != len(want) {
+		t.Errorf("got operations %v, want %v", params, want)
+	}
+	for id, w := range want {
+		if got, ok := params[id]; !ok || strings.Join(got, ",") != w {
+			t.Errorf("%s parameters = %v, want %q", id, got, w)
+		}
+	}
+
+	var pet *ir.TypeDef
+	for i := range result.Types {
+		if result.Types[i].Name == "Pet" {
+			pet = &result.Types[i]
+		}
+	}
+	if pet == nil {
+		t.Fatal("Pet type from schemas/pet.yaml not bundled")
+	}
+	fields := make(map[string]string)
+	for _, f := range pet.Fields {
+		fields[f.Name] = f.Description
+	}
+	if _, ok := fields["name"]; !ok {
+		t.Errorf("Pet fields = %v, want name", fields)
+	}
+	if fields["tag"] != "A free-form label" {
+		t.Errorf("Pet.tag description = %q, want the one behind the local ref in pet.yaml", fields["tag"])
+	}
+}
+
+func TestBundle_Errors(t *testing.T) {
+	write := func(t *testing.T, dir, name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fetch := func(dir string) error {
+		_, err := NewBundle().Fetch(context.Background(), instructions.SpecSource{Path: dir, Type: "openapi-bundle"})
+		return err
+	}
+
+	t.Run("missing entrypoint", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "api.yaml", "openapi: 3.0.0\n")
+		if err := fetch(dir); err == nil || !strings.Contains(err.Error(), "no entrypoint") || !strings.Contains(err.Error(), "index.yaml") {
+			t.Errorf("error = %v, want a missing entrypoint error naming the candidates", err)
+		}
+	})
+
+	t.Run("ref escapes the directory", func(t *testing.T) {
+		root := t.TempDir()
+		dir := filepath.Join(root, "api")
+		write(t, root, "outside.yaml", "type: string\n")
+		write(t, dir, "index.yaml", "openapi: 3.0.0\npaths:\n  /x:\n    $ref: ./paths/x.yaml\n")
+		write(t, dir, "paths/x.yaml", "get:\n  responses:\n    '200':\n      content:\n        application/json:\n          schema:\n            $ref: ../../outside.yaml\n")
+		if err := fetch(dir); err == nil || !strings.Contains(err.Error(), "escapes the bundle directory") || !strings.Contains(err.Error(), "paths/x.yaml") {
+			t.Errorf("error = %v, want an escape error naming the referencing file", err)
+		}
+	})
+
+	t.Run("missing ref target", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "openapi.yaml", "openapi: 3.0.0\ncomponents:\n  schemas:\n    Pet:\n      $ref: ./schemas.yaml#/Pet\n")
+		write(t, dir, "schemas.yaml", "Dog: {type: object}\n")
+		if err := fetch(dir); err == nil || !strings.Contains(err.Error(), "schemas.yaml has no /Pet") {
+			t.Errorf("error = %v, want a missing target error", err)
+		}
+	})
+
+	t.Run("recursive refs", func(t *testing.T) {
+		dir := t.TempDir()
+		write(t, dir, "openapi.yaml", "openapi: 3.0.0\ncomponents:\n  schemas:\n    Node:\n      $ref: ./node.yaml\n")
+		write(t, dir, "node.yaml", "type: object\nproperties:\n  next:\n    $ref: ./node.yaml\n")
+		if err := fetch(dir); err != nil {
+			t.Errorf("recursive refs should bundle, got %v", err)
+		}
+	})
+}
+
+func TestParse_HeaderAndCookieParameters(t *testing.T) {
+	p := New()
+	data := readTestdata(t, "header-cookie-params.yaml")
+	result, err := p.Parse(data, instructions.SpecSource{Path: "testdata/header-cookie-params.yaml"})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+
+	params := make(map[string][]string) // operation ID -> "in:name"
+	for _, op := range result.Operations {
+		for _, param := range op.Parameters {
+			params[op.ID] = append(params[op.ID], param.In+":"+param.Name)
+		}
+	}
+	want := map[string]string{
+		"listReports": "query:limit,header:X-Request-ID,header:X-Tenant,cookie:session",
+		"getReport":   "path:reportId,header:X-Tenant,cookie:preview",
+	}
+	for id, w := range want {
+		if got := strings.Join(params[id], ","); got != w {
+			t.Errorf("%s parameters = %s, want %s", id, got, w)
+		}
+	}
+}
+
+func TestParse_RateLimits(t *testing.T) {
+	doc := `openapi: 3.0.0
+info: {title: Limits, version: "1"}
+paths:
+  /search:
+    get:
+      operationId: search
+      x-ratelimit: {limit: 100, period: minute}
+      responses:
+        "200":
+          description: OK
+          headers:
+            X-RateLimit-Remaining: {schema: {type: integer}}
+        "429":
+          description: Too many requests
+          headers:
+            Retry-After: {schema: {type: integer}}
+  /exports:
+    post:
+      operationId: createExport
+      description: Starts an export. Limited to 10 requests per hour per account.
+      responses:
+        "202": {description: Accepted}
+  /throttled:
+    get:
+      operationId: throttled
+      responses:
+        "429": {description: Slow down}
+  /pets:
+    get:
+      operationId: listPets
+      description: Returns up to 100 pets per page.
+      responses:
+        "200": {description: OK}
+`
+	result, err := New().Parse([]byte(doc), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	ops := make(map[string]ir.Operation)
+	for _, op := range result.Operations {
+		ops[op.ID] = op
+	}
+
+	if rl := ops["search"].RateLimit; rl == nil || rl.Limit != "100 requests per minute" || rl.Source != "x-ratelimit" ||
+		!rl.TooManyRequests || !rl.RetryAfter || strings.Join(rl.Headers, ",") != "X-RateLimit-Remaining,Retry-After" {
+		t.Errorf("search rate limit = %+v", rl)
+	}
+	if rl := ops["createExport"].RateLimit; rl == nil || rl.Limit != "10 requests per hour" || rl.Source != "description" || rl.TooManyRequests {
+		t.Errorf("createExport rate limit = %+v", rl)
+	}
+	if rl := ops["throttled"].RateLimit; rl == nil || rl.Limit != "" || !rl.TooManyRequests || rl.RetryAfter {
+		t.Errorf("throttled rate limit = %+v, want a 429 without a limit", rl)
+	}
+	if rl := ops["listPets"].RateLimit; rl != nil {
+		t.Errorf("listPets rate limit = %+v, want none (page sizes are not limits)", rl)
+	}
+}
+
+func TestParse_ResponseHeaders(t *testing.T) {
+	doc := `openapi: 3.0.0
+info: {title: Headers, version: "1"}
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      responses:
+        "201":
+          description: Created
+          headers:
+            Location: {description: URL of the new pet, schema: {type: string, format: uri}}
+            X-Request-Id: {$ref: "#/components/headers/RequestId"}
+        "400": {description: Bad request}
+components:
+  headers:
+    RequestId: {description: Correlation ID, schema: {type: string}}
+`
+	result, err := New().Parse([]byte(doc), instructions.SpecSource{})
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	resps := result.Operations[0].Responses
+	want := []ir.Header{
+		{Name: "Location", Type: "string(uri)", Description: "URL of the new pet"},
+		{Name: "X-Request-Id", Type: "string", Description: "Correlation ID"},
+	}
+	if len(resps) != 2 || !reflect.DeepEqual(resps[0].Headers, want) {
+		t.Errorf("201 headers = %+v, want %+v", resps[0].Headers, want)
+	}
+	if len(resps) == 2 && resps[1].Headers != nil {
+		t.Errorf("400 headers = %+v, want none", resps[1].Headers)
+	}
+}
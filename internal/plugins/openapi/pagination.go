@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// Query parameter names that identify each pagination style, checked in
+// order so a cursor API that also accepts limit is reported as cursor.
+var paginationParams = []struct {
+	style string
+	names []string
+}{
+	{"cursor", []string{"cursor", "after", "before", "page_token", "pagetoken", "next_token", "nexttoken", "continuation", "starting_after", "ending_before"}},
+	{"offset", []string{"offset", "skip", "start"}},
+	{"page", []string{"page", "page_number", "pagenumber"}},
+}
+
+// paginationSizeParams bound page size; they accompany a style but don't
+// define one on their own.
+var paginationSizeParams = []string{"limit", "per_page", "perpage", "page_size", "pagesize", "size", "max_results", "maxresults", "top"}
+
+// detectPagination infers an operation's pagination scheme from its query
+// parameters and response headers, or returns nil if it doesn't page.
+func detectPagination(params []ir.Parameter, responses map[string]openAPIResp) *ir.Pagination {
+	queryNames := make(map[string]string) // normalized → declared name
+	for _, param := range params {
+		if param.In == "query" {
+			queryNames[strings.ToLower(param.Name)] = param.Name
+		}
+	}
+
+	var sizeParams []string
+	for _, name := range paginationSizeParams {
+		if declared, ok := queryNames[name]; ok {
+			sizeParams = append(sizeParams, declared)
+		}
+	}
+
+	for _, candidate := range paginationParams {
+		for _, name := range candidate.names {
+			if declared, ok := queryNames[name]; ok {
+				return &ir.Pagination{Style: candidate.style, Params: append([]string{declared}, sizeParams...)}
+			}
+		}
+	}
+
+	// Link headers (RFC 8288) and X-Next-* style headers carry the next page
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		for header := range responses[code].Headers {
+			lower := strings.ToLower(header)
+			if lower == "link" {
+				return &ir.Pagination{Style: "link", Params: sizeParams}
+			}
+			if strings.Contains(lower, "next") {
+				return &ir.Pagination{Style: "cursor", Params: sizeParams}
+			}
+		}
+	}
+	return nil
+}
+
+// paginationStyles summarizes the pagination styles used across operations,
+// most common first, e.g. "cursor, offset".
+func paginationStyles(ops []ir.Operation) string {
+	counts := make(map[string]int)
+	for _, op := range ops {
+		if op.Pagination != nil {
+			counts[op.Pagination.Style]++
+		}
+	}
+	styles := make([]string, 0, len(counts))
+	for style := range counts {
+		styles = append(styles, style)
+	}
+	sort.Slice(styles, func(i, j int) bool {
+		if counts[styles[i]] != counts[styles[j]] {
+			return counts[styles[i]] > counts[styles[j]]
+		}
+		return styles[i] < styles[j]
+	})
+	return strings.Join(styles, ", ")
+}
@@ -0,0 +1,140 @@
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+// rateLimitInText matches a limit stated in prose, e.g. "100 requests per
+// minute" or "5,000 calls/hour".
+var rateLimitInText = regexp.MustCompile(`(?i)\b\d[\d,]*\s+(?:requests?|calls?|req)\s*(?:per|/|an?|every)\s*(?:second|minute|hour|day|month|sec|min|hr)\b`)
+
+// detectRateLimit collects an operation's explicit rate-limit signals: x-
+// rate-limit extensions, limits stated in its description, a 429 response,
+// and rate-limit response headers. It returns nil when there are none.
+func detectRateLimit(extra map[string]any, description string, responses map[string]openAPIResp) *ir.RateLimit {
+	var rl ir.RateLimit
+
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if isRateLimitName(key) {
+			if limit := formatRateLimit(extra[key]); limit != "" {
+				rl.Limit, rl.Source = limit, key
+				break
+			}
+		}
+	}
+	if rl.Limit == "" {
+		texts := []string{description}
+		if resp, ok := responses["429"]; ok {
+			texts = append(texts, resp.Description)
+		}
+		for _, text := range texts {
+			if match := rateLimitInText.FindString(text); match != "" {
+				rl.Limit, rl.Source = match, "description"
+				break
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		headers := make([]string, 0, len(responses[code].Headers))
+		for header := range responses[code].Headers {
+			headers = append(headers, header)
+		}
+		sort.Strings(headers)
+		for _, header := range headers {
+			if code == "429" && strings.EqualFold(header, "Retry-After") {
+				rl.RetryAfter = true
+			}
+			if (isRateLimitName(header) || strings.EqualFold(header, "Retry-After")) && !seen[strings.ToLower(header)] {
+				seen[strings.ToLower(header)] = true
+				rl.Headers = append(rl.Headers, header)
+			}
+		}
+	}
+	_, rl.TooManyRequests = responses["429"]
+
+	if rl.Limit == "" && !rl.TooManyRequests && len(rl.Headers) == 0 {
+		return nil
+	}
+	return &rl
+}
+
+// isRateLimitName reports whether an extension or header name is about
+// rate limits or quotas, e.g. x-ratelimit, x-rate-limit, X-RateLimit-Remaining.
+func isRateLimitName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, marker := range []string{"ratelimit", "rate-limit", "rate_limit", "quota", "throttl"} {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatRateLimit renders an x-ratelimit value as text. A mapping with a
+// count and a period reads as "<count> requests per <period>"; other
+// mappings list their fields.
+func formatRateLimit(v any) string {
+	switch v := v.(type) {
+	case map[string]any:
+		count := firstValue(v, "limit", "requests", "rate", "max", "count", "quota")
+		period := firstValue(v, "period", "window", "interval", "per", "unit", "duration")
+		if count != "" && period != "" {
+			return count + " requests per " + period
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		fields := make([]string, 0, len(keys))
+		for _, key := range keys {
+			if s := formatRateLimit(v[key]); s != "" {
+				fields = append(fields, key+": "+s)
+			}
+		}
+		return strings.Join(fields, ", ")
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			if s := formatRateLimit(item); s != "" {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, "; ")
+	case nil:
+		return ""
+	case bool:
+		return "" // e.g. x-ratelimit: true says nothing about the limit
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+// firstValue returns the first of keys present in m, as text.
+func firstValue(m map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if v, ok := m[key]; ok {
+			if s := formatRateLimit(v); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+// Package plugins assembles the registry of every built-in spec plugin.
+package plugins
+
+import (
+	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/plugins/asyncapi"
+	"github.com/roberthamel/skill-compiler/internal/plugins/cli"
+	"github.com/roberthamel/skill-compiler/internal/plugins/codebase"
+	"github.com/roberthamel/skill-compiler/internal/plugins/har"
+	"github.com/roberthamel/skill-compiler/internal/plugins/jsonschema"
+	"github.com/roberthamel/skill-compiler/internal/plugins/openapi"
+	"github.com/roberthamel/skill-compiler/internal/plugins/postman"
+)
+
+// NewRegistry returns a registry with every built-in plugin registered.
+func NewRegistry() *ir.Registry {
+	reg := ir.NewRegistry()
+	reg.Register(asyncapi.New())
+	reg.Register(jsonschema.New())
+	reg.Register(postman.New())
+	reg.Register(har.New())
+	reg.Register(openapi.New())
+	reg.Register(openapi.NewBundle())
+	reg.Register(cli.New())
+	reg.Register(codebase.New())
+	return reg
+}
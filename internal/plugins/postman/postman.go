@@ -0,0 +1,532 @@
+package postman
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/fetch"
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/sniff"
+)
+
+// Plugin handles Postman Collection v2.1 exports.
+type Plugin struct{}
+
+func New() *Plugin { return &Plugin{} }
+
+func (p *Plugin) Name() string { return "postman" }
+
+func (p *Plugin) Detect(source instructions.SpecSource) bool {
+	if source.Type == "postman" {
+		return true
+	}
+	if source.Type != "" || source.Path == "" {
+		return false
+	}
+	lower := strings.ToLower(source.Path)
+	if strings.HasSuffix(lower, ".postman_collection.json") {
+		return true
+	}
+	return sniff.File(source.Path) == sniff.Postman
+}
+
+func (p *Plugin) Fetch(ctx context.Context, source instructions.SpecSource) ([]byte, error) {
+	if source.Path != "" {
+		return os.ReadFile(source.Path)
+	}
+	if source.URL != "" {
+		return fetch.URL(ctx, source)
+	}
+	if source.Command != "" {
+		return fetch.Command(ctx, source)
+	}
+	return nil, fmt.Errorf("postman plugin: no path, url, or command in spec source")
+}
+
+type collection struct {
+	Info     collectionInfo `json:"info"`
+	Item     []item         `json:"item"`
+	Auth     *auth          `json:"auth"`
+	Variable []keyValue     `json:"variable"`
+}
+
+type collectionInfo struct {
+	PostmanID   string      `json:"_postman_id"`
+	Name        string      `json:"name"`
+	Description description `json:"description"`
+	Schema      string      `json:"schema"`
+}
+
+// item is either a folder (with nested items) or a request.
+type item struct {
+	Name        string      `json:"name"`
+	Description description `json:"description"`
+	Item        []item      `json:"item"`
+	Request     *request    `json:"request"`
+	Response    []response  `json:"response"`
+	Auth        *auth       `json:"auth"`
+}
+
+type request struct {
+	Method      string      `json:"method"`
+	Header      []keyValue  `json:"header"`
+	URL         requestURL  `json:"url"`
+	Body        *body       `json:"body"`
+	Auth        *auth       `json:"auth"`
+	Description description `json:"description"`
+}
+
+type requestURL struct {
+	Raw      string     `json:"raw"`
+	Path     []string   `json:"path"`
+	Query    []keyValue `json:"query"`
+	Variable []keyValue `json:"variable"`
+}
+
+// UnmarshalJSON accepts both the string and object forms of a request URL.
+func (u *requestURL) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err == nil {
+		u.Raw = raw
+		return nil
+	}
+	type plain requestURL
+	var v plain
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*u = requestURL(v)
+	return nil
+}
+
+type body struct {
+	Mode       string     `json:"mode"` // raw, urlencoded, formdata, file, graphql
+	Raw        string     `json:"raw"`
+	URLEncoded []keyValue `json:"urlencoded"`
+	FormData   []keyValue `json:"formdata"`
+	Options    struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type response struct {
+	Name   string     `json:"name"`
+	Status string     `json:"status"`
+	Code   int        `json:"code"`
+	Header []keyValue `json:"header"`
+	Body   string     `json:"body"`
+}
+
+type keyValue struct {
+	Key         string      `json:"key"`
+	Value       any         `json:"value"`
+	Type        string      `json:"type"`
+	Description description `json:"description"`
+	Disabled    bool        `json:"disabled"`
+}
+
+func (kv keyValue) value() string {
+	if kv.Value == nil {
+		return ""
+	}
+	return fmt.Sprint(kv.Value)
+}
+
+type auth struct {
+	Type   string     `json:"type"`
+	APIKey []keyValue `json:"apikey"`
+}
+
+// description accepts both plain strings and {content, type} objects.
+type description string
+
+func (d *description) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*d = description(s)
+		return nil
+	}
+	var obj struct {
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*d = description(obj.Content)
+	return nil
+}
+
+// parser accumulates IR state while walking the collection tree.
+type parser struct {
+	result *ir.IntermediateRepr
+	ids    map[string]int
+	auth   map[string]bool
+}
+
+func (p *Plugin) Parse(raw []byte, source instructions.SpecSource) (*ir.IntermediateRepr, error) {
+	var col collection
+	if err := json.Unmarshal(raw, &col); err != nil {
+		return nil, fmt.Errorf("parsing Postman collection: %w", err)
+	}
+	if col.Info.Schema != "" && !strings.Contains(col.Info.Schema, "v2.") {
+		return nil, fmt.Errorf("unsupported Postman collection schema %q (only v2.x supported)", col.Info.Schema)
+	}
+
+	ps := &parser{
+		result: &ir.IntermediateRepr{
+			Metadata: map[string]string{
+				"title":       col.Info.Name,
+				"description": string(col.Info.Description),
+				"type":        "postman",
+			},
+		},
+		ids:  make(map[string]int),
+		auth: make(map[string]bool),
+	}
+	for _, v := range col.Variable {
+		switch strings.ToLower(v.Key) {
+		case "baseurl", "base_url", "url", "host":
+			ps.result.Metadata["baseUrl"] = v.value()
+		}
+	}
+
+	ps.walk(col.Item, nil, col.Auth)
+	return ps.result, nil
+}
+
+// walk visits items depth-first; folders become groups named by their path.
+func (ps *parser) walk(items []item, folders []string, inherited *auth) {
+	var groupName string
+	if len(folders) > 0 {
+		groupName = strings.Join(folders, " / ")
+	}
+
+	for _, it := range items {
+		if it.Request == nil {
+			effective := inherited
+			if it.Auth != nil {
+				effective = it.Auth
+			}
+			path := append(append([]string{}, folders...), it.Name)
+			ps.result.Groups = append(ps.result.Groups, ir.Group{
+				Name:        strings.Join(path, " / "),
+				Description: string(it.Description),
+			})
+			ps.walk(it.Item, path, effective)
+			continue
+		}
+
+		effective := inherited
+		if it.Request.Auth != nil {
+			effective = it.Request.Auth
+		} else if it.Auth != nil {
+			effective = it.Auth
+		}
+		op := ps.operation(it, effective)
+		if groupName != "" {
+			op.Tags = []string{groupName}
+			// Look the group up each time: nested folders may grow the slice
+			if group := ps.findGroup(groupName); group != nil {
+				group.Operations = append(group.Operations, op.ID)
+			}
+		}
+		ps.result.Operations = append(ps.result.Operations, op)
+	}
+}
+
+func (ps *parser) findGroup(name string) *ir.Group {
+	for i := range ps.result.Groups {
+		if ps.result.Groups[i].Name == name {
+			return &ps.result.Groups[i]
+		}
+	}
+	return nil
+}
+
+func (ps *parser) operation(it item, effective *auth) ir.Operation {
+	req := it.Request
+	id := ps.uniqueID(operationID(it.Name, req.Method))
+	desc := string(req.Description)
+	if desc == "" {
+		desc = string(it.Description)
+	}
+
+	op := ir.Operation{
+		ID:          id,
+		Name:        it.Name,
+		Description: desc,
+		Method:      strings.ToUpper(req.Method),
+		Path:        requestPath(req.URL),
+	}
+
+	for _, v := range req.URL.Variable {
+		op.Parameters = append(op.Parameters, ir.Parameter{
+			Name:        v.Key,
+			In:          "path",
+			Description: string(v.Description),
+			Required:    true,
+			Type:        "string",
+			Default:     v.value(),
+		})
+	}
+	for _, q := range req.URL.Query {
+		if q.Disabled {
+			continue
+		}
+		op.Parameters = append(op.Parameters, ir.Parameter{
+			Name:        q.Key,
+			In:          "query",
+			Description: string(q.Description),
+			Type:        "string",
+			Default:     q.value(),
+		})
+	}
+	contentType := ""
+	for _, h := range req.Header {
+		if h.Disabled {
+			continue
+		}
+		if strings.EqualFold(h.Key, "Content-Type") {
+			contentType = h.value()
+			continue
+		}
+		op.Parameters = append(op.Parameters, ir.Parameter{
+			Name:        h.Key,
+			In:          "header",
+			Description: string(h.Description),
+			Type:        "string",
+			Default:     h.value(),
+		})
+	}
+
+	if req.Body != nil {
+		op.RequestBody = ps.requestBody(id, req.Body, contentType)
+	}
+
+	for _, r := range it.Response {
+		status := fmt.Sprint(r.Code)
+		if r.Code == 0 {
+			status = "default"
+		}
+		resp := ir.Response{StatusCode: status, Description: r.Name}
+		if resp.Description == "" {
+			resp.Description = r.Status
+		}
+		if r.Body != "" {
+			ct := ""
+			for _, h := range r.Header {
+				if strings.EqualFold(h.Key, "Content-Type") {
+					ct = h.value()
+				}
+			}
+			resp.Body = &ir.TypeRef{
+				ContentType: ct,
+				Examples:    []ir.Example{{Name: r.Name, Summary: r.Status, Value: r.Body}},
+			}
+		}
+		op.Responses = append(op.Responses, resp)
+	}
+
+	if effective != nil && effective.Type != "" && effective.Type != "noauth" {
+		op.Auth = [][]string{{ps.authScheme(effective)}}
+	}
+	return op
+}
+
+// requestBody converts a Postman body into a TypeRef, inferring a type from
+// raw JSON samples so the reference can describe the payload shape.
+func (ps *parser) requestBody(opID string, b *body, contentType string) *ir.TypeRef {
+	ref := &ir.TypeRef{ContentType: contentType}
+	switch b.Mode {
+	case "raw":
+		if ref.ContentType == "" && b.Options.Raw.Language == "json" {
+			ref.ContentType = "application/json"
+		}
+		if strings.TrimSpace(b.Raw) == "" {
+			return ref
+		}
+		ref.Examples = []ir.Example{{Value: b.Raw}}
+		var sample map[string]any
+		if err := json.Unmarshal([]byte(b.Raw), &sample); err == nil {
+			ref.TypeName = opID + "Request"
+			ps.result.Types = append(ps.result.Types, inferType(ref.TypeName, sample))
+			if ref.ContentType == "" {
+				ref.ContentType = "application/json"
+			}
+		}
+	case "urlencoded", "formdata":
+		fields := b.URLEncoded
+		if b.Mode == "formdata" {
+			fields = b.FormData
+			if ref.ContentType == "" {
+				ref.ContentType = "multipart/form-data"
+			}
+		} else if ref.ContentType == "" {
+			ref.ContentType = "application/x-www-form-urlencoded"
+		}
+		ref.TypeName = opID + "Form"
+		td := ir.TypeDef{Name: ref.TypeName}
+		for _, f := range fields {
+			if f.Disabled {
+				continue
+			}
+			typ := "string"
+			if f.Type == "file" {
+				typ = "file"
+			}
+			td.Fields = append(td.Fields, ir.TypeField{Name: f.Key, Type: typ, Description: string(f.Description)})
+		}
+		ps.result.Types = append(ps.result.Types, td)
+	default:
+		ref.Description = b.Mode + " body"
+	}
+	return ref
+}
+
+// authScheme registers a Postman auth block as an AuthScheme and returns its ID.
+func (ps *parser) authScheme(a *auth) string {
+	id := a.Type
+	if ps.auth[id] {
+		return id
+	}
+	ps.auth[id] = true
+
+	scheme := ir.AuthScheme{ID: id, Type: a.Type}
+	switch a.Type {
+	case "bearer", "basic", "digest":
+		scheme.Type = "http"
+		scheme.Scheme = a.Type
+	case "apikey":
+		scheme.Type = "apiKey"
+		scheme.In = "header"
+		for _, kv := range a.APIKey {
+			switch kv.Key {
+			case "key":
+				scheme.Name = kv.value()
+			case "in":
+				scheme.In = kv.value()
+			}
+		}
+	case "oauth2":
+		scheme.Type = "oauth2"
+	}
+	ps.result.Auth = append(ps.result.Auth, scheme)
+	return id
+}
+
+func (ps *parser) uniqueID(id string) string {
+	ps.ids[id]++
+	if n := ps.ids[id]; n > 1 {
+		return fmt.Sprintf("%s_%d", id, n)
+	}
+	return id
+}
+
+func (p *Plugin) Validate(parsed *ir.IntermediateRepr) []ir.Warning {
+	var warnings []ir.Warning
+	for _, op := range parsed.Operations {
+		if op.Description == "" {
+			warnings = append(warnings, ir.Warning{
+				Message: fmt.Sprintf("request %q has no description", op.Name),
+			})
+		}
+		if len(op.Responses) == 0 {
+			warnings = append(warnings, ir.Warning{
+				Message: fmt.Sprintf("request %q has no saved example responses", op.Name),
+			})
+		}
+	}
+	return warnings
+}
+
+// requestPath builds a templated path, mapping :var segments to {var}.
+func requestPath(u requestURL) string {
+	segments := u.Path
+	if len(segments) == 0 && u.Raw != "" {
+		raw := u.Raw
+		if i := strings.IndexAny(raw, "?#"); i >= 0 {
+			raw = raw[:i]
+		}
+		if i := strings.Index(raw, "://"); i >= 0 {
+			raw = raw[i+3:]
+		}
+		parts := strings.Split(raw, "/")
+		if len(parts) > 1 {
+			segments = parts[1:]
+		}
+	}
+	var out []string
+	for _, seg := range segments {
+		if strings.HasPrefix(seg, ":") {
+			seg = "{" + seg[1:] + "}"
+		}
+		if seg != "" {
+			out = append(out, seg)
+		}
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+// operationID derives an identifier like "list_pets" from a request name.
+func operationID(name, method string) string {
+	var b strings.Builder
+	lastUnderscore := true
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			lastUnderscore = false
+		} else if !lastUnderscore {
+			b.WriteByte('_')
+			lastUnderscore = true
+		}
+	}
+	id := strings.Trim(b.String(), "_")
+	if id == "" {
+		id = strings.ToLower(method)
+	}
+	return id
+}
+
+// inferType builds a TypeDef from a sample JSON object.
+func inferType(name string, sample map[string]any) ir.TypeDef {
+	td := ir.TypeDef{Name: name}
+	keys := make([]string, 0, len(sample))
+	for k := range sample {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		td.Fields = append(td.Fields, ir.TypeField{Name: k, Type: jsonType(sample[k])})
+	}
+	return td
+}
+
+func jsonType(v any) string {
+	switch val := v.(type) {
+	case string:
+		return "string"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		if len(val) > 0 {
+			return "[]" + jsonType(val[0])
+		}
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return ""
+	}
+}
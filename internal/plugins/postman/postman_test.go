@@ -0,0 +1,153 @@
+package postman
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+)
+
+const fixture = "petstore.postman_collection.json"
+
+func parseFixture(t *testing.T) *ir.IntermediateRepr {
+	t.Helper()
+	p := New()
+	source := instructions.SpecSource{Path: filepath.Join("testdata", fixture)}
+	raw, err := p.Fetch(context.Background(), source)
+	if err != nil {
+		t.Fatalf("fetch error: %v", err)
+	}
+	result, err := p.Parse(raw, source)
+	if err != nil {
+		t.Fatalf("parse error: %v", err)
+	}
+	return result
+}
+
+func TestDetect(t *testing.T) {
+	p := New()
+	dir := t.TempDir()
+	exported := filepath.Join(dir, "export.json")
+	_ = os.WriteFile(exported, []byte(`{"info": {"_postman_id": "x", "name": "API"}, "item": []}`), 0o644)
+	openapi := filepath.Join(dir, "api.json")
+	_ = os.WriteFile(openapi, []byte(`{"openapi": "3.0.0", "info": {"title": "API"}}`), 0o644)
+
+	tests := []struct {
+		name   string
+		source instructions.SpecSource
+		want   bool
+	}{
+		{"explicit type", instructions.SpecSource{Type: "postman", URL: "http://example.com"}, true},
+		{"collection suffix", instructions.SpecSource{Path: "api.postman_collection.json"}, true},
+		{"_postman_id key", instructions.SpecSource{Path: exported}, true},
+		{"openapi json", instructions.SpecSource{Path: openapi}, false},
+		{"openapi type", instructions.SpecSource{Type: "openapi", Path: exported}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := p.Detect(tt.source)
+			if got != tt.want {
+				t.Errorf("Detect(%+v) = %v, want %v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Operations(t *testing.T) {
+	result := parseFixture(t)
+
+	if len(result.Operations) != 4 {
+		t.Fatalf("got %d operations, want 4", len(result.Operations))
+	}
+	ops := map[string]ir.Operation{}
+	for _, op := range result.Operations {
+		ops[op.ID] = op
+	}
+
+	list := ops["list_pets"]
+	if list.Method != "GET" || list.Path != "/pets" {
+		t.Errorf("list_pets = %s %s, want GET /pets", list.Method, list.Path)
+	}
+	in := map[string]string{}
+	for _, param := range list.Parameters {
+		in[param.Name] = param.In
+	}
+	if in["limit"] != "query" || in["X-Request-ID"] != "header" {
+		t.Errorf("list_pets parameters = %+v", list.Parameters)
+	}
+	if _, ok := in["debug"]; ok {
+		t.Error("disabled query parameter should be skipped")
+	}
+	if len(list.Responses) != 1 || list.Responses[0].StatusCode != "200" {
+		t.Fatalf("list_pets responses = %+v, want one 200", list.Responses)
+	}
+	body := list.Responses[0].Body
+	if body == nil || len(body.Examples) != 1 || body.ContentType != "application/json" {
+		t.Errorf("list_pets 200 body = %+v, want saved JSON example", body)
+	}
+
+	get := ops["get_pet"]
+	if get.Path != "/pets/{petId}" {
+		t.Errorf("get_pet path = %q, want /pets/{petId}", get.Path)
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].In != "path" || !get.Parameters[0].Required {
+		t.Errorf("get_pet parameters = %+v, want required path param", get.Parameters)
+	}
+
+	create := ops["create_pet"]
+	if create.Path != "/pets" {
+		t.Errorf("create_pet path = %q, want /pets (from string URL)", create.Path)
+	}
+	if create.RequestBody == nil || create.RequestBody.TypeName != "create_petRequest" {
+		t.Fatalf("create_pet body = %+v, want inferred type", create.RequestBody)
+	}
+	if create.RequestBody.ContentType != "application/json" {
+		t.Errorf("create_pet content type = %q", create.RequestBody.ContentType)
+	}
+}
+
+func TestParse_GroupsAndAuth(t *testing.T) {
+	result := parseFixture(t)
+
+	if len(result.Groups) != 1 || result.Groups[0].Name != "Pets" {
+		t.Fatalf("groups = %+v, want single Pets group", result.Groups)
+	}
+	if result.Groups[0].Description != "Manage pets" || len(result.Groups[0].Operations) != 3 {
+		t.Errorf("Pets group = %+v", result.Groups[0])
+	}
+
+	schemes := map[string]ir.AuthScheme{}
+	for _, a := range result.Auth {
+		schemes[a.ID] = a
+	}
+	if s := schemes["apikey"]; s.Type != "apiKey" || s.Name != "X-API-Key" || s.In != "header" {
+		t.Errorf("apikey scheme = %+v", s)
+	}
+	if s := schemes["bearer"]; s.Type != "http" || s.Scheme != "bearer" {
+		t.Errorf("bearer scheme = %+v", s)
+	}
+
+	for _, op := range result.Operations {
+		switch op.ID {
+		case "list_pets":
+			if op.AuthText() != "apikey" {
+				t.Errorf("list_pets auth = %v, want inherited apikey", op.Auth)
+			}
+		case "create_pet":
+			if op.AuthText() != "bearer" {
+				t.Errorf("create_pet auth = %v, want request-level bearer", op.Auth)
+			}
+		case "health":
+			if len(op.Auth) != 0 {
+				t.Errorf("health auth = %v, want none", op.Auth)
+			}
+		}
+	}
+
+	if result.Metadata["baseUrl"] != "https://api.petstore.example.com" {
+		t.Errorf("baseUrl = %q", result.Metadata["baseUrl"])
+	}
+}
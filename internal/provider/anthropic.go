@@ -5,25 +5,36 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/redact"
 )
 
+// defaultAnthropicVersion is the anthropic-version header sent unless
+// configured otherwise.
+const defaultAnthropicVersion = "2023-06-01"
+
 // Anthropic implements the Provider interface using the Anthropic Messages API.
 type Anthropic struct {
 	apiKey  string
 	model   string
 	baseURL string
+	pacer   pacer
+	// version and beta are the anthropic-version and anthropic-beta
+	// headers; an empty version means defaultAnthropicVersion.
+	version string
+	beta    []string
 }
 
 func (a *Anthropic) Name() string { return "anthropic" }
 
 type anthropicRequest struct {
-	Model     string             `json:"model"`
-	MaxTokens int                `json:"max_tokens"`
-	System    string             `json:"system,omitempty"`
-	Messages  []anthropicMessage `json:"messages"`
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	System        string             `json:"system,omitempty"`
+	Messages      []anthropicMessage `json:"messages"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
 }
 
 type anthropicMessage struct {
@@ -36,8 +47,9 @@ type anthropicResponse struct {
 		Type string `json:"type"`
 		Text string `json:"text"`
 	} `json:"content"`
-	Model string `json:"model"`
-	Usage struct {
+	Model      string `json:"model"`
+	StopReason string `json:"stop_reason"`
+	Usage      *struct {
 		InputTokens  int `json:"input_tokens"`
 		OutputTokens int `json:"output_tokens"`
 	} `json:"usage"`
@@ -47,7 +59,10 @@ type anthropicResponse struct {
 	} `json:"error"`
 }
 
-func (a *Anthropic) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+func (a *Anthropic) Generate(ctx context.Context, req GenerateRequest) (_ *GenerateResponse, err error) {
+	// Error bodies can echo request parameters, and base URLs can carry tokens
+	defer func() { err = redact.Error(err, a.apiKey) }()
+
 	model := req.Model
 	if model == "" {
 		model = a.model
@@ -64,6 +79,7 @@ func (a *Anthropic) Generate(ctx context.Context, req GenerateRequest) (*Generat
 		Messages: []anthropicMessage{
 			{Role: "user", Content: req.UserMessage},
 		},
+		StopSequences: req.StopSequences,
 	}
 
 	data, err := json.Marshal(body)
@@ -72,27 +88,23 @@ func (a *Anthropic) Generate(ctx context.Context, req GenerateRequest) (*Generat
 	}
 
 	url := strings.TrimRight(a.baseURL, "/") + "/v1/messages"
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
-	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("x-api-key", a.apiKey)
-	httpReq.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	respData, err := io.ReadAll(resp.Body)
+	status, respData, header, rateLimit, err := a.pacer.do(ctx, func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		for name, value := range a.headers() {
+			httpReq.Header.Set(name, value)
+		}
+		return httpReq, nil
+	}, parseAnthropicRateLimit)
 	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("anthropic API error (HTTP %d): %s", resp.StatusCode, string(respData))
+	if status != http.StatusOK {
+		return nil, &StatusError{Provider: "anthropic", StatusCode: status, Body: string(respData)}
 	}
 
 	var apiResp anthropicResponse
@@ -111,10 +123,43 @@ func (a *Anthropic) Generate(ctx context.Context, req GenerateRequest) (*Generat
 		}
 	}
 
-	return &GenerateResponse{
-		Content:   content,
-		Model:     apiResp.Model,
-		TokensIn:  apiResp.Usage.InputTokens,
-		TokensOut: apiResp.Usage.OutputTokens,
-	}, nil
+	resp := &GenerateResponse{
+		Content:      content,
+		Model:        apiResp.Model,
+		RateLimit:    rateLimit,
+		RequestID:    header.Get("request-id"),
+		FinishReason: anthropicFinishReason(apiResp.StopReason),
+	}
+	if apiResp.Usage != nil {
+		resp.TokensIn, resp.TokensOut = apiResp.Usage.InputTokens, apiResp.Usage.OutputTokens
+	}
+	resp.Warnings = responseWarnings(resp, apiResp.Usage != nil, apiResp.Content != nil, "content")
+	return resp, nil
+}
+
+// headers returns the auth and version headers every request carries.
+func (a *Anthropic) headers() map[string]string {
+	version := a.version
+	if version == "" {
+		version = defaultAnthropicVersion
+	}
+	headers := map[string]string{"x-api-key": a.apiKey, "anthropic-version": version}
+	if len(a.beta) > 0 {
+		headers["anthropic-beta"] = strings.Join(a.beta, ",")
+	}
+	return headers
+}
+
+// anthropicFinishReason normalizes a stop_reason.
+func anthropicFinishReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return FinishStop
+	case "max_tokens":
+		return FinishLength
+	case "refusal":
+		return FinishContentFilter
+	default:
+		return reason
+	}
 }
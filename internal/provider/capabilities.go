@@ -0,0 +1,69 @@
+package provider
+
+// Capabilities are the request features a provider's model supports. The
+// pipeline checks them before using a feature, rather than sending a request
+// the API would reject.
+type Capabilities struct {
+	// SystemPrompt is false for models without a separate system role,
+	// such as OpenAI's o1-mini; the system prompt is then sent at the top of
+	// the user message.
+	SystemPrompt bool
+	// JSONMode is set when the model can be constrained to answer with a
+	// JSON object.
+	JSONMode bool
+}
+
+// CapabilityReporter is implemented by providers that know their model's
+// capabilities.
+type CapabilityReporter interface {
+	Capabilities() Capabilities
+}
+
+// DefaultCapabilities are assumed for providers that do not report any:
+// a system prompt, and nothing optional.
+var DefaultCapabilities = Capabilities{SystemPrompt: true}
+
+// CapabilitiesOf returns p's capabilities, or DefaultCapabilities when p
+// does not report them.
+func CapabilitiesOf(p Provider) Capabilities {
+	if r, ok := p.(CapabilityReporter); ok {
+		return r.Capabilities()
+	}
+	return DefaultCapabilities
+}
+
+// Adapt rewrites req to use only supported features: without a system role
+// the system prompt is folded into the user message, and without JSON mode
+// the JSON flag is dropped (the prompt still asks for JSON).
+func (c Capabilities) Adapt(req GenerateRequest) GenerateRequest {
+	if !c.SystemPrompt && req.SystemPrompt != "" {
+		req.UserMessage = req.SystemPrompt + "\n\n---\n\n" + req.UserMessage
+		req.SystemPrompt = ""
+	}
+	if !c.JSONMode {
+		req.JSON = false
+	}
+	return req
+}
+
+func (a *Anthropic) Capabilities() Capabilities {
+	return DefaultCapabilities
+}
+
+func (o *OpenAI) Capabilities() Capabilities {
+	caps := openAICapabilities(o.model)
+	// Compatible servers behind a custom base URL rarely implement
+	// response_format
+	caps.JSONMode = caps.JSONMode && o.baseURL == openAIBaseURL
+	return caps
+}
+
+// openAICapabilities are an OpenAI model's capabilities on the OpenAI API.
+// Reasoning models take the system prompt as a developer message, except
+// o1-mini and o1-preview, which have neither that nor JSON mode.
+func openAICapabilities(model string) Capabilities {
+	if legacyReasoningModel(model) {
+		return Capabilities{}
+	}
+	return Capabilities{SystemPrompt: true, JSONMode: true}
+}
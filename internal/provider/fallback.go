@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Fallback sends requests to the first of its providers that is still
+// available. A provider that stays rate limited past its retries, fails
+// with a server error, or cannot be reached is given up on for the rest of
+// the build, and the request goes to the next one. Other errors, such as a
+// rejected request, are returned as they are.
+type Fallback struct {
+	Providers []Provider
+	// OnSwitch, when set, is called when the provider at index from is
+	// given up on in favor of the one at index to.
+	OnSwitch func(from, to int, err error)
+
+	mu      sync.Mutex
+	current int
+}
+
+// Name is the name of the provider currently in use.
+func (f *Fallback) Name() string {
+	return f.Providers[f.active()].Name()
+}
+
+// Capabilities claims every feature, as Generate adapts each request to the
+// provider it is sent to.
+func (f *Fallback) Capabilities() Capabilities {
+	return Capabilities{SystemPrompt: true, JSONMode: true}
+}
+
+func (f *Fallback) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+	for {
+		i := f.active()
+		p := f.Providers[i]
+		resp, err := p.Generate(ctx, CapabilitiesOf(p).Adapt(req))
+		if err == nil {
+			if resp.Provider == "" {
+				resp.Provider = p.Name()
+			}
+			return resp, nil
+		}
+		if ctx.Err() != nil || !Unavailable(err) || i == len(f.Providers)-1 {
+			return nil, err
+		}
+		f.giveUp(i, err)
+	}
+}
+
+// active returns the index of the provider in use.
+func (f *Fallback) active() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.current
+}
+
+// giveUp moves on from the provider at index i, unless a concurrent
+// request already has.
+func (f *Fallback) giveUp(i int, err error) {
+	f.mu.Lock()
+	switched := f.current == i
+	if switched {
+		f.current = i + 1
+	}
+	f.mu.Unlock()
+	if switched && f.OnSwitch != nil {
+		f.OnSwitch(i, i+1, err)
+	}
+}
+
+// Unavailable reports whether err means the provider cannot serve requests
+// for now: it is rate limited past its retries, failing with a server
+// error, or unreachable.
+func Unavailable(err error) bool {
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.StatusCode == http.StatusTooManyRequests || status.StatusCode >= 500
+	}
+	var netErr *url.Error
+	return errors.As(err, &netErr)
+}
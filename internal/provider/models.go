@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/redact"
+)
+
+// ModelLister is implemented by providers that can list the models
+// available to the configured API key.
+type ModelLister interface {
+	Models(ctx context.Context) ([]string, error)
+}
+
+// KnownModels returns a static list of common models for a provider name,
+// for when the provider cannot list its own.
+func KnownModels(name string) []string {
+	switch strings.ToLower(name) {
+	case "anthropic":
+		return []string{"claude-opus-4-1", "claude-sonnet-4-6", "claude-sonnet-4-5", "claude-haiku-4-5"}
+	case "openai":
+		return []string{"gpt-4o", "gpt-4o-mini", "gpt-4.1", "gpt-4.1-mini", "o3", "o4-mini"}
+	default:
+		return nil
+	}
+}
+
+// Models lists the models from the Anthropic models API, following pages.
+func (a *Anthropic) Models(ctx context.Context) (_ []string, err error) {
+	defer func() { err = redact.Error(err, a.apiKey) }()
+
+	var ids []string
+	afterID := ""
+	for {
+		endpoint := strings.TrimRight(a.baseURL, "/") + "/v1/models?limit=1000"
+		if afterID != "" {
+			endpoint += "&after_id=" + url.QueryEscape(afterID)
+		}
+		var page struct {
+			Data []struct {
+				ID string `json:"id"`
+			} `json:"data"`
+			HasMore bool   `json:"has_more"`
+			LastID  string `json:"last_id"`
+		}
+		err := getJSON(ctx, endpoint, a.headers(), &page)
+		if err != nil {
+			return nil, fmt.Errorf("anthropic models API: %w", err)
+		}
+		for _, m := range page.Data {
+			ids = append(ids, m.ID)
+		}
+		if !page.HasMore || page.LastID == "" {
+			return ids, nil
+		}
+		afterID = page.LastID
+	}
+}
+
+// Models lists the models from the OpenAI-compatible /v1/models endpoint.
+func (o *OpenAI) Models(ctx context.Context) (_ []string, err error) {
+	defer func() { err = redact.Error(err, o.apiKey) }()
+
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	endpoint := strings.TrimRight(o.baseURL, "/") + "/v1/models"
+	if err := getJSON(ctx, endpoint, map[string]string{"Authorization": "Bearer " + o.apiKey}, &list); err != nil {
+		return nil, fmt.Errorf("openai models API: %w", err)
+	}
+	ids := make([]string, 0, len(list.Data))
+	for _, m := range list.Data {
+		ids = append(ids, m.ID)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// getJSON fetches endpoint with the given headers and decodes a 200 response.
+func getJSON(ctx context.Context, endpoint string, headers map[string]string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	for k, val := range headers {
+		req.Header.Set(k, val)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(data))
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	return nil
+}
@@ -5,16 +5,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
+
+	"github.com/roberthamel/skill-compiler/internal/redact"
 )
 
+// openAIBaseURL is the OpenAI API, used when no base URL is configured.
+const openAIBaseURL = "https://api.openai.com"
+
+// reasoningTokenReserve is added to a reasoning model's output limit for
+// the reasoning tokens it spends before answering, as OpenAI recommends.
+const reasoningTokenReserve = 25000
+
+// maxOpenAIStopSequences is the most stop sequences the API accepts.
+const maxOpenAIStopSequences = 4
+
 // OpenAI implements the Provider interface using the OpenAI Chat Completions API.
 type OpenAI struct {
 	apiKey  string
 	model   string
 	baseURL string
+	pacer   pacer
 }
 
 func (o *OpenAI) Name() string { return "openai" }
@@ -23,6 +35,12 @@ type openaiRequest struct {
 	Model               string          `json:"model"`
 	Messages            []openaiMessage `json:"messages"`
 	MaxCompletionTokens int             `json:"max_completion_tokens,omitempty"`
+	ResponseFormat      *openaiFormat   `json:"response_format,omitempty"`
+	Stop                []string        `json:"stop,omitempty"`
+}
+
+type openaiFormat struct {
+	Type string `json:"type"`
 }
 
 type openaiMessage struct {
@@ -35,9 +53,10 @@ type openaiResponse struct {
 		Message struct {
 			Content string `json:"content"`
 		} `json:"message"`
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 	Model string `json:"model"`
-	Usage struct {
+	Usage *struct {
 		PromptTokens     int `json:"prompt_tokens"`
 		CompletionTokens int `json:"completion_tokens"`
 	} `json:"usage"`
@@ -47,17 +66,29 @@ type openaiResponse struct {
 	} `json:"error"`
 }
 
-func (o *OpenAI) Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error) {
+func (o *OpenAI) Generate(ctx context.Context, req GenerateRequest) (_ *GenerateResponse, err error) {
+	// Error bodies can echo request parameters, and base URLs can carry tokens
+	defer func() { err = redact.Error(err, o.apiKey) }()
+
 	model := req.Model
 	if model == "" {
 		model = o.model
 	}
 
+	// Reasoning models reject the system role: newer ones take a developer
+	// message instead, and o1-mini and o1-preview only user messages
 	messages := []openaiMessage{}
-	if req.SystemPrompt != "" {
+	userMessage := req.UserMessage
+	switch {
+	case req.SystemPrompt == "":
+	case legacyReasoningModel(model):
+		userMessage = req.SystemPrompt + "\n\n---\n\n" + userMessage
+	case reasoningModel(model):
+		messages = append(messages, openaiMessage{Role: "developer", Content: req.SystemPrompt})
+	default:
 		messages = append(messages, openaiMessage{Role: "system", Content: req.SystemPrompt})
 	}
-	messages = append(messages, openaiMessage{Role: "user", Content: req.UserMessage})
+	messages = append(messages, openaiMessage{Role: "user", Content: userMessage})
 
 	body := openaiRequest{
 		Model:    model,
@@ -65,6 +96,17 @@ func (o *OpenAI) Generate(ctx context.Context, req GenerateRequest) (*GenerateRe
 	}
 	if req.MaxTokens > 0 {
 		body.MaxCompletionTokens = req.MaxTokens
+		if reasoningModel(model) {
+			// The limit covers hidden reasoning tokens as well as the output
+			body.MaxCompletionTokens += reasoningTokenReserve
+		}
+	}
+	if req.JSON {
+		body.ResponseFormat = &openaiFormat{Type: "json_object"}
+	}
+	// Reasoning models reject the stop parameter
+	if !reasoningModel(model) {
+		body.Stop = req.StopSequences[:min(len(req.StopSequences), maxOpenAIStopSequences)]
 	}
 
 	data, err := json.Marshal(body)
@@ -73,26 +115,21 @@ func (o *OpenAI) Generate(ctx context.Context, req GenerateRequest) (*GenerateRe
 	}
 
 	url := strings.TrimRight(o.baseURL, "/") + "/v1/chat/completions"
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	status, respData, header, rateLimit, err := o.pacer.do(ctx, func() (*http.Request, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", "application/json")
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+		return httpReq, nil
+	}, parseOpenAIRateLimit)
 	if err != nil {
-		return nil, fmt.Errorf("creating request: %w", err)
+		return nil, err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
 
-	resp, err := http.DefaultClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("sending request: %w", err)
-	}
-	defer func() { _ = resp.Body.Close() }()
-
-	respData, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("reading response: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("openai API error (HTTP %d): %s", resp.StatusCode, string(respData))
+	if status != http.StatusOK {
+		return nil, &StatusError{Provider: "openai", StatusCode: status, Body: string(respData)}
 	}
 
 	var apiResp openaiResponse
@@ -104,15 +141,36 @@ func (o *OpenAI) Generate(ctx context.Context, req GenerateRequest) (*GenerateRe
 		return nil, fmt.Errorf("openai API error: %s: %s", apiResp.Error.Type, apiResp.Error.Message)
 	}
 
-	content := ""
+	content, finishReason := "", ""
 	if len(apiResp.Choices) > 0 {
 		content = apiResp.Choices[0].Message.Content
+		finishReason = apiResp.Choices[0].FinishReason
+	}
+
+	resp := &GenerateResponse{
+		Content:      content,
+		Model:        apiResp.Model,
+		RateLimit:    rateLimit,
+		RequestID:    header.Get("x-request-id"),
+		FinishReason: finishReason,
+	}
+	if apiResp.Usage != nil {
+		resp.TokensIn, resp.TokensOut = apiResp.Usage.PromptTokens, apiResp.Usage.CompletionTokens
 	}
+	resp.Warnings = responseWarnings(resp, apiResp.Usage != nil, len(apiResp.Choices) > 0, "choices")
+	return resp, nil
+}
+
+// reasoningModel reports whether model is an OpenAI o-series reasoning
+// model (o1, o3, o4-mini, ...). They take no temperature or system role.
+func reasoningModel(model string) bool {
+	model = strings.ToLower(model)
+	return len(model) > 1 && model[0] == 'o' && model[1] >= '0' && model[1] <= '9'
+}
 
-	return &GenerateResponse{
-		Content:   content,
-		Model:     apiResp.Model,
-		TokensIn:  apiResp.Usage.PromptTokens,
-		TokensOut: apiResp.Usage.CompletionTokens,
-	}, nil
+// legacyReasoningModel reports whether model is o1-mini or o1-preview,
+// which accept only user and assistant messages.
+func legacyReasoningModel(model string) bool {
+	model = strings.ToLower(model)
+	return strings.HasPrefix(model, "o1-mini") || strings.HasPrefix(model, "o1-preview")
 }
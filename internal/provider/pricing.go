@@ -0,0 +1,49 @@
+package provider
+
+import "strings"
+
+// Price is a model's list price in US dollars per million tokens.
+type Price struct {
+	Input  float64
+	Output float64
+}
+
+// Cost is the price of a request with the given token counts.
+func (p Price) Cost(tokensIn, tokensOut int) float64 {
+	return (float64(tokensIn)*p.Input + float64(tokensOut)*p.Output) / 1e6
+}
+
+// prices are list prices keyed by model name prefix; the longest matching
+// prefix wins, so dated snapshots share their family's price.
+var prices = map[string]Price{
+	"claude-opus-4-5":   {5, 25},
+	"claude-opus-4":     {15, 75},
+	"claude-sonnet-4":   {3, 15},
+	"claude-haiku-4-5":  {1, 5},
+	"claude-3-7-sonnet": {3, 15},
+	"claude-3-5-haiku":  {0.8, 4},
+	"gpt-4o":            {2.5, 10},
+	"gpt-4o-mini":       {0.15, 0.6},
+	"gpt-4.1":           {2, 8},
+	"gpt-4.1-mini":      {0.4, 1.6},
+	"gpt-4.1-nano":      {0.1, 0.4},
+	"o3":                {2, 8},
+	"o3-mini":           {1.1, 4.4},
+	"o4-mini":           {1.1, 4.4},
+}
+
+// PriceFor returns the list price of a model, or false when it is unknown,
+// such as a model behind a custom base URL. Prices change; treat costs
+// derived from them as estimates.
+func PriceFor(model string) (Price, bool) {
+	best := ""
+	for prefix := range prices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return Price{}, false
+	}
+	return prices[best], true
+}
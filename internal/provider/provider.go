@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/roberthamel/skill-compiler/internal/config"
@@ -14,6 +15,13 @@ type GenerateRequest struct {
 	UserMessage  string
 	MaxTokens    int
 	Model        string
+	// JSON asks for a JSON object response. Set it only when the provider's
+	// Capabilities include JSONMode; see Capabilities.Adapt.
+	JSON bool
+	// StopSequences end generation when the model writes one of them; the
+	// response does not include it. OpenAI takes at most four and ignores
+	// them for reasoning models.
+	StopSequences []string
 }
 
 // GenerateResponse is the output from an LLM generation call.
@@ -22,45 +30,181 @@ type GenerateResponse struct {
 	Model     string
 	TokensIn  int
 	TokensOut int
+	RateLimit *RateLimit // remaining budget, when the provider reports one
+	// RequestID is the provider's ID for the request, for matching it in
+	// the provider's dashboards and logs.
+	RequestID string
+	// FinishReason is why generation stopped: FinishStop, FinishLength,
+	// FinishContentFilter, or the provider's own reason for anything else.
+	FinishReason string
+	// Warnings describe fields the response lacked or left empty, such as
+	// usage, so a change in the provider's response format is noticed
+	// rather than read as zero tokens or empty output.
+	Warnings []string
+	// Provider names the provider that answered, when it is not simply the
+	// one called: Fallback sets it, as its answers may come from any of its
+	// providers.
+	Provider string
 }
 
+// responseWarnings checks a decoded response for the fields sc relies on.
+// JSON decoding leaves missing fields zero, so a renamed field would
+// otherwise pass for an empty response. hasUsage and hasContent report
+// whether the usage and content fields were present; contentField names
+// the latter.
+func responseWarnings(resp *GenerateResponse, hasUsage, hasContent bool, contentField string) []string {
+	var warnings []string
+	switch {
+	case !hasUsage:
+		warnings = append(warnings, "response has no usage field; token counts are unknown")
+	case resp.TokensIn == 0 || (resp.TokensOut == 0 && resp.Content != ""):
+		warnings = append(warnings, fmt.Sprintf("response usage reports %d input and %d output tokens", resp.TokensIn, resp.TokensOut))
+	}
+	switch {
+	case !hasContent:
+		warnings = append(warnings, "response has no "+contentField+" field")
+	case resp.Content == "" && resp.FinishReason != FinishContentFilter:
+		warnings = append(warnings, "response content is empty")
+	}
+	return warnings
+}
+
+// StatusError is a provider API's non-OK HTTP response.
+type StatusError struct {
+	Provider   string
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s API error (HTTP %d): %s", e.Provider, e.StatusCode, e.Body)
+}
+
+// Finish reasons, normalized across providers.
+const (
+	FinishStop          = "stop"           // the model finished
+	FinishLength        = "length"         // the output token limit cut it off
+	FinishContentFilter = "content_filter" // the provider withheld the output
+)
+
 // Provider is the interface for LLM providers.
 type Provider interface {
 	Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error)
 	Name() string
 }
 
-// New creates a provider from resolved config.
+// DefaultModels maps provider names to the model used when none is
+// configured. The default-models table in sc config adds to and overrides it.
+var DefaultModels = map[string]string{
+	"anthropic": "claude-sonnet-4-6",
+	"openai":    "gpt-4o",
+}
+
+// ModelFor returns the model a provider created from resolved uses: the
+// configured model, else the default-models entry for the provider name,
+// else the built-in default. Custom endpoints have no built-in default.
+func ModelFor(resolved *config.Resolved) (string, error) {
+	if resolved.Model != "" {
+		return resolved.Model, nil
+	}
+	name := strings.ToLower(resolved.Provider)
+	if name == "" && resolved.BaseURL == "" {
+		name = "anthropic"
+	}
+	if model := resolved.DefaultModels[name]; model != "" {
+		return model, nil
+	}
+	if model := DefaultModels[name]; model != "" {
+		return model, nil
+	}
+	if name == "" {
+		return "", fmt.Errorf("no model set for custom endpoint %s: set one with --model, provider.model in the frontmatter, or `sc config set model <model>`", resolved.BaseURL)
+	}
+	return "", fmt.Errorf("no default model for provider %q: set one with --model, provider.model in the frontmatter, or `sc config set default-models.%s <model>`", name, name)
+}
+
+// New creates a provider from resolved config. Its model is never empty:
+// see ModelFor. With SC_PROVIDER_RECORD set the provider is wrapped in a
+// recording RecordingProvider; with SC_PROVIDER_REPLAY it is replaced by a
+// replaying one, which needs no API key. With fallback providers configured
+// it is a Fallback trying the resolved provider first.
 func New(resolved *config.Resolved) (Provider, error) {
+	p, err := newOne(resolved)
+	if err != nil || len(resolved.Fallbacks) == 0 {
+		return p, err
+	}
+	f := &Fallback{Providers: []Provider{p}}
+	for i, fallback := range resolved.Fallbacks {
+		fp, err := newOne(fallback)
+		if err != nil {
+			return nil, fmt.Errorf("fallback-providers[%d]: %w", i, err)
+		}
+		f.Providers = append(f.Providers, fp)
+	}
+	return f, nil
+}
+
+// newOne creates the resolved provider, ignoring its fallbacks.
+func newOne(resolved *config.Resolved) (Provider, error) {
+	model, modelErr := ModelFor(resolved)
+	recordDir, replayDir := os.Getenv(EnvRecord), os.Getenv(EnvReplay)
+	if recordDir != "" && replayDir != "" {
+		return nil, fmt.Errorf("%s and %s cannot both be set", EnvRecord, EnvReplay)
+	}
+	if replayDir != "" {
+		if modelErr != nil {
+			return nil, modelErr
+		}
+		name := strings.ToLower(resolved.Provider)
+		if name == "" {
+			name = "anthropic"
+		}
+		return &RecordingProvider{Dir: replayDir, Replay: true, Model: model, ProviderName: name}, nil
+	}
+
+	p, err := newProvider(resolved, model)
+	if err != nil {
+		return nil, err
+	}
+	if modelErr != nil {
+		return nil, modelErr
+	}
+	if recordDir != "" {
+		return &RecordingProvider{Provider: p, Dir: recordDir, Model: model}, nil
+	}
+	return p, nil
+}
+
+// NewLister creates a provider for listing models. Unlike New it needs no
+// model, so custom endpoints can be queried before one is chosen.
+func NewLister(resolved *config.Resolved) (Provider, error) {
+	model, _ := ModelFor(resolved)
+	return newProvider(resolved, model)
+}
+
+func newProvider(resolved *config.Resolved, model string) (Provider, error) {
 	name := strings.ToLower(resolved.Provider)
 	baseURL := resolved.BaseURL
 	apiKey := resolved.APIKey
-	model := resolved.Model
 
 	switch {
 	case name == "anthropic" || (name == "" && baseURL == ""):
 		if apiKey == "" {
 			return nil, fmt.Errorf("API key required: set SC_API_KEY, ANTHROPIC_API_KEY, or run `sc config set api-key <key>`")
 		}
-		if model == "" {
-			model = "claude-sonnet-4-6"
-		}
 		url := baseURL
 		if url == "" {
 			url = "https://api.anthropic.com"
 		}
-		return &Anthropic{apiKey: apiKey, model: model, baseURL: url}, nil
+		return &Anthropic{apiKey: apiKey, model: model, baseURL: url, version: resolved.AnthropicVersion, beta: resolved.AnthropicBeta}, nil
 
 	case name == "openai":
 		if apiKey == "" {
 			return nil, fmt.Errorf("API key required: set SC_API_KEY, OPENAI_API_KEY, or run `sc config set api-key <key>`")
 		}
-		if model == "" {
-			model = "gpt-4o"
-		}
 		url := baseURL
 		if url == "" {
-			url = "https://api.openai.com"
+			url = openAIBaseURL
 		}
 		return &OpenAI{apiKey: apiKey, model: model, baseURL: url}, nil
 
@@ -70,15 +214,9 @@ func New(resolved *config.Resolved) (Provider, error) {
 			return nil, fmt.Errorf("API key required for custom provider")
 		}
 		if strings.Contains(name, "anthropic") {
-			if model == "" {
-				model = "claude-sonnet-4-6"
-			}
-			return &Anthropic{apiKey: apiKey, model: model, baseURL: baseURL}, nil
+			return &Anthropic{apiKey: apiKey, model: model, baseURL: baseURL, version: resolved.AnthropicVersion, beta: resolved.AnthropicBeta}, nil
 		}
 		// Default to OpenAI protocol for custom endpoints
-		if model == "" {
-			model = "gpt-4o"
-		}
 		return &OpenAI{apiKey: apiKey, model: model, baseURL: baseURL}, nil
 
 	default:
@@ -3,10 +3,15 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/roberthamel/skill-compiler/internal/config"
 )
@@ -60,6 +65,97 @@ func TestNew_DefaultAnthropic(t *testing.T) {
 	}
 }
 
+func TestRecordingProvider_RecordThenReplay(t *testing.T) {
+	dir := t.TempDir()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"recorded"}],"model":"claude-sonnet-4-6","usage":{"input_tokens":3,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+	resolved := &config.Resolved{Provider: "anthropic", APIKey: "test-key", BaseURL: server.URL}
+	req := GenerateRequest{SystemPrompt: "sys", UserMessage: "hello"}
+
+	t.Setenv(EnvRecord, dir)
+	p, err := New(resolved)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := p.Generate(context.Background(), req); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 || calls != 1 {
+		t.Fatalf("recorded %d fixtures with %d calls, want 1 and 1", len(entries), calls)
+	}
+
+	// Replay needs neither the server nor an API key
+	t.Setenv(EnvRecord, "")
+	t.Setenv(EnvReplay, dir)
+	p, err = New(&config.Resolved{Provider: "anthropic"})
+	if err != nil {
+		t.Fatalf("New in replay mode: %v", err)
+	}
+	resp, err := p.Generate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if resp.Content != "recorded" || resp.TokensIn != 3 || calls != 1 {
+		t.Errorf("replayed %+v after %d calls, want the recorded response without a call", resp, calls)
+	}
+
+	req.UserMessage = "a different prompt"
+	if _, err := p.Generate(context.Background(), req); err == nil || !strings.Contains(err.Error(), "no fixture") {
+		t.Errorf("error = %v, want a missing fixture", err)
+	}
+
+	t.Setenv(EnvRecord, dir)
+	if _, err := New(resolved); err == nil {
+		t.Error("expected an error with both record and replay set")
+	}
+}
+
+func TestModelFor(t *testing.T) {
+	tests := []struct {
+		resolved config.Resolved
+		want     string
+		wantErr  string
+	}{
+		{config.Resolved{}, "claude-sonnet-4-6", ""},
+		{config.Resolved{Provider: "OpenAI"}, "gpt-4o", ""},
+		{config.Resolved{Provider: "openai", Model: "o3"}, "o3", ""},
+		{config.Resolved{Provider: "openai", DefaultModels: map[string]string{"openai": "gpt-4.1"}}, "gpt-4.1", ""},
+		{config.Resolved{Provider: "ollama", BaseURL: "http://localhost:11434", DefaultModels: map[string]string{"ollama": "llama3"}}, "llama3", ""},
+		{config.Resolved{Provider: "ollama", BaseURL: "http://localhost:11434"}, "", `no default model for provider "ollama"`},
+		{config.Resolved{BaseURL: "http://localhost:11434"}, "", "no model set for custom endpoint"},
+	}
+	for _, tt := range tests {
+		got, err := ModelFor(&tt.resolved)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ModelFor(%+v) error = %v, want %q", tt.resolved, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ModelFor(%+v) = %q, %v; want %q", tt.resolved, got, err, tt.want)
+		}
+	}
+
+	p, err := New(&config.Resolved{Provider: "openai", APIKey: "test-key"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.(*OpenAI).model != "gpt-4o" {
+		t.Errorf("model = %q, want the openai default", p.(*OpenAI).model)
+	}
+	if _, err := New(&config.Resolved{Provider: "ollama", BaseURL: "http://localhost:11434", APIKey: "k"}); err == nil {
+		t.Error("expected error for custom endpoint without a model")
+	}
+	if _, err := NewLister(&config.Resolved{Provider: "ollama", BaseURL: "http://localhost:11434", APIKey: "k"}); err != nil {
+		t.Errorf("NewLister should not need a model: %v", err)
+	}
+}
+
 func TestAnthropic_Generate(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify request format
@@ -92,17 +188,10 @@ func TestAnthropic_Generate(t *testing.T) {
 		}
 
 		// Respond
-		resp := anthropicResponse{
-			Content: []struct {
-				Type string `json:"type"`
-				Text string `json:"text"`
-			}{{Type: "text", Text: "response content"}},
-			Model: "test-model",
-		}
-		resp.Usage.InputTokens = 10
-		resp.Usage.OutputTokens = 20
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(resp)
+		w.Header().Set("request-id", "req_123")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"response content"}],"model":"test-model",` +
+			`"stop_reason":"max_tokens","usage":{"input_tokens":10,"output_tokens":20}}`))
 	}))
 	defer server.Close()
 
@@ -121,6 +210,83 @@ func TestAnthropic_Generate(t *testing.T) {
 	if resp.TokensIn != 10 || resp.TokensOut != 20 {
 		t.Errorf("tokens = %d/%d, want 10/20", resp.TokensIn, resp.TokensOut)
 	}
+	if resp.RequestID != "req_123" || resp.FinishReason != FinishLength {
+		t.Errorf("request ID, finish reason = %q, %q, want req_123, length", resp.RequestID, resp.FinishReason)
+	}
+	if len(resp.Warnings) != 0 {
+		t.Errorf("warnings = %q, want none for a complete response", resp.Warnings)
+	}
+}
+
+func TestAnthropic_VersionOverride(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("anthropic-version"); got != "2024-01-01" {
+			t.Errorf("anthropic-version = %q, want the configured 2024-01-01", got)
+		}
+		if got := r.Header.Get("anthropic-beta"); got != "feature-a,feature-b" {
+			t.Errorf("anthropic-beta = %q, want feature-a,feature-b", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"model":"test-model",` +
+			`"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`))
+	}))
+	defer server.Close()
+
+	prov, err := New(&config.Resolved{
+		Provider:         "anthropic",
+		APIKey:           "test-key",
+		Model:            "test-model",
+		BaseURL:          server.URL,
+		AnthropicVersion: "2024-01-01",
+		AnthropicBeta:    []string{"feature-a", "feature-b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prov.Generate(context.Background(), GenerateRequest{UserMessage: "hi", MaxTokens: 10}); err != nil {
+		t.Fatalf("generate error: %v", err)
+	}
+}
+
+func TestGenerate_WarnsOnIncompleteResponses(t *testing.T) {
+	serve := func(body string) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+	tests := []struct {
+		name string
+		prov func(url string) Provider
+		body string
+		want []string
+	}{
+		{"anthropic without usage", func(url string) Provider { return &Anthropic{apiKey: "k", model: "m", baseURL: url} },
+			`{"content":[{"type":"text","text":"hi"}],"stop_reason":"end_turn"}`,
+			[]string{"response has no usage field; token counts are unknown"}},
+		{"anthropic with renamed usage and content", func(url string) Provider { return &Anthropic{apiKey: "k", model: "m", baseURL: url} },
+			`{"output":[{"type":"text","text":"hi"}],"usage":{"prompt_tokens":3,"completion_tokens":1}}`,
+			[]string{"response usage reports 0 input and 0 output tokens", "response has no content field"}},
+		{"openai without usage", func(url string) Provider { return &OpenAI{apiKey: "k", model: "m", baseURL: url} },
+			`{"choices":[{"message":{"content":"hi"},"finish_reason":"stop"}]}`,
+			[]string{"response has no usage field; token counts are unknown"}},
+		{"openai with empty content", func(url string) Provider { return &OpenAI{apiKey: "k", model: "m", baseURL: url} },
+			`{"choices":[{"message":{"content":""},"finish_reason":"stop"}],"usage":{"prompt_tokens":3,"completion_tokens":0}}`,
+			[]string{"response content is empty"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := tt.prov(serve(tt.body)).Generate(context.Background(), GenerateRequest{UserMessage: "hi"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Join(resp.Warnings, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("warnings = %q, want %q", resp.Warnings, tt.want)
+			}
+		})
+	}
 }
 
 func TestOpenAI_Generate(t *testing.T) {
@@ -147,20 +313,10 @@ func TestOpenAI_Generate(t *testing.T) {
 			t.Errorf("got %d messages, want 2", len(req.Messages))
 		}
 
-		resp := openaiResponse{
-			Choices: []struct {
-				Message struct {
-					Content string `json:"content"`
-				} `json:"message"`
-			}{{Message: struct {
-				Content string `json:"content"`
-			}{Content: "openai response"}}},
-			Model: "test-model",
-		}
-		resp.Usage.PromptTokens = 15
-		resp.Usage.CompletionTokens = 25
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(resp)
+		w.Header().Set("x-request-id", "req_456")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"openai response"},"finish_reason":"stop"}],` +
+			`"model":"test-model","usage":{"prompt_tokens":15,"completion_tokens":25}}`))
 	}))
 	defer server.Close()
 
@@ -179,4 +335,397 @@ func TestOpenAI_Generate(t *testing.T) {
 	if resp.TokensIn != 15 || resp.TokensOut != 25 {
 		t.Errorf("tokens = %d/%d, want 15/25", resp.TokensIn, resp.TokensOut)
 	}
+	if resp.RequestID != "req_456" || resp.FinishReason != FinishStop {
+		t.Errorf("request ID, finish reason = %q, %q, want req_456, stop", resp.RequestID, resp.FinishReason)
+	}
+}
+
+func TestGenerate_RedactsSecretsInErrors(t *testing.T) {
+	const key = "sk-live-0123456789"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Some gateways echo the request back in error bodies
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid request","echo":{"Authorization":"Bearer ` + key + `","url":"/v1?api-key=` + key + `"}}`))
+	}))
+	defer server.Close()
+
+	providers := []Provider{
+		&OpenAI{apiKey: key, model: "m", baseURL: server.URL},
+		&Anthropic{apiKey: key, model: "m", baseURL: server.URL},
+	}
+	for _, prov := range providers {
+		_, err := prov.Generate(context.Background(), GenerateRequest{UserMessage: "hi"})
+		if err == nil {
+			t.Fatalf("%s: expected error", prov.Name())
+		}
+		if strings.Contains(err.Error(), key) {
+			t.Errorf("%s: error leaks API key: %v", prov.Name(), err)
+		}
+		if !strings.Contains(err.Error(), "[REDACTED]") || !strings.Contains(err.Error(), "HTTP 400") {
+			t.Errorf("%s: error = %v, want redacted HTTP 400 error", prov.Name(), err)
+		}
+	}
+}
+
+func TestOpenAI_RetriesRateLimitedRequests(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("x-ratelimit-limit-requests", "50")
+		w.Header().Set("x-ratelimit-remaining-requests", "49")
+		w.Header().Set("x-ratelimit-reset-requests", "1.2s")
+		w.Header().Set("x-ratelimit-limit-tokens", "40000")
+		w.Header().Set("x-ratelimit-remaining-tokens", "39000")
+		w.Header().Set("x-ratelimit-reset-tokens", "6m0s")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer server.Close()
+
+	prov := &OpenAI{apiKey: "test-key", model: "m", baseURL: server.URL}
+	resp, err := prov.Generate(context.Background(), GenerateRequest{UserMessage: "user"})
+	if err != nil {
+		t.Fatalf("generate error: %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("calls = %d, want a retry after the 429", calls)
+	}
+	want := RateLimit{
+		RequestsLimit: 50, RequestsRemaining: 49, RequestsReset: 1200 * time.Millisecond,
+		TokensLimit: 40000, TokensRemaining: 39000, TokensReset: 6 * time.Minute,
+	}
+	if resp.RateLimit == nil || *resp.RateLimit != want {
+		t.Errorf("RateLimit = %+v, want %+v", resp.RateLimit, want)
+	}
+	if got := resp.RateLimit.String(); got != "49/50 requests, 39000/40000 tokens remaining" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestPacer_SlowsDownAsBudgetRunsLow(t *testing.T) {
+	var p pacer
+	p.observe(&RateLimit{RequestsLimit: 100, RequestsRemaining: 50, RequestsReset: time.Minute})
+	if p.gap != 0 {
+		t.Errorf("gap = %s with half the budget left, want none", p.gap)
+	}
+
+	h := http.Header{}
+	h.Set("anthropic-ratelimit-requests-limit", "100")
+	h.Set("anthropic-ratelimit-requests-remaining", "90")
+	h.Set("anthropic-ratelimit-tokens-limit", "1000")
+	h.Set("anthropic-ratelimit-tokens-remaining", "100")
+	h.Set("anthropic-ratelimit-tokens-reset", time.Now().Add(time.Minute).UTC().Format(time.RFC3339))
+	rl := parseAnthropicRateLimit(h)
+	p.observe(rl)
+	if p.gap < 20*time.Second || p.gap > 30*time.Second {
+		t.Errorf("gap = %s at 10%% of the token budget, want about half the reset window", p.gap)
+	}
+
+	rl.TokensRemaining = 0
+	p.observe(rl)
+	if wait := time.Until(p.next); wait < 50*time.Second {
+		t.Errorf("next request in %s with the budget exhausted, want held until reset", wait)
+	}
+}
+
+func TestModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Header.Get("Authorization") == "Bearer test-key":
+			_, _ = w.Write([]byte(`{"data":[{"id":"gpt-b"},{"id":"gpt-a"}]}`))
+		case r.Header.Get("x-api-key") == "test-key" && r.URL.Query().Get("after_id") == "":
+			_, _ = w.Write([]byte(`{"data":[{"id":"claude-a"}],"has_more":true,"last_id":"claude-a"}`))
+		case r.Header.Get("x-api-key") == "test-key":
+			_, _ = w.Write([]byte(`{"data":[{"id":"claude-b"}],"has_more":false}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	var _ ModelLister = &Anthropic{}
+	got, err := (&OpenAI{apiKey: "test-key", baseURL: server.URL}).Models(context.Background())
+	if err != nil || strings.Join(got, ",") != "gpt-a,gpt-b" {
+		t.Errorf("OpenAI Models = %v, %v; want sorted gpt-a,gpt-b", got, err)
+	}
+	got, err = (&Anthropic{apiKey: "test-key", baseURL: server.URL}).Models(context.Background())
+	if err != nil || strings.Join(got, ",") != "claude-a,claude-b" {
+		t.Errorf("Anthropic Models = %v, %v; want both pages", got, err)
+	}
+	if _, err := (&OpenAI{apiKey: "wrong", baseURL: server.URL}).Models(context.Background()); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Models with a bad key: err = %v, want HTTP 401", err)
+	}
+}
+
+func TestTokenizerFor(t *testing.T) {
+	for _, tt := range []struct {
+		provider, model, name string
+		text                  string
+		want                  int
+	}{
+		{"anthropic", "claude-sonnet-4-6", "", "hello world, hello!", 4},
+		{"openai", "gpt-4o", "", "hello world, hello!", 5},
+		{"openai", "o3", "", "日本語", 3},
+		{"", "", "tiktoken", "internationalization 12345", 6},
+	} {
+		tok, err := TokenizerFor(tt.provider, tt.model, tt.name)
+		if err != nil {
+			t.Fatalf("TokenizerFor(%q, %q, %q): %v", tt.provider, tt.model, tt.name, err)
+		}
+		if got := tok.CountTokens(tt.text); got != tt.want {
+			t.Errorf("TokenizerFor(%q, %q, %q) counts %q as %d, want %d", tt.provider, tt.model, tt.name, tt.text, got, tt.want)
+		}
+	}
+
+	if _, err := TokenizerFor("", "", "sentencepiece"); err == nil || !strings.Contains(err.Error(), "heuristic, tiktoken") {
+		t.Errorf("unknown tokenizer error = %v", err)
+	}
+	RegisterTokenizer("exact", TokenizerFunc(func(string) int { return 42 }))
+	if tok, err := TokenizerFor("openai", "gpt-4o", "exact"); err != nil || tok.CountTokens("x") != 42 {
+		t.Errorf("registered tokenizer not selected (err %v)", err)
+	}
+}
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		prov Provider
+		want Capabilities
+	}{
+		{&Anthropic{model: "claude-sonnet-4-6"}, Capabilities{SystemPrompt: true}},
+		{&OpenAI{model: "gpt-4o", baseURL: openAIBaseURL}, Capabilities{SystemPrompt: true, JSONMode: true}},
+		{&OpenAI{model: "gpt-4o", baseURL: "http://localhost:11434"}, Capabilities{SystemPrompt: true}},
+		{&OpenAI{model: "o1", baseURL: openAIBaseURL}, Capabilities{SystemPrompt: true, JSONMode: true}},
+		{&OpenAI{model: "o1-mini", baseURL: openAIBaseURL}, Capabilities{}},
+		{&OpenAI{model: "o3-mini", baseURL: openAIBaseURL}, Capabilities{SystemPrompt: true, JSONMode: true}},
+		{&RecordingProvider{Replay: true, ProviderName: "openai", Model: "o1-preview"}, Capabilities{}},
+		{&RecordingProvider{Provider: &OpenAI{model: "o1-mini"}}, Capabilities{}},
+	}
+	for _, tt := range tests {
+		if got := CapabilitiesOf(tt.prov); got != tt.want {
+			t.Errorf("CapabilitiesOf(%s %+v) = %+v, want %+v", tt.prov.Name(), tt.prov, got, tt.want)
+		}
+	}
+
+	req := GenerateRequest{SystemPrompt: "system", UserMessage: "user", JSON: true}
+	if got := DefaultCapabilities.Adapt(req); got.SystemPrompt != "system" || got.UserMessage != "user" || got.JSON {
+		t.Errorf("Adapt with defaults = %+v, want the system prompt kept and JSON dropped", got)
+	}
+	if got := (Capabilities{JSONMode: true}).Adapt(req); got.SystemPrompt != "" || got.UserMessage != "system\n\n---\n\nuser" || !got.JSON {
+		t.Errorf("Adapt without a system role = %+v, want the system prompt folded into the message", got)
+	}
+}
+
+func TestOpenAI_JSONMode(t *testing.T) {
+	var format *openaiFormat
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		format = req.ResponseFormat
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"content": "{}"}}]}`))
+	}))
+	defer server.Close()
+
+	prov := &OpenAI{apiKey: "test-key", model: "gpt-4o", baseURL: server.URL}
+	if _, err := prov.Generate(context.Background(), GenerateRequest{UserMessage: "json please", JSON: true}); err != nil {
+		t.Fatal(err)
+	}
+	if format == nil || format.Type != "json_object" {
+		t.Errorf("response_format = %+v, want json_object", format)
+	}
+	if _, err := prov.Generate(context.Background(), GenerateRequest{UserMessage: "prose"}); err != nil {
+		t.Fatal(err)
+	}
+	if format != nil {
+		t.Errorf("response_format = %+v, want none without JSON", format)
+	}
+}
+
+func TestStopSequences(t *testing.T) {
+	var raw map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw = nil
+		_ = json.NewDecoder(r.Body).Decode(&raw)
+		w.Header().Set("Content-Type", "application/json")
+		if strings.HasSuffix(r.URL.Path, "/v1/messages") {
+			_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":1,"output_tokens":1}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+	req := GenerateRequest{UserMessage: "user", StopSequences: []string{"a", "b", "c", "d", "e"}}
+
+	tests := []struct {
+		prov  Provider
+		param string
+		want  int
+	}{
+		{&Anthropic{apiKey: "k", model: "claude-sonnet-4-6", baseURL: server.URL}, "stop_sequences", 5},
+		{&OpenAI{apiKey: "k", model: "gpt-4o", baseURL: server.URL}, "stop", maxOpenAIStopSequences},
+		{&OpenAI{apiKey: "k", model: "o3-mini", baseURL: server.URL}, "stop", 0},
+	}
+	for _, tt := range tests {
+		if _, err := tt.prov.Generate(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := raw[tt.param].([]any)
+		if len(got) != tt.want {
+			t.Errorf("%s %s = %v, want %d sequences", tt.prov.Name(), tt.param, raw[tt.param], tt.want)
+		}
+	}
+
+	if _, err := tests[1].prov.Generate(context.Background(), GenerateRequest{UserMessage: "user"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := raw["stop"]; ok {
+		t.Errorf("request without stop sequences sends stop = %v", raw["stop"])
+	}
+}
+
+func TestOpenAI_ReasoningModels(t *testing.T) {
+	var got openaiRequest
+	var raw map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, raw = openaiRequest{}, nil
+		_ = json.Unmarshal(data, &got)
+		_ = json.Unmarshal(data, &raw)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices": [{"message": {"content": "ok"}}]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		model     string
+		roles     string
+		user      string
+		maxTokens int
+	}{
+		{"gpt-4o", "system,user", "user", 1000},
+		{"o3-mini", "developer,user", "user", 1000 + reasoningTokenReserve},
+		{"o1", "developer,user", "user", 1000 + reasoningTokenReserve},
+		{"o1-mini", "user", "system\n\n---\n\nuser", 1000 + reasoningTokenReserve},
+	}
+	for _, tt := range tests {
+		prov := &OpenAI{apiKey: "test-key", model: tt.model, baseURL: server.URL}
+		if _, err := prov.Generate(context.Background(), GenerateRequest{SystemPrompt: "system", UserMessage: "user", MaxTokens: 1000}); err != nil {
+			t.Fatalf("%s: %v", tt.model, err)
+		}
+		var roles []string
+		for _, m := range got.Messages {
+			roles = append(roles, m.Role)
+		}
+		if strings.Join(roles, ",") != tt.roles || got.Messages[len(got.Messages)-1].Content != tt.user {
+			t.Errorf("%s messages = %+v, want roles %s", tt.model, got.Messages, tt.roles)
+		}
+		if got.MaxCompletionTokens != tt.maxTokens {
+			t.Errorf("%s max_completion_tokens = %d, want %d", tt.model, got.MaxCompletionTokens, tt.maxTokens)
+		}
+		for _, param := range []string{"temperature", "max_tokens", "top_p"} {
+			if _, ok := raw[param]; ok {
+				t.Errorf("%s request sends %s", tt.model, param)
+			}
+		}
+	}
+}
+
+func TestPriceFor(t *testing.T) {
+	tests := []struct {
+		model string
+		want  Price
+		ok    bool
+	}{
+		{"claude-sonnet-4-6", Price{3, 15}, true},
+		{"claude-opus-4-1-20250805", Price{15, 75}, true},
+		{"claude-opus-4-5", Price{5, 25}, true},
+		{"gpt-4o-mini-2024-07-18", Price{0.15, 0.6}, true},
+		{"gpt-4o", Price{2.5, 10}, true},
+		{"llama3", Price{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := PriceFor(tt.model)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("PriceFor(%q) = %v, %v, want %v, %v", tt.model, got, ok, tt.want, tt.ok)
+		}
+	}
+	if cost := (Price{3, 15}).Cost(1_000_000, 200_000); cost != 6 {
+		t.Errorf("Cost = %v, want 6", cost)
+	}
+}
+
+func TestFallback(t *testing.T) {
+	var primaryCalls int
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryCalls++
+		w.WriteHeader(529)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"content":"from openai"},"finish_reason":"stop"}],` +
+			`"model":"gpt-4o","usage":{"prompt_tokens":1,"completion_tokens":1}}`))
+	}))
+	defer secondary.Close()
+
+	p, err := New(&config.Resolved{
+		Provider: "anthropic", APIKey: "k1", Model: "claude-sonnet-4-6", BaseURL: primary.URL,
+		Fallbacks: []*config.Resolved{{Provider: "openai", APIKey: "k2", Model: "gpt-4o", BaseURL: secondary.URL}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, ok := p.(*Fallback)
+	if !ok {
+		t.Fatalf("New with fallbacks = %T, want *Fallback", p)
+	}
+	var switches []string
+	f.OnSwitch = func(from, to int, err error) {
+		switches = append(switches, fmt.Sprintf("%d->%d: %v", from, to, err))
+	}
+
+	for range 2 {
+		resp, err := f.Generate(context.Background(), GenerateRequest{UserMessage: "hi", MaxTokens: 10})
+		if err != nil {
+			t.Fatalf("Generate: %v", err)
+		}
+		if resp.Content != "from openai" || resp.Provider != "openai" {
+			t.Errorf("response = %q from %q, want the fallback's", resp.Content, resp.Provider)
+		}
+	}
+	if primaryCalls != 1 {
+		t.Errorf("primary called %d times, want once before it is given up on", primaryCalls)
+	}
+	if len(switches) != 1 || !strings.Contains(switches[0], "0->1: anthropic API error (HTTP 529)") {
+		t.Errorf("switches = %q, want one from the primary", switches)
+	}
+	if f.Name() != "openai" {
+		t.Errorf("Name = %q, want the provider in use", f.Name())
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{&StatusError{Provider: "openai", StatusCode: 429}, true},
+		{&StatusError{Provider: "openai", StatusCode: 503}, true},
+		{fmt.Errorf("chunk 1 of 2: %w", &StatusError{Provider: "anthropic", StatusCode: 529}), true},
+		{&StatusError{Provider: "openai", StatusCode: 400}, false},
+		{&StatusError{Provider: "openai", StatusCode: 401}, false},
+		{fmt.Errorf("sending request: %w", &url.Error{Op: "Post", URL: "http://x", Err: io.EOF}), true},
+		{fmt.Errorf("parsing response: bad json"), false},
+	}
+	for _, tt := range tests {
+		if got := Unavailable(tt.err); got != tt.want {
+			t.Errorf("Unavailable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
 }
@@ -0,0 +1,215 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is a provider's remaining request and token budget as reported
+// by response headers. A zero limit means the provider did not report it.
+type RateLimit struct {
+	RequestsLimit     int
+	RequestsRemaining int
+	RequestsReset     time.Duration // until the request budget refills
+	TokensLimit       int
+	TokensRemaining   int
+	TokensReset       time.Duration // until the token budget refills
+}
+
+func (rl *RateLimit) String() string {
+	var parts []string
+	if rl.RequestsLimit > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d requests", rl.RequestsRemaining, rl.RequestsLimit))
+	}
+	if rl.TokensLimit > 0 {
+		parts = append(parts, fmt.Sprintf("%d/%d tokens", rl.TokensRemaining, rl.TokensLimit))
+	}
+	return strings.Join(parts, ", ") + " remaining"
+}
+
+// remaining returns the fraction left of the tighter budget and how long
+// until that budget refills.
+func (rl *RateLimit) remaining() (float64, time.Duration) {
+	frac, reset := 1.0, time.Duration(0)
+	if rl.RequestsLimit > 0 {
+		frac, reset = float64(rl.RequestsRemaining)/float64(rl.RequestsLimit), rl.RequestsReset
+	}
+	if rl.TokensLimit > 0 {
+		if f := float64(rl.TokensRemaining) / float64(rl.TokensLimit); f < frac {
+			frac, reset = f, rl.TokensReset
+		}
+	}
+	return frac, reset
+}
+
+// parseAnthropicRateLimit reads the anthropic-ratelimit-* headers, whose
+// resets are RFC 3339 timestamps.
+func parseAnthropicRateLimit(h http.Header) *RateLimit {
+	reset := func(name string) time.Duration {
+		t, err := time.Parse(time.RFC3339, h.Get(name))
+		if err != nil {
+			return 0
+		}
+		return max(time.Until(t), 0)
+	}
+	rl := &RateLimit{
+		RequestsLimit:     headerInt(h, "anthropic-ratelimit-requests-limit"),
+		RequestsRemaining: headerInt(h, "anthropic-ratelimit-requests-remaining"),
+		RequestsReset:     reset("anthropic-ratelimit-requests-reset"),
+		TokensLimit:       headerInt(h, "anthropic-ratelimit-tokens-limit"),
+		TokensRemaining:   headerInt(h, "anthropic-ratelimit-tokens-remaining"),
+		TokensReset:       reset("anthropic-ratelimit-tokens-reset"),
+	}
+	if rl.RequestsLimit == 0 && rl.TokensLimit == 0 {
+		return nil
+	}
+	return rl
+}
+
+// parseOpenAIRateLimit reads the x-ratelimit-* headers, whose resets are
+// durations such as "1s" or "6m0s".
+func parseOpenAIRateLimit(h http.Header) *RateLimit {
+	reset := func(name string) time.Duration {
+		d, _ := time.ParseDuration(h.Get(name))
+		return d
+	}
+	rl := &RateLimit{
+		RequestsLimit:     headerInt(h, "x-ratelimit-limit-requests"),
+		RequestsRemaining: headerInt(h, "x-ratelimit-remaining-requests"),
+		RequestsReset:     reset("x-ratelimit-reset-requests"),
+		TokensLimit:       headerInt(h, "x-ratelimit-limit-tokens"),
+		TokensRemaining:   headerInt(h, "x-ratelimit-remaining-tokens"),
+		TokensReset:       reset("x-ratelimit-reset-tokens"),
+	}
+	if rl.RequestsLimit == 0 && rl.TokensLimit == 0 {
+		return nil
+	}
+	return rl
+}
+
+func headerInt(h http.Header, name string) int {
+	n, _ := strconv.Atoi(h.Get(name))
+	return n
+}
+
+// paceThreshold is the fraction of a rate-limit budget below which the
+// pacer starts spacing out requests.
+const paceThreshold = 0.2
+
+// maxRateLimitRetries bounds how often a request rejected with HTTP 429 is
+// retried before the error is returned.
+const maxRateLimitRetries = 3
+
+// pacer spaces out the requests that share a provider as its rate-limit
+// budget runs low, so concurrent artifact generation slows down instead of
+// failing. The zero value sends requests immediately.
+type pacer struct {
+	mu   sync.Mutex
+	next time.Time     // earliest start for the next request
+	gap  time.Duration // spacing between request starts
+}
+
+// wait blocks until the caller's turn to send a request.
+func (p *pacer) wait(ctx context.Context) error {
+	p.mu.Lock()
+	now := time.Now()
+	start := p.next
+	if start.Before(now) {
+		start = now
+	}
+	p.next = start.Add(p.gap)
+	p.mu.Unlock()
+
+	d := start.Sub(now)
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// observe adapts the spacing to the budget a response reported. Above
+// paceThreshold requests are not delayed; below it the gap grows toward the
+// full reset window, and an exhausted budget holds requests until it refills.
+func (p *pacer) observe(rl *RateLimit) {
+	if rl == nil {
+		return
+	}
+	frac, reset := rl.remaining()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if frac >= paceThreshold {
+		p.gap = 0
+		return
+	}
+	p.gap = time.Duration(float64(reset) * (1 - frac/paceThreshold))
+	if frac <= 0 {
+		p.holdLocked(reset)
+	}
+}
+
+// hold delays every request for at least d.
+func (p *pacer) hold(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.holdLocked(d)
+}
+
+func (p *pacer) holdLocked(d time.Duration) {
+	if until := time.Now().Add(d); until.After(p.next) {
+		p.next = until
+	}
+}
+
+// do sends the request built by newReq once the pacer allows, returning the
+// status, body, and reported rate limit. A 429 response holds the pacer for
+// the server's Retry-After delay (or an exponential backoff) and is retried.
+func (p *pacer) do(ctx context.Context, newReq func() (*http.Request, error), parse func(http.Header) *RateLimit) (int, []byte, http.Header, *RateLimit, error) {
+	for attempt := 0; ; attempt++ {
+		if err := p.wait(ctx); err != nil {
+			return 0, nil, nil, nil, err
+		}
+		httpReq, err := newReq()
+		if err != nil {
+			return 0, nil, nil, nil, fmt.Errorf("creating request: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(httpReq)
+		if err != nil {
+			return 0, nil, nil, nil, fmt.Errorf("sending request: %w", err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return 0, nil, nil, nil, fmt.Errorf("reading response: %w", err)
+		}
+
+		rl := parse(resp.Header)
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < maxRateLimitRetries {
+			p.hold(retryAfter(resp.Header, attempt))
+			continue
+		}
+		p.observe(rl)
+		return resp.StatusCode, data, resp.Header, rl, nil
+	}
+}
+
+// retryAfter returns the delay a 429 response asks for, falling back to an
+// exponential backoff from one second.
+func retryAfter(h http.Header, attempt int) time.Duration {
+	if secs, err := strconv.Atoi(h.Get("Retry-After")); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second
+	}
+	return time.Second << attempt
+}
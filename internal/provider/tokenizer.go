@@ -0,0 +1,120 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tokenizer counts the tokens a model would see in a text. Counts feed the
+// token budget for chunking and dry-run estimates, so an approximation is
+// fine as long as it does not badly undercount.
+type Tokenizer interface {
+	CountTokens(text string) int
+}
+
+// TokenizerFunc adapts a function to the Tokenizer interface.
+type TokenizerFunc func(text string) int
+
+func (f TokenizerFunc) CountTokens(text string) int { return f(text) }
+
+// Built-in tokenizer names.
+const (
+	// TokenizerHeuristic assumes about four bytes per token.
+	TokenizerHeuristic = "heuristic"
+	// TokenizerTiktoken splits text the way tiktoken's BPE encodings
+	// pre-tokenize it and estimates tokens per piece. Register a real BPE
+	// implementation under this name to replace the estimate.
+	TokenizerTiktoken = "tiktoken"
+)
+
+var (
+	tokenizersMu sync.RWMutex
+	tokenizers   = map[string]Tokenizer{
+		TokenizerHeuristic: TokenizerFunc(heuristicTokens),
+		TokenizerTiktoken:  TokenizerFunc(pretokenTokens),
+	}
+)
+
+// RegisterTokenizer makes a tokenizer selectable by name, replacing any
+// registered under the same name. It lets library users plug in an exact
+// tokenizer without this module depending on one.
+func RegisterTokenizer(name string, t Tokenizer) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	tokenizers[strings.ToLower(name)] = t
+}
+
+// TokenizerFor returns the named tokenizer, or when name is empty the
+// default for the provider and model: tiktoken-style for OpenAI GPT and o-series
+// models, the heuristic otherwise.
+func TokenizerFor(providerName, model, name string) (Tokenizer, error) {
+	if name == "" {
+		name = TokenizerHeuristic
+		if usesTiktoken(strings.ToLower(providerName), strings.ToLower(model)) {
+			name = TokenizerTiktoken
+		}
+	}
+	tokenizersMu.RLock()
+	defer tokenizersMu.RUnlock()
+	t, ok := tokenizers[strings.ToLower(name)]
+	if !ok {
+		names := make([]string, 0, len(tokenizers))
+		for n := range tokenizers {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown tokenizer %q (registered: %s)", name, strings.Join(names, ", "))
+	}
+	return t, nil
+}
+
+func usesTiktoken(providerName, model string) bool {
+	if strings.HasPrefix(model, "gpt-") || strings.HasPrefix(model, "chatgpt-") {
+		return true
+	}
+	if reasoningModel(model) {
+		return true // o1, o3, o4-mini
+	}
+	return providerName == "openai" && model == ""
+}
+
+// heuristicTokens is the default estimate: about four bytes per token.
+func heuristicTokens(text string) int {
+	return len(text) / 4
+}
+
+// pretokenPattern approximates the cl100k/o200k pre-tokenizer: contractions,
+// words with one leading space, runs of up to three digits, punctuation runs,
+// and whitespace.
+var pretokenPattern = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)| ?\p{L}+| ?\p{N}{1,3}| ?[^\s\p{L}\p{N}]+|\s+`)
+
+// pretokenTokens splits text into pre-tokens and estimates each: short
+// Latin words and digit runs are usually one token and longer words split
+// every few letters, while CJK and other non-Latin scripts cost about a
+// token per character.
+func pretokenTokens(text string) int {
+	n := 0
+	for _, piece := range pretokenPattern.FindAllString(text, -1) {
+		word := strings.TrimPrefix(piece, " ")
+		switch r, _ := utf8.DecodeRuneInString(word); {
+		case word == "":
+			n++ // a lone space
+		case unicode.IsSpace(r):
+			n += (len(word) + 7) / 8
+		case unicode.IsNumber(r):
+			n++ // at most three digits
+		case unicode.IsLetter(r) && r > unicode.MaxLatin1:
+			n += utf8.RuneCountInString(word)
+		case unicode.IsLetter(r):
+			n += max(1, (len(word)+3)/5)
+		default:
+			n += max(1, (len(word)+1)/2)
+		}
+	}
+	return n
+}
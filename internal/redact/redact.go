@@ -0,0 +1,49 @@
+// Package redact masks credentials in strings and errors before they are
+// shown to the user.
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Mask replaces each redacted value.
+const Mask = "[REDACTED]"
+
+var (
+	// ?api-key=..., &token=..., SAS &sig=...
+	queryParam = regexp.MustCompile(`(?i)([?&](?:api[-_]?key|key|access_token|token|sig|signature)=)[^&\s"']+`)
+	// Authorization: Bearer ..., "x-api-key": "..."
+	headerValue = regexp.MustCompile(`(?i)((?:authorization|x-api-key|api-key)["']?\s*[:=]\s*["']?)(?:(?:bearer|basic)\s+)?[^\s"',}&]+`)
+	bearerToken = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]+`)
+)
+
+// String masks the given secrets wherever they appear in s, along with
+// credential-bearing query parameters, auth header values, and bearer tokens.
+func String(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, Mask)
+		}
+	}
+	s = queryParam.ReplaceAllString(s, "${1}"+Mask)
+	s = headerValue.ReplaceAllString(s, "${1}"+Mask)
+	return bearerToken.ReplaceAllString(s, "Bearer "+Mask)
+}
+
+// Error returns err with its message passed through String. The original
+// error stays reachable through errors.Is and errors.As.
+func Error(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+	return &redactedError{msg: String(err.Error(), secrets...), err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
@@ -0,0 +1,45 @@
+package redact
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"literal key", `{"error":"bad key sk-abc123"}`, `{"error":"bad key [REDACTED]"}`},
+		{"query api-key", "https://x.openai.azure.com/v1?api-key=abc&v=2", "https://x.openai.azure.com/v1?api-key=[REDACTED]&v=2"},
+		{"sas signature", "https://blob/x?sv=2020&sig=Zm9v%3D", "https://blob/x?sv=2020&sig=[REDACTED]"},
+		{"authorization header", "Authorization: Bearer tok.123", "Authorization: [REDACTED]"},
+		{"json header", `{"x-api-key": "k-999"}`, `{"x-api-key": "[REDACTED]"}`},
+		{"bare bearer", "sent bearer abc.def", "sent Bearer [REDACTED]"},
+		{"nothing secret", "HTTP 500: internal error", "HTTP 500: internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := String(tt.in, "sk-abc123"); got != tt.want {
+				t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	if Error(nil, "k") != nil {
+		t.Error("Error(nil) should be nil")
+	}
+	err := Error(fmt.Errorf("request with key sk-1: %w", context.Canceled), "sk-1")
+	if strings.Contains(err.Error(), "sk-1") {
+		t.Errorf("error not redacted: %v", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Error("redacted error should unwrap to the original")
+	}
+}
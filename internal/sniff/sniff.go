@@ -0,0 +1,135 @@
+// Package sniff guesses a spec document's format from its content, so
+// plugin detection does not depend on the file extension.
+package sniff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+)
+
+// Format is a spec format recognized by its content.
+type Format string
+
+const (
+	Unknown  Format = ""
+	OpenAPI  Format = "openapi" // OpenAPI 3.x or Swagger 2.0
+	AsyncAPI Format = "asyncapi"
+	GraphQL  Format = "graphql" // introspection result
+	Postman  Format = "postman" // collection with info._postman_id
+)
+
+// peekSize bounds how much of a file is read; the identifying keys sit near
+// the top of every format recognized here.
+const peekSize = 64 << 10
+
+// File sniffs the start of the file at path. Unreadable files and
+// directories are Unknown.
+func File(path string) Format {
+	f, err := os.Open(path)
+	if err != nil {
+		return Unknown
+	}
+	defer func() { _ = f.Close() }()
+
+	head := make([]byte, peekSize)
+	n, _ := io.ReadFull(f, head)
+	return Bytes(head[:n])
+}
+
+// Bytes sniffs a document, which may be truncated: JSON is recognized by its
+// top-level keys (and data.__schema or info._postman_id), YAML by an
+// unindented openapi, swagger, asyncapi, or __schema key.
+func Bytes(data []byte) Format {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimLeft(data, " \t\r\n")
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return sniffJSON(trimmed)
+	}
+	return sniffYAML(data)
+}
+
+// topLevel maps a top-level key to the format it identifies.
+var topLevel = map[string]Format{
+	"openapi":  OpenAPI,
+	"swagger":  OpenAPI,
+	"asyncapi": AsyncAPI,
+	"__schema": GraphQL,
+}
+
+func sniffYAML(data []byte) Format {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+			continue
+		}
+		key, _, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if f, ok := topLevel[strings.Trim(strings.TrimSpace(key), `"'`)]; ok {
+			return f
+		}
+	}
+	return Unknown
+}
+
+// sniffJSON walks the object's tokens until a key identifies the format or
+// the (possibly truncated) input ends.
+func sniffJSON(data []byte) Format {
+	type frame struct {
+		object  bool
+		wantKey bool
+		key     string
+	}
+	var stack []frame
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return Unknown
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				if len(stack) > 0 {
+					stack[len(stack)-1].wantKey = true // the value has begun
+				}
+				stack = append(stack, frame{object: d == '{', wantKey: true})
+			default:
+				stack = stack[:len(stack)-1]
+				if len(stack) == 0 {
+					return Unknown
+				}
+			}
+			continue
+		}
+		top := &stack[len(stack)-1]
+		if !top.object {
+			continue
+		}
+		if !top.wantKey {
+			top.wantKey = true // a scalar value
+			continue
+		}
+		key, _ := tok.(string)
+		top.key, top.wantKey = key, false
+		switch len(stack) {
+		case 1:
+			if f, ok := topLevel[key]; ok {
+				return f
+			}
+		case 2:
+			switch parent := stack[0].key; {
+			case parent == "data" && key == "__schema":
+				return GraphQL
+			case parent == "info" && key == "_postman_id":
+				return Postman
+			}
+		}
+	}
+}
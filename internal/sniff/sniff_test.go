@@ -0,0 +1,31 @@
+package sniff
+
+import "testing"
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Format
+	}{
+		{"openapi yaml", "# API\nopenapi: 3.1.0\ninfo:\n  title: Pets\n", OpenAPI},
+		{"swagger json", `{"swagger": "2.0", "paths": {}}`, OpenAPI},
+		{"quoted yaml key", "\"asyncapi\": '3.0.0'\n", AsyncAPI},
+		{"asyncapi after nested keys", `{"info": {"openapi": "decoy"}, "asyncapi": "2.6.0"}`, AsyncAPI},
+		{"graphql introspection", `{"data": {"__schema": {"queryType": {"name": "Query"}}}}`, GraphQL},
+		{"bare __schema", `{"__schema": {}}`, GraphQL},
+		{"postman", `{"info": {"name": "Pets", "_postman_id": "abc"}, "item": []}`, Postman},
+		{"bom", "\xef\xbb\xbfopenapi: 3.0.0\n", OpenAPI},
+		{"indented key", "paths:\n  openapi: nested\n", Unknown},
+		{"json schema", `{"$schema": "https://json-schema.org/draft/2020-12/schema", "type": "object"}`, Unknown},
+		{"truncated json", `{"info": {"title": "x", "description": "cut off`, Unknown},
+		{"empty", "", Unknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Bytes([]byte(tt.data)); got != tt.want {
+				t.Errorf("Bytes(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
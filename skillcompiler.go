@@ -0,0 +1,1176 @@
+// Package skillcompiler compiles COMPILER_INSTRUCTIONS.md and its spec
+// sources into Agent Skills directories and llms.txt documentation. It is the
+// library behind the sc command and keeps no global state, so several builds
+// may run concurrently in one process.
+package skillcompiler
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roberthamel/skill-compiler/internal/cache"
+	"github.com/roberthamel/skill-compiler/internal/config"
+	"github.com/roberthamel/skill-compiler/internal/diag"
+	"github.com/roberthamel/skill-compiler/internal/generate"
+	"github.com/roberthamel/skill-compiler/internal/gittarget"
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+	"github.com/roberthamel/skill-compiler/internal/ir"
+	"github.com/roberthamel/skill-compiler/internal/plugins"
+	"github.com/roberthamel/skill-compiler/internal/plugins/openapi"
+	"github.com/roberthamel/skill-compiler/internal/provider"
+)
+
+// Warning is a diagnostic from instructions validation or spec parsing.
+type Warning = diag.Diagnostic
+
+// Severity ranks a Warning.
+type Severity = diag.Severity
+
+const (
+	SeverityError   = diag.SeverityError
+	SeverityWarning = diag.SeverityWarning
+	SeverityInfo    = diag.SeverityInfo
+)
+
+// FS is the filesystem a build reads previous artifacts from and writes
+// new ones to.
+type FS = generate.FS
+
+// MemoryFS is an FS held in memory, for tests and for embedding sc.
+type MemoryFS = generate.MemorySink
+
+// NewMemoryFS returns an empty MemoryFS.
+func NewMemoryFS() *MemoryFS { return generate.NewMemorySink() }
+
+// ProviderConfig overrides LLM provider settings. Empty fields fall back to
+// the instructions frontmatter, then SC_* environment variables, then the
+// sc config file.
+type ProviderConfig struct {
+	Provider string // anthropic, openai
+	Model    string
+	APIKey   string
+	BaseURL  string
+}
+
+// BuildOptions configures a Build.
+type BuildOptions struct {
+	// InstructionsPath names the instructions file; relative spec paths in
+	// it resolve against its directory. Instructions, if non-nil, is parsed
+	// instead of reading the file; with no path (or "-" for stdin), relative
+	// spec paths resolve against the working directory.
+	InstructionsPath string
+	Instructions     []byte
+	// Dir holds the lockfile and cache (default: the working directory).
+	Dir string
+	// Spec replaces the frontmatter spec sources with a file path or an
+	// http(s) URL; single-skill files only.
+	Spec string
+	// OutputDir replaces the frontmatter out directory.
+	OutputDir string
+	Provider  ProviderConfig
+	// Variants replaces the frontmatter variant tags that select conditional
+	// blocks in the instructions body.
+	Variants []string
+
+	Only            []string // generate only these artifact IDs
+	Force           bool     // ignore the lockfile and regenerate everything
+	Offline         bool     // never call the provider or fetch URLs
+	Enrich          bool     // draft missing descriptions before generating
+	EnrichWriteBack bool     // with Enrich, write drafts back into YAML OpenAPI files
+	ContinueOnError bool     // keep going when an artifact fails
+	MaxTokens       int      // output token limit per request; 0 uses per-artifact defaults
+	// SeedExamples is a JSON or JSONL file of recorded interactions
+	// (method, path, status, requestBody, responseBody) that examples.md is
+	// grounded in.
+	SeedExamples string
+	// OutputFormat is the reference and examples format (markdown, mdx, or
+	// asciidoc) for artifacts whose frontmatter sets none.
+	OutputFormat string
+	DryRun       bool // estimate prompts without calling the provider
+	Diff         bool // generate, then report changes instead of writing
+	// IncludeRawHelp sends the reference each CLI command's verbatim --help
+	// output. The frontmatter's include-raw-help also turns it on.
+	IncludeRawHelp bool
+	// ExplainCache logs, for each artifact, whether it is up to date and
+	// otherwise which input changed: the spec, the instruction sections, or
+	// the prompt. It has no effect with Force or DryRun, which skip the
+	// cache check.
+	ExplainCache bool
+	// PromptOnly implies DryRun and makes each artifact's Content the
+	// prompts it would send, as formatted by generate.FormatPrompts.
+	PromptOnly bool
+	// NoWrite keeps artifacts in memory: they are returned in SkillResult.Files
+	// but not written to the output directory. The lockfile and cache are
+	// still updated.
+	NoWrite bool
+	Verbose bool
+	// ReadOnly is NoWrite that also leaves the lockfile, the cache, and
+	// spec files untouched, so a build writes nothing at all. Previous
+	// artifacts and cached outputs are still read.
+	ReadOnly bool
+	// FS holds the output directories: previous artifacts are read from it
+	// and new ones written to it. Nil uses the OS filesystem. The lockfile
+	// and cache stay on disk under Dir.
+	FS FS
+	// ArtifactsFrom, when set, generates only the changelog, comparing the
+	// artifacts in this directory with the previous ones in PreviousFrom
+	// (default: the output directory) instead of regenerating them. The
+	// entry is still added to the output directory's CHANGELOG.md.
+	// Multi-skill builds read each skill from a subdirectory named by its
+	// slug, as they are written.
+	ArtifactsFrom string
+	PreviousFrom  string
+	// GitTarget, when set, commits the output directories to a git branch
+	// after the build. Dir must be inside a git repository.
+	GitTarget *GitTarget
+	// SinceCommit, when set, skips the whole build, parsing no specs and
+	// calling no provider, when neither the instructions file, the seed
+	// examples, nor any local spec path changed in git since this commit.
+	// Spec sources that are not local paths, such as URLs and commands,
+	// always count as changed. It requires InstructionsPath and has no
+	// effect with Force.
+	SinceCommit string
+	// OnEvent, if set, receives each artifact's progress as the build runs,
+	// possibly from several goroutines at once.
+	OnEvent func(Event)
+	// Plan implies ReadOnly and stops after the cache check: each enabled
+	// artifact is reported through OnEvent as cached, skipped, or pending,
+	// and no provider is called.
+	Plan bool
+
+	// Log receives progress messages and ErrLog warnings and errors; nil
+	// discards them.
+	Log    io.Writer
+	ErrLog io.Writer
+}
+
+// EventKind is what happened to an artifact; see Event.
+type EventKind string
+
+// Event kinds, in the order an artifact can go through them. A cached or
+// skipped artifact is never started; a dry run reports done without it.
+const (
+	EventCached  EventKind = "cached"  // up to date in the lockfile
+	EventPending EventKind = "pending" // would be generated; Plan only
+	EventStarted EventKind = "started" // calling the provider
+	EventSkipped EventKind = "skipped" // nothing to generate it from
+	EventDone    EventKind = "done"    // generated
+	EventFailed  EventKind = "failed"
+)
+
+// Event reports the progress of one artifact to BuildOptions.OnEvent.
+type Event struct {
+	Kind     EventKind
+	Skill    string // the skill's name
+	Artifact string
+	Path     string // relative to the skill's OutputDir
+	Err      error  // set for EventFailed
+}
+
+// GitTarget is a git branch a build's output directories are committed to.
+// The commit is made in a temporary worktree, so the working tree and the
+// checked out branch are left alone. The branch is created if missing.
+type GitTarget struct {
+	Branch string
+	Remote string // pushed the branch after committing, when set
+	// Message is the commit message; {name} is replaced by the skill names
+	// and {changelog} by the new changelog entries. Empty uses
+	// DefaultGitMessage.
+	Message string
+}
+
+// DefaultGitMessage is the GitTarget commit message template.
+const DefaultGitMessage = "Update {name} skill\n\n{changelog}"
+
+// BuildResult describes a finished build.
+type BuildResult struct {
+	Provider string // empty when no provider was needed
+	Model    string
+	Skills   []SkillResult
+	// GitCommit is the commit made on the GitTarget branch; empty when
+	// there is no target or the branch already matched.
+	GitCommit string
+	// Unchanged is set when SinceCommit found no input changed; Skills is
+	// then empty.
+	Unchanged bool
+}
+
+// SkillResult describes one skill of a build.
+type SkillResult struct {
+	Name      string
+	OutputDir string
+	// Artifacts lists every artifact attempted this run, in artifact order.
+	// Artifacts found up to date in the lockfile are counted in Cached.
+	Artifacts []Artifact
+	// Files holds the files written this run, keyed by path relative to
+	// OutputDir.
+	Files    map[string][]byte
+	Cached   int
+	UpToDate bool // every artifact was a cache hit; nothing was generated
+	Warnings []Warning
+	// NewWarnings counts warnings and errors not recorded by the previous
+	// build; info diagnostics don't count.
+	NewWarnings int
+	// CachedArtifacts are the artifacts Cached counts, with their ID and
+	// path only.
+	CachedArtifacts []Artifact
+}
+
+// Artifact is the outcome of generating one artifact.
+type Artifact struct {
+	ID        string
+	Path      string // relative to the skill's OutputDir
+	Content   string // empty when skipped
+	Err       error
+	Model     string
+	TokensIn  int
+	TokensOut int
+	// Provider names the provider that generated the artifact; with
+	// fallback providers it may differ between artifacts.
+	Provider string
+	// RequestID and FinishReason are the provider's, for matching a
+	// generation in its dashboards; see provider.GenerateResponse.
+	RequestID    string
+	FinishReason string
+}
+
+// Generated counts artifacts generated successfully this run.
+func (s SkillResult) Generated() int {
+	n := 0
+	for _, a := range s.Artifacts {
+		if a.Err == nil && a.Content != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the artifacts that failed this run.
+func (s SkillResult) Failed() []Artifact {
+	var failed []Artifact
+	for _, a := range s.Artifacts {
+		if a.Err != nil {
+			failed = append(failed, a)
+		}
+	}
+	return failed
+}
+
+// RegisterTokenizer makes a token counter selectable by name with the
+// frontmatter's provider.tokenizer, replacing a built-in one of the same
+// name ("heuristic" or "tiktoken"). Use it to plug in an exact tokenizer.
+func RegisterTokenizer(name string, count func(text string) int) {
+	provider.RegisterTokenizer(name, provider.TokenizerFunc(count))
+}
+
+// Build parses the instructions, processes each skill's spec sources, and
+// generates the artifacts that are not already up to date. On error, the
+// returned result holds the skills completed before the failure, then the
+// failing skill with the artifacts it attempted. Cancelling ctx, e.g. at a
+// deadline, stops spec fetches and provider calls; artifacts finished by
+// then are already written.
+func Build(ctx context.Context, opts BuildOptions) (*BuildResult, error) {
+	if opts.PromptOnly {
+		opts.DryRun = true
+	}
+	if opts.Plan {
+		opts.ReadOnly = true
+	}
+	b := &builder{opts: opts, log: opts.Log, errLog: opts.ErrLog}
+	if b.log == nil {
+		b.log = io.Discard
+	}
+	if b.errLog == nil {
+		b.errLog = io.Discard
+	}
+	return b.build(ctx)
+}
+
+// builder holds the state of one Build.
+type builder struct {
+	opts        BuildOptions
+	log, errLog io.Writer
+	prov        provider.Provider
+	lockFile    *cache.LockFile
+	dir         string
+	seeds       []generate.SeedExample
+	tokenizer   provider.Tokenizer
+	model       string // configured, for the lockfile's per-input hashes
+	// warningsChanged is set when a skill reported a diagnostic the
+	// lockfile did not record, so the lockfile is saved.
+	warningsChanged bool
+}
+
+// watchFallback lists a build's fallback providers and reports when the
+// build switches to one.
+func (b *builder) watchFallback(f *provider.Fallback, resolved *config.Resolved) {
+	names := []string{providerLabel(resolved)}
+	for _, fallback := range resolved.Fallbacks {
+		names = append(names, providerLabel(fallback))
+	}
+	fmt.Fprintf(b.log, "Fallback providers: %s\n", strings.Join(names[1:], ", "))
+	f.OnSwitch = func(from, to int, err error) {
+		fmt.Fprintf(b.errLog, "WARNING: %s is unavailable (%s); using %s for the remaining artifacts\n", names[from], err, names[to])
+	}
+}
+
+// providerLabel names a resolved provider and its model, e.g.
+// "openai (model: gpt-4o)".
+func providerLabel(resolved *config.Resolved) string {
+	name := cmp.Or(resolved.Provider, resolved.BaseURL, "anthropic")
+	if model, err := provider.ModelFor(resolved); err == nil {
+		return fmt.Sprintf("%s (model: %s)", name, model)
+	}
+	return name
+}
+
+// fs returns the filesystem holding the output directories.
+func (b *builder) fs() FS {
+	if b.opts.FS != nil {
+		return b.opts.FS
+	}
+	return generate.OSFS{}
+}
+
+func (b *builder) build(ctx context.Context) (*BuildResult, error) {
+	opts := b.opts
+	var inst *instructions.Instructions
+	var err error
+	if opts.Instructions != nil {
+		inst, err = instructions.ParseBytesVariants(opts.Instructions, opts.Variants)
+	} else {
+		var data []byte
+		data, err = os.ReadFile(opts.InstructionsPath)
+		if err != nil {
+			return nil, fmt.Errorf("reading instructions file: %w", err)
+		}
+		inst, err = instructions.ParseBytesVariants(data, opts.Variants)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.InstructionsPath != "" && opts.InstructionsPath != "-" {
+		inst.Dir = filepath.Dir(opts.InstructionsPath)
+	}
+	skills := inst.Skills()
+	multi := len(inst.Frontmatter.Skills) > 0
+	if multi && opts.Spec != "" {
+		return nil, fmt.Errorf("a spec override cannot be used with a multi-skill instructions file")
+	}
+	if opts.ArtifactsFrom != "" {
+		if len(opts.Only) > 0 && !slices.Equal(opts.Only, []string{string(generate.ArtifactChangelog)}) {
+			return nil, fmt.Errorf("artifacts-from generates only the changelog; it cannot be combined with only")
+		}
+		b.opts.Only = []string{string(generate.ArtifactChangelog)}
+		opts = b.opts
+	} else if opts.PreviousFrom != "" {
+		return nil, fmt.Errorf("previous-from requires artifacts-from")
+	}
+
+	// Skip everything, spec parsing included, when no input changed in git
+	if opts.SinceCommit != "" && !opts.Force {
+		changed, err := b.changedSince(ctx, skills)
+		if err != nil {
+			return nil, fmt.Errorf("since-commit: %w", err)
+		}
+		if !changed {
+			fmt.Fprintf(b.log, "No inputs changed since %s\n", opts.SinceCommit)
+			return &BuildResult{Unchanged: true}, nil
+		}
+	}
+
+	if opts.SeedExamples != "" {
+		b.seeds, err = generate.LoadSeedExamples(opts.SeedExamples)
+		if err != nil {
+			return nil, fmt.Errorf("loading seed examples: %w", err)
+		}
+	}
+
+	// Resolve provider (shared by all skills)
+	fmProvider := &config.Config{
+		Provider: inst.Frontmatter.Provider.Provider,
+		Model:    inst.Frontmatter.Provider.Model,
+		APIKey:   inst.Frontmatter.Provider.APIKey,
+		BaseURL:  inst.Frontmatter.Provider.BaseURL,
+	}
+	for _, fb := range inst.Frontmatter.Provider.FallbackProviders {
+		fmProvider.FallbackProviders = append(fmProvider.FallbackProviders, config.Config{
+			Provider: fb.Provider,
+			Model:    fb.Model,
+			APIKey:   fb.APIKey,
+			BaseURL:  fb.BaseURL,
+		})
+	}
+	resolved, err := config.Resolve(opts.Provider.Provider, opts.Provider.Model, opts.Provider.APIKey, opts.Provider.BaseURL, fmProvider)
+	if err != nil {
+		return nil, fmt.Errorf("resolving provider config: %w", err)
+	}
+
+	model, _ := provider.ModelFor(resolved)
+	b.model = model
+	b.tokenizer, err = provider.TokenizerFor(resolved.Provider, model, inst.Frontmatter.Provider.Tokenizer)
+	if err != nil {
+		return nil, fmt.Errorf("provider.tokenizer: %w", err)
+	}
+
+	// Create provider (unless dry-run, offline, or planning, where it is
+	// never called)
+	result := &BuildResult{}
+	if !opts.DryRun && !opts.Offline && !opts.Plan {
+		b.prov, err = provider.New(resolved)
+		if err != nil {
+			return nil, err
+		}
+		result.Provider, result.Model = b.prov.Name(), model
+		fmt.Fprintf(b.log, "Using provider: %s (model: %s)\n", b.prov.Name(), model)
+		if f, ok := b.prov.(*provider.Fallback); ok {
+			b.watchFallback(f, resolved)
+		}
+	}
+
+	// The lockfile is shared; multi-skill builds namespace entries by skill name
+	b.dir = opts.Dir
+	if b.dir == "" {
+		b.dir, _ = os.Getwd()
+	}
+	b.lockFile, _ = cache.LoadLockFile(b.dir)
+
+	// Check the git target before spending any provider calls
+	publish := opts.GitTarget != nil && !opts.DryRun && !opts.Diff && !opts.ReadOnly
+	if publish {
+		if opts.NoWrite || opts.FS != nil {
+			return nil, fmt.Errorf("git target: the outputs must be written to disk")
+		}
+		if _, err := gittarget.Root(ctx, b.dir); err != nil {
+			return nil, fmt.Errorf("git target: %w", err)
+		}
+	}
+
+	for _, sk := range skills {
+		outputDir := sk.Frontmatter.Out
+		cachePrefix := ""
+		snapshots := snapshotDirs{current: opts.ArtifactsFrom, previous: opts.PreviousFrom}
+		if multi {
+			fmt.Fprintf(b.log, "\n== %s ==\n", sk.Frontmatter.Name)
+			cachePrefix = sk.Frontmatter.Name + "/"
+			if opts.OutputDir != "" {
+				outputDir = filepath.Join(opts.OutputDir, sk.Slug())
+			}
+			for _, dir := range []*string{&snapshots.current, &snapshots.previous} {
+				if *dir != "" {
+					*dir = filepath.Join(*dir, sk.Slug())
+				}
+			}
+		} else if opts.OutputDir != "" {
+			outputDir = opts.OutputDir
+		}
+
+		var sources []instructions.SpecSource
+		if opts.Spec != "" {
+			sources = []instructions.SpecSource{specOverride(opts.Spec)}
+		} else {
+			sources, err = sk.ResolveSpecSources()
+			if err != nil {
+				return result, instructions.SkillError(multi, sk, fmt.Errorf("resolving spec sources: %w", err))
+			}
+		}
+
+		sr, err := b.buildSkill(ctx, sk, sources, outputDir, cachePrefix, snapshots)
+		if err != nil {
+			result.Skills = append(result.Skills, sr)
+			return result, instructions.SkillError(multi, sk, err)
+		}
+		result.Skills = append(result.Skills, sr)
+	}
+
+	if opts.DryRun || opts.Diff || opts.ReadOnly {
+		return result, nil
+	}
+
+	// Save when anything was generated or the recorded warnings changed
+	save := b.warningsChanged
+	for _, sr := range result.Skills {
+		save = save || sr.Generated() > 0
+	}
+	if save {
+		_ = cache.SaveLockFile(b.dir, b.lockFile)
+	}
+	if publish {
+		if result.GitCommit, err = b.publish(ctx, result); err != nil {
+			return result, fmt.Errorf("git target: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// specOverride is the spec source the Spec option names: a URL when it has
+// an http or https scheme, otherwise a file path.
+func specOverride(spec string) instructions.SpecSource {
+	if strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://") {
+		return instructions.SpecSource{URL: spec}
+	}
+	return instructions.SpecSource{Path: spec}
+}
+
+// changedSince reports whether any input of the skills changed in git since
+// opts.SinceCommit: the instructions file, the seed examples, or a local
+// spec path. Other spec sources cannot be checked and count as changed.
+func (b *builder) changedSince(ctx context.Context, skills []*instructions.Instructions) (bool, error) {
+	opts := b.opts
+	if opts.InstructionsPath == "" || opts.InstructionsPath == "-" {
+		return false, fmt.Errorf("an instructions file is required")
+	}
+	paths := []string{opts.InstructionsPath}
+	if opts.SeedExamples != "" {
+		paths = append(paths, opts.SeedExamples)
+	}
+	for _, sk := range skills {
+		sources := []instructions.SpecSource{specOverride(opts.Spec)}
+		if opts.Spec == "" {
+			var err error
+			if sources, err = sk.ResolveSpecSources(); err != nil {
+				return false, fmt.Errorf("resolving spec sources: %w", err)
+			}
+		}
+		for _, src := range sources {
+			if src.Path == "" {
+				fmt.Fprintf(b.log, "Spec source %s is not a local path; building\n", src)
+				return true, nil
+			}
+			paths = append(paths, src.Path)
+		}
+	}
+	for i, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return false, err
+		}
+		paths[i] = abs
+	}
+	changed, err := gittarget.Changed(ctx, filepath.Dir(paths[0]), opts.SinceCommit, paths)
+	if err != nil {
+		return false, err
+	}
+	if len(changed) > 0 {
+		fmt.Fprintf(b.log, "Changed since %s: %s\n", opts.SinceCommit, strings.Join(changed, ", "))
+	}
+	return len(changed) > 0, nil
+}
+
+// publish commits the skills' output directories to the git target, with a
+// message made from the template and the new changelog entries.
+func (b *builder) publish(ctx context.Context, result *BuildResult) (string, error) {
+	target := b.opts.GitTarget
+	var names, entries, dirs []string
+	for _, sr := range result.Skills {
+		names = append(names, sr.Name)
+		for _, a := range sr.Artifacts {
+			if a.ID == string(generate.ArtifactChangelog) && a.Err == nil {
+				if entry := generate.LatestChangelogEntry(a.Content); entry != "" {
+					if len(result.Skills) > 1 {
+						entry = sr.Name + ":\n\n" + entry
+					}
+					entries = append(entries, entry)
+				}
+			}
+		}
+		if _, err := os.Stat(sr.OutputDir); err == nil && !slices.Contains(dirs, sr.OutputDir) {
+			dirs = append(dirs, sr.OutputDir)
+		}
+	}
+	if len(dirs) == 0 {
+		return "", nil
+	}
+
+	message := target.Message
+	if message == "" {
+		message = DefaultGitMessage
+	}
+	message = strings.NewReplacer("{name}", strings.Join(names, ", "), "{changelog}", strings.Join(entries, "\n\n")).Replace(message)
+	commit, err := gittarget.Publish(ctx, b.dir, gittarget.Target{Branch: target.Branch, Remote: target.Remote}, dirs, strings.TrimSpace(message)+"\n")
+	if commit != "" {
+		fmt.Fprintf(b.log, "Committed outputs to branch %s (%.12s)\n", target.Branch, commit)
+	}
+	return commit, err
+}
+
+// snapshotDirs are the artifact directories a changelog-only build
+// compares; see BuildOptions.ArtifactsFrom.
+type snapshotDirs struct {
+	current, previous string
+}
+
+// buildSkill parses one skill's specs, generates its uncached artifacts, and
+// writes them to outputDir, updating lockfile entries under cachePrefix.
+func (b *builder) buildSkill(ctx context.Context, inst *instructions.Instructions, sources []instructions.SpecSource,
+	outputDir, cachePrefix string, snapshots snapshotDirs) (SkillResult, error) {
+	opts := b.opts
+	summary := SkillResult{Name: inst.Frontmatter.Name, OutputDir: outputDir}
+
+	// Process specs through plugin pipeline
+	fmt.Fprintln(b.log, "Parsing spec sources...")
+	reg := plugins.NewRegistry()
+	reg.Offline = opts.Offline
+	if !opts.ReadOnly {
+		reg.SpecCacheDir = filepath.Join(cache.CacheDir(b.dir), "specs")
+	}
+	parsedIR, warnings, err := reg.ProcessSources(ctx, sources)
+	if err != nil {
+		return summary, fmt.Errorf("processing specs: %w", err)
+	}
+	summary.Warnings = append(inst.Validate(), warnings...)
+	summary.Warnings = append(summary.Warnings, derivedSectionWarnings(inst, parsedIR)...)
+	// An empty IR usually means a broken spec source; generating from it
+	// would only produce invented docs
+	emptySpecErr := parsedIR.Empty() && inst.Frontmatter.EmptySpec == instructions.EmptySpecError
+	if parsedIR.Empty() && !emptySpecErr {
+		summary.Warnings = append(summary.Warnings, Warning{
+			Severity: diag.SeverityWarning,
+			Code:     "empty-spec",
+			Message:  "spec sources yielded no operations or types; skipping the reference, llms-api, and examples (check the spec path and type, or set empty-spec: error to fail)",
+		})
+	}
+	_ = diag.Write(b.errLog, diag.FormatText, summary.Warnings)
+	if emptySpecErr {
+		return summary, fmt.Errorf("spec sources yielded no operations or types; check the spec path and type")
+	}
+	fmt.Fprintf(b.log, "Parsed %d operations, %d types, %d auth schemes\n",
+		len(parsedIR.Operations), len(parsedIR.Types), len(parsedIR.Auth))
+	if len(b.seeds) > 0 {
+		_, unmatched := generate.MatchSeedExamples(parsedIR.Operations, b.seeds)
+		fmt.Fprintf(b.log, "Seed examples: %d of %d match an operation\n", len(b.seeds)-len(unmatched), len(b.seeds))
+	}
+
+	// Expand {name}/{date}/{version}; the previous build may live elsewhere
+	outVars := generate.OutVars{Name: inst.Frontmatter.Name, Version: parsedIR.Metadata["version"], Date: time.Now()}
+	prevDir := generate.PreviousOutputDir(outputDir, outVars)
+	outputDir = generate.ExpandOut(outputDir, outVars)
+	summary.OutputDir = outputDir
+	// Every build compares the warnings with the lockfile; only builds that
+	// save it record them
+	added := b.lockFile.SetWarnings(inst.Frontmatter.Name, warningEntry(summary.Warnings))
+	b.warningsChanged = b.warningsChanged || len(added) > 0
+	for _, m := range added {
+		if !strings.HasPrefix(m, string(diag.SeverityInfo)+": ") {
+			summary.NewWarnings++
+		}
+	}
+
+	// Load previous artifacts for changelog
+	prevArtifacts := generate.LoadPreviousArtifactsFS(b.fs(), prevDir, inst.Frontmatter.Name)
+	var currentArtifacts map[generate.ArtifactID]string
+	if snapshots.current != "" {
+		currentArtifacts = generate.LoadPreviousArtifactsFS(b.fs(), snapshots.current, inst.Frontmatter.Name)
+		if len(currentArtifacts) == 0 {
+			return summary, fmt.Errorf("no artifacts found in %s", snapshots.current)
+		}
+		if snapshots.previous != "" {
+			// The entry still goes on the output directory's changelog
+			changelog := prevArtifacts[generate.ArtifactChangelog]
+			prevArtifacts = generate.LoadPreviousArtifactsFS(b.fs(), snapshots.previous, inst.Frontmatter.Name)
+			prevArtifacts[generate.ArtifactChangelog] = changelog
+		}
+		fmt.Fprintf(b.log, "Comparing artifacts in %s with the previous ones\n", snapshots.current)
+	}
+	// Outputs are compared and extended without the file header, which is
+	// added again as they are written
+	header, err := inst.FileHeader()
+	if err != nil {
+		return summary, err
+	}
+	generate.StripFileHeaders(prevArtifacts, header)
+	generate.StripFileHeaders(currentArtifacts, header)
+
+	units := []skillUnit{{inst: inst, ir: parsedIR, cachePrefix: cachePrefix, prev: prevArtifacts, current: currentArtifacts,
+		artifacts: generate.AllArtifacts, only: opts.Only}}
+	var index []generate.GroupSkill
+	if inst.Frontmatter.Mode == instructions.ModePerGroup && snapshots.current == "" {
+		units, index = groupUnits(inst, parsedIR, cachePrefix, prevArtifacts, opts.Only)
+		fmt.Fprintf(b.log, "Building %d group skills\n", len(index))
+	}
+
+	// Files are always collected in memory, and also written to the output
+	// directory unless NoWrite or ReadOnly is set
+	files := generate.NewMemorySink()
+	var sink generate.Sink = files
+	if !opts.NoWrite && !opts.ReadOnly {
+		sink = generate.TeeSink{files, generate.FSSink{FS: b.fs(), Dir: outputDir}}
+	}
+	if header != "" {
+		sink = generate.HeaderSink{Sink: sink, Header: header}
+	}
+
+	upToDate := true
+	for _, u := range units {
+		if len(units) > 1 && u.dir != "" {
+			fmt.Fprintf(b.log, "\n-- %s --\n", u.dir)
+		}
+		unitUpToDate, err := b.generateUnit(ctx, u, outputDir, sink, &summary)
+		if err != nil {
+			return summary, err
+		}
+		upToDate = upToDate && unitUpToDate
+		if !unitUpToDate && opts.EnrichWriteBack && !opts.ReadOnly && !opts.DryRun && !opts.Diff {
+			b.writeBackDescriptions(reg, sources, u.ir)
+		}
+	}
+	if upToDate {
+		summary.UpToDate = true
+		return summary, nil
+	}
+	if opts.DryRun || opts.Diff || opts.Plan {
+		return summary, nil
+	}
+
+	if len(index) > 0 {
+		content := generate.GroupSkillIndex(inst, index)
+		if err := sink.WriteFile(filepath.Join(inst.Slug(), "SKILL.md"), []byte(content), 0o644); err != nil {
+			return summary, fmt.Errorf("writing skill index: %w", err)
+		}
+	}
+
+	summary.Files = make(map[string][]byte)
+	for _, path := range files.Paths() {
+		summary.Files[path], _ = files.File(path)
+	}
+	return summary, nil
+}
+
+// skillUnit is one pipeline run of a skill build. A single-mode skill is one
+// unit; a per-group skill is a unit per group plus one for the artifacts
+// that cover the whole API.
+type skillUnit struct {
+	inst        *instructions.Instructions
+	ir          *ir.IntermediateRepr
+	dir         string // skill directory relative to the output directory; empty uses the slug
+	cachePrefix string
+	prev        map[generate.ArtifactID]string
+	artifacts   []generate.ArtifactID // checked against the cache
+	only        []string
+	// current are the artifacts a changelog-only build compares prev with
+	current map[generate.ArtifactID]string
+}
+
+// groupArtifacts are generated once per group in per-group mode; the rest
+// are generated once for the whole API.
+var groupArtifacts = []generate.ArtifactID{
+	generate.ArtifactSkill, generate.ArtifactReference, generate.ArtifactExamples, generate.ArtifactScripts,
+}
+
+// groupUnits splits a per-group skill into a unit per IR group, each with
+// its operations and the types they use, under <slug>/<group>/, and a unit
+// for the llms files and changelog. only is the build's --only filter.
+func groupUnits(inst *instructions.Instructions, parsedIR *ir.IntermediateRepr, cachePrefix string,
+	prev map[generate.ArtifactID]string, only []string) ([]skillUnit, []generate.GroupSkill) {
+	enabled := func(ids []generate.ArtifactID, in bool) (out []generate.ArtifactID, names []string) {
+		for _, id := range generate.AllArtifacts {
+			if slices.Contains(ids, id) != in {
+				continue
+			}
+			if len(only) > 0 && !slices.Contains(only, string(id)) ||
+				len(only) == 0 && !inst.Frontmatter.ArtifactEnabled(string(id)) {
+				continue
+			}
+			out = append(out, id)
+			names = append(names, string(id))
+		}
+		return out, names
+	}
+
+	var units []skillUnit
+	var index []generate.GroupSkill
+	groupIDs, groupOnly := enabled(groupArtifacts, true)
+	seen := make(map[string]int)
+	for _, g := range parsedIR.Groups {
+		if len(g.Operations) == 0 {
+			continue
+		}
+		slug := instructions.Slug(g.Name)
+		if seen[slug]++; seen[slug] > 1 {
+			slug = fmt.Sprintf("%s-%d", slug, seen[slug])
+		}
+		groupInst := *inst
+		groupInst.Frontmatter.Name = inst.Frontmatter.Name + "-" + slug
+		index = append(index, generate.GroupSkill{Group: g, Dir: slug})
+		if len(groupIDs) == 0 {
+			continue
+		}
+		units = append(units, skillUnit{
+			inst:        &groupInst,
+			ir:          parsedIR.Subset(g.Operations).WithUsedTypes(),
+			dir:         filepath.Join(inst.Slug(), slug),
+			cachePrefix: cachePrefix + slug + "/",
+			artifacts:   groupIDs,
+			only:        groupOnly,
+		})
+	}
+	if ids, names := enabled(groupArtifacts, false); len(ids) > 0 {
+		units = append(units, skillUnit{inst: inst, ir: parsedIR, cachePrefix: cachePrefix, prev: prev, artifacts: ids, only: names})
+	}
+	return units, index
+}
+
+// generateUnit runs the pipeline for one unit: it skips artifacts the cache
+// has, writes and records the rest, and adds them to summary. It reports
+// whether every artifact was cached.
+func (b *builder) generateUnit(ctx context.Context, u skillUnit, outputDir string, sink generate.Sink, summary *SkillResult) (bool, error) {
+	opts := b.opts
+	irJSON, _ := u.ir.CanonicalJSON()
+	specContent := string(irJSON)
+
+	// Build pipeline
+	pipeline := &generate.Pipeline{
+		Provider: b.prov,
+		IR:       u.ir,
+		Inst:     u.inst,
+		Opts: generate.Options{
+			OutputDir:       outputDir,
+			Only:            u.only,
+			Force:           opts.Force,
+			DryRun:          opts.DryRun,
+			Diff:            opts.Diff,
+			Verbose:         opts.Verbose,
+			PrevArtifacts:   u.prev,
+			Offline:         opts.Offline,
+			Enrich:          opts.Enrich,
+			ContinueOnError: opts.ContinueOnError,
+			MaxTokens:       opts.MaxTokens,
+			SeedExamples:    b.seeds,
+			OutputFormat:    opts.OutputFormat,
+			IncludeRawHelp:  opts.IncludeRawHelp,
+			PromptOnly:      opts.PromptOnly,
+			Tokenizer:       b.tokenizer,
+			Log:             b.log,
+			SkillDir:        u.dir,
+			EmptySpec:       u.ir.Empty(),
+		},
+	}
+
+	// Check cache per artifact — skip unchanged ones unless forced. A
+	// changelog from artifact snapshots does not depend on the hashed inputs.
+	skipArtifact := make(map[generate.ArtifactID]bool)
+	if !opts.Force && !opts.DryRun && u.current == nil {
+		fmt.Fprintln(b.log, "Checking cache...")
+		enabled := pipeline.EnabledArtifacts()
+		allUpToDate := true
+		for _, id := range u.artifacts {
+			if pipeline.SkipForEmptySections(id) || pipeline.SkipForEmptySpec(id) {
+				continue
+			}
+			prompt := pipeline.SystemPromptFor(id)
+			sections := pipeline.RelevantSections(id)
+			inputHash := cache.HashInput(specContent, sections, prompt)
+			if opts.ExplainCache {
+				inputs := cache.HashInputs(specContent, sections, prompt, b.model)
+				fmt.Fprintf(b.log, "  %s: %s\n", id, b.lockFile.ExplainCache(u.cachePrefix+string(id), inputHash, inputs))
+			}
+			if b.lockFile.IsUpToDate(u.cachePrefix+string(id), inputHash) {
+				skipArtifact[id] = true
+				summary.Cached++
+				summary.CachedArtifacts = append(summary.CachedArtifacts, Artifact{ID: string(id), Path: pipeline.ArtifactPath(id)})
+				if slices.Contains(enabled, id) {
+					b.emit(Event{Kind: EventCached, Skill: u.inst.Frontmatter.Name, Artifact: string(id), Path: pipeline.ArtifactPath(id)})
+				}
+			} else {
+				allUpToDate = false
+			}
+		}
+		if allUpToDate && !opts.Plan {
+			return true, nil
+		}
+	}
+	pipeline.Opts.SkipArtifacts = skipArtifact
+	pipeline.Opts.CurrentArtifacts = u.current
+	if opts.Plan {
+		pending := pipeline.NeedsGeneration()
+		for _, id := range pipeline.EnabledArtifacts() {
+			e := Event{Kind: EventSkipped, Skill: u.inst.Frontmatter.Name, Artifact: string(id), Path: pipeline.ArtifactPath(id)}
+			switch {
+			case skipArtifact[id]:
+				continue // reported as cached above
+			case slices.Contains(pending, id):
+				e.Kind = EventPending
+			}
+			b.emit(e)
+		}
+		return len(pending) == 0, nil
+	}
+	if opts.OnEvent != nil {
+		name := u.inst.Frontmatter.Name
+		pipeline.Opts.OnStart = func(id generate.ArtifactID) {
+			b.emit(Event{Kind: EventStarted, Skill: name, Artifact: string(id), Path: pipeline.ArtifactPath(id)})
+		}
+		pipeline.Opts.OnFinish = func(r generate.ArtifactResult) {
+			e := Event{Kind: EventDone, Skill: name, Artifact: string(r.ID), Path: r.FilePath, Err: r.Err}
+			switch {
+			case r.Err != nil:
+				e.Kind = EventFailed
+			case skipArtifact[r.ID]:
+				return // reported as cached above
+			case r.Content == "":
+				e.Kind = EventSkipped
+			}
+			b.emit(e)
+		}
+	}
+
+	// Record each artifact in the cache and lockfile as it completes, so a
+	// rerun after a failure resumes with only the failed and remaining ones
+	var lockMu sync.Mutex
+	record := func(r generate.ArtifactResult) {
+		prompt := pipeline.SystemPromptFor(r.ID)
+		sections := pipeline.RelevantSections(r.ID)
+		inputHash := cache.HashInput(specContent, sections, prompt)
+		inputs := cache.HashInputs(specContent, sections, prompt, b.model)
+		outputHash := cache.HashOutput(r.Content)
+		providerName, model := "", ""
+		if r.Response != nil {
+			providerName, model = r.Response.Provider, r.Response.Model
+		}
+		key := u.cachePrefix + string(r.ID)
+		lockMu.Lock()
+		defer lockMu.Unlock()
+		b.lockFile.UpdateEntry(key, inputHash, outputHash, providerName, model, inputs)
+		_ = cache.WriteCached(b.dir, key, r.Content)
+	}
+	if !opts.DryRun && !opts.Diff && !opts.ReadOnly {
+		pipeline.Opts.OnArtifact = func(r generate.ArtifactResult) {
+			// The changelog is prepended to the previous one before recording
+			if r.ID == generate.ArtifactChangelog {
+				return
+			}
+			if err := generate.WriteResultsTo(sink, []generate.ArtifactResult{r}); err != nil {
+				return // recorded only once written, so the rerun regenerates it
+			}
+			record(r)
+			lockMu.Lock()
+			defer lockMu.Unlock()
+			_ = cache.SaveLockFile(b.dir, b.lockFile)
+		}
+	}
+
+	// Run generation
+	fmt.Fprintln(b.log, "Generating artifacts...")
+	results, err := pipeline.Run(ctx)
+	if err != nil {
+		summary.Artifacts = append(summary.Artifacts, artifacts(results)...)
+		return false, err
+	}
+
+	// Display results, naming each artifact's provider when fallbacks
+	// produced some of them
+	mixed := mixedProviders(results)
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(b.errLog, "ERROR generating %s: %s\n", r.ID, r.Err)
+			continue
+		}
+		status := "generated"
+		if r.Content == "" {
+			status = "skipped"
+		}
+		tokenInfo := ""
+		if opts.Verbose && r.Response != nil {
+			tokenInfo = fmt.Sprintf(" (in: %d, out: %d tokens)", r.Response.TokensIn, r.Response.TokensOut)
+		}
+		if mixed != nil && r.Response != nil {
+			tokenInfo += fmt.Sprintf(" [%s]", r.Response.Provider)
+		}
+		fmt.Fprintf(b.log, "  %s: %s%s\n", r.ID, status, tokenInfo)
+	}
+	if mixed != nil {
+		_ = diag.Write(b.errLog, diag.FormatText, []Warning{*mixed})
+		summary.Warnings = append(summary.Warnings, *mixed)
+	}
+
+	// Lint the generated SKILL.md description and the scripts' env vars so
+	// authors can re-prompt
+	for _, r := range results {
+		if r.Err != nil || r.Content == "" {
+			continue
+		}
+		var lint []Warning
+		switch r.ID {
+		case generate.ArtifactSkill:
+			lint = generate.LintSkill(r.Content, filepath.Join(outputDir, r.FilePath), generate.LintConfig(u.inst.Frontmatter))
+		case generate.ArtifactScripts:
+			lint = generate.LintScripts(r.Content, filepath.Join(outputDir, r.FilePath), u.inst)
+		}
+		_ = diag.Write(b.errLog, diag.FormatText, lint)
+		summary.Warnings = append(summary.Warnings, lint...)
+	}
+
+	if opts.DryRun {
+		summary.Artifacts = append(summary.Artifacts, artifacts(results)...)
+		return false, nil
+	}
+
+	// Handle diff mode
+	if opts.Diff {
+		fmt.Fprintln(b.log, "\nDiff mode — showing changes without writing:")
+		header, _ := u.inst.FileHeader()
+		for _, r := range results {
+			if r.Content == "" || r.Err != nil {
+				continue
+			}
+			existing, err := b.fs().ReadFile(filepath.Join(outputDir, r.FilePath))
+			if err != nil {
+				fmt.Fprintf(b.log, "\n--- %s (new file) ---\n", r.FilePath)
+			} else if string(existing) != generate.AddFileHeader(r.FilePath, r.Content, header) {
+				fmt.Fprintf(b.log, "\n--- %s (changed) ---\n", r.FilePath)
+			}
+		}
+		summary.Artifacts = append(summary.Artifacts, artifacts(results)...)
+		return false, nil
+	}
+
+	// Artifacts were written and recorded as they finished unless
+	// OnArtifact was unset; the changelog is always written here, once
+	// prepended to the previous one
+	streamed := pipeline.Opts.OnArtifact != nil
+	if !streamed {
+		var rest []generate.ArtifactResult
+		for _, r := range results {
+			if r.ID != generate.ArtifactChangelog {
+				rest = append(rest, r)
+			}
+		}
+		if err := generate.WriteResultsTo(sink, rest); err != nil {
+			return false, fmt.Errorf("writing artifacts: %w", err)
+		}
+	}
+	for i, r := range results {
+		if r.ID == generate.ArtifactChangelog && r.Content != "" {
+			existingChangelog := u.prev[generate.ArtifactChangelog]
+			results[i].Content = generate.PrependChangelogEntry(r.Content, existingChangelog)
+			if err := sink.WriteFile(r.FilePath, []byte(results[i].Content), 0o644); err != nil {
+				return false, fmt.Errorf("writing artifacts: %w", err)
+			}
+		}
+	}
+
+	// Update cache and lockfile entries
+	for _, r := range results {
+		if r.Err != nil || r.Content == "" || opts.ReadOnly || (streamed && r.ID != generate.ArtifactChangelog) {
+			continue
+		}
+		record(r)
+	}
+	summary.Artifacts = append(summary.Artifacts, artifacts(results)...)
+	return false, nil
+}
+
+// emit reports e to opts.OnEvent, if set.
+func (b *builder) emit(e Event) {
+	if b.opts.OnEvent != nil {
+		b.opts.OnEvent(e)
+	}
+}
+
+// mixedProviders returns a warning listing which provider generated which
+// artifacts when more than one did, or nil.
+func mixedProviders(results []generate.ArtifactResult) *Warning {
+	byProvider := make(map[string][]string)
+	for _, r := range results {
+		if r.Err == nil && r.Response != nil && r.Response.Provider != "" {
+			byProvider[r.Response.Provider] = append(byProvider[r.Response.Provider], string(r.ID))
+		}
+	}
+	if len(byProvider) < 2 {
+		return nil
+	}
+	names := make([]string, 0, len(byProvider))
+	for name := range byProvider {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = name + ": " + strings.Join(byProvider[name], ", ")
+	}
+	return &Warning{
+		Severity: diag.SeverityWarning,
+		Code:     "mixed-providers",
+		Message:  "artifacts were generated by different providers after a fallback (" + strings.Join(parts, "; ") + "); regenerate with --force once the primary is back for consistent output",
+	}
+}
+
+func artifacts(results []generate.ArtifactResult) []Artifact {
+	out := make([]Artifact, 0, len(results))
+	for _, r := range results {
+		a := Artifact{ID: string(r.ID), Path: r.FilePath, Content: r.Content, Err: r.Err}
+		if r.Response != nil {
+			a.Provider, a.Model, a.TokensIn, a.TokensOut = r.Response.Provider, r.Response.Model, r.Response.TokensIn, r.Response.TokensOut
+			a.RequestID, a.FinishReason = r.Response.RequestID, r.Response.FinishReason
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// derivedSectionWarnings flags the instruction sections the "docs"
+// empty-sections policy fills from the project docs, so authors review them.
+func derivedSectionWarnings(inst *instructions.Instructions, parsedIR *ir.IntermediateRepr) []Warning {
+	derived := generate.DerivedSections(inst, parsedIR)
+	names := make([]string, 0, len(derived))
+	for name := range derived {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var warnings []Warning
+	for _, name := range names {
+		warnings = append(warnings, Warning{
+			Severity: diag.SeverityWarning,
+			Code:     "derived-section",
+			Message:  fmt.Sprintf("# %s is empty; using content auto-derived from the project docs (review the output, or write the section)", name),
+		})
+	}
+	return warnings
+}
+
+// warningEntry summarizes diagnostics for the lockfile.
+func warningEntry(diags []diag.Diagnostic) cache.WarningEntry {
+	entry := cache.WarningEntry{Counts: make(map[string]int)}
+	for sev, n := range diag.Counts(diags) {
+		entry.Counts[string(sev)] = n
+	}
+	for _, d := range diags {
+		sev := d.Severity
+		if sev == "" {
+			sev = diag.SeverityWarning
+		}
+		entry.Messages = append(entry.Messages, fmt.Sprintf("%s: %s", sev, d))
+	}
+	sort.Strings(entry.Messages)
+	return entry
+}
+
+// writeBackDescriptions copies enriched descriptions into the OpenAPI spec
+// files they came from. Failures are reported but don't fail the build.
+func (b *builder) writeBackDescriptions(reg *ir.Registry, sources []instructions.SpecSource, parsedIR *ir.IntermediateRepr) {
+	for _, src := range sources {
+		if src.Path == "" {
+			continue
+		}
+		if plugin, err := reg.Detect(src); err != nil || plugin.Name() != "openapi" {
+			continue
+		}
+		n, err := openapi.ApplyDescriptions(src.Path, parsedIR.Operations)
+		if err != nil {
+			fmt.Fprintf(b.errLog, "WARNING: %s\n", err)
+			continue
+		}
+		if n > 0 {
+			fmt.Fprintf(b.log, "Wrote %d descriptions back to %s\n", n, src.Path)
+		}
+	}
+}
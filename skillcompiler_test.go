@@ -0,0 +1,544 @@
+package skillcompiler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/roberthamel/skill-compiler/internal/instructions"
+)
+
+func petstoreInstructions(t *testing.T) []byte {
+	t.Helper()
+	spec, err := filepath.Abs("internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(fmt.Sprintf("---\nname: pets\nspec: %s\nout: ./out/\n---\n# Product\nPets.\n", spec))
+}
+
+func TestBuild_ConcurrentInMemory(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	const builds = 3
+	results := make([]*BuildResult, builds)
+	errs := make([]error, builds)
+	dirs := make([]string, builds)
+	var wg sync.WaitGroup
+	for i := range builds {
+		dirs[i] = t.TempDir()
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = Build(context.Background(), BuildOptions{
+				Instructions: petstoreInstructions(t),
+				Dir:          dirs[i],
+				OutputDir:    filepath.Join(dirs[i], "out"),
+				Provider:     ProviderConfig{Provider: "openai", Model: "m", APIKey: "test", BaseURL: srv.URL},
+				Only:         []string{"skill", "llms"},
+				NoWrite:      true,
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range builds {
+		if errs[i] != nil {
+			t.Fatalf("build %d: %v", i, errs[i])
+		}
+		sr := results[i].Skills[0]
+		if sr.Generated() != 2 || len(sr.Failed()) != 0 {
+			t.Errorf("build %d: generated %d, failed %v", i, sr.Generated(), sr.Failed())
+		}
+		if got := string(sr.Files[filepath.Join("pets", "SKILL.md")]); got != "generated" {
+			t.Errorf("build %d: SKILL.md = %q, want in-memory content", i, got)
+		}
+		if _, err := os.Stat(sr.OutputDir); !os.IsNotExist(err) {
+			t.Errorf("build %d: NoWrite created %s", i, sr.OutputDir)
+		}
+		if _, err := os.Stat(filepath.Join(dirs[i], ".sc-lock.json")); err != nil {
+			t.Errorf("build %d: lockfile not written: %v", i, err)
+		}
+	}
+}
+
+func TestBuild_ReadOnlyWritesNothing(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	result, err := Build(context.Background(), BuildOptions{
+		Instructions: petstoreInstructions(t),
+		Dir:          dir,
+		OutputDir:    filepath.Join(dir, "out"),
+		Provider:     ProviderConfig{Provider: "openai", Model: "m", APIKey: "test", BaseURL: srv.URL},
+		Only:         []string{"skill"},
+		ReadOnly:     true,
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if got := string(result.Skills[0].Files[filepath.Join("pets", "SKILL.md")]); got != "generated" {
+		t.Errorf("SKILL.md = %q, want in-memory content", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		names := make([]string, len(entries))
+		for i, e := range entries {
+			names[i] = e.Name()
+		}
+		t.Errorf("ReadOnly build wrote %v", names)
+	}
+}
+
+func TestBuild_DryRunReportsWarnings(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	data := []byte(strings.Replace(string(petstoreInstructions(t)), "# Product\nPets.\n", "# Workflows\nNone.\n", 1))
+
+	result, err := Build(context.Background(), BuildOptions{Instructions: data, Dir: t.TempDir(), DryRun: true})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if result.Provider != "" {
+		t.Errorf("Provider = %q, want none for a dry run", result.Provider)
+	}
+	sr := result.Skills[0]
+	found := false
+	for _, w := range sr.Warnings {
+		found = found || w.Code == "missing-section"
+	}
+	if !found {
+		t.Errorf("Warnings = %v, want missing-section", sr.Warnings)
+	}
+	if len(sr.Artifacts) == 0 || sr.Generated() == 0 {
+		t.Errorf("Artifacts = %v, want dry-run estimates", sr.Artifacts)
+	}
+	if len(sr.Files) != 0 {
+		t.Errorf("Files = %v, want none for a dry run", sr.Files)
+	}
+}
+
+func TestBuild_MemoryFS(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	fsys := NewMemoryFS()
+	if err := fsys.WriteFile(filepath.Join(out, "CHANGELOG.md"), []byte("# Changelog\n\n## Earlier entry\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Build(context.Background(), BuildOptions{
+		Instructions: petstoreInstructions(t),
+		Dir:          dir,
+		OutputDir:    out,
+		Provider:     ProviderConfig{Provider: "openai", Model: "m", APIKey: "test", BaseURL: srv.URL},
+		Only:         []string{"skill", "changelog"},
+		FS:           fsys,
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if got, err := fsys.ReadFile(filepath.Join(out, "pets", "SKILL.md")); err != nil || string(got) != "generated" {
+		t.Errorf("SKILL.md in the FS = %q, %v", got, err)
+	}
+	if got, _ := fsys.ReadFile(filepath.Join(out, "CHANGELOG.md")); !strings.Contains(string(got), "## Earlier entry") {
+		t.Errorf("CHANGELOG.md should keep the entry read from the FS, got:\n%s", got)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("build with a MemoryFS created %s on disk", out)
+	}
+}
+
+func TestBuild_PerGroup(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	instructions := strings.Replace(string(petstoreInstructions(t)), "out: ./out/\n", "out: ./out/\nmode: per-group\n", 1)
+	result, err := Build(context.Background(), BuildOptions{
+		Instructions: []byte(instructions),
+		Dir:          dir,
+		OutputDir:    filepath.Join(dir, "out"),
+		Provider:     ProviderConfig{Provider: "openai", Model: "m", APIKey: "test", BaseURL: srv.URL},
+		Only:         []string{"skill", "llms"},
+		NoWrite:      true,
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	files := result.Skills[0].Files
+	for _, path := range []string{filepath.Join("pets", "pets", "SKILL.md"), "llms.txt"} {
+		if string(files[path]) != "generated" {
+			t.Errorf("%s = %q, want the generated content", path, files[path])
+		}
+	}
+	index := string(files[filepath.Join("pets", "SKILL.md")])
+	if !strings.Contains(index, "name: pets\n") || !strings.Contains(index, "- [pets](pets/SKILL.md)") ||
+		!strings.Contains(index, "(3 operations)") {
+		t.Errorf("index SKILL.md should link the group skill, got:\n%s", index)
+	}
+	if calls != 2 {
+		t.Errorf("got %d provider calls, want one for SKILL.md and one for llms.txt", calls)
+	}
+}
+
+func TestBuild_ArtifactsFrom(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	var mu sync.Mutex
+	var prompts []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		prompts = append(prompts, string(body))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	out, prev, current := filepath.Join(dir, "out"), filepath.Join(dir, "prev"), filepath.Join(dir, "current")
+	fsys := NewMemoryFS()
+	for path, content := range map[string]string{
+		filepath.Join(out, "CHANGELOG.md"):         "# Changelog\n\n## Earlier entry\n",
+		filepath.Join(prev, "pets", "SKILL.md"):    "old skill text",
+		filepath.Join(current, "pets", "SKILL.md"): "hand-edited skill text",
+	} {
+		if err := fsys.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := Build(context.Background(), BuildOptions{
+		Instructions:  petstoreInstructions(t),
+		Dir:           dir,
+		OutputDir:     out,
+		Provider:      ProviderConfig{Provider: "openai", Model: "m", APIKey: "test", BaseURL: srv.URL},
+		ArtifactsFrom: current,
+		PreviousFrom:  prev,
+		FS:            fsys,
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	if len(prompts) != 1 {
+		t.Fatalf("got %d provider calls, want only the changelog", len(prompts))
+	}
+	for _, want := range []string{"Previous skill\\nold skill text", "Current skill\\nhand-edited skill text"} {
+		if !strings.Contains(prompts[0], want) {
+			t.Errorf("changelog prompt should contain %q", want)
+		}
+	}
+	if got := result.Skills[0].Generated(); got != 1 {
+		t.Errorf("generated %d artifacts, want 1", got)
+	}
+	got, _ := fsys.ReadFile(filepath.Join(out, "CHANGELOG.md"))
+	if !strings.Contains(string(got), "generated") || !strings.Contains(string(got), "## Earlier entry") {
+		t.Errorf("CHANGELOG.md should gain an entry above the earlier one, got:\n%s", got)
+	}
+	if _, err := fsys.ReadFile(filepath.Join(out, "pets", "SKILL.md")); err == nil {
+		t.Error("SKILL.md should not be regenerated")
+	}
+}
+
+func TestBuild_ExplainCache(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	build := func(instructions []byte, model string) string {
+		var log strings.Builder
+		if _, err := Build(context.Background(), BuildOptions{
+			Instructions: instructions,
+			Dir:          dir,
+			OutputDir:    filepath.Join(dir, "out"),
+			Provider:     ProviderConfig{Provider: "openai", Model: model, APIKey: "test", BaseURL: srv.URL},
+			Only:         []string{"skill", "llms"},
+			ExplainCache: true,
+			Log:          &log,
+		}); err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		return log.String()
+	}
+
+	if log := build(petstoreInstructions(t), "m"); !strings.Contains(log, "skill: not cached: no lockfile entry") {
+		t.Errorf("first build should report no lockfile entries:\n%s", log)
+	}
+	if log := build(petstoreInstructions(t), "m2"); !strings.Contains(log, "llms: up to date (model changed from m to m2") {
+		t.Errorf("rebuild with another model should report the cache as up to date:\n%s", log)
+	}
+	edited := []byte(strings.Replace(string(petstoreInstructions(t)), "Pets.", "Pets and owners.", 1))
+	if log := build(edited, "m2"); !strings.Contains(log, "skill: changed: sections") || !strings.Contains(log, "llms: changed: sections") {
+		t.Errorf("an edited Product section should be reported:\n%s", log)
+	}
+}
+
+func TestBuild_EmptySpec(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "empty.yaml")
+	if err := os.WriteFile(spec, []byte("openapi: 3.0.0\ninfo:\n  title: Empty\n  version: \"1\"\npaths: {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	instructions := fmt.Sprintf("---\nname: pets\nspec: %s\nout: ./out/\n---\n# Product\nPets.\n", spec)
+	opts := BuildOptions{
+		Instructions: []byte(instructions),
+		Dir:          dir,
+		OutputDir:    filepath.Join(dir, "out"),
+		Provider:     ProviderConfig{Provider: "openai", Model: "m", APIKey: "test", BaseURL: srv.URL},
+		Only:         []string{"reference", "llms"},
+		NoWrite:      true,
+	}
+	result, err := Build(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	sr := result.Skills[0]
+	found := false
+	for _, w := range sr.Warnings {
+		found = found || w.Code == "empty-spec"
+	}
+	if !found {
+		t.Errorf("Warnings = %v, want empty-spec", sr.Warnings)
+	}
+	if calls != 1 || sr.Files["llms.txt"] == nil {
+		t.Errorf("calls = %d, files = %v, want only llms.txt generated", calls, sr.Files)
+	}
+
+	calls = 0
+	opts.Instructions = []byte(strings.Replace(instructions, "out: ./out/\n", "out: ./out/\nempty-spec: error\n", 1))
+	if _, err := Build(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "no operations or types") {
+		t.Errorf("Build with empty-spec: error = %v, want an empty spec error", err)
+	}
+	if calls != 0 {
+		t.Errorf("calls = %d, want none when the empty spec fails the build", calls)
+	}
+}
+
+func TestBuild_SinceCommit(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, kv := range [][2]string{
+		{"GIT_AUTHOR_NAME", "test"}, {"GIT_AUTHOR_EMAIL", "test@example.com"},
+		{"GIT_COMMITTER_NAME", "test"}, {"GIT_COMMITTER_EMAIL", "test@example.com"},
+		{"GIT_CONFIG_GLOBAL", os.DevNull}, {"GIT_CONFIG_NOSYSTEM", "1"},
+	} {
+		t.Setenv(kv[0], kv[1])
+	}
+	repo := t.TempDir()
+	spec, err := os.ReadFile("internal/plugins/openapi/testdata/petstore.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"pets/petstore.yaml":            string(spec),
+		"pets/COMPILER_INSTRUCTIONS.md": "---\nname: pets\nspec: ./petstore.yaml\nout: ./out/\n---\n# Product\nPets.\n",
+		"README.md":                     "# Monorepo\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{{"init", "-q"}, {"add", "."}, {"commit", "-q", "-m", "init"}} {
+		if out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	build := func() *BuildResult {
+		t.Helper()
+		result, err := Build(context.Background(), BuildOptions{
+			InstructionsPath: filepath.Join(repo, "pets", "COMPILER_INSTRUCTIONS.md"),
+			Dir:              t.TempDir(),
+			SinceCommit:      "HEAD",
+			DryRun:           true,
+		})
+		if err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		return result
+	}
+
+	// Changes outside the skill's inputs do not trigger a build
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("# Changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if result := build(); !result.Unchanged || len(result.Skills) != 0 {
+		t.Errorf("build with unchanged inputs = %+v, want Unchanged", result)
+	}
+
+	// A URL spec cannot be checked in git, so it always counts as changed
+	inst, err := instructions.Parse(filepath.Join(repo, "pets", "COMPILER_INSTRUCTIONS.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &builder{opts: BuildOptions{
+		InstructionsPath: filepath.Join(repo, "pets", "COMPILER_INSTRUCTIONS.md"),
+		Spec:             "https://example.com/petstore.yaml",
+		SinceCommit:      "HEAD",
+	}, log: io.Discard}
+	if changed, err := b.changedSince(context.Background(), inst.Skills()); err != nil || !changed {
+		t.Errorf("changedSince with a URL spec = %v, %v; want true", changed, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(repo, "pets", "petstore.yaml"), append(spec, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if result := build(); result.Unchanged || len(result.Skills) != 1 {
+		t.Errorf("build with a changed spec = %+v, want a build", result)
+	}
+
+	_, err = Build(context.Background(), BuildOptions{Instructions: petstoreInstructions(t), SinceCommit: "HEAD", DryRun: true})
+	if err == nil || !strings.Contains(err.Error(), "instructions file is required") {
+		t.Errorf("Build from bytes with SinceCommit = %v, want an error", err)
+	}
+}
+
+// countingFS counts the writes to each file of a MemoryFS.
+type countingFS struct {
+	*MemoryFS
+	mu     sync.Mutex
+	writes map[string]int
+}
+
+func (c *countingFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	c.mu.Lock()
+	c.writes[filepath.Base(name)]++
+	c.mu.Unlock()
+	return c.MemoryFS.WriteFile(name, data, perm)
+}
+
+func TestBuild_WritesEachArtifactOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	fsys := &countingFS{MemoryFS: NewMemoryFS(), writes: map[string]int{}}
+	_, err := Build(context.Background(), BuildOptions{
+		Instructions: petstoreInstructions(t),
+		Dir:          dir,
+		OutputDir:    filepath.Join(dir, "out"),
+		Provider:     ProviderConfig{Provider: "openai", Model: "m", APIKey: "test", BaseURL: srv.URL},
+		Only:         []string{"skill", "llms", "changelog"},
+		FS:           fsys,
+	})
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	for _, name := range []string{"SKILL.md", "llms.txt", "CHANGELOG.md"} {
+		if fsys.writes[name] != 1 {
+			t.Errorf("%s written %d times, want once (all writes: %v)", name, fsys.writes[name], fsys.writes)
+		}
+	}
+}
+
+func TestBuild_OnEvent(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"generated"}}],"model":"m","usage":{"prompt_tokens":3,"completion_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	fsys := NewMemoryFS()
+	build := func(plan bool) []string {
+		t.Helper()
+		var mu sync.Mutex
+		var events []string
+		_, err := Build(context.Background(), BuildOptions{
+			Instructions: petstoreInstructions(t),
+			Dir:          dir,
+			OutputDir:    filepath.Join(dir, "out"),
+			Provider:     ProviderConfig{Provider: "openai", Model: "m", APIKey: "test", BaseURL: srv.URL},
+			Only:         []string{"skill", "llms"},
+			FS:           fsys,
+			Plan:         plan,
+			OnEvent: func(e Event) {
+				mu.Lock()
+				defer mu.Unlock()
+				events = append(events, fmt.Sprintf("%s %s %s %s", e.Skill, e.Artifact, e.Kind, e.Path))
+			},
+		})
+		if err != nil {
+			t.Fatalf("Build: %v", err)
+		}
+		sort.Strings(events)
+		return events
+	}
+
+	// A plan lists what a build would generate, and writes nothing
+	want := []string{"pets llms pending llms.txt", "pets skill pending pets/SKILL.md"}
+	if got := build(true); !slices.Equal(got, want) {
+		t.Errorf("plan events = %q, want %q", got, want)
+	}
+	if paths := fsys.Paths(); len(paths) != 0 {
+		t.Errorf("plan wrote %v", paths)
+	}
+
+	want = []string{
+		"pets llms done llms.txt", "pets llms started llms.txt",
+		"pets skill done pets/SKILL.md", "pets skill started pets/SKILL.md",
+	}
+	if got := build(false); !slices.Equal(got, want) {
+		t.Errorf("first build events = %q, want %q", got, want)
+	}
+	want = []string{"pets llms cached llms.txt", "pets skill cached pets/SKILL.md"}
+	if got := build(false); !slices.Equal(got, want) {
+		t.Errorf("rebuild events = %q, want %q", got, want)
+	}
+	if got := build(true); !slices.Equal(got, want) {
+		t.Errorf("plan events after a build = %q, want %q", got, want)
+	}
+}